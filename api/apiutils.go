@@ -6,6 +6,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/ironcore-dev/controller-utils/metautils"
 	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
@@ -112,3 +113,53 @@ func IsManagedBy(o apiutils.Object, manager string) bool {
 	actual, ok := o.GetLabels()[ManagerLabel]
 	return ok && actual == manager
 }
+
+// MachineDisplayName returns a human readable name of the machine derived from its iri labels,
+// falling back to the machine id.
+func MachineDisplayName(machine *Machine) string {
+	labels, err := GetLabelsAnnotation(machine.Metadata)
+	if err != nil {
+		return machine.ID
+	}
+
+	name := labels[MachineNameLabel]
+	if name == "" {
+		return machine.ID
+	}
+	if namespace := labels[MachineNamespaceLabel]; namespace != "" {
+		return namespace + "/" + name
+	}
+	return name
+}
+
+func FindMachineCondition(status MachineStatus, conditionType MachineConditionType) (MachineCondition, bool) {
+	for _, condition := range status.Conditions {
+		if condition.Type == conditionType {
+			return condition, true
+		}
+	}
+	return MachineCondition{}, false
+}
+
+// SetMachineCondition adds or updates the condition of the same type. The last transition time is only
+// bumped if the status of the condition changes.
+func SetMachineCondition(status *MachineStatus, condition MachineCondition) {
+	for i, existing := range status.Conditions {
+		if existing.Type != condition.Type {
+			continue
+		}
+
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		} else if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = time.Now()
+		}
+		status.Conditions[i] = condition
+		return
+	}
+
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = time.Now()
+	}
+	status.Conditions = append(status.Conditions, condition)
+}
@@ -7,6 +7,37 @@ const (
 	LabelsAnnotation = "cloud-hypervisor-provider.ironcore.dev/labels"
 
 	AnnotationsAnnotation = "cloud-hypervisor-provider.ironcore.dev/annotations"
+
+	// PciDevicesAnnotation lists comma separated pci addresses to pass through to the machine.
+	PciDevicesAnnotation = "cloud-hypervisor-provider.ironcore.dev/pci-devices"
+
+	// BootTimeoutAnnotation overrides the boot timeout (e.g. 10m) of the machine.
+	BootTimeoutAnnotation = "cloud-hypervisor-provider.ironcore.dev/boot-timeout"
+
+	// ConfigDriveAnnotation holds a json encoded ConfigDriveSpec to attach as config drive to the machine.
+	ConfigDriveAnnotation = "cloud-hypervisor-provider.ironcore.dev/config-drive"
+
+	// BootAnnotation holds a json encoded BootSpec overriding the boot source of the machine.
+	BootAnnotation = "cloud-hypervisor-provider.ironcore.dev/boot"
+
+	// VolumeSerialsAnnotation holds a json encoded map of volume names to the serial the disk is exposed
+	// with to the guest.
+	VolumeSerialsAnnotation = "cloud-hypervisor-provider.ironcore.dev/volume-serials"
+
+	// VolumeTuningAnnotation holds a json encoded map of volume names to the VolumeTuning of the volume.
+	VolumeTuningAnnotation = "cloud-hypervisor-provider.ironcore.dev/volume-tuning"
+
+	// VolumeFilesystemsAnnotation holds a json encoded map of empty disk volume names to the Filesystem the
+	// disk is formatted with before it is first attached.
+	VolumeFilesystemsAnnotation = "cloud-hypervisor-provider.ironcore.dev/volume-filesystems"
+
+	// VsockAnnotation holds the context id of the guest to connect a vsock device to the machine with. The
+	// connections are proxied to the vsock socket in the machine directory.
+	VsockAnnotation = "cloud-hypervisor-provider.ironcore.dev/vsock"
+
+	// SnapshotAnnotation requests a snapshot of all attached volumes of the machine under the given name.
+	// Changing the name takes another snapshot.
+	SnapshotAnnotation = "cloud-hypervisor-provider.ironcore.dev/snapshot"
 )
 
 const (
@@ -14,6 +45,13 @@ const (
 	ClassLabel   = "cloud-hypervisor-provider.ironcore.dev/class"
 )
 
+const (
+	// MachineNamespaceLabel and MachineNameLabel are set by the machinepoollet on the iri machine and
+	// identify the ironcore machine the provider machine belongs to.
+	MachineNamespaceLabel = "machinepoollet.ironcore.dev/machine-namespace"
+	MachineNameLabel      = "machinepoollet.ironcore.dev/machine-name"
+)
+
 const (
 	MachineManager = "cloud-hypervisor-provider"
 )
@@ -4,6 +4,7 @@
 package api
 
 import (
+	"fmt"
 	"time"
 
 	apiutils "github.com/ironcore-dev/provider-utils/apiutils/api"
@@ -30,7 +31,78 @@ type MachineSpec struct {
 	Volumes           []*VolumeSpec           `json:"volumes"`
 	NetworkInterfaces []*NetworkInterfaceSpec `json:"networkInterfaces"`
 
+	PciDevices []string `json:"pciDevices,omitempty"`
+
+	// BootTimeout overrides the boot timeout of the provider for this machine.
+	BootTimeout time.Duration `json:"bootTimeout,omitempty"`
+
 	ShutdownAt time.Time `json:"shutdownAt,omitempty"`
+
+	// QoSClass is the quality of service tier of the machine, derived from its machine class.
+	QoSClass QoSClass `json:"qosClass,omitempty"`
+	// NumaNode pins the vm to the host numa node, derived from its machine class.
+	NumaNode *int `json:"numaNode,omitempty"`
+
+	// ConfigDrive is attached read-only as openstack config drive for cloud-init if set.
+	ConfigDrive *ConfigDriveSpec `json:"configDrive,omitempty"`
+
+	// Boot overrides the boot source of the provider for this machine.
+	Boot *BootSpec `json:"boot,omitempty"`
+
+	// Vsock connects a vsock device to the vm for guest agents if set.
+	Vsock *VsockSpec `json:"vsock,omitempty"`
+
+	// Snapshot is the name of the snapshot to take of all attached volumes, if set.
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// VsockSpec is the vsock device of a vm. Its connections are proxied to the vsock socket in the machine
+// directory on the host.
+type VsockSpec struct {
+	// CID is the context id of the guest, unique on the host.
+	CID int64 `json:"cid"`
+}
+
+const (
+	// MinVsockCID is the lowest guest context id, the ones below are reserved for the hypervisor and the host.
+	MinVsockCID = 3
+	// MaxVsockCID is the highest guest context id, the one above is reserved as wildcard.
+	MaxVsockCID = 1<<32 - 2
+)
+
+func ValidateVsockSpec(vsock *VsockSpec) error {
+	if vsock.CID < MinVsockCID || vsock.CID > MaxVsockCID {
+		return fmt.Errorf("vsock cid %d is out of range [%d, %d]", vsock.CID, MinVsockCID, MaxVsockCID)
+	}
+	return nil
+}
+
+// BootSpec is the payload a vm boots from. Kernel boots the vm directly from a kernel, with the optional
+// Initramfs and Cmdline, instead of the Firmware.
+type BootSpec struct {
+	Firmware  string `json:"firmware,omitempty"`
+	Kernel    string `json:"kernel,omitempty"`
+	Initramfs string `json:"initramfs,omitempty"`
+	Cmdline   string `json:"cmdline,omitempty"`
+}
+
+func ValidateBootSpec(boot *BootSpec) error {
+	switch {
+	case boot.Firmware != "" && boot.Kernel != "":
+		return fmt.Errorf("firmware and kernel are mutually exclusive")
+	case boot.Firmware == "" && boot.Kernel == "":
+		return fmt.Errorf("either a firmware or a kernel is required")
+	case boot.Kernel == "" && (boot.Initramfs != "" || boot.Cmdline != ""):
+		return fmt.Errorf("initramfs and cmdline require a kernel")
+	default:
+		return nil
+	}
+}
+
+type ConfigDriveSpec struct {
+	Hostname string   `json:"hostname"`
+	SSHKeys  []string `json:"sshKeys,omitempty"`
+	UserData []byte   `json:"userData,omitempty"`
 }
 
 type MachineStatus struct {
@@ -38,6 +110,47 @@ type MachineStatus struct {
 	NetworkInterfaceStatus []NetworkInterfaceStatus `json:"networkInterfaceStatus"`
 	State                  MachineState             `json:"state"`
 	ImageRef               string                   `json:"imageRef"`
+	Conditions             []MachineCondition       `json:"conditions,omitempty"`
+	// Message explains what currently keeps the machine from running, empty if nothing does.
+	Message string `json:"message,omitempty"`
+
+	// BootStartedAt is the time the vm was first asked to power on without having reached running since.
+	BootStartedAt time.Time `json:"bootStartedAt,omitempty"`
+
+	// Snapshot is the name of the last snapshot taken of the volumes.
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+type MachineConditionType string
+
+const (
+	// MachineConditionBooted reports whether the vm reached running after it was powered on.
+	MachineConditionBooted MachineConditionType = "Booted"
+	// MachineConditionVolumesHealthy reports whether the backends of all attached volumes are usable.
+	MachineConditionVolumesHealthy MachineConditionType = "VolumesHealthy"
+	// MachineConditionDiskPressure reports whether preparing the disks of the machine failed for lack of space.
+	MachineConditionDiskPressure MachineConditionType = "DiskPressure"
+	// MachineConditionQuarantined reports whether the machine is no longer reconciled automatically because its
+	// reconciliation failed repeatedly.
+	MachineConditionQuarantined MachineConditionType = "Quarantined"
+	// MachineConditionImageMissing reports whether the boot image is missing in the local store and its registry.
+	MachineConditionImageMissing MachineConditionType = "ImageMissing"
+)
+
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+type MachineCondition struct {
+	Type               MachineConditionType `json:"type"`
+	Status             ConditionStatus      `json:"status"`
+	Reason             string               `json:"reason,omitempty"`
+	Message            string               `json:"message,omitempty"`
+	LastTransitionTime time.Time            `json:"lastTransitionTime"`
 }
 
 type MachineState string
@@ -55,28 +168,88 @@ type PowerState int32
 const (
 	PowerStatePowerOn  PowerState = 0
 	PowerStatePowerOff PowerState = 1
+	// PowerStatePaused keeps the vm in memory without running it, so it continues where it stopped once it
+	// is powered on again.
+	PowerStatePaused PowerState = 2
 )
 
+// QoSClass decides how the host resources of a machine are protected against other machines.
+type QoSClass string
+
+const (
+	QoSClassGuaranteed QoSClass = "Guaranteed"
+	QoSClassBurstable  QoSClass = "Burstable"
+	QoSClassBestEffort QoSClass = "BestEffort"
+)
+
+func ValidateQoSClass(class QoSClass) error {
+	switch class {
+	case QoSClassGuaranteed, QoSClassBurstable, QoSClassBestEffort:
+		return nil
+	default:
+		return fmt.Errorf("invalid qos class %q, must be one of %s, %s, %s",
+			class, QoSClassGuaranteed, QoSClassBurstable, QoSClassBestEffort)
+	}
+}
+
 type VolumeSpec struct {
 	Name       string            `json:"name"`
 	Device     string            `json:"device"`
 	LocalDisk  *LocalDiskSpec    `json:"LocalDisk,omitempty"`
 	Connection *VolumeConnection `json:"cephDisk,omitempty"`
+	Serial     string            `json:"serial,omitempty"`
+	Tuning     *VolumeTuning     `json:"tuning,omitempty"`
 	DeletedAt  *time.Time        `json:"deletedAt,omitempty"`
 }
 
+// VolumeTuning overrides the backend defaults of a volume, zero values keep the defaults.
+type VolumeTuning struct {
+	// ReadaheadBytes is the maximum readahead of the volume backend.
+	ReadaheadBytes int64 `json:"readaheadBytes,omitempty"`
+	// QueueDepth is the size of each virtio queue of the disk.
+	QueueDepth int `json:"queueDepth,omitempty"`
+	// FlushIntervalSeconds is the interval the writes cached by the volume backend are flushed in while the vm
+	// runs, overriding the flush interval of the provider.
+	FlushIntervalSeconds int64 `json:"flushIntervalSeconds,omitempty"`
+}
+
 type VolumeStatus struct {
-	Name   string      `json:"name,omitempty"`
-	Type   VolumeType  `json:"type,omitempty"`
-	Path   string      `json:"path,omitempty"`
-	Handle string      `json:"handle,omitempty"`
-	State  VolumeState `json:"state,omitempty"`
-	Size   int64       `json:"size,omitempty"`
+	Name          string      `json:"name,omitempty"`
+	Type          VolumeType  `json:"type,omitempty"`
+	Path          string      `json:"path,omitempty"`
+	Handle        string      `json:"handle,omitempty"`
+	State         VolumeState `json:"state,omitempty"`
+	Size          int64       `json:"size,omitempty"`
+	AllocatedSize int64       `json:"allocatedSize,omitempty"`
+	Serial        string      `json:"serial,omitempty"`
+	QueueDepth    int         `json:"queueDepth,omitempty"`
+	// BackingHandle is the handle of the backing an attached volume was migrated to. The vm keeps knowing
+	// the volume by Handle and Path until it is detached.
+	BackingHandle string `json:"backingHandle,omitempty"`
 }
 
 type LocalDiskSpec struct {
 	Size  int64   `json:"size"`
 	Image *string `json:"image"`
+	// Filesystem formats an empty disk with the filesystem on creation.
+	Filesystem Filesystem `json:"filesystem,omitempty"`
+}
+
+// Filesystem is a filesystem an empty disk can be pre-provisioned with.
+type Filesystem string
+
+const (
+	FilesystemExt4 Filesystem = "ext4"
+	FilesystemXFS  Filesystem = "xfs"
+)
+
+func ValidateFilesystem(filesystem Filesystem) error {
+	switch filesystem {
+	case FilesystemExt4, FilesystemXFS:
+		return nil
+	default:
+		return fmt.Errorf("invalid filesystem %q, must be one of %s, %s", filesystem, FilesystemExt4, FilesystemXFS)
+	}
 }
 
 type VolumeConnection struct {
@@ -146,6 +319,18 @@ func HasBootImage(machine *Machine) *string {
 	return nil
 }
 
+// BootDevice is the device of the volume machines boot from.
+const BootDevice = "oda"
+
+// IsBootVolume reports whether the machine boots from the volume, i.e. it is on the boot device or
+// populated with the boot image.
+func IsBootVolume(volume *VolumeSpec) bool {
+	if volume.Device == BootDevice {
+		return true
+	}
+	return volume.LocalDisk != nil && volume.LocalDisk.Image != nil
+}
+
 func IsImageReferenced(machine *Machine, image string) bool {
 	bootImage := HasBootImage(machine)
 	if bootImage == nil {
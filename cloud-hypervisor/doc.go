@@ -3,8 +3,10 @@
 
 package cloud_hypervisor
 
-// To fetch latest openapi spec:
+// To fetch the openapi spec of the cloud-hypervisor release installed on the hosts (keep the version in sync
+// with dev-vm/cloud-hypervisor-prepare.sh and docs/config/ignition.md). Do not edit the spec by hand, options
+// cloud-hypervisor accepts but its spec lacks are added in package vmm.
 // nolint:lll
-// curl -s https://raw.githubusercontent.com/cloud-hypervisor/cloud-hypervisor/master/vmm/src/api/openapi/cloud-hypervisor.yaml -O
+// curl -s https://raw.githubusercontent.com/cloud-hypervisor/cloud-hypervisor/v51.1/vmm/src/api/openapi/cloud-hypervisor.yaml -O
 
 //go:generate bash -c "mkdir -p client && cat ./cloud-hypervisor.yaml | ../bin/oapi-codegen -package=client -generate=types,client,spec -o=./client/client.go /dev/stdin"
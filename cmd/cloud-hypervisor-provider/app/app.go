@@ -9,23 +9,33 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/audit"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/cgroup"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/compaction"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/controllers"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/imageutils"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/pci"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/networkinterface"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/networkinterface/options"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume/ceph"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume/localdisk"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/raw"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server/version"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/sockets"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
 	"github.com/ironcore-dev/ironcore-image/oci/remote"
 	ocistore "github.com/ironcore-dev/ironcore-image/oci/store"
-	"github.com/ironcore-dev/ironcore/broker/common"
 	commongrpc "github.com/ironcore-dev/ironcore/broker/common/grpc"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
 	"github.com/ironcore-dev/provider-utils/eventutils/event"
@@ -36,30 +46,141 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
 type Options struct {
-	Address string
+	Version bool
+
+	Address               string
+	RequestTimeout        time.Duration
+	MaxConcurrentRequests int
+	AuditLog              string
+	EnableReflection      bool
 
 	RootDir         string
+	DataDir         string
 	MachineStoreDir string
 
-	MachineClasses MachineClassOptions
+	MachineStoreCompactionInterval time.Duration
+	MachineStoreRetention          time.Duration
+
+	SocketLeakScanInterval time.Duration
+	CleanupLeakedSockets   bool
+
+	MachineClasses      MachineClassOptions
+	DefaultMachineClass string
 
 	CloudHypervisorSocketsPath  string
 	CloudHypervisorFirmwarePath string
+	CloudHypervisorKernelPath   string
+	CloudHypervisorBinary       string
+	CloudHypervisorSerialMode   string
+	CloudHypervisorSerialLogMax int64
+	CloudHypervisorConsoleMode  string
+	CloudHypervisorDiskAIO      string
+	CloudHypervisorRngSource    string
+	DetachVMs                   bool
+	VMMSocketTimeout            time.Duration
 
 	QMPSocketPath string
 
+	CephOSDOpTimeout   time.Duration
+	CephMonOpTimeout   time.Duration
+	CephConnectTimeout time.Duration
+
+	CephSecretNamespace  string
+	CephSecretKubeconfig string
+
+	LocalDiskSparse        bool
+	LocalDiskImageCache    bool
+	LocalDiskImageOverlays bool
+
+	DrainFile string
+
+	NodeResourcesAddress string
+
+	MetricsAddress string
+
+	StreamingAddress string
+	StreamingURL     string
+	ExecTokenTTL     time.Duration
+
+	PciDevices []string
+
+	CgroupRoot string
+
+	ReconcileTimeout time.Duration
+	VMInfoCacheTTL   time.Duration
+
+	BootTimeout           time.Duration
+	PowerOffOnBootTimeout bool
+
+	GuestShutdownPolicy string
+	ShutdownGracePeriod time.Duration
+
+	QuarantineThreshold int
+
+	ReconcilerWorkers int
+
+	PowerOnRate  float64
+	PowerOnBurst int
+
+	PrioritizeMachineChanges bool
+
+	ProbeVolumes        bool
+	VolumeFlushInterval time.Duration
+
+	ValidateImageArchitecture bool
+	ImagePullTimeout          time.Duration
+
+	CpuOvercommit    float64
+	MemoryOvercommit float64
+	MemoryReserve    int64
+	MinFreeDisk      int64
+	NumaPlacement    bool
+
+	Pools PoolOptions
+
 	NicPlugin *options.Options
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Version, "version", false, "Print the version information and exit.")
+
 	fs.StringVar(&o.Address, "address", "/run/chp/iri-machinebroker.sock", "Address to listen on.")
+	fs.DurationVar(
+		&o.RequestTimeout,
+		"request-timeout",
+		time.Minute,
+		"Maximum duration an iri request is served before it fails. Zero disables the timeout.",
+	)
+	fs.IntVar(
+		&o.MaxConcurrentRequests,
+		"max-concurrent-requests",
+		64,
+		"Maximum number of iri requests served at once, excess requests are rejected. Zero disables the limit.",
+	)
+	fs.StringVar(
+		&o.AuditLog,
+		"audit-log",
+		"",
+		"File the operations affecting vms are audited to as JSON, '-' for stdout. Empty disables auditing.",
+	)
+	fs.BoolVar(
+		&o.EnableReflection,
+		"enable-reflection",
+		false,
+		"Serve the grpc reflection service, allowing clients like grpcurl to discover the iri api.",
+	)
 
 	fs.StringVar(
 		&o.RootDir,
@@ -67,6 +188,13 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 		"/var/lib/chp",
 		"Path to the directory where the provider manages its content at.",
 	)
+	fs.StringVar(
+		&o.DataDir,
+		"provider-data-dir",
+		"",
+		"Path to an existing directory, e.g. the mount of a data disk, to keep the machines and images in "+
+			"instead of the root dir. Sockets are kept in the root dir.",
+	)
 
 	fs.StringVar(
 		&o.MachineStoreDir,
@@ -75,6 +203,32 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 		"Path to the directory of the machine store.",
 	)
 
+	fs.DurationVar(
+		&o.MachineStoreCompactionInterval,
+		"provider-machine-store-compaction-interval",
+		compaction.DefaultInterval,
+		"Interval in which fully deleted machine records are removed from the machine store.",
+	)
+
+	fs.DurationVar(
+		&o.MachineStoreRetention,
+		"provider-machine-store-retention",
+		compaction.DefaultRetention,
+		"Duration a fully deleted machine record is kept in the machine store before it is removed.",
+	)
+	fs.DurationVar(
+		&o.SocketLeakScanInterval,
+		"socket-leak-scan-interval",
+		sockets.DefaultInterval,
+		"Interval in which the machine dirs are scanned for sockets of machines that are gone.",
+	)
+	fs.BoolVar(
+		&o.CleanupLeakedSockets,
+		"cleanup-leaked-sockets",
+		false,
+		"Remove the leaked sockets found in the machine dirs.",
+	)
+
 	fs.StringVar(
 		&o.QMPSocketPath,
 		"qmp-socket-path",
@@ -82,6 +236,38 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 		"Path to the qmp socket.",
 	)
 
+	fs.DurationVar(
+		&o.CephOSDOpTimeout,
+		"ceph-osd-op-timeout",
+		0,
+		"Time after which requests of ceph volumes to unresponsive osds fail instead of hanging. Zero keeps the ceph default.",
+	)
+	fs.DurationVar(
+		&o.CephMonOpTimeout,
+		"ceph-mon-op-timeout",
+		0,
+		"Time after which requests of ceph volumes to unresponsive monitors fail. Zero keeps the ceph default.",
+	)
+	fs.DurationVar(
+		&o.CephConnectTimeout,
+		"ceph-connect-timeout",
+		0,
+		"Time ceph volumes may take to connect to the cluster. Zero keeps the ceph default.",
+	)
+	fs.StringVar(
+		&o.CephSecretNamespace,
+		"ceph-secret-namespace",
+		"",
+		"Namespace of the kubernetes secrets ceph volumes may reference by the secretName attribute "+
+			"instead of passing their secret data. Empty disables referencing secrets.",
+	)
+	fs.StringVar(
+		&o.CephSecretKubeconfig,
+		"ceph-secret-kubeconfig",
+		"",
+		"Path to the kubeconfig of the cluster of the ceph secrets. Defaults to the in-cluster config.",
+	)
+
 	fs.StringVar(
 		&o.CloudHypervisorSocketsPath,
 		"cloud-hypervisor-sockets-path",
@@ -96,10 +282,313 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 		"Path to the cloud-hypervisor firmware.",
 	)
 
+	fs.StringVar(
+		&o.CloudHypervisorKernelPath,
+		"cloud-hypervisor-kernel-path",
+		"",
+		"Path to a kernel to boot machines from directly instead of the firmware.",
+	)
+
+	fs.StringVar(
+		&o.CloudHypervisorBinary,
+		"cloud-hypervisor-binary",
+		"cloud-hypervisor",
+		"Path to the cloud-hypervisor binary used to detect its version.",
+	)
+
+	fs.BoolVar(
+		&o.LocalDiskSparse,
+		"localdisk-sparse",
+		false,
+		"Back local disks with sparse files that only allocate written blocks.",
+	)
+
+	fs.BoolVar(
+		&o.LocalDiskImageCache,
+		"localdisk-image-cache",
+		false,
+		"Share a read-only base per image and clone local disks of machines from it. Requires a filesystem "+
+			"supporting reflinks.",
+	)
+
+	fs.BoolVar(
+		&o.LocalDiskImageOverlays,
+		"localdisk-image-overlays",
+		false,
+		"Attach a shared read-only base per image digest with a private qcow2 overlay per machine disk.",
+	)
+
+	fs.StringVar(
+		&o.DrainFile,
+		"drain-file",
+		"/var/lib/chp/drain",
+		"Path to a file whose existence puts the provider into drain mode, rejecting new machines.",
+	)
+
+	fs.StringVar(
+		&o.NodeResourcesAddress,
+		"node-resources-address",
+		"",
+		"Address to serve the capacity and allocation of the pools in kubernetes node resource format on. "+
+			"Disabled if empty.",
+	)
+
+	fs.StringVar(
+		&o.MetricsAddress,
+		"metrics-address",
+		"",
+		"Address to serve the prometheus metrics of the provider on. Disabled if empty.",
+	)
+
+	fs.StringVar(
+		&o.StreamingAddress,
+		"streaming-address",
+		"",
+		"Address to serve the exec sessions of machines on, bridged to their serial socket. Requires the Socket "+
+			"serial mode. Disabled if empty.",
+	)
+	fs.StringVar(
+		&o.StreamingURL,
+		"streaming-url",
+		"",
+		"URL the streaming server is reachable at by exec clients. Defaults to http://<streaming-address>.",
+	)
+	fs.DurationVar(
+		&o.ExecTokenTTL,
+		"exec-token-ttl",
+		server.DefaultExecTokenTTL,
+		"Duration an exec url can be connected to.",
+	)
+
+	fs.StringSliceVar(
+		&o.PciDevices,
+		"pci-device",
+		nil,
+		"PCI address (dddd:bb:dd.f) of a vfio-pci bound host device available for passthrough.",
+	)
+
+	fs.StringVar(
+		&o.CgroupRoot,
+		"cgroup-root",
+		"",
+		"Delegated cgroup v2 directory to place the cloud-hypervisor processes of machines in, applying the "+
+			"cpu weights and memory protections of their qos class. Disabled if empty.",
+	)
+
+	fs.DurationVar(
+		&o.ReconcileTimeout,
+		"reconcile-timeout",
+		controllers.DefaultReconcileTimeout,
+		"Maximum duration of a single machine reconciliation before it is aborted and requeued.",
+	)
+
+	fs.StringVar(
+		&o.CloudHypervisorSerialMode,
+		"cloud-hypervisor-serial-mode",
+		string(vmm.SerialModeFile),
+		fmt.Sprintf("Connection of the serial port of machines (%s, %s, %s, %s). %s logs to a file in the machine directory.",
+			vmm.SerialModeTty, vmm.SerialModeFile, vmm.SerialModeSocket, vmm.SerialModeOff, vmm.SerialModeFile),
+	)
+	fs.Int64Var(
+		&o.CloudHypervisorSerialLogMax,
+		"cloud-hypervisor-serial-log-max-bytes",
+		16*1024*1024,
+		"Bytes of output a serial log may hold before it is moved to its backup. Zero disables the cap.",
+	)
+
+	fs.StringVar(
+		&o.CloudHypervisorConsoleMode,
+		"cloud-hypervisor-console-mode",
+		string(vmm.ConsoleModeOff),
+		fmt.Sprintf("Connection of the virtio console of machines (%s, %s, %s).",
+			vmm.ConsoleModeOff, vmm.ConsoleModePty, vmm.ConsoleModeTty),
+	)
+
+	fs.StringVar(
+		&o.CloudHypervisorDiskAIO,
+		"cloud-hypervisor-disk-aio",
+		string(vmm.DiskAIODefault),
+		fmt.Sprintf("AIO backend of the file disks of machines (%s, %s, %s). Defaults to the choice of "+
+			"cloud-hypervisor.", vmm.DiskAIOIoUring, vmm.DiskAIONative, vmm.DiskAIOThreads),
+	)
+	fs.StringVar(
+		&o.CloudHypervisorRngSource,
+		"cloud-hypervisor-rng-source",
+		vmm.DefaultRngSource,
+		"Host entropy source of the virtio-rng device of machines.",
+	)
+
+	fs.DurationVar(
+		&o.VMMSocketTimeout,
+		"vmm-socket-timeout",
+		osutils.DefaultSocketWaitTimeout,
+		"Time waited for the sockets of cloud-hypervisor and the qemu-storage-daemon to accept connections.",
+	)
+
+	fs.BoolVar(
+		&o.DetachVMs,
+		"detach-vms",
+		true,
+		"Keep machines running when the provider stops. Otherwise, running machines are shut down on exit.",
+	)
+
+	fs.DurationVar(
+		&o.VMInfoCacheTTL,
+		"vm-info-cache-ttl",
+		time.Second,
+		"Duration the vm info queried from cloud-hypervisor is reused across reconciliations. Zero disables the cache.",
+	)
+
+	fs.DurationVar(
+		&o.BootTimeout,
+		"boot-timeout",
+		controllers.DefaultBootTimeout,
+		"Maximum duration for a powered on machine to reach running before its boot is considered failed.",
+	)
+
+	fs.BoolVar(
+		&o.PowerOffOnBootTimeout,
+		"power-off-on-boot-timeout",
+		false,
+		"Power off machines that did not reach running within their boot timeout.",
+	)
+
+	fs.StringVar(
+		&o.GuestShutdownPolicy,
+		"guest-shutdown-policy",
+		string(controllers.GuestShutdownPolicyRestart),
+		fmt.Sprintf("Handling of machines shut down by their guest (%s, %s).",
+			controllers.GuestShutdownPolicyRestart, controllers.GuestShutdownPolicyStop),
+	)
+
+	fs.DurationVar(
+		&o.ShutdownGracePeriod,
+		"shutdown-grace-period",
+		30*time.Second,
+		"Time the guest is given to shut down a machine that is powered off or deleted before it is powered off. "+
+			"Zero powers machines off right away.",
+	)
+
+	fs.IntVar(
+		&o.QuarantineThreshold,
+		"quarantine-threshold",
+		10,
+		"Number of consecutive failed reconciliations after which a machine is no longer requeued until it "+
+			"changes. Zero disables quarantining.",
+	)
+
+	fs.IntVar(
+		&o.ReconcilerWorkers,
+		"reconciler-workers",
+		controllers.DefaultWorkerSize,
+		"Number of machines reconciled concurrently.",
+	)
+
+	fs.Float64Var(
+		&o.PowerOnRate,
+		"power-on-rate",
+		0,
+		"Number of vms powered on per second across all pools. Further vms are powered on once the rate allows "+
+			"to. Zero does not limit the rate.",
+	)
+
+	fs.IntVar(
+		&o.PowerOnBurst,
+		"power-on-burst",
+		1,
+		"Number of vms powered on at once within the power on rate.",
+	)
+
+	fs.BoolVar(
+		&o.ProbeVolumes,
+		"probe-volumes",
+		false,
+		"Check that the backend of a volume is reachable before attaching it to the vm, retrying unreachable volumes later.",
+	)
+	fs.DurationVar(
+		&o.VolumeFlushInterval,
+		"volume-flush-interval",
+		0,
+		"Interval in which the write-back caches of the volumes of running vms are flushed, 0 to flush them only "+
+			"before powering off. The flush interval of the volume tuning takes precedence.",
+	)
+
+	fs.BoolVar(
+		&o.PrioritizeMachineChanges,
+		"prioritize-machine-changes",
+		true,
+		"Reconcile created and deleted machines ahead of machines requeued or resynced.",
+	)
+
+	fs.BoolVar(
+		&o.ValidateImageArchitecture,
+		"validate-image-architecture",
+		true,
+		"Reject boot images whose architecture does not match the host.",
+	)
+	fs.DurationVar(
+		&o.ImagePullTimeout,
+		"image-pull-timeout",
+		30*time.Minute,
+		"Maximum duration of an image pull before it is cancelled and marked failed. Zero disables the timeout.",
+	)
+
+	fs.Float64Var(
+		&o.CpuOvercommit,
+		"cpu-overcommit",
+		1.0,
+		"Ratio of cpus that can be allocated to machines per host cpu. Must be >= 1.0.",
+	)
+
+	fs.Float64Var(
+		&o.MemoryOvercommit,
+		"memory-overcommit",
+		1.0,
+		"Ratio of memory that can be allocated to machines per byte of host memory. Must be >= 1.0.",
+	)
+
+	fs.Int64Var(
+		&o.MemoryReserve,
+		"memory-reserve",
+		0,
+		"Bytes of host memory kept free for the host and never handed out to machines.",
+	)
+
+	fs.Int64Var(
+		&o.MinFreeDisk,
+		"min-free-disk",
+		0,
+		"Bytes of free disk space below which the host reports no capacity. Zero disables the check.",
+	)
+
+	fs.BoolVar(
+		&o.NumaPlacement,
+		"numa-placement",
+		true,
+		"Place the memory and vcpus of machines on the host numa node with the most free resources.",
+	)
+
 	fs.Var(
 		&o.MachineClasses,
 		"machine-class",
-		"Supported machine classes (format: name,cpu,memory)",
+		"Supported machine classes (format: name,cpu,memory[,qos-class[,numa-node]]). The qos class is one of "+
+			"Guaranteed, Burstable or BestEffort and defaults to Burstable. The numa node pins the machines of "+
+			"the class to a host numa node.",
+	)
+
+	fs.StringVar(
+		&o.DefaultMachineClass,
+		"default-machine-class",
+		"",
+		"Machine class applied to machines created without a class. Must be a supported machine class.",
+	)
+
+	fs.Var(
+		&o.Pools,
+		"pool",
+		"Machine pool served on its own address (format: name,address[,machine-class...]). "+
+			"The cloud-hypervisor sockets of a pool are read from <cloud-hypervisor-sockets-path>/<name>. "+
+			"Defaults to a single pool serving all machine classes.",
 	)
 
 	o.NicPlugin = options.NewDefaultOptions()
@@ -120,6 +609,10 @@ func Command() *cobra.Command {
 			cmd.SetContext(ctrl.LoggerInto(cmd.Context(), ctrl.Log))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Version {
+				_, err := fmt.Fprintln(cmd.OutOrStdout(), version.Get(cmd.Context(), opts.CloudHypervisorBinary))
+				return err
+			}
 			return Run(cmd.Context(), opts)
 		},
 	}
@@ -137,18 +630,31 @@ func Run(ctx context.Context, opts Options) error {
 	log := ctrl.LoggerFrom(ctx)
 	setupLog := log.WithName("setup")
 
-	var classes []mcr.MachineClass
-	for _, class := range opts.MachineClasses {
-		classes = append(classes, mcr.MachineClass(class))
+	versionInfo := version.Get(ctx, opts.CloudHypervisorBinary)
+	setupLog.Info("Version",
+		"runtimeVersion", versionInfo.RuntimeVersion,
+		"commit", versionInfo.Commit,
+		"cloudHypervisorVersion", versionInfo.CloudHypervisorVersion,
+		"cloudHypervisorAPIVersion", versionInfo.CloudHypervisorAPIVersion,
+	)
+
+	poolConfigs, err := opts.PoolConfigs()
+	if err != nil {
+		setupLog.Error(err, "failed to resolve pools")
+		return err
 	}
 
-	classRegistry, err := mcr.NewMachineClassRegistry(classes)
+	overcommit := capacity.Overcommit{
+		Cpu:    opts.CpuOvercommit,
+		Memory: opts.MemoryOvercommit,
+	}
+	hostCapacity, numaTracker, err := newHostCapacity(setupLog, opts, overcommit)
 	if err != nil {
-		setupLog.Error(err, "failed to initialize provider host")
+		setupLog.Error(err, "failed to initialize host capacity")
 		return err
 	}
 
-	hostPaths, err := host.PathsAt(opts.RootDir)
+	hostPaths, err := host.PathsAtWithOptions(opts.RootDir, host.PathsOptions{DataDir: opts.DataDir})
 	if err != nil {
 		setupLog.Error(err, "failed to initialize provider host")
 		return err
@@ -173,11 +679,12 @@ func Run(ctx context.Context, opts Options) error {
 		return err
 	}
 
-	imgCache, err := ociutils.NewLocalCache(log, reg, ociStore, nil)
+	localCache, err := ociutils.NewLocalCache(log, reg, ociStore, nil)
 	if err != nil {
 		setupLog.Error(err, "failed to initialize oci manager")
 		return err
 	}
+	imgCache := imageutils.NewPullTimeoutCache(log, localCache, reg, ociStore, opts.ImagePullTimeout)
 
 	rawInst, err := raw.Instance(raw.Default())
 	if err != nil {
@@ -190,17 +697,39 @@ func Run(ctx context.Context, opts Options) error {
 		log.WithName("ceph-volume-plugin"),
 		hostPaths,
 		opts.QMPSocketPath,
+		ceph.Options{
+			OSDOpTimeout:      opts.CephOSDOpTimeout,
+			MonOpTimeout:      opts.CephMonOpTimeout,
+			ConnectTimeout:    opts.CephConnectTimeout,
+			SocketWaitTimeout: opts.VMMSocketTimeout,
+		},
 	)
 	if err != nil {
 		setupLog.Error(err, "failed to initialize qmp provider")
 		return err
 	}
 
+	cephSecrets, err := cephSecretGetter(opts)
+	if err != nil {
+		setupLog.Error(err, "failed to initialize ceph secrets")
+		return err
+	}
+
+	registeredPlugins, err := volume.RegisteredPlugins()
+	if err != nil {
+		setupLog.Error(err, "failed to create registered plugins")
+		return err
+	}
+
 	pluginManager := volume.NewPluginManager()
-	if err := pluginManager.InitPlugins(hostPaths, []volume.Plugin{
-		ceph.NewPlugin(qmpProvider),
-		localdisk.NewPlugin(rawInst, imgCache),
-	}); err != nil {
+	if err := pluginManager.InitPlugins(hostPaths, append([]volume.Plugin{
+		ceph.NewPluginWithOptions(qmpProvider, ceph.PluginOptions{Secrets: cephSecrets}),
+		localdisk.NewPlugin(rawInst, imgCache, localdisk.Options{
+			Sparse:        opts.LocalDiskSparse,
+			ImageCache:    opts.LocalDiskImageCache,
+			ImageOverlays: opts.LocalDiskImageOverlays,
+		}),
+	}, registeredPlugins...)); err != nil {
 		setupLog.Error(err, "failed to initialize plugins")
 		return err
 	}
@@ -219,15 +748,399 @@ func Run(ctx context.Context, opts Options) error {
 		return err
 	}
 
+	pciManager, err := pci.NewManager(log.WithName("pci-manager"), pci.ManagerOptions{
+		Devices: opts.PciDevices,
+	})
+	if err != nil {
+		setupLog.Error(err, "failed to initialize pci manager")
+		return err
+	}
+
+	var cgroupManager *cgroup.Manager
+	if opts.CgroupRoot != "" {
+		cgroupManager, err = cgroup.NewManager(opts.CgroupRoot)
+		if err != nil {
+			setupLog.Error(err, "failed to initialize cgroup manager")
+			return err
+		}
+	}
+
+	features := hostFeatures(opts, cgroupManager != nil)
+
+	var auditLog *audit.Logger
+	if opts.AuditLog != "" {
+		auditLog, err = audit.Open(opts.AuditLog)
+		if err != nil {
+			setupLog.Error(err, "failed to open audit log")
+			return err
+		}
+		defer func() {
+			if err := auditLog.Close(); err != nil {
+				setupLog.Error(err, "failed to close audit log")
+			}
+		}()
+	}
+
+	powerOnLimiter, err := newPowerOnLimiter(opts)
+	if err != nil {
+		setupLog.Error(err, "failed to initialize power on limiter")
+		return err
+	}
+
+	streamer, err := newStreamer(log, opts, hostPaths)
+	if err != nil {
+		setupLog.Error(err, "failed to initialize streamer")
+		return err
+	}
+
+	// The vsock cids of the vms have to be unique on the host, across all pools.
+	vsockTracker := vmm.NewVsockTracker()
+
+	socketLeakScanner, err := sockets.NewLeakScanner(log.WithName("socket-leak-scanner"), hostPaths, sockets.Options{
+		Interval: opts.SocketLeakScanInterval,
+		Cleanup:  opts.CleanupLeakedSockets,
+	})
+	if err != nil {
+		setupLog.Error(err, "failed to initialize socket leak scanner")
+		return err
+	}
+
+	var pools []*pool
+	for _, poolConfig := range poolConfigs {
+		p, err := newPool(ctx, log, poolConfig, poolDependencies{
+			firmwarePath:      opts.CloudHypervisorFirmwarePath,
+			kernelPath:        opts.CloudHypervisorKernelPath,
+			paths:             hostPaths,
+			imageCache:        imgCache,
+			raw:               rawInst,
+			pluginManager:     pluginManager,
+			nicPlugin:         nicPlugin,
+			capacity:          hostCapacity,
+			overcommit:        overcommit,
+			memoryReserve:     opts.MemoryReserve,
+			minFreeDisk:       opts.MinFreeDisk,
+			numa:              numaTracker,
+			vsock:             vsockTracker,
+			pciManager:        pciManager,
+			cgroupManager:     cgroupManager,
+			socketLeakScanner: socketLeakScanner,
+			defaultClass:      opts.DefaultMachineClass,
+			reconcileTimeout:  opts.ReconcileTimeout,
+			vmInfoCacheTTL:    opts.VMInfoCacheTTL,
+			serialMode:        vmm.SerialMode(opts.CloudHypervisorSerialMode),
+			serialLogMax:      opts.CloudHypervisorSerialLogMax,
+			consoleMode:       vmm.ConsoleMode(opts.CloudHypervisorConsoleMode),
+			diskAIO:           vmm.DiskAIO(opts.CloudHypervisorDiskAIO),
+			rngSource:         opts.CloudHypervisorRngSource,
+			socketTimeout:     opts.VMMSocketTimeout,
+			detachVMs:         opts.DetachVMs,
+			features:          features,
+			versionInfo:       versionInfo,
+			bootTimeout:       opts.BootTimeout,
+			powerOffOnBoot:    opts.PowerOffOnBootTimeout,
+			guestShutdown:     controllers.GuestShutdownPolicy(opts.GuestShutdownPolicy),
+			shutdownGrace:     opts.ShutdownGracePeriod,
+			quarantine:        opts.QuarantineThreshold,
+			workers:           opts.ReconcilerWorkers,
+			powerOnLimiter:    powerOnLimiter,
+			prioritizeChanges: opts.PrioritizeMachineChanges,
+			probeVolumes:      opts.ProbeVolumes,
+			flushInterval:     opts.VolumeFlushInterval,
+			validateImageArch: opts.ValidateImageArchitecture,
+			architecture:      platform.Architecture,
+			compaction: compaction.Options{
+				Interval:  opts.MachineStoreCompactionInterval,
+				Retention: opts.MachineStoreRetention,
+			},
+			requestLimits: server.RequestLimits{
+				Timeout:       opts.RequestTimeout,
+				MaxConcurrent: opts.MaxConcurrentRequests,
+			},
+			auditLog:   auditLog,
+			reflection: opts.EnableReflection,
+			streamer:   streamer,
+		})
+		if err != nil {
+			setupLog.Error(err, "failed to initialize pool", "Pool", poolConfig.Name)
+			return err
+		}
+		pools = append(pools, p)
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		setupLog.Info("Starting oci cache")
+		if err := imgCache.Start(ctx); err != nil {
+			setupLog.Error(err, "failed to start oci cache")
+			return err
+		}
+		return nil
+	})
+
+	for _, p := range pools {
+		p.start(ctx, g)
+	}
+
+	g.Go(func() error {
+		setupLog.Info("Starting socket leak scanner")
+		socketLeakScanner.Start(ctx)
+		return nil
+	})
+
+	startOptionalServices(ctx, g, log, opts, pools, streamer)
+	return g.Wait()
+}
+
+// hostFeatures returns the optional features enabled on the host.
+func hostFeatures(opts Options, qosCgroups bool) []string {
+	features := []string{server.FeatureConfigDrive}
+	if len(opts.PciDevices) > 0 {
+		features = append(features, server.FeaturePciPassthrough)
+	}
+	if qosCgroups {
+		features = append(features, server.FeatureQoSCgroups)
+	}
+	if opts.LocalDiskSparse {
+		features = append(features, server.FeatureSparseLocalDisks)
+	}
+	if opts.LocalDiskImageOverlays {
+		features = append(features, server.FeatureLocalDiskImageOverlays)
+	}
+	return features
+}
+
+// newPowerOnLimiter returns the limiter of the power on rate, nil if the rate is not limited.
+func newPowerOnLimiter(opts Options) (*rate.Limiter, error) {
+	if opts.PowerOnRate == 0 {
+		return nil, nil
+	}
+	if opts.PowerOnRate < 0 || opts.PowerOnBurst < 1 {
+		return nil, fmt.Errorf("power on rate and burst must be positive, got %v and %d", opts.PowerOnRate,
+			opts.PowerOnBurst)
+	}
+	return rate.NewLimiter(rate.Limit(opts.PowerOnRate), opts.PowerOnBurst), nil
+}
+
+// newStreamer returns the streamer of the exec sessions, nil if no streaming address is configured.
+func newStreamer(log logr.Logger, opts Options, hostPaths host.Paths) (*server.Streamer, error) {
+	if opts.StreamingAddress == "" {
+		return nil, nil
+	}
+	if vmm.SerialMode(opts.CloudHypervisorSerialMode) != vmm.SerialModeSocket {
+		return nil, fmt.Errorf("exec requires the %s serial mode", vmm.SerialModeSocket)
+	}
+	streamingURL := opts.StreamingURL
+	if streamingURL == "" {
+		streamingURL = "http://" + opts.StreamingAddress
+	}
+	return server.NewStreamer(log.WithName("streamer"), server.StreamerOptions{
+		URL:          streamingURL,
+		TokenTTL:     opts.ExecTokenTTL,
+		SerialSocket: hostPaths.MachineSerialSocket,
+	})
+}
+
+// startOptionalServices starts the services serving the pools that are enabled by the options.
+func startOptionalServices(
+	ctx context.Context,
+	g *errgroup.Group,
+	log logr.Logger,
+	opts Options,
+	pools []*pool,
+	streamer *server.Streamer,
+) {
+	setupLog := log.WithName("setup")
+
+	if opts.DrainFile != "" {
+		g.Go(func() error {
+			setupLog.Info("Starting drain file watcher", "File", opts.DrainFile)
+			watchDrainFile(ctx, log.WithName("drain"), opts.DrainFile, pools)
+			return nil
+		})
+	}
+
+	if opts.NodeResourcesAddress != "" {
+		g.Go(func() error {
+			if err := RunNodeResourcesServer(ctx, setupLog, opts.NodeResourcesAddress, pools); err != nil {
+				setupLog.Error(err, "failed to start node resources server")
+				return err
+			}
+			return nil
+		})
+	}
+
+	if opts.MetricsAddress != "" {
+		g.Go(func() error {
+			if err := RunMetricsServer(ctx, setupLog, opts.MetricsAddress); err != nil {
+				setupLog.Error(err, "failed to start metrics server")
+				return err
+			}
+			return nil
+		})
+	}
+
+	if streamer != nil {
+		g.Go(func() error {
+			if err := RunStreamingServer(ctx, setupLog, opts.StreamingAddress, streamer); err != nil {
+				setupLog.Error(err, "failed to start streaming server")
+				return err
+			}
+			return nil
+		})
+	}
+}
+
+// cephSecretGetter returns the getter of the secrets referenced by ceph volumes, nil if referencing secrets
+// is disabled.
+// newHostCapacity returns the accounting of the host resources and the numa placement of vms. Both are shared
+// by all pools, as the pools share the host.
+func newHostCapacity(
+	setupLog logr.Logger,
+	opts Options,
+	overcommit capacity.Overcommit,
+) (*capacity.Accountant, *vmm.NumaTracker, error) {
+	hostResources, err := capacity.HostResources()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get host resources: %w", err)
+	}
+	setupLog.Info("Host resources", "cpu", hostResources.Cpu, "memory", hostResources.MemoryBytes)
+
+	accountant, err := capacity.NewAccountant(hostResources, capacity.AccountantOptions{
+		Overcommit:    overcommit,
+		MemoryReserve: opts.MemoryReserve,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var numaNodes []capacity.NumaNode
+	if opts.NumaPlacement {
+		numaNodes, err = capacity.NumaTopology(capacity.DefaultSysfsNodesPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get host numa topology: %w", err)
+		}
+		setupLog.Info("Host numa topology", "nodes", len(numaNodes))
+	}
+	return accountant, vmm.NewNumaTracker(numaNodes), nil
+}
+
+func cephSecretGetter(opts Options) (ceph.SecretGetter, error) {
+	if opts.CephSecretNamespace == "" {
+		return nil, nil
+	}
+
+	var (
+		cfg *rest.Config
+		err error
+	)
+	if opts.CephSecretKubeconfig != "" {
+		cfg, err = clientcmd.BuildConfigFromFlags("", opts.CephSecretKubeconfig)
+	} else {
+		cfg, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config of the ceph secrets cluster: %w", err)
+	}
+
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize client of the ceph secrets cluster: %w", err)
+	}
+	return ceph.NewKubernetesSecretGetter(c, opts.CephSecretNamespace), nil
+}
+
+type poolDependencies struct {
+	firmwarePath  string
+	kernelPath    string
+	paths         host.Paths
+	imageCache    ociutils.Cache
+	raw           raw.Raw
+	pluginManager *volume.PluginManager
+	nicPlugin     networkinterface.Plugin
+	capacity      *capacity.Accountant
+	overcommit    capacity.Overcommit
+	memoryReserve int64
+	minFreeDisk   int64
+	numa          *vmm.NumaTracker
+	vsock         *vmm.VsockTracker
+	pciManager    *pci.Manager
+	cgroupManager *cgroup.Manager
+	// socketLeakScanner tracks the machine stores of all pools, the machine dirs are shared.
+	socketLeakScanner *sockets.LeakScanner
+
+	defaultClass string
+
+	compaction compaction.Options
+
+	reconcileTimeout  time.Duration
+	vmInfoCacheTTL    time.Duration
+	serialMode        vmm.SerialMode
+	serialLogMax      int64
+	consoleMode       vmm.ConsoleMode
+	diskAIO           vmm.DiskAIO
+	rngSource         string
+	socketTimeout     time.Duration
+	detachVMs         bool
+	bootTimeout       time.Duration
+	powerOffOnBoot    bool
+	guestShutdown     controllers.GuestShutdownPolicy
+	shutdownGrace     time.Duration
+	quarantine        int
+	workers           int
+	powerOnLimiter    *rate.Limiter
+	prioritizeChanges bool
+	probeVolumes      bool
+	flushInterval     time.Duration
+
+	validateImageArch bool
+	architecture      string
+
+	requestLimits server.RequestLimits
+	auditLog      *audit.Logger
+	reflection    bool
+	streamer      *server.Streamer
+
+	features    []string
+	versionInfo version.Info
+}
+
+// vmmCloseTimeout bounds shutting down the vms of a pool on exit.
+const vmmCloseTimeout = 30 * time.Second
+
+type pool struct {
+	config PoolConfig
+
+	log      logr.Logger
+	setupLog logr.Logger
+
+	machineEvents     *event.ListWatchSource[*api.Machine]
+	eventRecorder     *recorder.Store
+	machineReconciler *controllers.MachineReconciler
+	vmm               *vmm.Manager
+	storeCompactor    *compaction.MachineStoreCompactor
+	server            *server.Server
+	requestLimits     server.RequestLimits
+	auditLog          *audit.Logger
+	reflection        bool
+}
+
+func newPool(ctx context.Context, log logr.Logger, config PoolConfig, deps poolDependencies) (*pool, error) {
+	log = log.WithValues("pool", config.Name)
+
+	classRegistry, err := mcr.NewMachineClassRegistry(config.MachineClasses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize machine class registry: %w", err)
+	}
+
 	machineStore, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
-		Dir:            opts.MachineStoreDir,
+		Dir:            config.MachineStoreDir,
 		NewFunc:        func() *api.Machine { return &api.Machine{} },
 		CreateStrategy: strategy.MachineStrategy,
 	})
 	if err != nil {
-		setupLog.Error(err, "failed to initialize machine store")
-		return err
+		return nil, fmt.Errorf("failed to initialize machine store: %w", err)
 	}
+	deps.socketLeakScanner.Track(machineStore)
 
 	machineEvents, err := event.NewListWatchSource[*api.Machine](
 		machineStore.List,
@@ -235,34 +1148,57 @@ func Run(ctx context.Context, opts Options) error {
 		event.ListWatchSourceOptions{},
 	)
 	if err != nil {
-		setupLog.Error(err, "failed to initialize machine events")
-		return err
+		return nil, fmt.Errorf("failed to initialize machine events: %w", err)
 	}
 
 	var socketsInUse []string
 	machines, err := machineStore.List(ctx)
 	if err != nil {
-		setupLog.Error(err, "failed to get initial machines")
-		return err
+		return nil, fmt.Errorf("failed to get initial machines: %w", err)
 	}
 	for _, machine := range machines {
 		if sock := ptr.Deref(machine.Spec.ApiSocketPath, ""); sock != "" {
 			socketsInUse = append(socketsInUse, sock)
 		}
+		deps.pciManager.Reserve(machine.ID, machine.Spec.PciDevices)
+	}
+
+	// Machines are resized within the machine classes of the pool, the largest class bounds the vms.
+	var (
+		maxVcpus       int
+		maxMemoryBytes int64
+	)
+	for _, class := range classRegistry.List() {
+		maxVcpus = max(maxVcpus, int(class.Cpu))
+		maxMemoryBytes = max(maxMemoryBytes, class.MemoryBytes)
 	}
 
 	virtualMachineManager, err := vmm.NewManager(
 		log.WithName("virtual-machine-manager"),
-		hostPaths,
+		deps.paths,
 		vmm.ManagerOptions{
-			CHSocketsPath:     opts.CloudHypervisorSocketsPath,
-			FirmwarePath:      opts.CloudHypervisorFirmwarePath,
+			CHSocketsPath:     config.CloudHypervisorSocketsPath,
+			FirmwarePath:      deps.firmwarePath,
+			KernelPath:        deps.kernelPath,
 			ReservedInstances: socketsInUse,
+			MemoryReserve:     deps.memoryReserve,
+			MemoryOvercommit:  deps.overcommit.Memory,
+			MaxVcpus:          maxVcpus,
+			MaxMemoryBytes:    maxMemoryBytes,
+			Numa:              deps.numa,
+			Vsock:             deps.vsock,
+			VMInfoTTL:         deps.vmInfoCacheTTL,
+			SerialMode:        deps.serialMode,
+			SerialLogMaxBytes: deps.serialLogMax,
+			ConsoleMode:       deps.consoleMode,
+			DiskAIO:           deps.diskAIO,
+			RngSource:         deps.rngSource,
+			DetachVMs:         deps.detachVMs,
+			SocketWaitTimeout: deps.socketTimeout,
 		},
 	)
 	if err != nil {
-		setupLog.Error(err, "failed to initialize virtual-machine-manager")
-		return err
+		return nil, fmt.Errorf("failed to initialize virtual-machine-manager: %w", err)
 	}
 
 	eventRecorder := recorder.NewEventStore(log, recorder.EventStoreOptions{})
@@ -272,75 +1208,152 @@ func Run(ctx context.Context, opts Options) error {
 		machineEvents,
 		eventRecorder,
 		virtualMachineManager,
-		pluginManager,
-		nicPlugin,
+		deps.pluginManager,
+		deps.nicPlugin,
 		controllers.MachineReconcilerOptions{
-			ImageCache: imgCache,
-			Raw:        rawInst,
-			Paths:      hostPaths,
+			ImageCache:                deps.imageCache,
+			Raw:                       deps.raw,
+			Paths:                     deps.paths,
+			PciDevices:                deps.pciManager,
+			Cgroups:                   deps.cgroupManager,
+			ReconcileTimeout:          deps.reconcileTimeout,
+			ValidateImageArchitecture: deps.validateImageArch,
+			Architecture:              deps.architecture,
+			BootTimeout:               deps.bootTimeout,
+			PowerOffOnBootTimeout:     deps.powerOffOnBoot,
+			GuestShutdownPolicy:       deps.guestShutdown,
+			ShutdownGracePeriod:       deps.shutdownGrace,
+			QuarantineThreshold:       deps.quarantine,
+			PrioritizeMachineChanges:  deps.prioritizeChanges,
+			ProbeVolumes:              deps.probeVolumes,
+			VolumeFlushInterval:       deps.flushInterval,
+			QueueName:                 "machine-" + config.Name,
+			WorkerSize:                deps.workers,
+			PowerOnLimiter:            deps.powerOnLimiter,
+			AuditLog:                  deps.auditLog,
 		},
 	)
 	if err != nil {
-		setupLog.Error(err, "failed to initialize machine controller")
-		return err
+		return nil, fmt.Errorf("failed to initialize machine controller: %w", err)
+	}
+
+	storeCompactor, err := compaction.NewMachineStoreCompactor(
+		log.WithName("machine-store-compactor"),
+		machineStore,
+		virtualMachineManager,
+		deps.compaction,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize machine store compactor: %w", err)
 	}
 
 	srv, err := server.New(machineStore, server.Options{
 		EventStore:           eventRecorder,
 		MachineClassRegistry: classRegistry,
+		DefaultMachineClass:  deps.defaultClass,
+		Capacity:             deps.capacity,
+		DiskDir:              deps.paths.MachinesDir(),
+		MinFreeDisk:          deps.minFreeDisk,
+		VolumePlugins:        deps.pluginManager,
+		Streamer:             deps.streamer,
+		Features:             deps.features,
+		VersionInfo:          deps.versionInfo,
 	})
 	if err != nil {
-		return fmt.Errorf("error creating server: %w", err)
+		return nil, fmt.Errorf("error creating server: %w", err)
 	}
 
-	g, ctx := errgroup.WithContext(ctx)
+	return &pool{
+		config:            config,
+		log:               log,
+		setupLog:          log.WithName("setup"),
+		machineEvents:     machineEvents,
+		eventRecorder:     eventRecorder,
+		machineReconciler: machineReconciler,
+		vmm:               virtualMachineManager,
+		storeCompactor:    storeCompactor,
+		server:            srv,
+		requestLimits:     deps.requestLimits,
+		auditLog:          deps.auditLog,
+		reflection:        deps.reflection,
+	}, nil
+}
+
+// start runs the pool until ctx is done and then shuts it down in stages: the grpc server stops accepting
+// requests and finishes the in-flight ones, then the reconciler is drained and finally the vms are closed.
+func (p *pool) start(ctx context.Context, g *errgroup.Group) {
+	reconcileCtx, stopReconcile := context.WithCancel(context.WithoutCancel(ctx))
+	reconcilerDone := make(chan struct{})
+
 	g.Go(func() error {
-		setupLog.Info("Starting oci cache")
-		if err := imgCache.Start(ctx); err != nil {
-			setupLog.Error(err, "failed to start oci cache")
+		defer close(reconcilerDone)
+		p.setupLog.Info("Starting machine reconciler")
+		if err := p.machineReconciler.Start(reconcileCtx); err != nil {
+			p.setupLog.Error(err, "failed to start machine reconciler")
 			return err
 		}
 		return nil
 	})
 
 	g.Go(func() error {
-		setupLog.Info("Starting machine reconciler")
-		if err := machineReconciler.Start(ctx); err != nil {
-			setupLog.Error(err, "failed to start machine reconciler")
+		p.vmm.RunSerialLogCapping(reconcileCtx)
+		return nil
+	})
+
+	g.Go(func() error {
+		p.setupLog.Info("Starting machine events")
+		if err := p.machineEvents.Start(reconcileCtx); err != nil {
+			p.setupLog.Error(err, "failed to start machine events")
 			return err
 		}
 		return nil
 	})
 
 	g.Go(func() error {
-		setupLog.Info("Starting machine events")
-		if err := machineEvents.Start(ctx); err != nil {
-			setupLog.Error(err, "failed to start machine events")
-			return err
+		<-reconcilerDone
+		p.setupLog.Info("Closing virtual machine manager")
+		closeCtx, cancel := context.WithTimeout(context.Background(), vmmCloseTimeout)
+		defer cancel()
+		if err := p.vmm.Close(closeCtx); err != nil {
+			p.setupLog.Error(err, "failed to close virtual machine manager")
 		}
 		return nil
 	})
 
 	g.Go(func() error {
-		setupLog.Info("Starting machine events garbage collector")
-		eventRecorder.Start(ctx)
+		p.setupLog.Info("Starting machine events garbage collector")
+		p.eventRecorder.Start(reconcileCtx)
 		return nil
 	})
 
 	g.Go(func() error {
-		setupLog.Info("Starting grpc server")
-		if err := RunGRPCServer(ctx, setupLog, log, srv, opts.Address); err != nil {
-			setupLog.Error(err, "failed to start grpc server")
+		p.setupLog.Info("Starting machine store compactor")
+		p.storeCompactor.Start(reconcileCtx)
+		return nil
+	})
+
+	g.Go(func() error {
+		defer stopReconcile()
+		p.setupLog.Info("Starting grpc server")
+		if err := RunGRPCServer(ctx, p.setupLog, p.log, p.server, p.config.Address, p.requestLimits, p.auditLog, p.reflection); err != nil {
+			p.setupLog.Error(err, "failed to start grpc server")
 			return err
 		}
 		return nil
 	})
-	return g.Wait()
 }
 
-func RunGRPCServer(ctx context.Context, setupLog, log logr.Logger, srv *server.Server, address string) error {
+func RunGRPCServer(
+	ctx context.Context,
+	setupLog, log logr.Logger,
+	srv *server.Server,
+	address string,
+	limits server.RequestLimits,
+	auditLog *audit.Logger,
+	enableReflection bool,
+) error {
 	log.V(1).Info("Cleaning up any previous socket")
-	if err := common.CleanupSocketIfExists(address); err != nil {
+	if err := CleanupStaleSocket(address); err != nil {
 		return fmt.Errorf("error cleaning up socket: %w", err)
 	}
 
@@ -348,9 +1361,14 @@ func RunGRPCServer(ctx context.Context, setupLog, log logr.Logger, srv *server.S
 		grpc.ChainUnaryInterceptor(
 			commongrpc.InjectLogger(log),
 			commongrpc.LogRequest,
+			server.AuditRequests(auditLog),
+			server.LimitRequests(limits),
 		),
 	)
 	iri.RegisterMachineRuntimeServer(grpcSrv, srv)
+	if enableReflection {
+		reflection.Register(grpcSrv)
+	}
 
 	log.V(1).Info("Start listening on unix socket", "Address", address)
 	l, err := net.Listen("unix", address)
@@ -372,9 +1390,11 @@ func RunGRPCServer(ctx context.Context, setupLog, log logr.Logger, srv *server.S
 	go func() {
 		<-ctx.Done()
 		setupLog.Info("Shutting down grpc server")
+		srv.Shutdown()
 		grpcSrv.GracefulStop()
 		setupLog.Info("Shut down grpc server")
 	}()
+	// Serve only returns once a graceful stop finished all in-flight requests.
 	if err := grpcSrv.Serve(l); err != nil {
 		return fmt.Errorf("error serving grpc: %w", err)
 	}
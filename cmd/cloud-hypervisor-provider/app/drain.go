@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const drainFilePollInterval = 5 * time.Second
+
+// watchDrainFile puts all pools into drain mode as long as the drain file exists. Creating the file is
+// the admin operation to prepare the host for maintenance, removing it makes the host schedulable again.
+func watchDrainFile(ctx context.Context, log logr.Logger, filename string, pools []*pool) {
+	draining := false
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		exists, err := osutils.RegularFileExists(filename)
+		if err != nil {
+			log.Error(err, "Failed to check drain file", "File", filename)
+			return
+		}
+		if exists == draining {
+			return
+		}
+
+		draining = exists
+		log.Info("Changing drain mode", "Draining", draining)
+		for _, p := range pools {
+			p.server.SetDraining(draining)
+		}
+	}, drainFilePollInterval)
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app_test
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cmd/cloud-hypervisor-provider/app"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("GRPC Server", func() {
+	var pool app.PoolConfig
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		pool = app.PoolConfig{
+			Address:         filepath.Join(tempDir, "default.sock"),
+			MachineStoreDir: filepath.Join(tempDir, "store"),
+			MachineClasses: []mcr.MachineClass{
+				{Name: "small", Cpu: 1000, MemoryBytes: 1024 * 1024 * 1024},
+			},
+		}
+	})
+
+	listServices := func(ctx context.Context, conn *grpc.ClientConn) (*reflectionpb.ServerReflectionResponse, error) {
+		stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		}); err != nil {
+			return nil, err
+		}
+		return stream.Recv()
+	}
+
+	serve := func(enableReflection bool) *grpc.ClientConn {
+		srv, _ := newPoolServer(pool)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		return dialGRPC(ctx, srv, pool.Address, enableReflection)
+	}
+
+	It("should serve the reflection service if enabled", func(ctx SpecContext) {
+		conn := serve(true)
+
+		resp, err := listServices(ctx, conn)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.GetListServicesResponse().GetService()).To(ContainElement(
+			HaveField("Name", iri.MachineRuntime_ServiceDesc.ServiceName),
+		))
+	})
+
+	It("should not serve the reflection service by default", func(ctx SpecContext) {
+		conn := serve(false)
+
+		_, err := listServices(ctx, conn)
+		Expect(status.Code(err)).To(Equal(codes.Unimplemented))
+
+		By("ensuring the iri api is still served")
+		Expect(iri.NewMachineRuntimeClient(conn).ListMachines(ctx, &iri.ListMachinesRequest{})).Error().NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const nodeResourcesShutdownTimeout = 5 * time.Second
+
+// RunNodeResourcesServer serves the node resources of every pool at /node-resources/<pool> and its host
+// status at /host-status/<pool>.
+func RunNodeResourcesServer(ctx context.Context, setupLog logr.Logger, address string, pools []*pool) error {
+	mux := http.NewServeMux()
+	for _, p := range pools {
+		mux.Handle("GET /node-resources/"+p.config.Name, p.server.NodeResourcesHandler())
+		mux.Handle("GET /host-status/"+p.config.Name, p.server.HostStatusHandler())
+	}
+
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	setupLog.Info("Starting node resources server", "Address", address)
+	go func() {
+		<-ctx.Done()
+		setupLog.Info("Shutting down node resources server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), nodeResourcesShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			setupLog.Error(err, "failed to shut down node resources server")
+		}
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error serving node resources: %w", err)
+	}
+	return nil
+}
@@ -7,27 +7,35 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
 )
 
 type MachineClass struct {
 	Name        string
 	Cpu         int64
 	MemoryBytes int64
+	QoSClass    api.QoSClass
+	NumaNode    *int
 }
 type MachineClassOptions []MachineClass
 
 func (ml *MachineClassOptions) String() string {
 	var parts []string
 	for _, m := range *ml {
-		parts = append(parts, fmt.Sprintf("%s,%d,%d", m.Name, m.Cpu, m.MemoryBytes))
+		part := fmt.Sprintf("%s,%d,%d,%s", m.Name, m.Cpu, m.MemoryBytes, m.QoSClass)
+		if m.NumaNode != nil {
+			part += fmt.Sprintf(",%d", *m.NumaNode)
+		}
+		parts = append(parts, part)
 	}
 	return strings.Join(parts, "; ")
 }
 
 func (ml *MachineClassOptions) Set(value string) error {
 	parts := strings.Split(value, ",")
-	if len(parts) != 3 {
-		return fmt.Errorf("invalid machine format: expected name,cpu,memory")
+	if len(parts) < 3 || len(parts) > 5 {
+		return fmt.Errorf("invalid machine format: expected name,cpu,memory[,qos-class[,numa-node]]")
 	}
 
 	cpuMillis, err := strconv.ParseInt(parts[1], 10, 64)
@@ -40,10 +48,29 @@ func (ml *MachineClassOptions) Set(value string) error {
 		return fmt.Errorf("invalid Memory value: %s", parts[2])
 	}
 
+	qosClass := api.QoSClassBurstable
+	if len(parts) >= 4 {
+		qosClass = api.QoSClass(parts[3])
+		if err := api.ValidateQoSClass(qosClass); err != nil {
+			return err
+		}
+	}
+
+	var numaNode *int
+	if len(parts) == 5 {
+		node, err := strconv.Atoi(parts[4])
+		if err != nil || node < 0 {
+			return fmt.Errorf("invalid numa node: %s", parts[4])
+		}
+		numaNode = &node
+	}
+
 	*ml = append(*ml, MachineClass{
 		Name:        parts[0],
 		Cpu:         cpuMillis,
 		MemoryBytes: memoryBytes,
+		QoSClass:    qosClass,
+		NumaNode:    numaNode,
 	})
 
 	return nil
@@ -52,3 +79,45 @@ func (ml *MachineClassOptions) Set(value string) error {
 func (ml *MachineClassOptions) Type() string {
 	return "machine-class"
 }
+
+type Pool struct {
+	Name           string
+	Address        string
+	MachineClasses []string
+}
+type PoolOptions []Pool
+
+func (pl *PoolOptions) String() string {
+	var parts []string
+	for _, p := range *pl {
+		parts = append(parts, strings.Join(append([]string{p.Name, p.Address}, p.MachineClasses...), ","))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (pl *PoolOptions) Set(value string) error {
+	parts := strings.Split(value, ",")
+	if len(parts) < 2 {
+		return fmt.Errorf("invalid pool format: expected name,address[,machine-class...]")
+	}
+
+	if parts[0] == "" || strings.ContainsRune(parts[0], '/') {
+		return fmt.Errorf("invalid pool name: %q", parts[0])
+	}
+
+	if parts[1] == "" {
+		return fmt.Errorf("invalid pool address: must not be empty")
+	}
+
+	*pl = append(*pl, Pool{
+		Name:           parts[0],
+		Address:        parts[1],
+		MachineClasses: parts[2:],
+	})
+
+	return nil
+}
+
+func (pl *PoolOptions) Type() string {
+	return "pool"
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app_test
+
+import (
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cmd/cloud-hypervisor-provider/app"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("MachineClassOptions", func() {
+	It("should parse the qos class of machine classes", func() {
+		var classes app.MachineClassOptions
+		Expect(classes.Set("small,1,1073741824")).To(Succeed())
+		Expect(classes.Set("large,4,8589934592,Guaranteed")).To(Succeed())
+
+		Expect(classes).To(Equal(app.MachineClassOptions{
+			{Name: "small", Cpu: 1, MemoryBytes: 1073741824, QoSClass: api.QoSClassBurstable},
+			{Name: "large", Cpu: 4, MemoryBytes: 8589934592, QoSClass: api.QoSClassGuaranteed},
+		}))
+	})
+
+	It("should parse the numa node of machine classes", func() {
+		var classes app.MachineClassOptions
+		Expect(classes.Set("pinned,2,2147483648,Guaranteed,1")).To(Succeed())
+
+		Expect(classes).To(Equal(app.MachineClassOptions{
+			{Name: "pinned", Cpu: 2, MemoryBytes: 2147483648, QoSClass: api.QoSClassGuaranteed, NumaNode: ptr.To(1)},
+		}))
+		Expect(classes.String()).To(Equal("pinned,2,2147483648,Guaranteed,1"))
+
+		Expect(classes.Set("pinned,2,2147483648,Guaranteed,-1")).To(MatchError(ContainSubstring("invalid numa node")))
+	})
+
+	It("should reject an invalid qos class", func() {
+		var classes app.MachineClassOptions
+		Expect(classes.Set("small,1,1073741824,Platinum")).To(MatchError(ContainSubstring(`invalid qos class "Platinum"`)))
+	})
+})
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+)
+
+const defaultPoolName = "default"
+
+type PoolConfig struct {
+	Name                       string
+	Address                    string
+	MachineStoreDir            string
+	CloudHypervisorSocketsPath string
+	MachineClasses             []mcr.MachineClass
+}
+
+// PoolConfigs resolves the configured pools. Without any explicit pool, a single default pool serving all
+// machine classes is derived from the top level options.
+func (o *Options) PoolConfigs() ([]PoolConfig, error) {
+	var classes []mcr.MachineClass
+	for _, class := range o.MachineClasses {
+		classes = append(classes, mcr.MachineClass(class))
+	}
+
+	if len(o.Pools) == 0 {
+		return []PoolConfig{{
+			Name:                       defaultPoolName,
+			Address:                    o.Address,
+			MachineStoreDir:            o.MachineStoreDir,
+			CloudHypervisorSocketsPath: o.CloudHypervisorSocketsPath,
+			MachineClasses:             classes,
+		}}, nil
+	}
+
+	classByName := make(map[string]mcr.MachineClass, len(classes))
+	for _, class := range classes {
+		classByName[class.Name] = class
+	}
+
+	var (
+		configs   []PoolConfig
+		names     = map[string]struct{}{}
+		addresses = map[string]struct{}{}
+	)
+	for _, pool := range o.Pools {
+		if _, ok := names[pool.Name]; ok {
+			return nil, fmt.Errorf("multiple pools with same name (%s) found", pool.Name)
+		}
+		names[pool.Name] = struct{}{}
+
+		if _, ok := addresses[pool.Address]; ok {
+			return nil, fmt.Errorf("multiple pools with same address (%s) found", pool.Address)
+		}
+		addresses[pool.Address] = struct{}{}
+
+		poolClasses := classes
+		if len(pool.MachineClasses) > 0 {
+			poolClasses = nil
+			for _, name := range pool.MachineClasses {
+				class, ok := classByName[name]
+				if !ok {
+					return nil, fmt.Errorf("pool %s references unknown machine class %s", pool.Name, name)
+				}
+				poolClasses = append(poolClasses, class)
+			}
+		}
+
+		configs = append(configs, PoolConfig{
+			Name:                       pool.Name,
+			Address:                    pool.Address,
+			MachineStoreDir:            filepath.Join(o.MachineStoreDir, pool.Name),
+			CloudHypervisorSocketsPath: filepath.Join(o.CloudHypervisorSocketsPath, pool.Name),
+			MachineClasses:             poolClasses,
+		})
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cmd/cloud-hypervisor-provider/app"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
+	"github.com/ironcore-dev/ironcore/iri/remote/machine"
+	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+var _ = Describe("Pools", func() {
+	var opts app.Options
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		opts = app.Options{
+			Address:                    filepath.Join(tempDir, "default.sock"),
+			MachineStoreDir:            filepath.Join(tempDir, "store"),
+			CloudHypervisorSocketsPath: filepath.Join(tempDir, "ch"),
+			MachineClasses: app.MachineClassOptions{
+				{Name: "small", Cpu: 1000, MemoryBytes: 1024 * 1024 * 1024},
+				{Name: "large", Cpu: 4000, MemoryBytes: 8 * 1024 * 1024 * 1024},
+			},
+		}
+	})
+
+	It("should default to a single pool serving all machine classes", func() {
+		pools, err := opts.PoolConfigs()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pools).To(ConsistOf(SatisfyAll(
+			HaveField("Address", opts.Address),
+			HaveField("MachineStoreDir", opts.MachineStoreDir),
+			HaveField("CloudHypervisorSocketsPath", opts.CloudHypervisorSocketsPath),
+			HaveField("MachineClasses", HaveLen(2)),
+		)))
+	})
+
+	It("should reject pools referencing unknown machine classes", func() {
+		Expect(opts.Pools.Set(fmt.Sprintf("a,%s,unknown", filepath.Join(GinkgoT().TempDir(), "a.sock")))).To(Succeed())
+		_, err := opts.PoolConfigs()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject pools sharing an address", func() {
+		Expect(opts.Pools.Set("a,/run/pool.sock")).To(Succeed())
+		Expect(opts.Pools.Set("b,/run/pool.sock")).To(Succeed())
+		_, err := opts.PoolConfigs()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should isolate the machine stores of multiple pools", func(ctx SpecContext) {
+		socketDir := GinkgoT().TempDir()
+		Expect(opts.Pools.Set(fmt.Sprintf("a,%s,small", filepath.Join(socketDir, "a.sock")))).To(Succeed())
+		Expect(opts.Pools.Set(fmt.Sprintf("b,%s,large", filepath.Join(socketDir, "b.sock")))).To(Succeed())
+
+		pools, err := opts.PoolConfigs()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pools).To(HaveLen(2))
+		Expect(pools[0].MachineStoreDir).NotTo(Equal(pools[1].MachineStoreDir))
+		Expect(pools[0].CloudHypervisorSocketsPath).NotTo(Equal(pools[1].CloudHypervisorSocketsPath))
+
+		By("starting both pools")
+		clientA := startPool(pools[0])
+		clientB := startPool(pools[1])
+
+		By("creating a machine in pool a")
+		createResp, err := clientA.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: "small",
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("ensuring the machine is only listed in pool a")
+		listResp, err := clientA.ListMachines(ctx, &iri.ListMachinesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listResp.Machines).To(ConsistOf(HaveField("Metadata.Id", createResp.Machine.Metadata.Id)))
+
+		listResp, err = clientB.ListMachines(ctx, &iri.ListMachinesRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listResp.Machines).To(BeEmpty())
+
+		By("ensuring pool b does not serve the machine classes of pool a")
+		_, err = clientB.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: "small",
+				},
+			},
+		})
+		Expect(err).To(HaveOccurred())
+
+		statusResp, err := clientB.Status(ctx, &iri.StatusRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusResp.MachineClassStatus).To(ConsistOf(HaveField("MachineClass.Name", "large")))
+	})
+
+	It("should not create machines once the shutdown began", func(ctx SpecContext) {
+		pools, err := opts.PoolConfigs()
+		Expect(err).NotTo(HaveOccurred())
+		srv, machineStore := newPoolServer(pools[0])
+
+		serveCtx, stopServing := context.WithCancel(context.Background())
+		DeferCleanup(stopServing)
+		client := serveGRPC(serveCtx, srv, pools[0].Address)
+
+		newMachine := func() *iri.CreateMachineRequest {
+			return &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{},
+					Spec: &iri.MachineSpec{
+						Power: iri.Power_POWER_ON,
+						Class: "small",
+					},
+				},
+			}
+		}
+
+		By("creating a machine while serving")
+		Expect(client.CreateMachine(ctx, newMachine())).Error().NotTo(HaveOccurred())
+
+		By("beginning the shutdown")
+		stopServing()
+		Eventually(srv.ShuttingDown).Should(BeTrue())
+
+		By("ensuring no new machines are created")
+		Expect(client.CreateMachine(ctx, newMachine())).Error().To(HaveOccurred())
+		Consistently(func() ([]*api.Machine, error) {
+			return machineStore.List(ctx)
+		}, time.Second).Should(HaveLen(1))
+	})
+})
+
+func newPoolServer(pool app.PoolConfig) (*server.Server, *hostutils.Store[*api.Machine]) {
+	machineStore, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+		Dir:            pool.MachineStoreDir,
+		NewFunc:        func() *api.Machine { return &api.Machine{} },
+		CreateStrategy: strategy.MachineStrategy,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	classRegistry, err := mcr.NewMachineClassRegistry(pool.MachineClasses)
+	Expect(err).NotTo(HaveOccurred())
+
+	srv, err := server.New(machineStore, server.Options{
+		MachineClassRegistry: classRegistry,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	return srv, machineStore
+}
+
+func startPool(pool app.PoolConfig) iri.MachineRuntimeClient {
+	srv, _ := newPoolServer(pool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	DeferCleanup(cancel)
+
+	return serveGRPC(ctx, srv, pool.Address)
+}
+
+func serveGRPC(ctx context.Context, srv *server.Server, address string) iri.MachineRuntimeClient {
+	return iri.NewMachineRuntimeClient(dialGRPC(ctx, srv, address, false))
+}
+
+// dialGRPC serves srv on address until ctx is done and returns a connection to it.
+func dialGRPC(ctx context.Context, srv *server.Server, address string, enableReflection bool) *grpc.ClientConn {
+	log := zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))
+
+	go func() {
+		defer GinkgoRecover()
+		Expect(app.RunGRPCServer(ctx, log, log, srv, address, server.RequestLimits{}, nil, enableReflection)).To(Succeed())
+	}()
+
+	Eventually(func() (os.FileMode, error) {
+		stat, err := os.Stat(address)
+		if err != nil {
+			return 0, err
+		}
+		return stat.Mode() & os.ModeSocket, nil
+	}).ShouldNot(BeZero())
+
+	grpcAddress, err := machine.GetAddressWithTimeout(3*time.Second, fmt.Sprintf("unix://%s", address))
+	Expect(err).NotTo(HaveOccurred())
+
+	conn, err := grpc.NewClient(grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(conn.Close)
+
+	return conn
+}
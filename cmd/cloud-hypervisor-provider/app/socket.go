@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ironcore-dev/ironcore/broker/common"
+)
+
+const socketProbeTimeout = time.Second
+
+// ErrSocketInUse is returned if a socket to clean up is still served by a live process.
+var ErrSocketInUse = errors.New("socket in use")
+
+// CleanupStaleSocket removes the socket left behind by a crashed process. A socket still accepting
+// connections belongs to a live process and is kept, removing it would leave the process serving
+// a socket no client can reach anymore.
+func CleanupStaleSocket(address string) error {
+	conn, err := net.DialTimeout("unix", address, socketProbeTimeout)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%w: %s", ErrSocketInUse, address)
+	}
+	return common.CleanupSocketIfExists(address)
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cmd/cloud-hypervisor-provider/app"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CleanupStaleSocket", func() {
+	var address string
+
+	BeforeEach(func() {
+		// Unix socket paths are length limited, keep the socket in a short temp dir.
+		dir, err := os.MkdirTemp("", "sock")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, dir)
+		address = filepath.Join(dir, "provider.sock")
+	})
+
+	It("should keep a socket served by a live process", func() {
+		listener, err := net.Listen("unix", address)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(listener.Close)
+
+		Expect(app.CleanupStaleSocket(address)).To(MatchError(app.ErrSocketInUse))
+		Expect(address).To(BeAnExistingFile())
+	})
+
+	It("should remove the socket of a crashed process", func() {
+		listener, err := net.Listen("unix", address)
+		Expect(err).NotTo(HaveOccurred())
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		Expect(listener.Close()).To(Succeed())
+		Expect(address).To(BeAnExistingFile())
+
+		Expect(app.CleanupStaleSocket(address)).To(Succeed())
+		Expect(address).NotTo(BeAnExistingFile())
+	})
+
+	It("should succeed without a socket", func() {
+		Expect(app.CleanupStaleSocket(address)).To(Succeed())
+	})
+})
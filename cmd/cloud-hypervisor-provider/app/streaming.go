@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+)
+
+const streamingShutdownTimeout = 5 * time.Second
+
+// RunStreamingServer serves the exec sessions handed out by the pools.
+func RunStreamingServer(ctx context.Context, setupLog logr.Logger, address string, streamer *server.Streamer) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET "+server.ExecPath, streamer)
+
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	setupLog.Info("Starting streaming server", "Address", address)
+	go func() {
+		<-ctx.Done()
+		setupLog.Info("Shutting down streaming server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), streamingShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			setupLog.Error(err, "failed to shut down streaming server")
+		}
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error serving streaming: %w", err)
+	}
+	return nil
+}
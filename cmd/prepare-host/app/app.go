@@ -34,6 +34,7 @@ type Options struct {
 	CloudHypervisorBinPath   string
 	CloudHypervisorBinSubDir string
 	CloudHypervisorBinUrl    string
+	CloudHypervisorLogLevel  string
 
 	CloudHypervisorFirmwarePath   string
 	CloudHypervisorFirmwareSubDir string
@@ -73,6 +74,13 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 		"",
 		"Cloud-hypervisor binary url.",
 	)
+	fs.StringVar(
+		&o.CloudHypervisorLogLevel,
+		"cloud-hypervisor-log-level",
+		LogLevelInfo,
+		fmt.Sprintf("Log level of the cloud-hypervisor instances. Must be one of %s, %s, %s.",
+			LogLevelQuiet, LogLevelInfo, LogLevelDebug),
+	)
 
 	fs.StringVar(
 		&o.CloudHypervisorFirmwarePath,
@@ -144,6 +152,12 @@ func Run(ctx context.Context, opts Options) error {
 		return fmt.Errorf("failed to set owner: %w", err)
 	}
 
+	logLevelEnvFile := path.Join(opts.ProviderBasePath, LogLevelEnvFile)
+	log.V(1).Info("writing log level", "path", logLevelEnvFile, "level", opts.CloudHypervisorLogLevel)
+	if err := WriteLogLevelEnv(logLevelEnvFile, opts.CloudHypervisorLogLevel); err != nil {
+		return err
+	}
+
 	chPresent := isFilePresent(log, path.Join(opts.CloudHypervisorBinPath, opts.CloudHypervisorBinSubDir, ChName))
 	if !opts.Download && !chPresent {
 		log.V(1).Info(
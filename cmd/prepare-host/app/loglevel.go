@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	LogLevelQuiet = "quiet"
+	LogLevelInfo  = "info"
+	LogLevelDebug = "debug"
+
+	// LogLevelEnvFile is the environment file in the provider base path the cloud-hypervisor units read
+	// their log arguments from.
+	LogLevelEnvFile = "cloud-hypervisor.env"
+	// LogArgsEnv is the variable the cloud-hypervisor units expand into their command line.
+	LogArgsEnv = "CH_LOG_ARGS"
+)
+
+// LogLevelArgs returns the cloud-hypervisor verbosity arguments for the log level.
+func LogLevelArgs(level string) ([]string, error) {
+	switch level {
+	case LogLevelQuiet:
+		return nil, nil
+	case LogLevelInfo:
+		return []string{"-v"}, nil
+	case LogLevelDebug:
+		return []string{"-vv"}, nil
+	default:
+		return nil, fmt.Errorf("invalid log level %q, must be one of %s, %s, %s",
+			level, LogLevelQuiet, LogLevelInfo, LogLevelDebug)
+	}
+}
+
+// WriteLogLevelEnv writes the environment file setting the verbosity arguments of the cloud-hypervisor units.
+func WriteLogLevelEnv(filename, level string) error {
+	args, err := LogLevelArgs(level)
+	if err != nil {
+		return err
+	}
+
+	data := fmt.Sprintf("%s=%s\n", LogArgsEnv, strings.Join(args, " "))
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return nil
+}
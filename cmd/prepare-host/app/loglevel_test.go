@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package app_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cmd/prepare-host/app"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogLevel", func() {
+	DescribeTable("should map the log level to the cloud-hypervisor args",
+		func(level string, expected []string) {
+			args, err := app.LogLevelArgs(level)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(args).To(Equal(expected))
+		},
+		Entry("quiet", app.LogLevelQuiet, nil),
+		Entry("info", app.LogLevelInfo, []string{"-v"}),
+		Entry("debug", app.LogLevelDebug, []string{"-vv"}),
+	)
+
+	It("should reject an invalid log level", func() {
+		_, err := app.LogLevelArgs("trace")
+		Expect(err).To(MatchError(ContainSubstring(`invalid log level "trace"`)))
+	})
+
+	It("should write the args to the environment file", func() {
+		filename := filepath.Join(GinkgoT().TempDir(), app.LogLevelEnvFile)
+		Expect(app.WriteLogLevelEnv(filename, app.LogLevelDebug)).To(Succeed())
+		Expect(os.ReadFile(filename)).To(BeEquivalentTo("CH_LOG_ARGS=-vv\n"))
+
+		Expect(app.WriteLogLevelEnv(filename, app.LogLevelQuiet)).To(Succeed())
+		Expect(os.ReadFile(filename)).To(BeEquivalentTo("CH_LOG_ARGS=\n"))
+	})
+
+	It("should not write the environment file for an invalid log level", func() {
+		filename := filepath.Join(GinkgoT().TempDir(), app.LogLevelEnvFile)
+		Expect(app.WriteLogLevelEnv(filename, "trace")).NotTo(Succeed())
+		Expect(filename).NotTo(BeAnExistingFile())
+	})
+})
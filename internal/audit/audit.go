@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package audit records the operations affecting vms, separate from the debug log.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+
+	// Stdout is the path writing the audit log to stdout.
+	Stdout = "-"
+)
+
+// Record is a single audited operation, written as one line of JSON.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	MachineID string    `json:"machineID,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Logger writes audit records. A nil Logger discards them, so callers need not check whether auditing is
+// enabled.
+type Logger struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+func NewLogger(w io.Writer) *Logger {
+	return &Logger{enc: json.NewEncoder(w)}
+}
+
+// Open returns a Logger appending to the file at path, or writing to stdout if path is Stdout.
+func Open(path string) (*Logger, error) {
+	if path == Stdout {
+		return NewLogger(os.Stdout), nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	l := NewLogger(f)
+	l.closer = f
+	return l, nil
+}
+
+// Record writes the outcome of the operation on the machine by the actor.
+func (l *Logger) Record(operation, machineID, actor string, opErr error) error {
+	if l == nil {
+		return nil
+	}
+
+	record := Record{
+		Time:      time.Now().UTC(),
+		Operation: operation,
+		MachineID: machineID,
+		Actor:     actor,
+		Result:    ResultSuccess,
+	}
+	if opErr != nil {
+		record.Result = ResultFailure
+		record.Error = opErr.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(record); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
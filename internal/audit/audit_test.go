@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package audit_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/audit"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func readRecords(path string) []audit.Record {
+	f, err := os.Open(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer func() { _ = f.Close() }()
+
+	var records []audit.Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record audit.Record
+		Expect(json.Unmarshal(scanner.Bytes(), &record)).To(Succeed())
+		records = append(records, record)
+	}
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+	return records
+}
+
+var _ = Describe("Logger", func() {
+	It("should append the records to the audit log file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "audit.log")
+
+		By("recording a successful operation")
+		logger, err := audit.Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logger.Record("create", "machine-1", "poollet", nil)).To(Succeed())
+		Expect(logger.Close()).To(Succeed())
+
+		By("recording a failed operation after reopening the file")
+		logger, err = audit.Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logger.Record("power-off", "machine-1", "poollet", errors.New("boom"))).To(Succeed())
+		Expect(logger.Close()).To(Succeed())
+
+		Expect(readRecords(path)).To(HaveExactElements(
+			SatisfyAll(
+				HaveField("Operation", "create"),
+				HaveField("MachineID", "machine-1"),
+				HaveField("Actor", "poollet"),
+				HaveField("Result", audit.ResultSuccess),
+				HaveField("Error", BeEmpty()),
+				HaveField("Time", Not(BeZero())),
+			),
+			SatisfyAll(
+				HaveField("Operation", "power-off"),
+				HaveField("Result", audit.ResultFailure),
+				HaveField("Error", "boom"),
+			),
+		))
+
+		info, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("should discard the records of a nil logger", func() {
+		var logger *audit.Logger
+		Expect(logger.Record("create", "machine-1", "poollet", nil)).To(Succeed())
+		Expect(logger.Close()).To(Succeed())
+	})
+})
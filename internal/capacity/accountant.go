@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/provider-utils/storeutils/store"
+)
+
+type AccountantOptions struct {
+	// Overcommit scales the host resources allocatable to machines. Defaults to no overcommit.
+	Overcommit Overcommit
+	// MemoryReserve is the host memory in bytes that is never allocated to machines.
+	MemoryReserve int64
+}
+
+func setAccountantOptionsDefaults(o *AccountantOptions) {
+	if o.Overcommit.Cpu == 0 {
+		o.Overcommit.Cpu = 1
+	}
+	if o.Overcommit.Memory == 0 {
+		o.Overcommit.Memory = 1
+	}
+}
+
+// Accountant accounts the resources of the host used by the machines of all tracked machine stores, so pools
+// sharing the host do not hand out the same resources.
+type Accountant struct {
+	host          Resources
+	overcommit    Overcommit
+	memoryReserve int64
+
+	storesMu sync.Mutex
+	stores   []store.Store[*api.Machine]
+
+	claimMu sync.Mutex
+}
+
+func NewAccountant(host Resources, opts AccountantOptions) (*Accountant, error) {
+	setAccountantOptionsDefaults(&opts)
+
+	if err := opts.Overcommit.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid overcommit: %w", err)
+	}
+
+	return &Accountant{
+		host:          host,
+		overcommit:    opts.Overcommit,
+		memoryReserve: opts.MemoryReserve,
+	}, nil
+}
+
+// Track adds the machines of the store to the used resources.
+func (a *Accountant) Track(store store.Store[*api.Machine]) {
+	a.storesMu.Lock()
+	defer a.storesMu.Unlock()
+	a.stores = append(a.stores, store)
+}
+
+// Lock serializes claiming resources for new machines across all tracked stores. The claim has to check the
+// free resources and create the machine while holding the lock.
+func (a *Accountant) Lock() {
+	a.claimMu.Lock()
+}
+
+func (a *Accountant) Unlock() {
+	a.claimMu.Unlock()
+}
+
+// Capacity returns the resources of the host.
+func (a *Accountant) Capacity() Resources {
+	return a.host
+}
+
+// Allocatable returns the resources of the host allocatable to machines after applying the overcommit and
+// reserve settings.
+func (a *Accountant) Allocatable() Resources {
+	allocatable := Allocatable(a.host, a.overcommit)
+	allocatable.MemoryBytes -= a.memoryReserve
+	return allocatable
+}
+
+// Used returns the resources used by the machines of all tracked stores.
+func (a *Accountant) Used(ctx context.Context) (Resources, error) {
+	a.storesMu.Lock()
+	stores := append([]store.Store[*api.Machine](nil), a.stores...)
+	a.storesMu.Unlock()
+
+	var used Resources
+	for _, s := range stores {
+		machines, err := s.List(ctx)
+		if err != nil {
+			return Resources{}, fmt.Errorf("error listing machines: %w", err)
+		}
+
+		for _, machine := range machines {
+			if !api.IsManagedBy(machine, api.MachineManager) {
+				continue
+			}
+			used.Cpu += machine.Spec.Cpu
+			used.MemoryBytes += machine.Spec.MemoryBytes
+		}
+	}
+	return used, nil
+}
+
+// Free returns the allocatable resources not used by the machines of the tracked stores.
+func (a *Accountant) Free(ctx context.Context) (Resources, error) {
+	used, err := a.Used(ctx)
+	if err != nil {
+		return Resources{}, err
+	}
+	return a.Allocatable().Sub(used), nil
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package capacity
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+)
+
+type Resources struct {
+	Cpu         int64
+	MemoryBytes int64
+}
+
+func (r Resources) Sub(o Resources) Resources {
+	return Resources{
+		Cpu:         r.Cpu - o.Cpu,
+		MemoryBytes: r.MemoryBytes - o.MemoryBytes,
+	}
+}
+
+type Overcommit struct {
+	Cpu    float64
+	Memory float64
+}
+
+func (o Overcommit) Validate() error {
+	if o.Cpu < 1 {
+		return fmt.Errorf("cpu overcommit ratio must be >= 1.0, got %v", o.Cpu)
+	}
+	if o.Memory < 1 {
+		return fmt.Errorf("memory overcommit ratio must be >= 1.0, got %v", o.Memory)
+	}
+	return nil
+}
+
+func Allocatable(host Resources, overcommit Overcommit) Resources {
+	return Resources{
+		Cpu:         int64(math.Floor(float64(host.Cpu) * overcommit.Cpu)),
+		MemoryBytes: int64(math.Floor(float64(host.MemoryBytes) * overcommit.Memory)),
+	}
+}
+
+// Quantity returns how many machines of the given class fit into the free resources.
+func Quantity(free Resources, class mcr.MachineClass) int64 {
+	if free.Cpu <= 0 || free.MemoryBytes <= 0 {
+		return 0
+	}
+
+	quantity := int64(math.MaxInt64)
+	if class.Cpu > 0 {
+		quantity = min(quantity, free.Cpu/class.Cpu)
+	}
+	if class.MemoryBytes > 0 {
+		quantity = min(quantity, free.MemoryBytes/class.MemoryBytes)
+	}
+	return quantity
+}
+
+func HostResources() (Resources, error) {
+	var info syscall.Sysinfo_t
+	if err := syscall.Sysinfo(&info); err != nil {
+		return Resources{}, fmt.Errorf("error getting system info: %w", err)
+	}
+
+	return Resources{
+		Cpu:         int64(runtime.NumCPU()),
+		MemoryBytes: int64(info.Totalram) * int64(info.Unit),
+	}, nil
+}
+
+const meminfoPath = "/proc/meminfo"
+
+// AvailableMemory returns the memory the host can currently hand out without swapping.
+func AvailableMemory() (int64, error) {
+	f, err := os.Open(meminfoPath)
+	if err != nil {
+		return 0, fmt.Errorf("error opening %s: %w", meminfoPath, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+
+		kiB, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing MemAvailable: %w", err)
+		}
+		return kiB * 1024, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading %s: %w", meminfoPath, err)
+	}
+	return 0, fmt.Errorf("MemAvailable not found in %s", meminfoPath)
+}
+
+// FreeMemory returns the available memory scaled by the overcommit ratio, minus the memory reserved for the host.
+func FreeMemory(available, reserve int64, overcommit float64) int64 {
+	return int64(math.Floor(float64(available)*overcommit)) - reserve
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package capacity
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const DefaultSysfsNodesPath = "/sys/devices/system/node"
+
+// NumaNode is a host numa node with the cpus and memory local to it.
+type NumaNode struct {
+	ID          int
+	CPUs        []int
+	MemoryBytes int64
+}
+
+// NumaTopology reads the numa nodes of the host from the sysfs nodes dir, ordered by id. Hosts without
+// numa support report no nodes.
+func NumaTopology(nodesPath string) ([]NumaNode, error) {
+	entries, err := os.ReadDir(nodesPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading %s: %w", nodesPath, err)
+	}
+
+	var nodes []NumaNode
+	for _, entry := range entries {
+		idStr, ok := strings.CutPrefix(entry.Name(), "node")
+		if !ok || !entry.IsDir() {
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+
+		nodeDir := filepath.Join(nodesPath, entry.Name())
+		cpuList, err := os.ReadFile(filepath.Join(nodeDir, "cpulist"))
+		if err != nil {
+			return nil, fmt.Errorf("error reading cpus of numa node %d: %w", id, err)
+		}
+		cpus, err := ParseCPUList(strings.TrimSpace(string(cpuList)))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing cpus of numa node %d: %w", id, err)
+		}
+
+		memoryBytes, err := nodeMemTotal(filepath.Join(nodeDir, "meminfo"))
+		if err != nil {
+			return nil, fmt.Errorf("error getting memory of numa node %d: %w", id, err)
+		}
+
+		nodes = append(nodes, NumaNode{ID: id, CPUs: cpus, MemoryBytes: memoryBytes})
+	}
+
+	slices.SortFunc(nodes, func(a, b NumaNode) int { return a.ID - b.ID })
+	return nodes, nil
+}
+
+// ParseCPUList parses a kernel cpu list like "0-3,8,10-11".
+func ParseCPUList(list string) ([]int, error) {
+	var cpus []int
+	if list == "" {
+		return cpus, nil
+	}
+
+	for part := range strings.SplitSeq(list, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu %q", first)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil {
+				return nil, fmt.Errorf("invalid cpu %q", last)
+			}
+			if end < start {
+				return nil, fmt.Errorf("invalid cpu range %q", part)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// nodeMemTotal reads the MemTotal line of a per-node meminfo file, e.g. "Node 0 MemTotal: 16384 kB".
+func nodeMemTotal(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("error opening %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] != "MemTotal:" {
+			continue
+		}
+
+		kiB, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing MemTotal: %w", err)
+		}
+		return kiB * 1024, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return 0, fmt.Errorf("MemTotal not found in %s", path)
+}
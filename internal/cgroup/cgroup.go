@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cgroup
+
+import (
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+)
+
+const (
+	// cpu.weight ranges from 1 to 10000, the kernel default is 100.
+	minCPUWeight = 1
+	maxCPUWeight = 10000
+
+	guaranteedCPUWeightPerCpu = 100
+	burstableCPUWeightPerCpu  = 50
+)
+
+// Resources are the cgroup v2 settings applied to the vmm process of a machine.
+type Resources struct {
+	CPUWeight uint64
+	// MemoryMin is never reclaimed from the machine.
+	MemoryMin int64
+	// MemoryLow is only reclaimed if no unprotected memory of other cgroups is left.
+	MemoryLow int64
+}
+
+// ResourcesFor returns the Resources of a machine of the given qos class and size.
+func ResourcesFor(class api.QoSClass, cpu, memoryBytes int64) (Resources, error) {
+	switch class {
+	case api.QoSClassGuaranteed:
+		return Resources{
+			CPUWeight: cpuWeight(guaranteedCPUWeightPerCpu * cpu),
+			MemoryMin: memoryBytes,
+		}, nil
+	case api.QoSClassBurstable:
+		return Resources{
+			CPUWeight: cpuWeight(burstableCPUWeightPerCpu * cpu),
+			MemoryLow: memoryBytes,
+		}, nil
+	case api.QoSClassBestEffort:
+		return Resources{
+			CPUWeight: minCPUWeight,
+		}, nil
+	default:
+		return Resources{}, api.ValidateQoSClass(class)
+	}
+}
+
+func cpuWeight(weight int64) uint64 {
+	return uint64(min(max(weight, minCPUWeight), maxCPUWeight))
+}
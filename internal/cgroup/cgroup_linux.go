@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package cgroup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Manager places vmm processes into child cgroups of a delegated cgroup v2 directory.
+type Manager struct {
+	root string
+}
+
+func NewManager(root string) (*Manager, error) {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("%s is not a cgroup v2 directory: %w", root, err)
+	}
+
+	if err := writeFile(root, "cgroup.subtree_control", "+cpu +memory"); err != nil {
+		return nil, fmt.Errorf("failed to enable cpu and memory controllers: %w", err)
+	}
+
+	return &Manager{root: root}, nil
+}
+
+func (m *Manager) Path(name string) string {
+	return filepath.Join(m.root, name)
+}
+
+// Apply moves the process into the cgroup name and sets its resources.
+func (m *Manager) Apply(name string, pid int, resources Resources) error {
+	dir := m.Path(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cgroup %s: %w", name, err)
+	}
+
+	for _, setting := range []struct {
+		file, value string
+	}{
+		{"cpu.weight", strconv.FormatUint(resources.CPUWeight, 10)},
+		{"memory.min", strconv.FormatInt(max(resources.MemoryMin, 0), 10)},
+		{"memory.low", strconv.FormatInt(max(resources.MemoryLow, 0), 10)},
+		{"cgroup.procs", strconv.Itoa(pid)},
+	} {
+		if err := writeFile(dir, setting.file, setting.value); err != nil {
+			return fmt.Errorf("failed to set %s of cgroup %s: %w", setting.file, name, err)
+		}
+	}
+	return nil
+}
+
+func writeFile(dir, file, value string) error {
+	return os.WriteFile(filepath.Join(dir, file), []byte(value), 0o644)
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package cgroup_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/cgroup"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cgroup", func() {
+	const memoryBytes = 2 * 1024 * 1024 * 1024
+
+	var (
+		root    string
+		manager *cgroup.Manager
+	)
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory"), 0o644)).To(Succeed())
+
+		var err error
+		manager, err = cgroup.NewManager(root)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(filepath.Join(root, "cgroup.subtree_control"))).To(BeEquivalentTo("+cpu +memory"))
+	})
+
+	DescribeTable("should set the cpu weight and memory protection of the qos class",
+		func(class api.QoSClass, cpuWeight, memoryMin, memoryLow string) {
+			resources, err := cgroup.ResourcesFor(class, 4, memoryBytes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manager.Apply("ch-0", 4242, resources)).To(Succeed())
+
+			dir := manager.Path("ch-0")
+			Expect(os.ReadFile(filepath.Join(dir, "cpu.weight"))).To(BeEquivalentTo(cpuWeight))
+			Expect(os.ReadFile(filepath.Join(dir, "memory.min"))).To(BeEquivalentTo(memoryMin))
+			Expect(os.ReadFile(filepath.Join(dir, "memory.low"))).To(BeEquivalentTo(memoryLow))
+			Expect(os.ReadFile(filepath.Join(dir, "cgroup.procs"))).To(BeEquivalentTo("4242"))
+		},
+		Entry("guaranteed", api.QoSClassGuaranteed, "400", "2147483648", "0"),
+		Entry("burstable", api.QoSClassBurstable, "200", "0", "2147483648"),
+		Entry("best effort", api.QoSClassBestEffort, "1", "0", "0"),
+	)
+
+	It("should cap the cpu weight", func() {
+		resources, err := cgroup.ResourcesFor(api.QoSClassGuaranteed, 512, memoryBytes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resources.CPUWeight).To(BeEquivalentTo(10000))
+	})
+
+	It("should reject an invalid qos class", func() {
+		_, err := cgroup.ResourcesFor("Platinum", 1, memoryBytes)
+		Expect(err).To(MatchError(ContainSubstring(`invalid qos class "Platinum"`)))
+	})
+
+	It("should reject a root that is not a cgroup v2 directory", func() {
+		_, err := cgroup.NewManager(GinkgoT().TempDir())
+		Expect(err).To(HaveOccurred())
+	})
+})
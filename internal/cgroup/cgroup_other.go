@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package cgroup
+
+import (
+	"errors"
+)
+
+var errUnsupported = errors.New("cgroups are only supported on linux")
+
+type Manager struct{}
+
+func NewManager(string) (*Manager, error) {
+	return nil, errUnsupported
+}
+
+func (m *Manager) Path(string) string {
+	return ""
+}
+
+func (m *Manager) Apply(string, int, Resources) error {
+	return errUnsupported
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package compaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
+	"github.com/ironcore-dev/provider-utils/storeutils/store"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	DefaultInterval  = 1 * time.Hour
+	DefaultRetention = 1 * time.Hour
+)
+
+type VMM interface {
+	GetVM(ctx context.Context, instanceID string) (*client.VmInfo, error)
+}
+
+type Options struct {
+	// Interval between two compactions of the machine store.
+	Interval time.Duration
+	// Retention is the time a fully deleted machine record is kept in the store before it is removed.
+	Retention time.Duration
+}
+
+func setOptionsDefaults(o *Options) {
+	if o.Interval == 0 {
+		o.Interval = DefaultInterval
+	}
+	if o.Retention == 0 {
+		o.Retention = DefaultRetention
+	}
+}
+
+// MachineStoreCompactor removes the records of machines that are deleted and have no finalizers left,
+// e.g. because the provider stopped before the store removed them.
+type MachineStoreCompactor struct {
+	log      logr.Logger
+	machines store.Store[*api.Machine]
+	vmm      VMM
+
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewMachineStoreCompactor(
+	log logr.Logger,
+	machines store.Store[*api.Machine],
+	vmm VMM,
+	opts Options,
+) (*MachineStoreCompactor, error) {
+	setOptionsDefaults(&opts)
+
+	if machines == nil {
+		return nil, fmt.Errorf("must specify machine store")
+	}
+	if vmm == nil {
+		return nil, fmt.Errorf("must specify vmm")
+	}
+
+	return &MachineStoreCompactor{
+		log:       log,
+		machines:  machines,
+		vmm:       vmm,
+		interval:  opts.Interval,
+		retention: opts.Retention,
+	}, nil
+}
+
+func (c *MachineStoreCompactor) Start(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		compacted, err := c.Compact(ctx)
+		if err != nil {
+			c.log.Error(err, "Failed to compact machine store")
+			return
+		}
+		if compacted > 0 {
+			c.log.V(1).Info("Compacted machine store", "removed", compacted)
+		}
+	}, c.interval)
+}
+
+// Compact removes the fully deleted machine records past the retention and returns how many were removed.
+func (c *MachineStoreCompactor) Compact(ctx context.Context) (int, error) {
+	machines, err := c.machines.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error listing machines: %w", err)
+	}
+
+	compacted := 0
+	for _, machine := range machines {
+		if machine.DeletedAt == nil || len(machine.Finalizers) > 0 {
+			continue
+		}
+		if time.Since(*machine.DeletedAt) < c.retention {
+			continue
+		}
+
+		log := c.log.WithValues("machineID", machine.ID)
+
+		live, err := c.hasLiveVM(ctx, machine)
+		if err != nil {
+			log.V(1).Info("Keeping deleted machine, failed to check its vm", "error", err)
+			continue
+		}
+		if live {
+			log.V(1).Info("Keeping deleted machine, its vm is still present")
+			continue
+		}
+
+		if err := c.machines.Delete(ctx, machine.ID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return compacted, fmt.Errorf("error removing machine %s: %w", machine.ID, err)
+		}
+		log.V(2).Info("Removed deleted machine from store")
+		compacted++
+	}
+
+	return compacted, nil
+}
+
+func (c *MachineStoreCompactor) hasLiveVM(ctx context.Context, machine *api.Machine) (bool, error) {
+	apiSocket := ptr.Deref(machine.Spec.ApiSocketPath, "")
+	if apiSocket == "" {
+		return false, nil
+	}
+
+	vm, err := c.vmm.GetVM(ctx, apiSocket)
+	if err != nil {
+		if errors.Is(err, vmm.ErrVmNotCreated) || errors.Is(err, vmm.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	platform := ptr.Deref(vm.Config.Platform, client.PlatformConfig{})
+	return ptr.Deref(platform.Uuid, "") == machine.ID, nil
+}
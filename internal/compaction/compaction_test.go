@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package compaction_test
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/compaction"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
+	apiutils "github.com/ironcore-dev/provider-utils/apiutils/api"
+	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+// fakeVMM serves vms of machines by api socket.
+type fakeVMM struct {
+	vms map[string]string
+}
+
+func (f *fakeVMM) GetVM(_ context.Context, instanceID string) (*client.VmInfo, error) {
+	machineID, ok := f.vms[instanceID]
+	if !ok {
+		return nil, vmm.ErrVmNotCreated
+	}
+	return &client.VmInfo{
+		Config: client.VmConfig{Platform: &client.PlatformConfig{Uuid: ptr.To(machineID)}},
+		State:  client.Running,
+	}, nil
+}
+
+var _ = Describe("MachineStoreCompactor", func() {
+	var (
+		machineStore *hostutils.Store[*api.Machine]
+		fake         *fakeVMM
+		compactor    *compaction.MachineStoreCompactor
+	)
+
+	BeforeEach(func() {
+		var err error
+		machineStore, err = hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+			Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+			NewFunc:        func() *api.Machine { return &api.Machine{} },
+			CreateStrategy: strategy.MachineStrategy,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		fake = &fakeVMM{vms: map[string]string{}}
+		compactor, err = compaction.NewMachineStoreCompactor(GinkgoLogr, machineStore, fake, compaction.Options{
+			Retention: time.Minute,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	createMachine := func(ctx context.Context, id string, deletedAt *time.Time, finalizers ...string) {
+		_, err := machineStore.Create(ctx, &api.Machine{
+			Metadata: apiutils.Metadata{
+				ID:         id,
+				DeletedAt:  deletedAt,
+				Finalizers: finalizers,
+			},
+			Spec: api.MachineSpec{ApiSocketPath: ptr.To("/run/chp/ch/" + id + ".sock")},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should remove deleted machine records past the retention", func(ctx SpecContext) {
+		expired := time.Now().Add(-time.Hour)
+		recent := time.Now()
+
+		By("seeding the store")
+		createMachine(ctx, "tombstone-1", &expired)
+		createMachine(ctx, "tombstone-2", &expired)
+		createMachine(ctx, "recent-tombstone", &recent)
+		createMachine(ctx, "deleting", &expired, "machine")
+		createMachine(ctx, "live", nil, "machine")
+		createMachine(ctx, "live-vm", &expired)
+		fake.vms["/run/chp/ch/live-vm.sock"] = "live-vm"
+
+		By("compacting the store")
+		compacted, err := compactor.Compact(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compacted).To(Equal(2))
+
+		machines, err := machineStore.List(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machines).To(ConsistOf(
+			HaveField("ID", "recent-tombstone"),
+			HaveField("ID", "deleting"),
+			HaveField("ID", "live"),
+			HaveField("ID", "live-vm"),
+		))
+
+		By("removing the tombstone once its vm is gone")
+		delete(fake.vms, "/run/chp/ch/live-vm.sock")
+		compacted, err = compactor.Compact(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compacted).To(Equal(1))
+	})
+})
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package configdrive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// VolumeLabel is the filesystem label cloud-init's ConfigDrive datasource looks for.
+	VolumeLabel = "config-2"
+
+	MetaDataPath = "openstack/latest/meta_data.json"
+	UserDataPath = "openstack/latest/user_data"
+
+	// MaxUserDataBytes mirrors the user data limit of openstack.
+	MaxUserDataBytes = 64 * 1024
+)
+
+// MetaData is the openstack meta data document read by cloud-init.
+type MetaData struct {
+	UUID       string            `json:"uuid"`
+	Name       string            `json:"name"`
+	Hostname   string            `json:"hostname"`
+	PublicKeys map[string]string `json:"public_keys,omitempty"`
+}
+
+func Validate(spec *api.ConfigDriveSpec) error {
+	if spec == nil {
+		return fmt.Errorf("config drive is nil")
+	}
+
+	var errs []error
+	if spec.Hostname == "" {
+		errs = append(errs, fmt.Errorf("hostname is required"))
+	} else if msgs := validation.IsDNS1123Subdomain(spec.Hostname); len(msgs) > 0 {
+		errs = append(errs, fmt.Errorf("invalid hostname %q: %s", spec.Hostname, strings.Join(msgs, ", ")))
+	}
+
+	for i, key := range spec.SSHKeys {
+		if len(strings.Fields(key)) < 2 {
+			errs = append(errs, fmt.Errorf("ssh key %d is not in authorized_keys format", i))
+		}
+	}
+
+	if len(spec.UserData) > MaxUserDataBytes {
+		errs = append(errs, fmt.Errorf("user data exceeds %d bytes", MaxUserDataBytes))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Build renders the config drive of the machine as iso9660 image to filename.
+func Build(filename, machineID string, spec *api.ConfigDriveSpec) error {
+	if err := Validate(spec); err != nil {
+		return fmt.Errorf("invalid config drive: %w", err)
+	}
+
+	metaData := MetaData{
+		UUID:     machineID,
+		Name:     spec.Hostname,
+		Hostname: spec.Hostname,
+	}
+	if len(spec.SSHKeys) > 0 {
+		metaData.PublicKeys = make(map[string]string, len(spec.SSHKeys))
+		for i, key := range spec.SSHKeys {
+			metaData.PublicKeys[strconv.Itoa(i)] = strings.TrimSpace(key)
+		}
+	}
+
+	metaDataJSON, err := json.Marshal(metaData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta data: %w", err)
+	}
+
+	files := map[string][]byte{
+		MetaDataPath: metaDataJSON,
+	}
+	if len(spec.UserData) > 0 {
+		files[UserDataPath] = spec.UserData
+	}
+
+	var buf bytes.Buffer
+	if err := writeISO(&buf, VolumeLabel, files, time.Now()); err != nil {
+		return fmt.Errorf("failed to render config drive: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create config drive file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write config drive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close config drive file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to move config drive into place: %w", err)
+	}
+	return nil
+}
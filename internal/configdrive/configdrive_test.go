@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package configdrive_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/configdrive"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const sectorSize = 2048
+
+// readISOFile looks up a file by its slash separated path in an iso9660 image.
+func readISOFile(img []byte, name string) ([]byte, error) {
+	pvd := img[16*sectorSize:]
+	root := pvd[156:190]
+	extent := binary.LittleEndian.Uint32(root[2:6])
+	size := binary.LittleEndian.Uint32(root[10:14])
+
+	elems := strings.Split(name, "/")
+	for i, elem := range elems {
+		if i == len(elems)-1 {
+			elem += ";1"
+		}
+
+		dir := img[int(extent)*sectorSize : int(extent)*sectorSize+int(size)]
+		found := false
+		for offset := 0; offset < len(dir); {
+			recLen := int(dir[offset])
+			if recLen == 0 {
+				offset += sectorSize - offset%sectorSize
+				continue
+			}
+			rec := dir[offset : offset+recLen]
+			if string(rec[33:33+int(rec[32])]) == elem {
+				extent = binary.LittleEndian.Uint32(rec[2:6])
+				size = binary.LittleEndian.Uint32(rec[10:14])
+				found = true
+				break
+			}
+			offset += recLen
+		}
+		if !found {
+			return nil, fmt.Errorf("%s not found", name)
+		}
+	}
+	return img[int(extent)*sectorSize : int(extent)*sectorSize+int(size)], nil
+}
+
+var _ = Describe("ConfigDrive", func() {
+	var filename string
+
+	BeforeEach(func() {
+		filename = filepath.Join(GinkgoT().TempDir(), "config-drive.iso")
+	})
+
+	It("should build an iso9660 config drive with meta and user data", func() {
+		Expect(configdrive.Build(filename, "machine-1", &api.ConfigDriveSpec{
+			Hostname: "web-1",
+			SSHKeys:  []string{"ssh-ed25519 AAAA user@host\n", "ssh-rsa BBBB"},
+			UserData: []byte("#cloud-config\n"),
+		})).To(Succeed())
+
+		img, err := os.ReadFile(filename)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(img) % sectorSize).To(BeZero())
+
+		By("inspecting the volume descriptors")
+		Expect(string(img[16*sectorSize+1 : 16*sectorSize+6])).To(Equal("CD001"))
+		Expect(strings.TrimRight(string(img[16*sectorSize+40:16*sectorSize+72]), " ")).To(Equal(configdrive.VolumeLabel))
+		Expect(img[17*sectorSize]).To(Equal(byte(255)))
+
+		By("reading the meta data")
+		data, err := readISOFile(img, configdrive.MetaDataPath)
+		Expect(err).NotTo(HaveOccurred())
+		metaData := configdrive.MetaData{}
+		Expect(json.Unmarshal(data, &metaData)).To(Succeed())
+		Expect(metaData).To(Equal(configdrive.MetaData{
+			UUID:     "machine-1",
+			Name:     "web-1",
+			Hostname: "web-1",
+			PublicKeys: map[string]string{
+				"0": "ssh-ed25519 AAAA user@host",
+				"1": "ssh-rsa BBBB",
+			},
+		}))
+
+		By("reading the user data")
+		Expect(readISOFile(img, configdrive.UserDataPath)).To(BeEquivalentTo("#cloud-config\n"))
+	})
+
+	It("should omit the user data if none is given", func() {
+		Expect(configdrive.Build(filename, "machine-1", &api.ConfigDriveSpec{Hostname: "web-1"})).To(Succeed())
+
+		img, err := os.ReadFile(filename)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readISOFile(img, configdrive.MetaDataPath)).NotTo(BeEmpty())
+		Expect(readISOFile(img, configdrive.UserDataPath)).Error().To(HaveOccurred())
+	})
+
+	DescribeTable("should reject invalid config drives",
+		func(spec *api.ConfigDriveSpec, message string) {
+			Expect(configdrive.Build(filename, "machine-1", spec)).To(MatchError(ContainSubstring(message)))
+			Expect(filename).NotTo(BeAnExistingFile())
+		},
+		Entry("missing hostname", &api.ConfigDriveSpec{}, "hostname is required"),
+		Entry("invalid hostname", &api.ConfigDriveSpec{Hostname: "Web_1"}, "invalid hostname"),
+		Entry("malformed ssh key", &api.ConfigDriveSpec{Hostname: "web-1", SSHKeys: []string{"AAAA"}}, "ssh key 0"),
+		Entry("oversized user data", &api.ConfigDriveSpec{
+			Hostname: "web-1",
+			UserData: make([]byte, configdrive.MaxUserDataBytes+1),
+		}, "user data exceeds"),
+	)
+})
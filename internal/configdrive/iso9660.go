@@ -0,0 +1,292 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package configdrive
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	sectorSize = 2048
+
+	// systemAreaSectors are the unused sectors in front of the volume descriptors.
+	systemAreaSectors = 16
+
+	dirRecordHeaderLen = 33
+)
+
+type isoFile struct {
+	name   string
+	data   []byte
+	extent uint32
+}
+
+type isoDir struct {
+	name   string
+	parent *isoDir
+	number int
+
+	dirs  []*isoDir
+	files []*isoFile
+
+	extent uint32
+	size   uint32
+}
+
+func (d *isoDir) dir(name string) *isoDir {
+	for _, child := range d.dirs {
+		if child.name == name {
+			return child
+		}
+	}
+	child := &isoDir{name: name, parent: d}
+	d.dirs = append(d.dirs, child)
+	return child
+}
+
+// writeISO writes an iso9660 image labeled with volumeID that contains the given files keyed by their
+// slash separated path.
+func writeISO(w io.Writer, volumeID string, files map[string][]byte, now time.Time) error {
+	if len(volumeID) > 32 {
+		return fmt.Errorf("volume id %q exceeds 32 characters", volumeID)
+	}
+
+	root := &isoDir{}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		dirName, fileName := path.Split(path.Clean(name))
+		parent := root
+		for _, elem := range strings.Split(strings.Trim(dirName, "/"), "/") {
+			if elem != "" {
+				parent = parent.dir(elem)
+			}
+		}
+		parent.files = append(parent.files, &isoFile{name: fileName, data: files[name]})
+	}
+
+	// The path table lists directories breadth first, ordered by their parent.
+	dirs := []*isoDir{root}
+	for i := 0; i < len(dirs); i++ {
+		dirs[i].number = i + 1
+		slices.SortFunc(dirs[i].dirs, func(a, b *isoDir) int { return strings.Compare(a.name, b.name) })
+		dirs = append(dirs, dirs[i].dirs...)
+	}
+
+	pathTableSize := 0
+	for _, d := range dirs {
+		pathTableSize += pathTableRecordLen(d)
+	}
+	pathTableSectors := sectors(pathTableSize)
+
+	// Layout: primary volume descriptor, terminator, little and big endian path tables, directories, files.
+	next := uint32(systemAreaSectors + 2)
+	lPathTable := next
+	next += pathTableSectors
+	mPathTable := next
+	next += pathTableSectors
+
+	for _, d := range dirs {
+		d.extent = next
+		d.size = dirExtentLen(d)
+		next += d.size / sectorSize
+	}
+	for _, d := range dirs {
+		for _, f := range d.files {
+			if len(f.data) == 0 {
+				continue
+			}
+			f.extent = next
+			next += sectors(len(f.data))
+		}
+	}
+
+	img := make([]byte, int(next)*sectorSize)
+
+	pvd := img[systemAreaSectors*sectorSize:]
+	pvd[0] = 1
+	copy(pvd[1:6], "CD001")
+	pvd[6] = 1
+	padString(pvd[8:40], "")
+	padString(pvd[40:72], volumeID)
+	putBothUint32(pvd[80:88], next)
+	putBothUint16(pvd[120:124], 1)
+	putBothUint16(pvd[124:128], 1)
+	putBothUint16(pvd[128:132], sectorSize)
+	putBothUint32(pvd[132:140], uint32(pathTableSize))
+	binary.LittleEndian.PutUint32(pvd[140:144], lPathTable)
+	binary.BigEndian.PutUint32(pvd[148:152], mPathTable)
+	putDirRecord(pvd[156:190], root.extent, root.size, true, []byte{0}, now)
+	for _, field := range [][2]int{{190, 318}, {318, 446}, {446, 574}, {574, 702}, {702, 739}, {739, 776}, {776, 813}} {
+		padString(pvd[field[0]:field[1]], "")
+	}
+	putDecDateTime(pvd[813:830], now)
+	putDecDateTime(pvd[830:847], now)
+	putDecDateTime(pvd[847:864], time.Time{})
+	putDecDateTime(pvd[864:881], now)
+	pvd[881] = 1
+
+	terminator := img[(systemAreaSectors+1)*sectorSize:]
+	terminator[0] = 255
+	copy(terminator[1:6], "CD001")
+	terminator[6] = 1
+
+	lTable := img[int(lPathTable)*sectorSize:]
+	mTable := img[int(mPathTable)*sectorSize:]
+	for _, d := range dirs {
+		id := pathTableID(d)
+		parent := 1
+		if d.parent != nil {
+			parent = d.parent.number
+		}
+
+		lTable[0], mTable[0] = byte(len(id)), byte(len(id))
+		binary.LittleEndian.PutUint32(lTable[2:6], d.extent)
+		binary.BigEndian.PutUint32(mTable[2:6], d.extent)
+		binary.LittleEndian.PutUint16(lTable[6:8], uint16(parent))
+		binary.BigEndian.PutUint16(mTable[6:8], uint16(parent))
+		copy(lTable[8:], id)
+		copy(mTable[8:], id)
+
+		n := pathTableRecordLen(d)
+		lTable, mTable = lTable[n:], mTable[n:]
+	}
+
+	for _, d := range dirs {
+		parent := d
+		if d.parent != nil {
+			parent = d.parent
+		}
+
+		var offset int
+		for _, rec := range dirRecords(d, parent) {
+			recLen := dirRecordLen(rec.id)
+			if offset%sectorSize+recLen > sectorSize {
+				offset += sectorSize - offset%sectorSize
+			}
+			start := int(d.extent)*sectorSize + offset
+			putDirRecord(img[start:start+recLen], rec.extent, rec.size, rec.dir, rec.id, now)
+			offset += recLen
+		}
+	}
+
+	for _, d := range dirs {
+		for _, f := range d.files {
+			copy(img[int(f.extent)*sectorSize:], f.data)
+		}
+	}
+
+	_, err := w.Write(img)
+	return err
+}
+
+type dirRecord struct {
+	id     []byte
+	extent uint32
+	size   uint32
+	dir    bool
+}
+
+func dirRecords(d, parent *isoDir) []dirRecord {
+	records := []dirRecord{
+		{id: []byte{0}, extent: d.extent, size: d.size, dir: true},
+		{id: []byte{1}, extent: parent.extent, size: parent.size, dir: true},
+	}
+
+	var children []dirRecord
+	for _, child := range d.dirs {
+		children = append(children, dirRecord{id: []byte(child.name), extent: child.extent, size: child.size, dir: true})
+	}
+	for _, f := range d.files {
+		children = append(children, dirRecord{id: []byte(f.name + ";1"), extent: f.extent, size: uint32(len(f.data))})
+	}
+	slices.SortFunc(children, func(a, b dirRecord) int { return strings.Compare(string(a.id), string(b.id)) })
+
+	return append(records, children...)
+}
+
+func dirExtentLen(d *isoDir) uint32 {
+	// Sizes of the referenced extents do not influence the record lengths.
+	var offset int
+	for _, rec := range dirRecords(d, d) {
+		recLen := dirRecordLen(rec.id)
+		if offset%sectorSize+recLen > sectorSize {
+			offset += sectorSize - offset%sectorSize
+		}
+		offset += recLen
+	}
+	return sectors(offset) * sectorSize
+}
+
+func dirRecordLen(id []byte) int {
+	n := dirRecordHeaderLen + len(id)
+	return n + n%2
+}
+
+func putDirRecord(b []byte, extent, size uint32, dir bool, id []byte, t time.Time) {
+	b[0] = byte(dirRecordLen(id))
+	putBothUint32(b[2:10], extent)
+	putBothUint32(b[10:18], size)
+
+	t = t.UTC()
+	b[18] = byte(t.Year() - 1900)
+	b[19] = byte(t.Month())
+	b[20] = byte(t.Day())
+	b[21] = byte(t.Hour())
+	b[22] = byte(t.Minute())
+	b[23] = byte(t.Second())
+
+	if dir {
+		b[25] = 2
+	}
+	putBothUint16(b[28:32], 1)
+	b[32] = byte(len(id))
+	copy(b[33:], id)
+}
+
+func pathTableID(d *isoDir) []byte {
+	if d.parent == nil {
+		return []byte{0}
+	}
+	return []byte(d.name)
+}
+
+func pathTableRecordLen(d *isoDir) int {
+	n := 8 + len(pathTableID(d))
+	return n + n%2
+}
+
+func putDecDateTime(b []byte, t time.Time) {
+	if t.IsZero() {
+		copy(b, strings.Repeat("0", 16))
+		return
+	}
+	copy(b, t.UTC().Format("20060102150405")+"00")
+}
+
+func padString(b []byte, s string) {
+	n := copy(b, s)
+	for i := n; i < len(b); i++ {
+		b[i] = ' '
+	}
+}
+
+func putBothUint16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b[0:2], v)
+	binary.BigEndian.PutUint16(b[2:4], v)
+}
+
+func putBothUint32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b[0:4], v)
+	binary.BigEndian.PutUint32(b[4:8], v)
+}
+
+func sectors(n int) uint32 {
+	return uint32((n + sectorSize - 1) / sectorSize)
+}
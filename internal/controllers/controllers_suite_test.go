@@ -5,14 +5,19 @@ package controllers_test
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/controllers"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/networkinterface"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/networkinterface/isolated"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume/localdisk"
@@ -28,6 +33,9 @@ import (
 	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+	"golang.org/x/time/rate"
+	"k8s.io/utils/ptr"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -37,11 +45,25 @@ const (
 	pollingInterval      = 50 * time.Millisecond
 	consistentlyDuration = 1 * time.Second
 	osImage              = "ghcr.io/ironcore-dev/os-images/virtualization/gardenlinux:latest"
+	reconcileTimeout     = 10 * time.Second
+	bootTimeout          = 3 * time.Second
+	quarantineThreshold  = 20
+	workerSize           = 4
+	powerOnRate          = 4
+	powerOnRateTolerance = 20 * time.Millisecond
 )
 
 var (
 	machineStore  *hostutils.Store[*api.Machine]
+	machineEvents *event.ListWatchSource[*api.Machine]
 	eventRecorder *recorder.Store
+	slowVolumes   *slowVolumePlugin
+	flakyDisks    *flakyDiskPlugin
+	fullDisks     *fullDiskPlugin
+	pendingDisks  *pendingDiskPlugin
+	asyncDisks    *asyncDiskPlugin
+	cachedDisks   *cachedDiskPlugin
+	failingNics   *failingNicPlugin
 )
 
 func TestControllers(t *testing.T) {
@@ -82,12 +104,26 @@ var _ = BeforeSuite(func(ctx context.Context) {
 	imgCache, err := ociutils.NewLocalCache(log, reg, ociStore, nil)
 	Expect(err).NotTo(HaveOccurred())
 
+	slowVolumes = newSlowVolumePlugin()
+	flakyDisks = &flakyDiskPlugin{}
+	fullDisks = &fullDiskPlugin{}
+	pendingDisks = &pendingDiskPlugin{}
+	asyncDisks = &asyncDiskPlugin{}
+	cachedDisks = &cachedDiskPlugin{}
 	volumePlugins := volume.NewPluginManager()
 	Expect(volumePlugins.InitPlugins(hostPaths, []volume.Plugin{
-		localdisk.NewPlugin(rawInst, imgCache),
+		localdisk.NewPlugin(rawInst, imgCache, localdisk.Options{}),
+		slowVolumes,
+		&missingDiskPlugin{},
+		flakyDisks,
+		fullDisks,
+		pendingDisks,
+		asyncDisks,
+		cachedDisks,
 	})).NotTo(HaveOccurred())
 
-	nicPlugin := isolated.NewPlugin()
+	failingNics = &failingNicPlugin{Plugin: isolated.NewPlugin()}
+	nicPlugin := &preparedNicPlugin{Plugin: failingNics}
 	Expect(nicPlugin.Init(hostPaths)).NotTo(HaveOccurred())
 
 	machineStore, err = hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
@@ -97,7 +133,7 @@ var _ = BeforeSuite(func(ctx context.Context) {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
-	machineEvents, err := event.NewListWatchSource[*api.Machine](
+	machineEvents, err = event.NewListWatchSource[*api.Machine](
 		machineStore.List,
 		machineStore.Watch,
 		event.ListWatchSourceOptions{},
@@ -123,6 +159,8 @@ var _ = BeforeSuite(func(ctx context.Context) {
 			CHSocketsPath:     chSocketDir,
 			FirmwarePath:      chFirmwarePath,
 			ReservedInstances: nil,
+			MaxVcpus:          2,
+			MaxMemoryBytes:    2147483648,
 		},
 	)
 	Expect(err).NotTo(HaveOccurred())
@@ -137,9 +175,17 @@ var _ = BeforeSuite(func(ctx context.Context) {
 		volumePlugins,
 		nicPlugin,
 		controllers.MachineReconcilerOptions{
-			ImageCache: imgCache,
-			Raw:        rawInst,
-			Paths:      hostPaths,
+			ImageCache:               imgCache,
+			Raw:                      rawInst,
+			Paths:                    hostPaths,
+			ReconcileTimeout:         reconcileTimeout,
+			GuestShutdownPolicy:      controllers.GuestShutdownPolicyStop,
+			ShutdownGracePeriod:      time.Second,
+			ProbeVolumes:             true,
+			QuarantineThreshold:      quarantineThreshold,
+			PrioritizeMachineChanges: true,
+			WorkerSize:               workerSize,
+			PowerOnLimiter:           rate.NewLimiter(powerOnRate, 1),
 		},
 	)
 	Expect(err).NotTo(HaveOccurred())
@@ -171,3 +217,392 @@ var _ = BeforeSuite(func(ctx context.Context) {
 	time.Sleep(200 * time.Millisecond)
 
 })
+
+const slowVolumeDriver = "slow"
+
+// slowVolumePlugin blocks applying volumes, ignoring any context cancellation, until released.
+type slowVolumePlugin struct {
+	host    volume.Host
+	release chan struct{}
+}
+
+func newSlowVolumePlugin() *slowVolumePlugin {
+	return &slowVolumePlugin{release: make(chan struct{})}
+}
+
+func (p *slowVolumePlugin) Release() {
+	close(p.release)
+}
+
+func (p *slowVolumePlugin) Init(host volume.Host) error {
+	p.host = host
+	return nil
+}
+
+func (p *slowVolumePlugin) Name() string {
+	return "cloud-hypervisor-provider.ironcore.dev/slow"
+}
+
+func (p *slowVolumePlugin) GetBackingVolumeID(spec *api.VolumeSpec) (string, error) {
+	return spec.Name, nil
+}
+
+func (p *slowVolumePlugin) CanSupport(spec *api.VolumeSpec) bool {
+	return spec.Connection != nil && spec.Connection.Driver == slowVolumeDriver
+}
+
+func (p *slowVolumePlugin) Apply(_ context.Context, spec *api.VolumeSpec, _ string) (*api.VolumeStatus, error) {
+	<-p.release
+	return &api.VolumeStatus{
+		Name:   spec.Name,
+		Type:   api.VolumeFileType,
+		Handle: spec.Name,
+		State:  api.VolumeStatePending,
+	}, nil
+}
+
+func (p *slowVolumePlugin) Delete(context.Context, string, string) error {
+	return nil
+}
+
+func (p *slowVolumePlugin) Snapshot(context.Context, string, string, string) error {
+	return nil
+}
+
+func (p *slowVolumePlugin) IsHealthy(context.Context, string, string) (bool, error) {
+	return true, nil
+}
+
+const missingDiskDriver = "missing-disk"
+
+// missingDiskPlugin prepares file volumes pointing to a non-existing disk, so that the vm fails to boot.
+type missingDiskPlugin struct {
+	host volume.Host
+}
+
+func (p *missingDiskPlugin) Init(host volume.Host) error {
+	p.host = host
+	return nil
+}
+
+func (p *missingDiskPlugin) Name() string {
+	return "cloud-hypervisor-provider.ironcore.dev/missing-disk"
+}
+
+func (p *missingDiskPlugin) GetBackingVolumeID(spec *api.VolumeSpec) (string, error) {
+	return spec.Name, nil
+}
+
+func (p *missingDiskPlugin) CanSupport(spec *api.VolumeSpec) bool {
+	return spec.Connection != nil && spec.Connection.Driver == missingDiskDriver
+}
+
+func (p *missingDiskPlugin) Apply(_ context.Context, spec *api.VolumeSpec, machineID string) (*api.VolumeStatus, error) {
+	return &api.VolumeStatus{
+		Name:   spec.Name,
+		Type:   api.VolumeFileType,
+		Path:   path.Join(p.host.MachineVolumeDir(machineID, p.Name(), spec.Name), "missing.raw"),
+		Handle: spec.Name,
+		State:  api.VolumeStatePrepared,
+	}, nil
+}
+
+func (p *missingDiskPlugin) Delete(context.Context, string, string) error {
+	return nil
+}
+
+func (p *missingDiskPlugin) Snapshot(context.Context, string, string, string) error {
+	return nil
+}
+
+func (p *missingDiskPlugin) IsHealthy(context.Context, string, string) (bool, error) {
+	return true, nil
+}
+
+const flakyDiskDriver = "flaky-disk"
+
+// flakyDiskPlugin prepares socket volumes whose backend reports unhealthy while unhealthy is set.
+type flakyDiskPlugin struct {
+	missingDiskPlugin
+	unhealthy atomic.Bool
+}
+
+func (p *flakyDiskPlugin) Name() string {
+	return "cloud-hypervisor-provider.ironcore.dev/flaky-disk"
+}
+
+func (p *flakyDiskPlugin) CanSupport(spec *api.VolumeSpec) bool {
+	return spec.Connection != nil && spec.Connection.Driver == flakyDiskDriver
+}
+
+func (p *flakyDiskPlugin) Apply(_ context.Context, spec *api.VolumeSpec, machineID string) (*api.VolumeStatus, error) {
+	return &api.VolumeStatus{
+		Name:   spec.Name,
+		Type:   api.VolumeSocketType,
+		Path:   p.host.MachineVolumeSocket(machineID, p.Name(), spec.Name),
+		Handle: spec.Name,
+		State:  api.VolumeStatePrepared,
+	}, nil
+}
+
+func (p *flakyDiskPlugin) IsHealthy(context.Context, string, string) (bool, error) {
+	return !p.unhealthy.Load(), nil
+}
+
+// flakyDiskStats are the io stats reported for every volume of the flaky disk plugin.
+var flakyDiskStats = volume.Stats{ReadBytes: 4096, WrittenBytes: 8192, ReadOperations: 1, WriteOperations: 2}
+
+func (p *flakyDiskPlugin) Stats(context.Context, string, string) (*volume.Stats, error) {
+	return &flakyDiskStats, nil
+}
+
+const fullDiskDriver = "full-disk"
+
+// fullDiskPlugin fails preparing volumes for lack of disk space while full is set.
+type fullDiskPlugin struct {
+	missingDiskPlugin
+	full    atomic.Bool
+	applies atomic.Int32
+}
+
+func (p *fullDiskPlugin) Name() string {
+	return "cloud-hypervisor-provider.ironcore.dev/full-disk"
+}
+
+func (p *fullDiskPlugin) CanSupport(spec *api.VolumeSpec) bool {
+	return spec.Connection != nil && spec.Connection.Driver == fullDiskDriver
+}
+
+func (p *fullDiskPlugin) Apply(ctx context.Context, spec *api.VolumeSpec, machineID string) (*api.VolumeStatus, error) {
+	p.applies.Add(1)
+	if p.full.Load() {
+		return nil, fmt.Errorf("failed creating the empty ephemeral disk: %w", unix.ENOSPC)
+	}
+	return p.missingDiskPlugin.Apply(ctx, spec, machineID)
+}
+
+const pendingDiskDriver = "pending-disk"
+
+// pendingDiskPlugin keeps volumes pending while pending is set and prepares an empty disk file afterward.
+type pendingDiskPlugin struct {
+	missingDiskPlugin
+	pending atomic.Bool
+}
+
+func (p *pendingDiskPlugin) Name() string {
+	return "cloud-hypervisor-provider.ironcore.dev/pending-disk"
+}
+
+func (p *pendingDiskPlugin) CanSupport(spec *api.VolumeSpec) bool {
+	return spec.Connection != nil && spec.Connection.Driver == pendingDiskDriver
+}
+
+func (p *pendingDiskPlugin) Apply(_ context.Context, spec *api.VolumeSpec, machineID string) (*api.VolumeStatus, error) {
+	status := &api.VolumeStatus{
+		Name:   spec.Name,
+		Type:   api.VolumeFileType,
+		Handle: spec.Name,
+		State:  api.VolumeStatePending,
+	}
+	if p.pending.Load() {
+		return status, nil
+	}
+
+	volumeDir := p.host.MachineVolumeDir(machineID, p.Name(), spec.Name)
+	if err := os.MkdirAll(volumeDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	status.Path = path.Join(volumeDir, "disk.raw")
+	if err := os.WriteFile(status.Path, make([]byte, 1024*1024), 0666); err != nil {
+		return nil, err
+	}
+	status.State = api.VolumeStatePrepared
+	return status, nil
+}
+
+const asyncDiskDriver = "async-disk"
+
+// asyncDiskPlugin prepares volumes in the background while preparing is set, until Finish notifies the
+// listeners, and prepares an empty disk file afterward.
+type asyncDiskPlugin struct {
+	pendingDiskPlugin
+	preparing atomic.Bool
+	applies   atomic.Int32
+
+	mu        sync.Mutex
+	listeners []volume.Listener
+}
+
+func (p *asyncDiskPlugin) Name() string {
+	return "cloud-hypervisor-provider.ironcore.dev/async-disk"
+}
+
+func (p *asyncDiskPlugin) CanSupport(spec *api.VolumeSpec) bool {
+	return spec.Connection != nil && spec.Connection.Driver == asyncDiskDriver
+}
+
+func (p *asyncDiskPlugin) Apply(ctx context.Context, spec *api.VolumeSpec, machineID string) (*api.VolumeStatus, error) {
+	p.applies.Add(1)
+	if p.preparing.Load() {
+		return nil, volume.ErrPreparing
+	}
+	return p.pendingDiskPlugin.Apply(ctx, spec, machineID)
+}
+
+func (p *asyncDiskPlugin) AddListener(listener volume.Listener) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listeners = append(p.listeners, listener)
+}
+
+func (p *asyncDiskPlugin) Finish(machineID, volumeName string) {
+	p.preparing.Store(false)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, listener := range p.listeners {
+		listener.HandlePrepared(volume.PreparedEvent{MachineID: machineID, VolumeName: volumeName})
+	}
+}
+
+const cachedDiskDriver = "cached-disk"
+
+// cachedDiskPlugin prepares empty disk files and records the state of the vm whenever a volume is flushed or
+// snapshotted.
+type cachedDiskPlugin struct {
+	pendingDiskPlugin
+
+	mu         sync.Mutex
+	flushes    map[string][]client.VmInfoState
+	flushTimes map[string][]time.Time
+	snapshots  map[string][]client.VmInfoState
+}
+
+func (p *cachedDiskPlugin) Name() string {
+	return "cloud-hypervisor-provider.ironcore.dev/cached-disk"
+}
+
+func (p *cachedDiskPlugin) CanSupport(spec *api.VolumeSpec) bool {
+	return spec.Connection != nil && spec.Connection.Driver == cachedDiskDriver
+}
+
+func (p *cachedDiskPlugin) Flush(ctx context.Context, _ *api.VolumeSpec, machineID string) error {
+	state, err := vmState(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.flushes == nil {
+		p.flushes = make(map[string][]client.VmInfoState)
+		p.flushTimes = make(map[string][]time.Time)
+	}
+	p.flushes[machineID] = append(p.flushes[machineID], state)
+	p.flushTimes[machineID] = append(p.flushTimes[machineID], time.Now())
+	return nil
+}
+
+func (p *cachedDiskPlugin) Snapshot(ctx context.Context, _ string, machineID string, _ string) error {
+	state, err := vmState(ctx, machineID)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.snapshots == nil {
+		p.snapshots = make(map[string][]client.VmInfoState)
+	}
+	p.snapshots[machineID] = append(p.snapshots[machineID], state)
+	return nil
+}
+
+// Snapshots returns the states of the vm of the machine at the snapshots of its volumes.
+func (p *cachedDiskPlugin) Snapshots(machineID string) []client.VmInfoState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]client.VmInfoState(nil), p.snapshots[machineID]...)
+}
+
+// vmState reads the state of the vm of the machine from its vmm, bypassing the vm info cache.
+func vmState(ctx context.Context, machineID string) (client.VmInfoState, error) {
+	machine, err := machineStore.Get(ctx, machineID)
+	if err != nil {
+		return "", err
+	}
+	chClient, err := vmm.NewUnixSocketClient(ptr.Deref(machine.Spec.ApiSocketPath, ""))
+	if err != nil {
+		return "", err
+	}
+	resp, err := chClient.GetVmInfoWithResponse(ctx)
+	if err != nil {
+		return "", err
+	}
+	if resp.JSON200 == nil {
+		return "", fmt.Errorf("failed to get vm info: %d", resp.StatusCode())
+	}
+	return resp.JSON200.State, nil
+}
+
+// Flushes returns the states of the vm of the machine at the flushes of its volumes.
+func (p *cachedDiskPlugin) Flushes(machineID string) []client.VmInfoState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]client.VmInfoState(nil), p.flushes[machineID]...)
+}
+
+// FlushTimes returns the times the volumes of the machine were flushed at.
+func (p *cachedDiskPlugin) FlushTimes(machineID string) []time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]time.Time(nil), p.flushTimes[machineID]...)
+}
+
+const failingNicName = "failing"
+
+// failingNicPlugin fails applying the network interface named failingNicName while failing is set.
+type failingNicPlugin struct {
+	networkinterface.Plugin
+	failing atomic.Bool
+}
+
+func (p *failingNicPlugin) Apply(
+	ctx context.Context,
+	spec *api.NetworkInterfaceSpec,
+	machineID string,
+) (*api.NetworkInterfaceStatus, error) {
+	if spec.Name == failingNicName && p.failing.Load() {
+		return nil, fmt.Errorf("injected failure applying nic %s", spec.Name)
+	}
+
+	status, err := p.Plugin.Apply(ctx, spec, machineID)
+	if err != nil {
+		return nil, err
+	}
+	status.Name = spec.Name
+	return status, nil
+}
+
+const preparedNicName = "prepared"
+
+// preparedNicPlugin reports the network interface named preparedNicName as prepared, so it is hot-plugged.
+type preparedNicPlugin struct {
+	networkinterface.Plugin
+}
+
+func (p *preparedNicPlugin) Apply(
+	ctx context.Context,
+	spec *api.NetworkInterfaceSpec,
+	machineID string,
+) (*api.NetworkInterfaceStatus, error) {
+	status, err := p.Plugin.Apply(ctx, spec, machineID)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Name == preparedNicName {
+		status.State = api.NetworkInterfaceStatePrepared
+		status.Path = path.Join("/run/fake-nics", machineID, spec.Name)
+	}
+	return status, nil
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+)
+
+// flushInterval returns the interval the volume is flushed in while the vm runs, zero if it is not flushed
+// periodically.
+func (r *MachineReconciler) flushInterval(vol *api.VolumeSpec) time.Duration {
+	if vol.Tuning != nil && vol.Tuning.FlushIntervalSeconds > 0 {
+		return time.Duration(vol.Tuning.FlushIntervalSeconds) * time.Second
+	}
+	return r.volumeFlushInterval
+}
+
+// flushDueVolumes flushes the attached volumes whose flush interval passed since their last flush, bounding the
+// writes lost with the host even if the guest does not sync them. The machine is requeued for the next flush.
+// Failed flushes are retried with the next one, they do not fail the reconciliation.
+func (r *MachineReconciler) flushDueVolumes(ctx context.Context, log logr.Logger, machine *api.Machine) {
+	// The machine is not reconciled concurrently, only the map of all machines has to be guarded.
+	r.volumeFlushMu.Lock()
+	lastFlushes := r.volumeFlushes[machine.ID]
+	r.volumeFlushMu.Unlock()
+
+	now := time.Now()
+	flushes := make(map[string]time.Time)
+	var next time.Duration
+	for _, vol := range machine.Spec.Volumes {
+		if vol.DeletedAt != nil {
+			continue
+		}
+		if status := getVolumeStatus(machine.Status.VolumeStatus, vol.Name); status.State != api.VolumeStateAttached {
+			continue
+		}
+		interval := r.flushInterval(vol)
+		if interval <= 0 {
+			continue
+		}
+
+		plugin, err := r.VolumePluginManager.FindPluginBySpec(vol)
+		if err != nil {
+			continue
+		}
+		flushable, ok := plugin.(volume.FlushablePlugin)
+		if !ok {
+			continue
+		}
+
+		// The first interval of a volume starts once it is seen attached to the running vm.
+		lastFlush, ok := lastFlushes[vol.Name]
+		if !ok {
+			lastFlush = now
+		}
+		if due := lastFlush.Add(interval); !now.Before(due) {
+			log.V(2).Info("Flush volume periodically", "name", vol.Name, "interval", interval)
+			if err := flushable.Flush(ctx, vol, machine.ID); err != nil {
+				log.V(1).Info("Failed to flush volume", "name", vol.Name, "error", err.Error())
+			}
+			lastFlush = now
+		}
+		flushes[vol.Name] = lastFlush
+
+		if wait := lastFlush.Add(interval).Sub(now); next == 0 || wait < next {
+			next = wait
+		}
+	}
+
+	if len(flushes) == 0 {
+		r.forgetVolumeFlushes(machine.ID)
+		return
+	}
+	r.volumeFlushMu.Lock()
+	r.volumeFlushes[machine.ID] = flushes
+	r.volumeFlushMu.Unlock()
+	r.queue.AddAfter(machine.ID, next)
+}
+
+// forgetVolumeFlushes drops the periodic flushes of the machine, they start over once its vm runs again.
+func (r *MachineReconciler) forgetVolumeFlushes(id string) {
+	r.volumeFlushMu.Lock()
+	defer r.volumeFlushMu.Unlock()
+	delete(r.volumeFlushes, id)
+}
@@ -4,27 +4,41 @@
 package controllers
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/audit"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/cgroup"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/configdrive"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/imageutils"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/machineindex"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/metrics"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/pci"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/networkinterface"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/priorityqueue"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/raw"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/snapshot"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
 	"github.com/ironcore-dev/provider-utils/eventutils/event"
 	"github.com/ironcore-dev/provider-utils/eventutils/recorder"
 	ociutils "github.com/ironcore-dev/provider-utils/ociutils/oci"
 	"github.com/ironcore-dev/provider-utils/storeutils/store"
 	"github.com/ironcore-dev/provider-utils/storeutils/utils"
+	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
@@ -33,6 +47,57 @@ import (
 
 const (
 	MachineFinalizer = "machine"
+
+	DefaultReconcileTimeout = 5 * time.Minute
+	DefaultBootTimeout      = 5 * time.Minute
+	DefaultWorkerSize       = 15
+
+	bootTimeoutReason       = "BootTimeout"
+	guestShutdownReason     = "GuestShutdown"
+	volumeUnhealthyReason   = "VolumeUnhealthy"
+	diskPressureReason      = "DiskPressure"
+	imageMissingReason      = "ImageMissing"
+	volumeUnreachableReason = "VolumeUnreachable"
+
+	// The reasons of the events of the lifecycle of vms.
+	createdVMReason      = "CreatedVM"
+	createVMFailedReason = "CreateVMFailed"
+	poweredOnReason      = "PoweredOn"
+	powerOnFailedReason  = "PowerOnFailed"
+	poweredOffReason     = "PoweredOff"
+	attachedDiskReason   = "AttachedDisk"
+	detachedDiskReason   = "DetachedDisk"
+	attachedNICReason    = "AttachedNIC"
+	detachedNICReason    = "DetachedNIC"
+
+	pausedVMRequeueInterval     = 5 * time.Second
+	volumeHealthRecheckInterval = 30 * time.Second
+	bootDiskRequeueInterval     = 2 * time.Second
+	volumeMigrationPollInterval = 5 * time.Second
+	volumeProbeRetryInterval    = 5 * time.Second
+	diskPressureRetryInterval   = time.Minute
+	imageMissingRetryInterval   = time.Minute
+	volumeSecretRetryInterval   = 30 * time.Second
+
+	// auditActor attributes the operations the reconciler performs on its own to it in the audit log.
+	auditActor = "machine-reconciler"
+)
+
+// errDiskPressure stops the reconciliation of a machine whose disks cannot be prepared for lack of space.
+// The machine is retried after diskPressureRetryInterval instead of being rate limited.
+var errDiskPressure = errors.New("disk pressure")
+
+// errReconcileInProgress is returned while a timed out reconciliation of the machine did not return yet.
+var errReconcileInProgress = errors.New("previous reconciliation still in progress")
+
+// GuestShutdownPolicy decides how the reconciler handles a vm that was shut down from inside the guest.
+type GuestShutdownPolicy string
+
+const (
+	// GuestShutdownPolicyRestart powers the vm on again.
+	GuestShutdownPolicyRestart GuestShutdownPolicy = "Restart"
+	// GuestShutdownPolicyStop powers the machine off, keeping the vm shut down.
+	GuestShutdownPolicyStop GuestShutdownPolicy = "Stop"
 )
 
 type MachineReconcilerOptions struct {
@@ -40,6 +105,80 @@ type MachineReconcilerOptions struct {
 	Raw        raw.Raw
 
 	Paths host.Paths
+
+	PciDevices *pci.Manager
+
+	// Cgroups applies the qos class of machines to their vmm process if set.
+	Cgroups *cgroup.Manager
+
+	// ReconcileTimeout bounds the duration of a single machine reconciliation.
+	ReconcileTimeout time.Duration
+
+	// ValidateImageArchitecture rejects boot images not matching Architecture.
+	ValidateImageArchitecture bool
+	Architecture              string
+
+	// BootTimeout is the time a powered on vm may take to reach running, unless overridden by the machine.
+	BootTimeout time.Duration
+	// PowerOffOnBootTimeout powers machines off that did not boot within their boot timeout.
+	PowerOffOnBootTimeout bool
+
+	// GuestShutdownPolicy is applied to vms shut down by their guest. Defaults to GuestShutdownPolicyRestart.
+	GuestShutdownPolicy GuestShutdownPolicy
+
+	// ShutdownGracePeriod is the time the guest is given to shut down a vm that is powered off or deleted,
+	// before the vm is powered off. Zero powers vms off right away.
+	ShutdownGracePeriod time.Duration
+
+	// QuarantineThreshold is the number of consecutive failed reconciliations after which a machine is
+	// quarantined. Zero disables quarantining.
+	QuarantineThreshold int
+
+	// QueueName labels the metrics of the reconcile queue. Defaults to "machine".
+	QueueName string
+	// WorkerSize is the number of machines reconciled concurrently. Defaults to DefaultWorkerSize.
+	WorkerSize int
+
+	// ProbeVolumes checks that the backend of a volume is reachable before its disk is added to the vm, so
+	// the guest does not hang on a dead disk. Unreachable volumes are retried later.
+	ProbeVolumes bool
+
+	// PrioritizeMachineChanges reconciles created and deleted machines ahead of machines requeued or resynced.
+	PrioritizeMachineChanges bool
+
+	// AuditLog records the resizes of vms, which are not requested through the iri. If unset, resizes are not
+	// audited.
+	AuditLog *audit.Logger
+
+	// PowerOnLimiter limits the rate vms are powered on at, independent of WorkerSize. It may be shared by the
+	// reconcilers of several pools. Machines exceeding it are powered on once it allows to. If unset, power ons
+	// are not limited.
+	PowerOnLimiter *rate.Limiter
+
+	// VolumeFlushInterval is the interval the writes cached by the backends of the attached volumes are flushed
+	// in while the vm runs, unless overridden by the volume. Zero disables the periodic flush.
+	VolumeFlushInterval time.Duration
+}
+
+func setMachineReconcilerOptionsDefaults(o *MachineReconcilerOptions) {
+	if o.ReconcileTimeout == 0 {
+		o.ReconcileTimeout = DefaultReconcileTimeout
+	}
+	if o.Architecture == "" {
+		o.Architecture = runtime.GOARCH
+	}
+	if o.BootTimeout == 0 {
+		o.BootTimeout = DefaultBootTimeout
+	}
+	if o.GuestShutdownPolicy == "" {
+		o.GuestShutdownPolicy = GuestShutdownPolicyRestart
+	}
+	if o.QueueName == "" {
+		o.QueueName = "machine"
+	}
+	if o.WorkerSize == 0 {
+		o.WorkerSize = DefaultWorkerSize
+	}
 }
 
 func NewMachineReconciler(
@@ -60,18 +199,66 @@ func NewMachineReconciler(
 		return nil, fmt.Errorf("must specify machine events")
 	}
 
+	setMachineReconcilerOptionsDefaults(&opts)
+
+	switch opts.GuestShutdownPolicy {
+	case GuestShutdownPolicyRestart, GuestShutdownPolicyStop:
+	default:
+		return nil, fmt.Errorf("invalid guest shutdown policy %q", opts.GuestShutdownPolicy)
+	}
+
+	if opts.WorkerSize < 0 {
+		return nil, fmt.Errorf("worker size must be positive, got %d", opts.WorkerSize)
+	}
+
+	if opts.PowerOnLimiter != nil && opts.PowerOnLimiter.Burst() < 1 {
+		return nil, fmt.Errorf("power on limiter burst must be positive, got %d", opts.PowerOnLimiter.Burst())
+	}
+
+	if opts.VolumeFlushInterval != 0 && opts.VolumeFlushInterval < time.Second {
+		return nil, fmt.Errorf("volume flush interval must be zero or at least 1s, got %s", opts.VolumeFlushInterval)
+	}
+
+	priority := priorityqueue.New[string]()
 	return &MachineReconciler{
 		log: log,
-		queue: workqueue.NewTypedRateLimitingQueue[string](
+		queue: metrics.NewRateLimitingQueueWithQueue(
+			opts.QueueName,
 			workqueue.DefaultTypedControllerRateLimiter[string](),
+			priority,
 		),
+		queueName:              opts.QueueName,
+		workerSize:             opts.WorkerSize,
+		priority:               priority,
+		prioritizeChanges:      opts.PrioritizeMachineChanges,
 		machines:               machines,
 		machineEvents:          machineEvents,
+		machineIndex:           machineindex.New(),
 		eventRecorder:          eventRecorder,
 		imageCache:             opts.ImageCache,
 		raw:                    opts.Raw,
 		paths:                  opts.Paths,
+		pciDevices:             opts.PciDevices,
+		cgroups:                opts.Cgroups,
+		reconcileTimeout:       opts.ReconcileTimeout,
+		validateImageArch:      opts.ValidateImageArchitecture,
+		architecture:           opts.Architecture,
+		bootTimeout:            opts.BootTimeout,
+		powerOffOnBootTimeout:  opts.PowerOffOnBootTimeout,
+		guestShutdownPolicy:    opts.GuestShutdownPolicy,
+		shutdownGracePeriod:    opts.ShutdownGracePeriod,
+		probeVolumes:           opts.ProbeVolumes,
+		auditLog:               opts.AuditLog,
+		quarantineThreshold:    opts.QuarantineThreshold,
+		quarantined:            make(map[string]string),
+		failures:               make(map[string]int),
+		abandoned:              sets.New[string](),
+		powerOnLimiter:         opts.PowerOnLimiter,
+		powerOns:               make(map[string]*rate.Reservation),
+		volumeFlushInterval:    opts.VolumeFlushInterval,
+		volumeFlushes:          make(map[string]map[string]time.Time),
 		vmm:                    vmm,
+		snapshotter:            snapshot.NewSnapshotter(log.WithName("snapshotter"), vmm, volumePluginManager),
 		VolumePluginManager:    volumePluginManager,
 		networkInterfacePlugin: nicPlugin,
 	}, nil
@@ -80,50 +267,121 @@ func NewMachineReconciler(
 type MachineReconciler struct {
 	log   logr.Logger
 	queue workqueue.TypedRateLimitingInterface[string]
+	// queueName labels the metrics of the queue and its workers.
+	queueName  string
+	workerSize int
+	// priority orders the queue, handing out prioritized machines first.
+	priority          *priorityqueue.Queue[string]
+	prioritizeChanges bool
 
 	imageCache ociutils.Cache
 	raw        raw.Raw
 
 	paths host.Paths
 
-	vmm *vmm.Manager
+	vmm         *vmm.Manager
+	snapshotter *snapshot.Snapshotter
+	pciDevices  *pci.Manager
+	cgroups     *cgroup.Manager
+
+	auditLog *audit.Logger
 
 	VolumePluginManager    *volume.PluginManager
 	networkInterfacePlugin networkinterface.Plugin
 
 	machines      store.Store[*api.Machine]
 	machineEvents event.Source[*api.Machine]
+	// machineIndex looks machines up by boot image and api socket.
+	machineIndex *machineindex.Index
 
 	eventRecorder recorder.EventRecorder
+
+	reconcileTimeout time.Duration
+
+	validateImageArch bool
+	architecture      string
+
+	bootTimeout           time.Duration
+	powerOffOnBootTimeout bool
+
+	guestShutdownPolicy GuestShutdownPolicy
+	shutdownGracePeriod time.Duration
+
+	probeVolumes bool
+
+	quarantineThreshold int
+	// quarantined holds the fingerprint of quarantined machines at the time they were quarantined.
+	quarantined map[string]string
+	// failures counts the consecutive failed reconciliations of machines.
+	failures      map[string]int
+	quarantinedMu sync.Mutex
+
+	// abandoned holds machines whose timed out reconciliation did not return yet.
+	abandoned   sets.Set[string]
+	abandonedMu sync.Mutex
+
+	// powerOnLimiter limits the rate vms are powered on at, it is nil if they are not limited.
+	powerOnLimiter *rate.Limiter
+	// powerOns holds the power on reservations of machines waiting for the rate limit.
+	powerOns  map[string]*rate.Reservation
+	powerOnMu sync.Mutex
+
+	volumeFlushInterval time.Duration
+	// volumeFlushes holds the time the attached volumes of running machines were last flushed periodically.
+	volumeFlushes map[string]map[string]time.Time
+	volumeFlushMu sync.Mutex
 }
 
 func (r *MachineReconciler) Start(ctx context.Context) error {
 	log := r.log
 
-	// TODO make configurable
-	workerSize := 15
+	machineIndexRegistration, err := r.machineEvents.AddHandler(r.machineIndex)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = r.machineEvents.RemoveHandler(machineIndexRegistration); err != nil {
+			log.Error(err, "failed to remove machine index handler")
+		}
+	}()
+
+	machines, err := r.machines.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list machines: %w", err)
+	}
+	for _, machine := range machines {
+		r.machineIndex.Set(machine)
+	}
 
 	r.imageCache.AddListener(ociutils.ListenerFuncs{
 		HandlePullDoneFunc: func(evt ociutils.PullDoneEvent) {
-			machines, err := r.machines.List(ctx)
-			if err != nil {
-				log.Error(err, "failed to list machine")
-				return
-			}
-
-			for _, machine := range machines {
-				if api.IsImageReferenced(machine, evt.Ref) {
-					r.eventRecorder.Eventf(machine.Metadata, corev1.EventTypeNormal, "ImagePullSucceeded", "Pulled image %s", evt.Ref)
-					log.V(1).Info("Image pulled: Requeue machines", "Image", evt.Ref, "Machine", machine.ID)
-					r.queue.Add(machine.ID)
+			for _, id := range r.machineIndex.MachinesByImage(evt.Ref) {
+				machine, err := r.machines.Get(ctx, id)
+				if err != nil {
+					log.Error(err, "failed to get machine", "Machine", id)
+					continue
 				}
+
+				r.eventf(machine, corev1.EventTypeNormal, "ImagePullSucceeded", "Pulled image %s", evt.Ref)
+				log.V(1).Info("Image pulled: Requeue machines", "Image", evt.Ref, "Machine", machine.ID)
+				r.queue.Add(machine.ID)
 			}
 		},
 	})
 
+	r.VolumePluginManager.AddListener(volume.ListenerFuncs{
+		HandlePreparedFunc: func(evt volume.PreparedEvent) {
+			log.V(1).Info("Volume prepared: Requeue machine", "Volume", evt.VolumeName, "Machine", evt.MachineID)
+			r.queue.Add(evt.MachineID)
+		},
+	})
+
 	machineEventHandlerRegistration, err := r.machineEvents.AddHandler(
 		event.HandlerFunc[*api.Machine](func(evt event.Event[*api.Machine]) {
 			log.V(2).Info("Machine event received", "type", evt.Type, "id", evt.Object.ID)
+			if r.prioritizeChanges && isMachineChange(evt) {
+				r.priority.Prioritize(evt.Object.ID)
+			}
 			r.queue.Add(evt.Object.ID)
 		}))
 	if err != nil {
@@ -141,10 +399,11 @@ func (r *MachineReconciler) Start(ctx context.Context) error {
 		r.queue.ShutDown()
 	}()
 
-	for i := 0; i < workerSize; i++ {
+	for i := 0; i < r.workerSize; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			defer metrics.TrackWorker(r.queueName)()
 			for r.processNextWorkItem(ctx, log) {
 			}
 		}()
@@ -154,6 +413,19 @@ func (r *MachineReconciler) Start(ctx context.Context) error {
 	return nil
 }
 
+// isMachineChange reports whether the event creates or deletes the machine, as opposed to updates and resyncs.
+func isMachineChange(evt event.Event[*api.Machine]) bool {
+	switch evt.Type {
+	case event.TypeCreated, event.TypeDeleted:
+		return true
+	case event.TypeUpdated:
+		// Deleting a machine with finalizers updates it.
+		return evt.Object.DeletedAt != nil
+	default:
+		return false
+	}
+}
+
 func (r *MachineReconciler) processNextWorkItem(ctx context.Context, log logr.Logger) bool {
 	id, shutdown := r.queue.Get()
 	if shutdown {
@@ -164,16 +436,81 @@ func (r *MachineReconciler) processNextWorkItem(ctx context.Context, log logr.Lo
 	log = log.WithValues("machineID", id)
 	ctx = logr.NewContext(ctx, log)
 
-	if err := r.reconcileMachine(ctx, id); err != nil {
+	if r.isQuarantined(ctx, log, id) {
+		log.V(2).Info("Machine is quarantined, skipping reconciliation")
+		r.queue.Forget(id)
+		return true
+	}
+
+	if err := r.reconcileMachineWithTimeout(ctx, log, id); err != nil {
 		log.Error(err, "failed to reconcile machine")
+		// Conflicting writes of the machine, e.g. its status message, are retried without counting as failure.
+		if !errors.Is(err, errReconcileInProgress) && !errors.Is(err, store.ErrResourceVersionNotLatest) &&
+			r.recordFailure(id) && r.quarantine(ctx, log, id, err) {
+			r.queue.Forget(id)
+			return true
+		}
 		r.queue.AddRateLimited(id)
 		return true
 	}
 
+	r.resetFailures(id)
 	r.queue.Forget(id)
 	return true
 }
 
+func (r *MachineReconciler) reconcileMachineWithTimeout(ctx context.Context, log logr.Logger, id string) error {
+	r.abandonedMu.Lock()
+	if r.abandoned.Has(id) {
+		r.abandonedMu.Unlock()
+		return errReconcileInProgress
+	}
+	r.abandonedMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.reconcileTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		err := r.reconcileMachine(ctx, id)
+
+		r.abandonedMu.Lock()
+		defer r.abandonedMu.Unlock()
+		r.abandoned.Delete(id)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	r.abandonedMu.Lock()
+	select {
+	case err := <-done:
+		r.abandonedMu.Unlock()
+		return err
+	default:
+		r.abandoned.Insert(id)
+		r.abandonedMu.Unlock()
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.V(1).Info("Reconciliation timed out", "timeout", r.reconcileTimeout)
+		if machine, err := r.machines.Get(context.Background(), id); err == nil {
+			r.eventf(machine, corev1.EventTypeWarning, "ReconcileTimeout", "Reconciliation exceeded %s", r.reconcileTimeout)
+		}
+	}
+	return fmt.Errorf("reconciliation aborted: %w", ctx.Err())
+}
+
+// eventf records an event for the machine, prefixing the message with the display name of the machine.
+func (r *MachineReconciler) eventf(machine *api.Machine, eventType, reason, messageFmt string, args ...any) {
+	r.eventRecorder.Eventf(machine.Metadata, eventType, reason, "Machine %s: "+messageFmt,
+		append([]any{api.MachineDisplayName(machine)}, args...)...)
+}
+
 func getNicName(id string) *string {
 	parts := strings.Split(id, "//")
 	if len(parts) != 2 {
@@ -198,8 +535,8 @@ func (r *MachineReconciler) getMachineState(
 		}
 		return client.Shutdown, err
 	}
-	if vm.State == client.Running {
-		return client.Running, nil
+	if vm.State == client.Running || vm.State == client.Paused {
+		return vm.State, nil
 	}
 	return client.Shutdown, nil
 }
@@ -237,9 +574,19 @@ func (r *MachineReconciler) deleteMachine(ctx context.Context, log logr.Logger,
 	}
 	log.V(1).Info("Got Machine state", "state", state)
 
-	if state == client.Running {
-		log.V(1).Info("Power machine off")
-		if err := r.vmm.PowerOff(ctx, apiSocket); err != nil {
+	// A paused vm is shut down like a running one, its volumes hold writes not flushed yet as well.
+	if state == client.Running || state == client.Paused {
+		if err := r.flushVolumes(ctx, log, machine); err != nil {
+			return err
+		}
+
+		gracePeriod := r.shutdownGracePeriod
+		if state == client.Paused {
+			// A paused guest cannot react to the power button.
+			gracePeriod = 0
+		}
+		log.V(1).Info("Shut machine down", "gracePeriod", gracePeriod)
+		if err := r.vmm.Shutdown(ctx, apiSocket, gracePeriod); err != nil {
 			if !errors.Is(err, vmm.ErrNotFound) {
 				return fmt.Errorf("failed to power off machine: %w", err)
 			}
@@ -274,6 +621,12 @@ func (r *MachineReconciler) deleteMachine(ctx context.Context, log logr.Logger,
 		}
 	}
 
+	if r.pciDevices != nil {
+		r.pciDevices.Release(machine.ID)
+	}
+	r.cancelPowerOn(machine.ID)
+	r.forgetVolumeFlushes(machine.ID)
+
 	if apiSocket != "" {
 		r.vmm.FreeApiSocket(ctx, apiSocket)
 	}
@@ -281,8 +634,13 @@ func (r *MachineReconciler) deleteMachine(ctx context.Context, log logr.Logger,
 	if err := os.RemoveAll(r.paths.MachineDir(machine.ID)); err != nil {
 		return fmt.Errorf("failed to remove machine directory: %w", err)
 	}
+	if err := os.RemoveAll(r.paths.MachineSocketsDir(machine.ID)); err != nil {
+		return fmt.Errorf("failed to remove machine sockets directory: %w", err)
+	}
 	log.V(1).Info("Removed machine directory")
 
+	metrics.DeleteMachineVolumeStats(machine.ID)
+
 	machine.Finalizers = utils.DeleteSliceElement(machine.Finalizers, MachineFinalizer)
 	if _, err := r.machines.Update(ctx, machine); store.IgnoreErrNotFound(err) != nil {
 		return fmt.Errorf("failed to update machine metadata: %w", err)
@@ -301,6 +659,10 @@ func (r *MachineReconciler) reconcileVolumes(ctx context.Context, log logr.Logge
 
 		plugin, err := r.VolumePluginManager.FindPluginBySpec(vol)
 		if err != nil {
+			if errors.Is(err, volume.ErrPluginNotFound) {
+				// Plugins are only initialized on startup, retrying does not help.
+				return blocked("%s", err)
+			}
 			return fmt.Errorf("failed to find plugin: %w", err)
 		}
 
@@ -318,13 +680,49 @@ func (r *MachineReconciler) reconcileVolumes(ctx context.Context, log logr.Logge
 			log.V(2).Info("Volume attached but deletion timestamp set", "name", vol.Name)
 		}
 
-		appliedVolume, err := plugin.Apply(ctx, vol, machine.ID)
+		appliedVolume, err := r.migrateVolume(ctx, log, machine, plugin, vol, status)
 		if err != nil {
-			return fmt.Errorf("failed to apply volume: %w", err)
+			if !errors.Is(err, volume.ErrMigrating) {
+				return fmt.Errorf("failed to migrate volume: %w", err)
+			}
+			// The vm keeps using the volume while it is migrated, go on reconciling the machine meanwhile.
+			log.V(1).Info("Volume is being migrated, reconcile later", "name", vol.Name)
+			r.queue.AddAfter(machine.ID, volumeMigrationPollInterval)
+			appliedVolume = &status
+		}
+		if appliedVolume == nil {
+			appliedVolume, err = r.reattachVolume(ctx, log, machine, plugin, vol, status)
+			if err != nil {
+				return fmt.Errorf("failed to reattach volume: %w", err)
+			}
+		}
+		if appliedVolume == nil {
+			appliedVolume, err = plugin.Apply(ctx, vol, machine.ID)
+			if err != nil {
+				if errors.Is(err, volume.ErrPreparing) {
+					log.V(1).Info("Volume is being prepared, reconcile later", "name", vol.Name)
+					r.eventf(machine, corev1.EventTypeNormal, "PreparingVolume", "Preparing volume %s in progress", vol.Name)
+					return &blockedError{message: fmt.Sprintf("waiting for volume %s to be prepared", vol.Name), err: err}
+				}
+				if osutils.IsNoSpace(err) {
+					return r.reportDiskPressure(ctx, log, machine, vol.Name, err)
+				}
+				if errors.Is(err, volume.ErrSecretNotFound) {
+					// The secret may be created after the machine, retry without counting as failure.
+					log.V(1).Info("Secret of volume not found, reconcile later", "name", vol.Name, "error", err.Error())
+					r.queue.AddAfter(machine.ID, volumeSecretRetryInterval)
+					return &blockedError{message: fmt.Sprintf("waiting for the secret of volume %s", vol.Name), err: err}
+				}
+				return fmt.Errorf("failed to apply volume: %w", err)
+			}
 		}
 		if status.State == api.VolumeStateAttached {
 			appliedVolume.State = status.State
 		}
+		appliedVolume.Serial = vol.Serial
+		if vol.Tuning != nil {
+			appliedVolume.QueueDepth = vol.Tuning.QueueDepth
+		}
 		updatedVolumeSpec = append(updatedVolumeSpec, vol)
 		updatedVolumeStatus = append(updatedVolumeStatus, *appliedVolume)
 		log.V(2).Info("Volume reconciled", "name", vol.Name)
@@ -333,6 +731,15 @@ func (r *MachineReconciler) reconcileVolumes(ctx context.Context, log logr.Logge
 	machine.Spec.Volumes = updatedVolumeSpec
 	machine.Status.VolumeStatus = updatedVolumeStatus
 
+	if condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionDiskPressure); found &&
+		condition.Status == api.ConditionTrue {
+		api.SetMachineCondition(&machine.Status, api.MachineCondition{
+			Type:   api.MachineConditionDiskPressure,
+			Status: api.ConditionFalse,
+			Reason: "DiskSpaceAvailable",
+		})
+	}
+
 	if _, err := r.machines.Update(ctx, machine); err != nil {
 		return fmt.Errorf("failed to update machine status: %w", err)
 	}
@@ -340,12 +747,126 @@ func (r *MachineReconciler) reconcileVolumes(ctx context.Context, log logr.Logge
 	return nil
 }
 
+// migrateVolume moves an attached volume whose spec points to another backing over to it while the vm
+// keeps running. It returns nil if the volume is not migrated.
+func (r *MachineReconciler) migrateVolume(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	plugin volume.Plugin,
+	vol *api.VolumeSpec,
+	status api.VolumeStatus,
+) (*api.VolumeStatus, error) {
+	migratablePlugin, ok := plugin.(volume.MigratablePlugin)
+	if !ok || vol.DeletedAt != nil || status.State != api.VolumeStateAttached {
+		return nil, nil
+	}
+
+	migrated, err := migratablePlugin.Migrate(ctx, vol, machine.ID, &status)
+	if err != nil {
+		return nil, err
+	}
+	if migrated != nil {
+		log.V(1).Info("Migrated volume", "name", vol.Name, "backingHandle", migrated.BackingHandle)
+		r.eventf(machine, corev1.EventTypeNormal, "MigratedVolume", "Migrated volume %s to %s", vol.Name, migrated.BackingHandle)
+	}
+	return migrated, nil
+}
+
+// reattachVolume returns the status of the existing backing of an applied volume, so it is attached again
+// without being provisioned anew. Apply is skipped for a reattached volume as it resolves the image and
+// prepares the backing of a missing volume, while a backing that is gone is only recreated with a
+// warning event. It returns nil if the volume has to be applied.
+func (r *MachineReconciler) reattachVolume(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	plugin volume.Plugin,
+	vol *api.VolumeSpec,
+	status api.VolumeStatus,
+) (*api.VolumeStatus, error) {
+	reattachablePlugin, ok := plugin.(volume.ReattachablePlugin)
+	if !ok || status.Handle == "" {
+		return nil, nil
+	}
+
+	reattached, err := reattachablePlugin.Reattach(ctx, vol, machine.ID, status.Handle)
+	if err != nil {
+		if errors.Is(err, volume.ErrVolumeNotFound) {
+			log.V(1).Info("Backing of volume not found, creating it anew", "name", vol.Name, "handle", status.Handle)
+			r.eventf(machine, corev1.EventTypeWarning, "VolumeNotFound",
+				"Backing of volume %s not found, creating it anew", vol.Name)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	log.V(2).Info("Reattach existing volume", "name", vol.Name, "handle", status.Handle)
+	return reattached, nil
+}
+
+func (r *MachineReconciler) reportDiskPressure(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	volumeName string,
+	cause error,
+) error {
+	message := fmt.Sprintf("no space left to prepare volume %s", volumeName)
+	if condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionDiskPressure); !found ||
+		condition.Status != api.ConditionTrue || condition.Message != message {
+		log.V(1).Info("Out of disk space preparing volume", "volume", volumeName, "error", cause.Error())
+		r.eventf(machine, corev1.EventTypeWarning, diskPressureReason, "Failed to prepare volume %s: %s", volumeName, cause)
+	}
+	api.SetMachineCondition(&machine.Status, api.MachineCondition{
+		Type:    api.MachineConditionDiskPressure,
+		Status:  api.ConditionTrue,
+		Reason:  diskPressureReason,
+		Message: message,
+	})
+	if _, err := r.machines.Update(ctx, machine); err != nil {
+		return fmt.Errorf("failed to update machine status: %w", err)
+	}
+
+	r.queue.AddAfter(machine.ID, diskPressureRetryInterval)
+	return errDiskPressure
+}
+
+// reportImageMissing sets the ImageMissing condition of a machine whose boot image is gone from the local store
+// and its registry. The image is pulled again after imageMissingRetryInterval.
+func (r *MachineReconciler) reportImageMissing(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	image string,
+	cause error,
+) error {
+	message := fmt.Sprintf("image %s is missing in the local store and its registry", image)
+	if condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionImageMissing); !found ||
+		condition.Status != api.ConditionTrue || condition.Message != message {
+		log.V(1).Info("Boot image is missing", "image", image, "error", cause.Error())
+		r.eventf(machine, corev1.EventTypeWarning, imageMissingReason, "Image %s: %s", image, cause)
+	}
+	api.SetMachineCondition(&machine.Status, api.MachineCondition{
+		Type:    api.MachineConditionImageMissing,
+		Status:  api.ConditionTrue,
+		Reason:  imageMissingReason,
+		Message: message,
+	})
+	if _, err := r.machines.Update(ctx, machine); err != nil {
+		return fmt.Errorf("failed to update machine status: %w", err)
+	}
+
+	r.queue.AddAfter(machine.ID, imageMissingRetryInterval)
+	return blocked("%s", message)
+}
+
 func (r *MachineReconciler) reconcileNics(ctx context.Context, log logr.Logger, machine *api.Machine) error {
 	var updatedNICStatus []api.NetworkInterfaceStatus
 	var updatedNICSpec []*api.NetworkInterfaceSpec
 
 	plugin := r.networkInterfacePlugin
-	for _, nic := range machine.Spec.NetworkInterfaces {
+	for i, nic := range machine.Spec.NetworkInterfaces {
 
 		log.V(2).Info("Reconcile NIC", "name", nic.Name, "plugin", plugin.Name())
 
@@ -364,7 +885,22 @@ func (r *MachineReconciler) reconcileNics(ctx context.Context, log logr.Logger,
 
 		appliedNIC, err := plugin.Apply(ctx, nic, machine.ID)
 		if err != nil {
-			return fmt.Errorf("failed to apply NIC: %w", err)
+			// Persist the progress so far, keeping the remaining NICs as they are, to retry idempotently.
+			for _, remaining := range machine.Spec.NetworkInterfaces[i:] {
+				updatedNICSpec = append(updatedNICSpec, remaining)
+				idx := slices.IndexFunc(machine.Status.NetworkInterfaceStatus, func(s api.NetworkInterfaceStatus) bool {
+					return s.Name == remaining.Name
+				})
+				if idx >= 0 {
+					updatedNICStatus = append(updatedNICStatus, machine.Status.NetworkInterfaceStatus[idx])
+				}
+			}
+			machine.Spec.NetworkInterfaces = updatedNICSpec
+			machine.Status.NetworkInterfaceStatus = updatedNICStatus
+			if _, updateErr := r.machines.Update(ctx, machine); updateErr != nil {
+				log.Error(updateErr, "failed to persist partially reconciled NICs")
+			}
+			return fmt.Errorf("failed to apply NIC %s: %w", nic.Name, err)
 		}
 		if status.State == api.NetworkInterfaceStateAttached {
 			appliedNIC.State = status.State
@@ -384,110 +920,344 @@ func (r *MachineReconciler) reconcileNics(ctx context.Context, log logr.Logger,
 	return nil
 }
 
-// nolint: dupl
-func (r *MachineReconciler) attachDetachDisks(
-	ctx context.Context,
-	log logr.Logger,
-	machine *api.Machine,
-	vm client.VmConfig,
-) error {
-	apiSocket := ptr.Deref(machine.Spec.ApiSocketPath, "")
-	currentDevices := sets.New[string]()
+// flushVolumes writes the writes cached by the backends of the attached volumes back before the vm is
+// powered off, as shutting the vm down does not flush them.
+func (r *MachineReconciler) flushVolumes(ctx context.Context, log logr.Logger, machine *api.Machine) error {
+	for _, vol := range machine.Spec.Volumes {
+		if status := getVolumeStatus(machine.Status.VolumeStatus, vol.Name); status.State != api.VolumeStateAttached {
+			continue
+		}
 
-	for _, dev := range ptr.Deref(vm.Disks, []client.DiskConfig{}) {
-		id := dev.Id
-		if id == nil {
+		plugin, err := r.VolumePluginManager.FindPluginBySpec(vol)
+		if err != nil {
+			return fmt.Errorf("failed to find plugin: %w", err)
+		}
+		flushable, ok := plugin.(volume.FlushablePlugin)
+		if !ok {
 			continue
 		}
-		currentDevices.Insert(ptr.Deref(id, ""))
+
+		log.V(2).Info("Flush volume", "name", vol.Name, "plugin", plugin.Name())
+		if err := flushable.Flush(ctx, vol, machine.ID); err != nil {
+			return fmt.Errorf("failed to flush volume %s: %w", vol.Name, err)
+		}
 	}
+	return nil
+}
 
-	var updatedVolumeStatus []api.VolumeStatus
+// checkVolumesHealth surfaces attached volumes whose backend is no longer usable, e.g. an unreachable
+// ceph cluster, via the VolumesHealthy condition. The volumes are kept attached.
+func (r *MachineReconciler) checkVolumesHealth(ctx context.Context, log logr.Logger, machine *api.Machine) error {
+	var unhealthy []string
 	for _, vol := range machine.Spec.Volumes {
-		status := getVolumeStatus(machine.Status.VolumeStatus, vol.Name)
-
-		if vol.DeletedAt == nil {
-			if !currentDevices.Has(status.Handle) {
-				if status.State != api.VolumeStatePrepared {
-					log.V(1).Info("Skip disk attachment: not prepared", "disk", vol.Name)
-					continue
-				}
-				if err := r.vmm.AddDisk(ctx, apiSocket, ptr.To(status)); err != nil {
-					return fmt.Errorf("failed to add disk %s: %w", vol.Name, err)
-				}
-
-				log.V(1).Info("Added disk", "disk", vol.Name)
-			}
-			status.State = api.VolumeStateAttached
-			updatedVolumeStatus = append(updatedVolumeStatus, status)
-		} else {
-			if currentDevices.Has(status.Handle) {
-				if err := r.vmm.RemoveDevice(ctx, apiSocket, status.Handle); err != nil {
-					return fmt.Errorf("failed to remove disk %s: %w", vol.Name, err)
-				}
-				log.V(1).Info("Removed disk", "disk", vol.Name)
+		if vol.DeletedAt != nil {
+			continue
+		}
+		if status := getVolumeStatus(machine.Status.VolumeStatus, vol.Name); status.State != api.VolumeStateAttached {
+			continue
+		}
 
-				updatedVolumeStatus = append(updatedVolumeStatus, status)
-				continue
-			}
+		plugin, err := r.VolumePluginManager.FindPluginBySpec(vol)
+		if err != nil {
+			return fmt.Errorf("failed to find plugin: %w", err)
+		}
 
-			log.V(1).Info("Disk not present: Update status", "disk", vol.Name)
-			status.State = api.VolumeStatePrepared
-			updatedVolumeStatus = append(updatedVolumeStatus, status)
+		healthy, err := plugin.IsHealthy(ctx, vol.Name, machine.ID)
+		if err != nil {
+			log.V(1).Info("Failed to check volume health", "volume", vol.Name, "error", err)
+		}
+		if !healthy {
+			unhealthy = append(unhealthy, vol.Name)
 		}
 	}
 
-	machine.Status.VolumeStatus = updatedVolumeStatus
-	if _, err := r.machines.Update(ctx, machine); err != nil {
-		return fmt.Errorf("failed to update machine status: %w", err)
+	if len(unhealthy) == 0 {
+		api.SetMachineCondition(&machine.Status, api.MachineCondition{
+			Type:   api.MachineConditionVolumesHealthy,
+			Status: api.ConditionTrue,
+			Reason: "Healthy",
+		})
+		return nil
 	}
 
+	message := fmt.Sprintf("volumes %s are unhealthy", strings.Join(unhealthy, ", "))
+	if condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionVolumesHealthy); !found ||
+		condition.Status != api.ConditionFalse || condition.Message != message {
+		log.V(1).Info("Detected unhealthy volumes", "volumes", unhealthy)
+		r.eventf(machine, corev1.EventTypeWarning, volumeUnhealthyReason, "Unhealthy volume backends: %s", strings.Join(unhealthy, ", "))
+	}
+	api.SetMachineCondition(&machine.Status, api.MachineCondition{
+		Type:    api.MachineConditionVolumesHealthy,
+		Status:  api.ConditionFalse,
+		Reason:  volumeUnhealthyReason,
+		Message: message,
+	})
+	r.queue.AddAfter(machine.ID, volumeHealthRecheckInterval)
 	return nil
 }
 
-// nolint: dupl
-func (r *MachineReconciler) attachDetachNICs(
-	ctx context.Context,
-	log logr.Logger,
-	machine *api.Machine,
-	vm client.VmConfig,
-) error {
-	apiSocket := ptr.Deref(machine.Spec.ApiSocketPath, "")
-	currentDevices := sets.New[string]()
-
-	for _, dev := range ptr.Deref(vm.Devices, []client.DeviceConfig{}) {
-		name := getNicName(ptr.Deref(dev.Id, ""))
-		if name == nil {
+// collectVolumeStats reports the io stats of the attached volumes whose backends count them as metrics.
+// Volumes whose stats are not available are not reported.
+func (r *MachineReconciler) collectVolumeStats(ctx context.Context, log logr.Logger, machine *api.Machine) {
+	stats := make(map[string]volume.Stats)
+	for _, vol := range machine.Spec.Volumes {
+		if vol.DeletedAt != nil {
+			continue
+		}
+		if status := getVolumeStatus(machine.Status.VolumeStatus, vol.Name); status.State != api.VolumeStateAttached {
 			continue
 		}
-		currentDevices.Insert(ptr.Deref(name, ""))
-	}
 
-	var updatedNICStatus []api.NetworkInterfaceStatus
+		plugin, err := r.VolumePluginManager.FindPluginBySpec(vol)
+		if err != nil {
+			log.V(1).Info("Failed to find plugin", "volume", vol.Name, "error", err)
+			continue
+		}
+		statsPlugin, ok := plugin.(volume.StatsPlugin)
+		if !ok {
+			continue
+		}
+
+		volumeStats, err := statsPlugin.Stats(ctx, vol.Name, machine.ID)
+		if err != nil {
+			log.V(1).Info("Failed to get volume stats", "volume", vol.Name, "error", err)
+			continue
+		}
+		if volumeStats != nil {
+			stats[vol.Name] = *volumeStats
+		}
+	}
+	metrics.SetMachineVolumeStats(machine.ID, stats)
+}
+
+// probeVolume reports whether the backend of the volume is reachable, so its disk can be added to the vm.
+// An unreachable volume is reported by an event and the machine is requeued.
+func (r *MachineReconciler) probeVolume(ctx context.Context, log logr.Logger, machine *api.Machine, vol *api.VolumeSpec) (bool, error) {
+	if !r.probeVolumes {
+		return true, nil
+	}
+
+	plugin, err := r.VolumePluginManager.FindPluginBySpec(vol)
+	if err != nil {
+		return false, fmt.Errorf("failed to find plugin: %w", err)
+	}
+
+	healthy, err := plugin.IsHealthy(ctx, vol.Name, machine.ID)
+	if err != nil {
+		log.V(1).Info("Failed to probe volume", "volume", vol.Name, "error", err)
+	}
+	if healthy {
+		return true, nil
+	}
+
+	log.V(1).Info("Volume unreachable, deferring attachment", "volume", vol.Name, "interval", volumeProbeRetryInterval)
+	r.eventf(machine, corev1.EventTypeWarning, volumeUnreachableReason, "Deferred attaching unreachable volume %s", vol.Name)
+	r.queue.AddAfter(machine.ID, volumeProbeRetryInterval)
+	return false, nil
+}
+
+// attachBootDisks ensures the boot disks of the machine are part of the vm before it is powered on, as
+// disks attached after power on are not available to the firmware. It reports whether all boot disks
+// are attached. Added disks are recorded in the vm config.
+func (r *MachineReconciler) attachBootDisks(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	vm *client.VmConfig,
+) (*api.Machine, bool, error) {
+	apiSocket := ptr.Deref(machine.Spec.ApiSocketPath, "")
+	currentDevices := sets.New[string]()
+	for _, disk := range ptr.Deref(vm.Disks, []client.DiskConfig{}) {
+		if disk.Id != nil {
+			currentDevices.Insert(*disk.Id)
+		}
+	}
+
+	var attached bool
+	for _, vol := range machine.Spec.Volumes {
+		if vol.DeletedAt != nil || !api.IsBootVolume(vol) {
+			continue
+		}
+
+		idx := slices.IndexFunc(machine.Status.VolumeStatus, func(s api.VolumeStatus) bool {
+			return s.Name == vol.Name
+		})
+		if idx < 0 {
+			return machine, false, nil
+		}
+		status := &machine.Status.VolumeStatus[idx]
+		if currentDevices.Has(status.Handle) {
+			continue
+		}
+		if status.State != api.VolumeStatePrepared && status.State != api.VolumeStateAttached {
+			return machine, false, nil
+		}
+
+		reachable, err := r.probeVolume(ctx, log, machine, vol)
+		if err != nil {
+			return machine, false, err
+		}
+		if !reachable {
+			return machine, false, blocked("waiting for volume %s to be reachable", vol.Name)
+		}
+
+		if err := r.vmm.AddDisk(ctx, apiSocket, status); err != nil {
+			return machine, false, fmt.Errorf("failed to add boot disk %s: %w", vol.Name, err)
+		}
+		log.V(1).Info("Added boot disk", "disk", vol.Name)
+		r.eventf(machine, corev1.EventTypeNormal, attachedDiskReason, "Attached boot disk %s", vol.Name)
+		vm.Disks = ptr.To(append(ptr.Deref(vm.Disks, nil), client.DiskConfig{Id: ptr.To(status.Handle)}))
+		status.State = api.VolumeStateAttached
+		attached = true
+	}
+
+	if attached {
+		updated, err := r.machines.Update(ctx, machine)
+		if err != nil {
+			return machine, false, fmt.Errorf("failed to update machine status: %w", err)
+		}
+		machine = updated
+	}
+	return machine, true, nil
+}
+
+// nolint: dupl
+func (r *MachineReconciler) attachDetachDisks(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	vm client.VmConfig,
+) error {
+	apiSocket := ptr.Deref(machine.Spec.ApiSocketPath, "")
+	currentDevices := sets.New[string]()
+
+	for _, dev := range ptr.Deref(vm.Disks, []client.DiskConfig{}) {
+		id := dev.Id
+		if id == nil {
+			continue
+		}
+		currentDevices.Insert(ptr.Deref(id, ""))
+	}
+
+	var updatedVolumeStatus []api.VolumeStatus
+	for _, vol := range machine.Spec.Volumes {
+		status := getVolumeStatus(machine.Status.VolumeStatus, vol.Name)
+
+		if vol.DeletedAt == nil {
+			if !currentDevices.Has(status.Handle) {
+				// An attached disk missing in the vm was lost, e.g. by a restart of the vmm, and is added again.
+				if status.State != api.VolumeStatePrepared && status.State != api.VolumeStateAttached {
+					log.V(1).Info("Skip disk attachment: not prepared", "disk", vol.Name)
+					updatedVolumeStatus = append(updatedVolumeStatus, status)
+					continue
+				}
+				reachable, err := r.probeVolume(ctx, log, machine, vol)
+				if err != nil {
+					return err
+				}
+				if !reachable {
+					updatedVolumeStatus = append(updatedVolumeStatus, status)
+					continue
+				}
+				if err := r.vmm.AddDisk(ctx, apiSocket, ptr.To(status)); err != nil {
+					return fmt.Errorf("failed to add disk %s: %w", vol.Name, err)
+				}
+
+				log.V(1).Info("Added disk", "disk", vol.Name)
+				r.eventf(machine, corev1.EventTypeNormal, attachedDiskReason, "Attached disk %s", vol.Name)
+			}
+			status.State = api.VolumeStateAttached
+			updatedVolumeStatus = append(updatedVolumeStatus, status)
+		} else {
+			if currentDevices.Has(status.Handle) {
+				if err := r.vmm.RemoveDevice(ctx, apiSocket, status.Handle); err != nil {
+					return fmt.Errorf("failed to remove disk %s: %w", vol.Name, err)
+				}
+				log.V(1).Info("Removed disk", "disk", vol.Name)
+				r.eventf(machine, corev1.EventTypeNormal, detachedDiskReason, "Detached disk %s", vol.Name)
+
+				updatedVolumeStatus = append(updatedVolumeStatus, status)
+				continue
+			}
+
+			log.V(1).Info("Disk not present: Update status", "disk", vol.Name)
+			status.State = api.VolumeStatePrepared
+			updatedVolumeStatus = append(updatedVolumeStatus, status)
+		}
+	}
+
+	machine.Status.VolumeStatus = updatedVolumeStatus
+	if _, err := r.machines.Update(ctx, machine); err != nil {
+		return fmt.Errorf("failed to update machine status: %w", err)
+	}
+
+	return nil
+}
+
+// attachDetachNICs hot-plugs the NICs of the machine into the vm. While the vm is paused, e.g. during a
+// snapshot, NIC changes are deferred and the machine is requeued until the vm is resumed.
+// nolint: dupl
+func (r *MachineReconciler) attachDetachNICs(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	vm client.VmConfig,
+	state client.VmInfoState,
+) error {
+	apiSocket := ptr.Deref(machine.Spec.ApiSocketPath, "")
+	currentDevices := sets.New[string]()
+
+	for _, dev := range ptr.Deref(vm.Devices, []client.DeviceConfig{}) {
+		name := getNicName(ptr.Deref(dev.Id, ""))
+		if name == nil {
+			continue
+		}
+		currentDevices.Insert(ptr.Deref(name, ""))
+	}
+
+	deferred := false
+	var updatedNICStatus []api.NetworkInterfaceStatus
 	for _, nic := range machine.Spec.NetworkInterfaces {
 		status := getNICStatus(machine.Status.NetworkInterfaceStatus, nic.Name)
 
 		if nic.DeletedAt == nil {
 			if !currentDevices.Has(status.Name) {
-				if status.State != api.NetworkInterfaceStatePrepared {
+				// An attached NIC missing in the vm was lost, e.g. by a restart of the vmm, and is added again.
+				if status.State != api.NetworkInterfaceStatePrepared && status.State != api.NetworkInterfaceStateAttached {
 					log.V(1).Info("Skip NIC attachment: not prepared", "nic", nic.Name)
+					updatedNICStatus = append(updatedNICStatus, status)
+					continue
+				}
+				if state == client.Paused {
+					log.V(1).Info("Defer NIC attachment: vm is paused", "nic", nic.Name)
+					updatedNICStatus = append(updatedNICStatus, status)
+					deferred = true
 					continue
 				}
 
 				if err := r.vmm.AddNIC(ctx, apiSocket, ptr.To(status)); err != nil {
-					return fmt.Errorf("failed to add disk %s: %w", nic.Name, err)
+					return fmt.Errorf("failed to add NIC %s: %w", nic.Name, err)
 				}
 
 				log.V(1).Info("Added NIC", "nic", nic.Name)
+				r.eventf(machine, corev1.EventTypeNormal, attachedNICReason, "Attached network interface %s", nic.Name)
 			}
 			status.State = api.NetworkInterfaceStateAttached
 			updatedNICStatus = append(updatedNICStatus, status)
 		} else {
 			if currentDevices.Has(status.Name) {
+				if state == client.Paused {
+					log.V(1).Info("Defer NIC detachment: vm is paused", "nic", nic.Name)
+					updatedNICStatus = append(updatedNICStatus, status)
+					deferred = true
+					continue
+				}
+
 				if err := r.vmm.RemoveNIC(ctx, apiSocket, nic.Name); err != nil {
 					return fmt.Errorf("failed to remove NIC %s: %w", status.Name, err)
 				}
 				log.V(1).Info("Removed NIC", "nic", status.Name)
+				r.eventf(machine, corev1.EventTypeNormal, detachedNICReason, "Detached network interface %s", nic.Name)
 
 				updatedNICStatus = append(updatedNICStatus, status)
 				r.queue.Add(machine.ID)
@@ -505,11 +1275,208 @@ func (r *MachineReconciler) attachDetachNICs(
 		return fmt.Errorf("failed to update machine status: %w", err)
 	}
 
+	if deferred {
+		r.queue.AddAfter(machine.ID, pausedVMRequeueInterval)
+	}
+
+	return nil
+}
+
+// trackBoot records when the vm was first asked to power on and flags the machine once it did not reach
+// running within its boot timeout.
+func (r *MachineReconciler) trackBoot(ctx context.Context, log logr.Logger, machine *api.Machine) (*api.Machine, error) {
+	bootTimeout := r.bootTimeout
+	if machine.Spec.BootTimeout > 0 {
+		bootTimeout = machine.Spec.BootTimeout
+	}
+
+	if machine.Status.BootStartedAt.IsZero() {
+		machine.Status.BootStartedAt = time.Now()
+		updated, err := r.machines.Update(ctx, machine)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update machine boot start: %w", err)
+		}
+		r.queue.AddAfter(machine.ID, bootTimeout)
+		return updated, nil
+	}
+
+	if elapsed := time.Since(machine.Status.BootStartedAt); elapsed < bootTimeout {
+		r.queue.AddAfter(machine.ID, bootTimeout-elapsed)
+		return machine, nil
+	}
+
+	condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionBooted)
+	timedOut := found && condition.Status == api.ConditionFalse && condition.Reason == bootTimeoutReason
+	if timedOut && !r.powerOffOnBootTimeout {
+		return machine, nil
+	}
+
+	if !timedOut {
+		log.V(1).Info("VM did not reach running in time", "machine", machine.ID, "bootTimeout", bootTimeout)
+		r.eventf(machine, corev1.EventTypeWarning, bootTimeoutReason, "VM did not reach running within %s", bootTimeout)
+		api.SetMachineCondition(&machine.Status, api.MachineCondition{
+			Type:    api.MachineConditionBooted,
+			Status:  api.ConditionFalse,
+			Reason:  bootTimeoutReason,
+			Message: fmt.Sprintf("vm did not reach running within %s", bootTimeout),
+		})
+	}
+	if r.powerOffOnBootTimeout {
+		machine.Spec.Power = api.PowerStatePowerOff
+		machine.Status.BootStartedAt = time.Time{}
+	}
+
+	updated, err := r.machines.Update(ctx, machine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update machine boot condition: %w", err)
+	}
+	return updated, nil
+}
+
+// logVMConfigDiff logs the fields of the config of the vm that differ from the machine, explaining why the
+// reconciliation keeps acting on the vm.
+func (r *MachineReconciler) logVMConfigDiff(log logr.Logger, machine *api.Machine, vm *client.VmInfo) {
+	diffs, err := r.vmm.DiffVMConfig(machine, vm.Config)
+	if err != nil {
+		log.Info("Failed to diff vm config", "machine", machine.ID, "error", err.Error())
+		return
+	}
+	if len(diffs) == 0 {
+		return
+	}
+	fields := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		fields = append(fields, diff.String())
+	}
+	log.Info("VM config differs from machine", "machine", machine.ID, "diff", fields)
+}
+
+func markBooted(machine *api.Machine) {
+	machine.Status.BootStartedAt = time.Time{}
+	api.SetMachineCondition(&machine.Status, api.MachineCondition{
+		Type:   api.MachineConditionBooted,
+		Status: api.ConditionTrue,
+		Reason: "Running",
+	})
+}
+
+func isBooted(machine *api.Machine) bool {
+	condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionBooted)
+	return found && condition.Status == api.ConditionTrue
+}
+
+// handleGuestShutdown applies the guest shutdown policy to a booted machine whose vm was shut down
+// without the provider powering it off.
+func (r *MachineReconciler) handleGuestShutdown(ctx context.Context, log logr.Logger, machine *api.Machine) (*api.Machine, error) {
+	log.V(1).Info("VM was shut down by the guest", "machine", machine.ID, "policy", r.guestShutdownPolicy)
+	r.eventf(machine, corev1.EventTypeNormal, guestShutdownReason, "VM was shut down by the guest, applying policy %s", r.guestShutdownPolicy)
+
+	api.SetMachineCondition(&machine.Status, api.MachineCondition{
+		Type:    api.MachineConditionBooted,
+		Status:  api.ConditionFalse,
+		Reason:  guestShutdownReason,
+		Message: "vm was shut down by the guest",
+	})
+	if r.guestShutdownPolicy == GuestShutdownPolicyStop {
+		machine.Spec.Power = api.PowerStatePowerOff
+	}
+
+	updated, err := r.machines.Update(ctx, machine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update machine after guest shutdown: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *MachineReconciler) assignPciDevices(machine *api.Machine) error {
+	if len(machine.Spec.PciDevices) == 0 {
+		return nil
+	}
+
+	if r.pciDevices == nil {
+		return fmt.Errorf("pci passthrough is not configured")
+	}
+
+	return r.pciDevices.Assign(machine.ID, machine.Spec.PciDevices)
+}
+
+// resizeVM grows or shrinks the running vm to the cpus and memory of the machine.
+func (r *MachineReconciler) resizeVM(ctx context.Context, log logr.Logger, machine *api.Machine, vm *client.VmInfo) error {
+	memoryBytes, err := vmm.AlignMemory(machine.Spec.MemoryBytes)
+	if err != nil {
+		return err
+	}
+	cpus, currentMemoryBytes := vmm.Size(vm)
+	if int64(cpus) == machine.Spec.Cpu && currentMemoryBytes == memoryBytes {
+		return nil
+	}
+
+	log.V(1).Info("Resizing vm", "machine", machine.ID,
+		"cpus", machine.Spec.Cpu, "memoryBytes", memoryBytes, "currentCpus", cpus, "currentMemoryBytes", currentMemoryBytes)
+	err = r.vmm.Resize(ctx, ptr.Deref(machine.Spec.ApiSocketPath, ""), int(machine.Spec.Cpu), memoryBytes)
+	if auditErr := r.auditLog.Record("resize", machine.ID, auditActor, err); auditErr != nil {
+		log.Error(auditErr, "Failed to audit resize", "machine", machine.ID)
+	}
+	if err != nil {
+		if errors.Is(err, vmm.ErrResizeUnsupported) || errors.Is(err, vmm.ErrInsufficientCapacity) {
+			r.eventf(machine, corev1.EventTypeWarning, "ResizeFailed", "Failed to resize vm: %s", err)
+		}
+		return fmt.Errorf("failed to resize vm: %w", err)
+	}
+	r.eventf(machine, corev1.EventTypeNormal, "Resized", "Resized vm to %d cpus and %d bytes of memory", machine.Spec.Cpu, memoryBytes)
 	return nil
 }
 
-// nolint: gocyclo
+// applyQoSClass places the vmm process serving the machine into the cgroup of its api socket, configured
+// for the qos class of the machine.
+func (r *MachineReconciler) applyQoSClass(ctx context.Context, log logr.Logger, machine *api.Machine, apiSocket string) error {
+	if r.cgroups == nil {
+		return nil
+	}
+
+	resources, err := cgroup.ResourcesFor(
+		cmp.Or(machine.Spec.QoSClass, api.QoSClassBurstable),
+		machine.Spec.Cpu,
+		machine.Spec.MemoryBytes,
+	)
+	if err != nil {
+		return err
+	}
+
+	pid, err := r.vmm.Pid(ctx, apiSocket)
+	if err != nil {
+		return fmt.Errorf("failed to get vmm pid: %w", err)
+	}
+
+	name := strings.ReplaceAll(strings.Trim(apiSocket, "/"), "/", "-")
+	log.V(2).Info("Applying qos class", "cgroup", name, "pid", pid, "cpuWeight", resources.CPUWeight)
+	return r.cgroups.Apply(name, pid, resources)
+}
+
+func (r *MachineReconciler) buildConfigDrive(log logr.Logger, machine *api.Machine) error {
+	if machine.Spec.ConfigDrive == nil {
+		return nil
+	}
+
+	log.V(2).Info("Building config drive")
+	return configdrive.Build(r.paths.MachineConfigDriveFile(machine.ID), machine.ID, machine.Spec.ConfigDrive)
+}
+
+// reconcileMachine syncs the machine and reports the outcome in its status message.
 func (r *MachineReconciler) reconcileMachine(ctx context.Context, id string) error {
+	err := r.syncMachine(ctx, id)
+	if msgErr := r.updateStatusMessage(ctx, id, err); msgErr != nil {
+		logr.FromContextOrDiscard(ctx).Error(msgErr, "failed to update status message")
+	}
+
+	var blockedErr *blockedError
+	if errors.As(err, &blockedErr) {
+		return nil
+	}
+	return err
+}
+
+func (r *MachineReconciler) syncMachine(ctx context.Context, id string) error {
 	log := logr.FromContextOrDiscard(ctx)
 
 	log.V(1).Info("Reconciling machine", "id", id)
@@ -523,6 +1490,9 @@ func (r *MachineReconciler) reconcileMachine(ctx context.Context, id string) err
 		return nil
 	}
 
+	log = log.WithValues("machine", api.MachineDisplayName(machine))
+	ctx = logr.NewContext(ctx, log)
+
 	if machine.DeletedAt != nil {
 		if err := r.deleteMachine(ctx, log, machine); err != nil {
 			return fmt.Errorf("failed to delete machine: %w", err)
@@ -545,31 +1515,14 @@ func (r *MachineReconciler) reconcileMachine(ctx context.Context, id string) err
 	}
 	log.V(2).Info("Successfully made machine directories")
 
-	if bootImage := api.HasBootImage(machine); bootImage != nil {
-		log.V(1).Info("Boot image referenced", "image", bootImage)
-
-		_, err := r.imageCache.Get(ctx, *bootImage)
-		if err != nil {
-			if errors.Is(err, ociutils.ErrImagePulling) {
-				log.V(1).Info("Image is pulling, reconcile later")
-				r.eventRecorder.Eventf(machine.Metadata, corev1.EventTypeNormal, "PullingImage", "Pulling image in progress")
-				return nil
-			}
-			return err
-		}
-		log.V(2).Info("Image is present")
+	machine, err = r.ensureBootImage(ctx, log, machine)
+	if err != nil {
+		return err
 	}
 
-	if machine.Spec.ApiSocketPath == nil {
-		sock, err := r.vmm.GetFreeApiSocket()
-		if err != nil {
-			return fmt.Errorf("failed to get free api socket: %w", err)
-		}
-		machine.Spec.ApiSocketPath = sock
-		machine, err = r.machines.Update(ctx, machine)
-		if err != nil {
-			return fmt.Errorf("failed to update machine status: %w", err)
-		}
+	machine, assigned, err := r.assignApiSocket(ctx, machine)
+	if err != nil {
+		return err
 	}
 
 	apiSocket := ptr.Deref(machine.Spec.ApiSocketPath, "")
@@ -578,7 +1531,21 @@ func (r *MachineReconciler) reconcileMachine(ctx context.Context, id string) err
 		return fmt.Errorf("failed to ping vmm: %w", err)
 	}
 
+	if assigned {
+		if err := r.reapOrphanVM(ctx, log, machine, apiSocket); err != nil {
+			return err
+		}
+	}
+
 	if err := r.reconcileVolumes(ctx, log, machine); err != nil {
+		if errors.Is(err, errDiskPressure) {
+			log.V(1).Info("Disk pressure, retrying later", "interval", diskPressureRetryInterval)
+			return blocked("no space left to prepare the volumes")
+		}
+		if errors.Is(err, volume.ErrPreparing) {
+			// The machine is requeued once the volume is prepared.
+			return err
+		}
 		return fmt.Errorf("failed to reconcile volumes: %w", err)
 	}
 
@@ -592,48 +1559,36 @@ func (r *MachineReconciler) reconcileMachine(ctx context.Context, id string) err
 			return fmt.Errorf("failed to get vm: %w", err)
 		}
 
-		log.V(1).Info("VM not created", "machine", machine.ID)
-
-		if err := r.vmm.CreateVM(ctx, machine); err != nil {
-			log.V(1).Info("Failed to create VM", "machine", machine.ID)
-			return fmt.Errorf("failed to create VM: %w", err)
-		}
-
-		log.V(1).Info("Successfully created VM, requeue", "machine", machine.ID)
-		r.queue.Add(machine.ID)
-		return nil
+		return r.createVM(ctx, log, machine, apiSocket)
 	}
 
 	if platform := ptr.Deref(vm.Config.Platform, client.PlatformConfig{}); ptr.Deref(platform.Uuid, "") != machine.ID {
 		return fmt.Errorf("machine and vm IDs do not match")
 	}
 
-	switch machine.Spec.Power {
-	case api.PowerStatePowerOn:
-		if vm.State != client.Running {
-			if err := r.vmm.PowerOn(ctx, apiSocket); err != nil {
-				return fmt.Errorf("failed to power on VM: %w", err)
-			}
-		}
-	case api.PowerStatePowerOff:
-		if vm.State == client.Running {
-			if err := r.vmm.PowerOff(ctx, apiSocket); err != nil {
-				return fmt.Errorf("failed to power off VM: %w", err)
-			}
-		}
+	if debugLog := log.V(2); debugLog.Enabled() {
+		r.logVMConfigDiff(debugLog, machine, vm)
 	}
 
-	if err := r.attachDetachDisks(ctx, log, machine, vm.Config); err != nil {
-		return fmt.Errorf("failed to attach detach disks: %w", err)
+	machine, done, err := r.reconcilePower(ctx, log, machine, vm, apiSocket)
+	if err != nil || done {
+		return err
 	}
 
-	if err := r.attachDetachNICs(ctx, log, machine, vm.Config); err != nil {
-		return fmt.Errorf("failed to attach detach disks: %w", err)
+	if err := r.reconcileAttachments(ctx, log, machine, vm); err != nil {
+		return err
+	}
+
+	machine, err = r.reconcileSnapshot(ctx, log, machine)
+	if err != nil {
+		return err
 	}
 
 	switch machine.Spec.Power {
 	case api.PowerStatePowerOn:
 		machine.Status.State = api.MachineStateRunning
+	case api.PowerStatePaused:
+		machine.Status.State = api.MachineStateSuspended
 	case api.PowerStatePowerOff:
 		machine.Status.State = api.MachineStateTerminated
 	}
@@ -646,3 +1601,334 @@ func (r *MachineReconciler) reconcileMachine(ctx context.Context, id string) err
 	log.V(1).Info("Reconciled machine successfully ", "machine", machine.ID)
 	return nil
 }
+
+// reconcileAttachments hot-plugs the disks and nics of the machine into its created vm and maintains the
+// attached volumes.
+func (r *MachineReconciler) reconcileAttachments(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	vm *client.VmInfo,
+) error {
+	if err := r.attachDetachDisks(ctx, log, machine, vm.Config); err != nil {
+		return fmt.Errorf("failed to attach detach disks: %w", err)
+	}
+
+	if err := r.checkVolumesHealth(ctx, log, machine); err != nil {
+		return fmt.Errorf("failed to check volumes health: %w", err)
+	}
+	r.collectVolumeStats(ctx, log, machine)
+
+	if machine.Spec.Power == api.PowerStatePowerOn && vm.State == client.Running {
+		r.flushDueVolumes(ctx, log, machine)
+	} else {
+		r.forgetVolumeFlushes(machine.ID)
+	}
+
+	if err := r.attachDetachNICs(ctx, log, machine, vm.Config, vm.State); err != nil {
+		return fmt.Errorf("failed to attach detach NICs: %w", err)
+	}
+	return nil
+}
+
+// reconcileSnapshot takes the snapshot of the volumes requested for the machine, unless it was taken already.
+func (r *MachineReconciler) reconcileSnapshot(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+) (*api.Machine, error) {
+	name := machine.Spec.Snapshot
+	if name == "" || name == machine.Status.Snapshot {
+		return machine, nil
+	}
+
+	log.V(1).Info("Snapshotting volumes", "snapshot", name)
+	if err := r.snapshotter.SnapshotMachine(ctx, machine, name); err != nil {
+		r.eventf(machine, corev1.EventTypeWarning, "SnapshotFailed", "Failed to snapshot volumes as %s: %s", name, err)
+		return nil, fmt.Errorf("failed to snapshot volumes: %w", err)
+	}
+
+	// The snapshot is recorded right away, taking it again fails.
+	machine.Status.Snapshot = name
+	machine, err := r.machines.Update(ctx, machine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update machine status: %w", err)
+	}
+	r.eventf(machine, corev1.EventTypeNormal, "Snapshotted", "Snapshotted volumes as %s", name)
+	return machine, nil
+}
+
+// ensureBootImage waits for the boot image of the machine to be pulled and validates it.
+func (r *MachineReconciler) ensureBootImage(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+) (*api.Machine, error) {
+	bootImage := api.HasBootImage(machine)
+	if bootImage == nil {
+		return machine, nil
+	}
+	log.V(1).Info("Boot image referenced", "image", bootImage)
+
+	img, err := r.imageCache.Get(ctx, *bootImage)
+	if err != nil {
+		if errors.Is(err, ociutils.ErrImagePulling) {
+			log.V(1).Info("Image is pulling, reconcile later")
+			r.eventf(machine, corev1.EventTypeNormal, "PullingImage", "Pulling image in progress")
+			return nil, blocked("waiting for image %s to be pulled", *bootImage)
+		}
+		if errors.Is(err, imageutils.ErrImageNotFound) {
+			return nil, r.reportImageMissing(ctx, log, machine, *bootImage, err)
+		}
+		if errors.Is(err, imageutils.ErrImagePullTimeout) {
+			r.eventf(machine, corev1.EventTypeWarning, "ImagePullTimeout", "Image %s: %s", *bootImage, err)
+		}
+		return nil, err
+	}
+	log.V(2).Info("Image is present")
+
+	if condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionImageMissing); found &&
+		condition.Status == api.ConditionTrue {
+		api.SetMachineCondition(&machine.Status, api.MachineCondition{
+			Type:   api.MachineConditionImageMissing,
+			Status: api.ConditionFalse,
+			Reason: "ImagePresent",
+		})
+		if machine, err = r.machines.Update(ctx, machine); err != nil {
+			return nil, fmt.Errorf("failed to update machine status: %w", err)
+		}
+	}
+
+	if r.validateImageArch {
+		if err := imageutils.ValidateArchitecture(img, r.architecture); err != nil {
+			if !errors.Is(err, imageutils.ErrArchitectureMismatch) {
+				log.V(1).Info("Skipping image architecture validation", "reason", err.Error())
+			} else {
+				r.eventf(machine, corev1.EventTypeWarning, "ImageArchMismatch", "Image %s: %s", *bootImage, err)
+				return nil, fmt.Errorf("invalid boot image: %w", err)
+			}
+		}
+	}
+	return machine, nil
+}
+
+// assignApiSocket assigns a free api socket to the machine, unless it has one already. It reports whether the
+// socket was newly assigned.
+func (r *MachineReconciler) assignApiSocket(ctx context.Context, machine *api.Machine) (*api.Machine, bool, error) {
+	if machine.Spec.ApiSocketPath != nil {
+		return machine, false, nil
+	}
+
+	sock, err := r.vmm.GetFreeApiSocket()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get free api socket: %w", err)
+	}
+	if owner, ok := r.machineIndex.MachineBySocket(*sock); ok && owner != machine.ID {
+		// The socket stays out of the free sockets, it is in use.
+		return nil, false, fmt.Errorf("api socket %s is already used by machine %s", *sock, owner)
+	}
+	machine.Spec.ApiSocketPath = sock
+	machine, err = r.machines.Update(ctx, machine)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to update machine status: %w", err)
+	}
+	r.machineIndex.Set(machine)
+	return machine, true, nil
+}
+
+// reapOrphanVM removes a vm of another machine left on a recycled api socket. It only has to run when the socket
+// was newly assigned, afterwards the vm on the socket belongs to the machine.
+func (r *MachineReconciler) reapOrphanVM(ctx context.Context, log logr.Logger, machine *api.Machine, apiSocket string) error {
+	reaped, err := r.vmm.ReapOrphanVM(ctx, apiSocket, machine.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reap orphan vm: %w", err)
+	}
+	if reaped {
+		log.V(1).Info("Reaped orphan vm on api socket", "socket", apiSocket)
+		r.eventf(machine, corev1.EventTypeWarning, "ReapedOrphanVM", "Removed orphan vm from api socket %s", apiSocket)
+	}
+	return nil
+}
+
+// createVM creates the vm of the machine and requeues the machine to power it on.
+func (r *MachineReconciler) createVM(ctx context.Context, log logr.Logger, machine *api.Machine, apiSocket string) error {
+	log.V(1).Info("VM not created", "machine", machine.ID)
+
+	if err := r.assignPciDevices(machine); err != nil {
+		r.eventf(machine, corev1.EventTypeWarning, "PciDevicesUnavailable", "Failed to assign pci devices: %s", err)
+		return fmt.Errorf("failed to assign pci devices: %w", err)
+	}
+
+	if err := r.applyQoSClass(ctx, log, machine, apiSocket); err != nil {
+		return fmt.Errorf("failed to apply qos class: %w", err)
+	}
+
+	if err := r.buildConfigDrive(log, machine); err != nil {
+		r.eventf(machine, corev1.EventTypeWarning, "ConfigDriveFailed", "Failed to build config drive: %s", err)
+		return fmt.Errorf("failed to build config drive: %w", err)
+	}
+
+	if err := r.vmm.CreateVM(ctx, machine); err != nil {
+		log.V(1).Info("Failed to create VM", "machine", machine.ID)
+		switch {
+		case errors.Is(err, vmm.ErrInsufficientCapacity):
+			r.eventf(machine, corev1.EventTypeWarning, "InsufficientCapacity", "Failed to create vm: %s", err)
+		case errors.Is(err, vmm.ErrNoBootSource):
+			r.eventf(machine, corev1.EventTypeWarning, "NoBootSource", "Failed to create vm: %s", err)
+		case errors.Is(err, vmm.ErrInvalidMemory):
+			r.eventf(machine, corev1.EventTypeWarning, "InvalidMemory", "Failed to create vm: %s", err)
+		default:
+			r.eventf(machine, corev1.EventTypeWarning, createVMFailedReason, "Failed to create vm: %s", err)
+		}
+		return fmt.Errorf("failed to create VM: %w", err)
+	}
+
+	log.V(1).Info("Successfully created VM, requeue", "machine", machine.ID)
+	r.eventf(machine, corev1.EventTypeNormal, createdVMReason, "Created vm")
+	r.queue.Add(machine.ID)
+	return nil
+}
+
+// reconcilePower drives the vm to the power state of the machine. It returns whether the reconciliation of the
+// machine is done for now, as the machine was requeued to continue with the changed vm.
+func (r *MachineReconciler) reconcilePower(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	vm *client.VmInfo,
+	apiSocket string,
+) (*api.Machine, bool, error) {
+	switch machine.Spec.Power {
+	case api.PowerStatePowerOn, api.PowerStatePaused:
+		switch vm.State {
+		case client.Running:
+			markBooted(machine)
+			if machine.Spec.Power == api.PowerStatePaused {
+				if err := r.vmm.Pause(ctx, apiSocket); err != nil {
+					return nil, false, fmt.Errorf("failed to pause VM: %w", err)
+				}
+				log.V(1).Info("Paused VM", "machine", machine.ID)
+				break
+			}
+			if err := r.resizeVM(ctx, log, machine, vm); err != nil {
+				return nil, false, err
+			}
+		case client.Paused:
+			// Only resume vms paused for the power state, others are paused by an operation like a snapshot.
+			if machine.Spec.Power == api.PowerStatePaused || machine.Status.State != api.MachineStateSuspended {
+				log.V(1).Info("VM is paused, leaving it to the pausing operation", "machine", machine.ID)
+				break
+			}
+			if err := r.vmm.Resume(ctx, apiSocket); err != nil {
+				return nil, false, fmt.Errorf("failed to resume VM: %w", err)
+			}
+			log.V(1).Info("Resumed VM", "machine", machine.ID)
+		case client.Created, client.Shutdown:
+			return r.powerOnVM(ctx, log, machine, vm, apiSocket)
+		default:
+			return nil, false, fmt.Errorf("unknown vm state %q", vm.State)
+		}
+	case api.PowerStatePowerOff:
+		if err := r.powerOffVM(ctx, log, machine, vm, apiSocket); err != nil {
+			return nil, false, err
+		}
+	}
+	return machine, false, nil
+}
+
+// powerOnVM boots the created or shut down vm once its boot disks are prepared and the power on rate limit
+// allows it. See reconcilePower for its results.
+func (r *MachineReconciler) powerOnVM(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	vm *client.VmInfo,
+	apiSocket string,
+) (*api.Machine, bool, error) {
+	var err error
+	if vm.State == client.Shutdown && isBooted(machine) {
+		machine, err = r.handleGuestShutdown(ctx, log, machine)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to handle guest shutdown: %w", err)
+		}
+		if machine.Spec.Power == api.PowerStatePowerOff {
+			log.V(1).Info("Keeping machine powered off after guest shutdown, requeue", "machine", machine.ID)
+			r.queue.Add(machine.ID)
+			return machine, true, nil
+		}
+	}
+
+	var ready bool
+	machine, ready, err = r.attachBootDisks(ctx, log, machine, &vm.Config)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to attach boot disks: %w", err)
+	}
+	if !ready {
+		log.V(1).Info("Boot disks not prepared yet, deferring power on", "machine", machine.ID)
+		r.queue.AddAfter(machine.ID, bootDiskRequeueInterval)
+		return nil, false, blocked("waiting for the boot disks to be prepared")
+	}
+
+	if delay := r.reservePowerOn(machine.ID); delay > 0 {
+		log.V(1).Info("Power on rate limit reached, deferring power on", "machine", machine.ID, "delay", delay)
+		r.queue.AddAfter(machine.ID, delay)
+		return nil, false, blocked("waiting for the power on rate limit")
+	}
+
+	log.V(1).Info("VM is configured but not running, powering on", "machine", machine.ID, "state", vm.State)
+	machine, err = r.trackBoot(ctx, log, machine)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to track boot: %w", err)
+	}
+	if machine.Spec.Power == api.PowerStatePowerOff {
+		log.V(1).Info("Powering off machine after boot timeout, requeue", "machine", machine.ID)
+		r.queue.Add(machine.ID)
+		return machine, true, nil
+	}
+
+	if err := r.vmm.PowerOn(ctx, apiSocket); err != nil {
+		r.eventf(machine, corev1.EventTypeWarning, powerOnFailedReason, "Failed to power on vm: %s", err)
+		return nil, false, fmt.Errorf("failed to power on VM: %w", err)
+	}
+	r.eventf(machine, corev1.EventTypeNormal, poweredOnReason, "Powered on vm")
+	if machine.Spec.Power == api.PowerStatePaused {
+		log.V(1).Info("Pausing VM once it is running, requeue", "machine", machine.ID)
+		r.queue.Add(machine.ID)
+	}
+	return machine, false, nil
+}
+
+// powerOffVM shuts the running or paused vm down, flushing its volumes first.
+func (r *MachineReconciler) powerOffVM(
+	ctx context.Context,
+	log logr.Logger,
+	machine *api.Machine,
+	vm *client.VmInfo,
+	apiSocket string,
+) error {
+	r.cancelPowerOn(machine.ID)
+	machine.Status.BootStartedAt = time.Time{}
+	if isBooted(machine) {
+		api.SetMachineCondition(&machine.Status, api.MachineCondition{
+			Type:   api.MachineConditionBooted,
+			Status: api.ConditionFalse,
+			Reason: poweredOffReason,
+		})
+	}
+	// A paused vm still holds its memory, shut it down as well so the resources of the host are released.
+	if vm.State == client.Running || vm.State == client.Paused {
+		if err := r.flushVolumes(ctx, log, machine); err != nil {
+			return err
+		}
+		gracePeriod := r.shutdownGracePeriod
+		if vm.State == client.Paused {
+			// A paused guest cannot react to the power button.
+			gracePeriod = 0
+		}
+		if err := r.vmm.Shutdown(ctx, apiSocket, gracePeriod); err != nil {
+			return fmt.Errorf("failed to power off VM: %w", err)
+		}
+		r.eventf(machine, corev1.EventTypeNormal, poweredOffReason, "Powered off vm")
+	}
+	return nil
+}
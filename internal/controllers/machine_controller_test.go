@@ -4,14 +4,23 @@
 package controllers_test
 
 import (
+	"context"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/controllers"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/metrics"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
+	"github.com/ironcore-dev/controller-utils/metautils"
 	apiutils "github.com/ironcore-dev/provider-utils/apiutils/api"
+	"github.com/ironcore-dev/provider-utils/eventutils/event"
+	"github.com/ironcore-dev/provider-utils/eventutils/recorder"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"k8s.io/utils/ptr"
@@ -110,4 +119,1894 @@ var _ = Describe("MachineController", func() {
 			}).Should(ContainSubstring("VM is not created"))
 		})
 	})
+
+	Context("Created VM", func() {
+		It("should power on a configured but not booted vm instead of recreating it", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a powered off machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOff,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("waiting for the vm to be created but not booted")
+			var apiSocket string
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateTerminated))
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+			}).Should(Succeed())
+
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+
+			resp, err := chClient.GetVmInfoWithResponse(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.JSON200).NotTo(BeNil())
+			Expect(resp.JSON200.State).To(Equal(client.Created))
+
+			By("powering on the machine")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				machine.Spec.Power = api.PowerStatePowerOn
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the existing vm is booted on the same socket")
+			Eventually(func(g Gomega) client.VmInfoState {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				g.Expect(resp.JSON200.Config.Platform.Uuid).To(Equal(ptr.To(machineID)))
+				return resp.JSON200.State
+			}).Should(Equal(client.Running))
+
+			machine, err := machineStore.Get(ctx, machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(machine.Spec.ApiSocketPath).To(Equal(ptr.To(apiSocket)))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("VMM Restart", func() {
+		It("should re-add attached disks missing in the vm", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine with an empty disk")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOff,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:      "data",
+							Device:    "oda",
+							LocalDisk: &api.LocalDiskSpec{Size: 1024 * 1024},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("waiting for the disk to be attached")
+			var handle, apiSocket string
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("State", api.VolumeStateAttached)))
+				handle = machine.Status.VolumeStatus[0].Handle
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+			}).Should(Succeed())
+
+			By("replacing the vm by one without disks as after a vmm restart")
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+
+			infoResp, err := chClient.GetVmInfoWithResponse(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infoResp.JSON200).NotTo(BeNil())
+			config := infoResp.JSON200.Config
+			config.Disks = nil
+
+			Expect(chClient.DeleteVMWithResponse(ctx)).Error().NotTo(HaveOccurred())
+			Expect(chClient.CreateVMWithResponse(ctx, config)).Error().NotTo(HaveOccurred())
+
+			By("triggering a reconciliation")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				metautils.SetAnnotation(machine, "test/vmm-restarted", "true")
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the disk is added to the vm again")
+			Eventually(func(g Gomega) []client.DiskConfig {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return ptr.Deref(resp.JSON200.Config.Disks, nil)
+			}).Should(ContainElement(HaveField("Id", ptr.To(handle))))
+
+			machine, err := machineStore.Get(ctx, machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("State", api.VolumeStateAttached)))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+
+		It("should re-add attached nics missing in the vm", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine with a nic")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOff,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					NetworkInterfaces: []*api.NetworkInterfaceSpec{
+						{Name: preparedNicName},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("waiting for the nic to be attached")
+			var apiSocket string
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.NetworkInterfaceStatus).To(ConsistOf(HaveField("State", api.NetworkInterfaceStateAttached)))
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+			}).Should(Succeed())
+
+			By("replacing the vm by one without devices as after a vmm restart")
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+
+			infoResp, err := chClient.GetVmInfoWithResponse(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(infoResp.JSON200).NotTo(BeNil())
+			Expect(ptr.Deref(infoResp.JSON200.Config.Devices, nil)).NotTo(BeEmpty())
+			config := infoResp.JSON200.Config
+			config.Devices = nil
+
+			Expect(chClient.DeleteVMWithResponse(ctx)).Error().NotTo(HaveOccurred())
+			Expect(chClient.CreateVMWithResponse(ctx, config)).Error().NotTo(HaveOccurred())
+
+			By("triggering a reconciliation")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				metautils.SetAnnotation(machine, "test/vmm-restarted", "true")
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the nic is added to the vm again")
+			Eventually(func(g Gomega) []client.DeviceConfig {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return ptr.Deref(resp.JSON200.Config.Devices, nil)
+			}).Should(ConsistOf(HaveField("Id", ptr.To("NIC//"+preparedNicName))))
+
+			machine, err := machineStore.Get(ctx, machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(machine.Status.NetworkInterfaceStatus).To(ConsistOf(SatisfyAll(
+				HaveField("Name", preparedNicName),
+				HaveField("State", api.NetworkInterfaceStateAttached),
+			)))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Volume Flush", func() {
+		It("should flush the volumes before powering the vm off", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine with a cached disk")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "data",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: cachedDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			waitForState := func(state api.MachineState) {
+				Eventually(func(g Gomega) {
+					machine, err := machineStore.Get(ctx, machineID)
+					g.Expect(err).NotTo(HaveOccurred())
+					g.Expect(machine.Status.State).To(Equal(state))
+				}).Should(Succeed())
+			}
+			setPower := func(power api.PowerState) {
+				Eventually(func() error {
+					machine, err := machineStore.Get(ctx, machineID)
+					if err != nil {
+						return err
+					}
+					machine.Spec.Power = power
+					_, err = machineStore.Update(ctx, machine)
+					return err
+				}).Should(Succeed())
+			}
+
+			waitForState(api.MachineStateRunning)
+			Expect(cachedDisks.Flushes(machineID)).To(BeEmpty())
+
+			By("powering the machine off")
+			setPower(api.PowerStatePowerOff)
+			waitForState(api.MachineStateTerminated)
+			Expect(cachedDisks.Flushes(machineID)).To(Equal([]client.VmInfoState{client.Running}))
+
+			By("powering the machine on again")
+			setPower(api.PowerStatePowerOn)
+			waitForState(api.MachineStateRunning)
+
+			By("deleting the running machine")
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			Eventually(func() []client.VmInfoState {
+				return cachedDisks.Flushes(machineID)
+			}).Should(Equal([]client.VmInfoState{client.Running, client.Running}))
+		})
+
+		It("should flush the volumes of a paused vm before deleting it", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a paused machine with a cached disk")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePaused,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "data",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: cachedDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateSuspended))
+			}).Should(Succeed())
+			Eventually(func() (client.VmInfoState, error) {
+				return vmState(ctx, machineID)
+			}).Should(Equal(client.Paused))
+
+			By("deleting the paused machine")
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			Eventually(func() []client.VmInfoState {
+				return cachedDisks.Flushes(machineID)
+			}).Should(Equal([]client.VmInfoState{client.Paused}))
+		})
+
+		It("should flush the volumes of the running vm in the flush interval", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine with a cached disk flushed every second")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "data",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: cachedDiskDriver},
+							Tuning:     &api.VolumeTuning{FlushIntervalSeconds: 1},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func(ctx SpecContext) {
+				Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			})
+
+			By("waiting for the running vm to be flushed repeatedly")
+			Eventually(func() int {
+				return len(cachedDisks.Flushes(machineID))
+			}).WithTimeout(10 * time.Second).Should(BeNumerically(">=", 3))
+			Expect(cachedDisks.Flushes(machineID)).To(HaveEach(client.Running))
+
+			flushTimes := cachedDisks.FlushTimes(machineID)
+			for i := 1; i < len(flushTimes); i++ {
+				Expect(flushTimes[i].Sub(flushTimes[i-1])).To(BeNumerically(">=", 900*time.Millisecond))
+			}
+		})
+	})
+
+	Context("Volume Snapshot", func() {
+		It("should snapshot the volumes of the paused vm once per requested snapshot", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine with a cached disk")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "data",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: cachedDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func(ctx SpecContext) {
+				Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			})
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+			}).Should(Succeed())
+
+			By("requesting a snapshot")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				machine.Spec.Snapshot = "snap-1"
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.Snapshot).To(Equal("snap-1"))
+			}).Should(Succeed())
+			Expect(cachedDisks.Snapshots(machineID)).To(Equal([]client.VmInfoState{client.Paused}))
+
+			By("ensuring the vm is resumed and the snapshot is not taken again")
+			Eventually(func() (client.VmInfoState, error) {
+				return vmState(ctx, machineID)
+			}).Should(Equal(client.Running))
+			Consistently(func() []client.VmInfoState {
+				return cachedDisks.Snapshots(machineID)
+			}).Should(HaveLen(1))
+		})
+	})
+
+	Context("VM Resize", func() {
+		It("should resize the running vm to the machine", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a running machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func(ctx SpecContext) {
+				Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			})
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+			}).Should(Succeed())
+
+			resize := func(cpu, memoryBytes int64) {
+				Eventually(func() error {
+					machine, err := machineStore.Get(ctx, machineID)
+					if err != nil {
+						return err
+					}
+					machine.Spec.Cpu = cpu
+					machine.Spec.MemoryBytes = memoryBytes
+					_, err = machineStore.Update(ctx, machine)
+					return err
+				}).Should(Succeed())
+			}
+
+			By("growing the machine")
+			resize(2, 2147483648)
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				chClient, err := vmm.NewUnixSocketClient(ptr.Deref(machine.Spec.ApiSocketPath, ""))
+				g.Expect(err).NotTo(HaveOccurred())
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+
+				cpus, memoryBytes := vmm.Size(resp.JSON200)
+				g.Expect(cpus).To(Equal(2))
+				g.Expect(memoryBytes).To(BeEquivalentTo(2147483648))
+			}).Should(Succeed())
+			Eventually(func() []*recorder.Event {
+				return eventRecorder.ListEvents()
+			}).Should(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "Resized"),
+			)))
+
+			By("growing the machine beyond the ceiling")
+			resize(4, 2147483648)
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.Message).To(ContainSubstring("resize unsupported"))
+			}).Should(Succeed())
+		})
+	})
+
+	Context("Volume Reattach", func() {
+		It("should reattach the existing data disk after the vm is recreated", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine with a data disk")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:      "data",
+							Device:    "oda",
+							LocalDisk: &api.LocalDiskSpec{Size: 1024 * 1024},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("waiting for the machine to run")
+			var volumeStatus api.VolumeStatus
+			var apiSocket string
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+				g.Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("State", api.VolumeStateAttached)))
+				volumeStatus = machine.Status.VolumeStatus[0]
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+			}).Should(Succeed())
+
+			By("writing data to the disk")
+			Expect(os.WriteFile(volumeStatus.Path, []byte("data"), 0666)).To(Succeed())
+
+			By("removing the vm as after a restart of the machine")
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chClient.ShutdownVMWithResponse(ctx)).Error().NotTo(HaveOccurred())
+			Expect(chClient.DeleteVMWithResponse(ctx)).Error().NotTo(HaveOccurred())
+
+			By("triggering a reconciliation")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				metautils.SetAnnotation(machine, "test/vm-removed", "true")
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the vm is recreated with the existing disk")
+			Eventually(func(g Gomega) {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				g.Expect(resp.JSON200.State).To(Equal(client.Running))
+				g.Expect(ptr.Deref(resp.JSON200.Config.Disks, nil)).To(ContainElement(SatisfyAll(
+					HaveField("Id", ptr.To(volumeStatus.Handle)),
+					HaveField("Path", ptr.To(volumeStatus.Path)),
+				)))
+			}).Should(Succeed())
+			Expect(os.ReadFile(volumeStatus.Path)).To(BeEquivalentTo("data"))
+			Expect(eventRecorder.ListEvents()).NotTo(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "VolumeNotFound"),
+			)))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Reconcile Timeout", func() {
+		It("should abort a blocking reconciliation and requeue the machine", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine with a volume blocking its reconciliation")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOff,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "slow",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: slowVolumeDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("waiting for the reconcile timeout event")
+			Eventually(func() []*recorder.Event {
+				return eventRecorder.ListEvents()
+			}).WithTimeout(3 * reconcileTimeout).Should(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "ReconcileTimeout"),
+			)))
+
+			By("releasing the blocking volume")
+			slowVolumes.Release()
+
+			By("ensuring the requeued reconciliation applies the volume")
+			Eventually(func(g Gomega) []api.VolumeStatus {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				return machine.Status.VolumeStatus
+			}).Should(ContainElement(HaveField("Name", "slow")))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Boot Timeout", func() {
+		It("should flag a machine whose vm never reaches running", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine with a disk preventing the vm from booting")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					BootTimeout: bootTimeout,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "missing",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: missingDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("waiting for the boot timeout event")
+			Eventually(func() []*recorder.Event {
+				return eventRecorder.ListEvents()
+			}).WithTimeout(10 * bootTimeout).Should(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "BootTimeout"),
+			)))
+
+			By("ensuring the machine reports the failed boot")
+			Eventually(func(g Gomega) []api.MachineCondition {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				return machine.Status.Conditions
+			}).Should(ContainElement(SatisfyAll(
+				HaveField("Type", api.MachineConditionBooted),
+				HaveField("Status", api.ConditionFalse),
+				HaveField("Reason", "BootTimeout"),
+			)))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Network Interfaces", func() {
+		It("should keep the nic state consistent when applying a nic fails", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+			failingNics.failing.Store(true)
+			DeferCleanup(failingNics.failing.Store, false)
+
+			By("creating a machine with a failing nic")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOff,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					NetworkInterfaces: []*api.NetworkInterfaceSpec{
+						{Name: "first"},
+						{Name: failingNicName},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("ensuring the applied nic is persisted and the failing nic is kept")
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Spec.NetworkInterfaces).To(ConsistOf(
+					HaveField("Name", "first"),
+					HaveField("Name", failingNicName),
+				))
+				g.Expect(machine.Status.NetworkInterfaceStatus).To(ConsistOf(HaveField("Name", "first")))
+			}).Should(Succeed())
+
+			By("resolving the failure")
+			failingNics.failing.Store(false)
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.NetworkInterfaceStatus).To(ConsistOf(
+					HaveField("Name", "first"),
+					HaveField("Name", failingNicName),
+				))
+			}).Should(Succeed())
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+
+		It("should keep the status of a nic that is not prepared yet", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a running machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func(ctx SpecContext) {
+				Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			})
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+			}).Should(Succeed())
+
+			By("adding a nic that is not prepared yet")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				machine.Spec.NetworkInterfaces = []*api.NetworkInterfaceSpec{{Name: "pending"}}
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.NetworkInterfaceStatus).To(ConsistOf(HaveField("Name", "pending")))
+			}).Should(Succeed())
+
+			By("ensuring the status of the pending nic is kept while the vm runs")
+			Consistently(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.NetworkInterfaceStatus).To(ConsistOf(SatisfyAll(
+					HaveField("Name", "pending"),
+					HaveField("State", api.NetworkInterfaceStatePending),
+				)))
+			}).Should(Succeed())
+		})
+	})
+
+	Context("Paused VM", func() {
+		It("should defer attaching a nic until the vm is resumed", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a running machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var apiSocket string
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+			}).Should(Succeed())
+
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+
+			vmDevices := func(g Gomega) []client.DeviceConfig {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return ptr.Deref(resp.JSON200.Config.Devices, nil)
+			}
+
+			By("pausing the vm")
+			Eventually(func(g Gomega) client.VmInfoState {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return resp.JSON200.State
+			}).Should(Equal(client.Running))
+			pauseResp, err := chClient.PauseVMWithResponse(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pauseResp.StatusCode()).To(Equal(http.StatusNoContent))
+
+			By("adding a nic to the paused machine")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				machine.Spec.NetworkInterfaces = append(machine.Spec.NetworkInterfaces, &api.NetworkInterfaceSpec{
+					Name: preparedNicName,
+				})
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the nic is kept prepared while the vm is paused")
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.NetworkInterfaceStatus).To(ConsistOf(SatisfyAll(
+					HaveField("Name", preparedNicName),
+					HaveField("State", api.NetworkInterfaceStatePrepared),
+				)))
+			}).Should(Succeed())
+			Consistently(vmDevices).WithTimeout(2 * time.Second).Should(BeEmpty())
+
+			By("resuming the vm")
+			resumeResp, err := chClient.ResumeVMWithResponse(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resumeResp.StatusCode()).To(Equal(http.StatusNoContent))
+
+			By("ensuring the deferred nic is attached")
+			Eventually(vmDevices).Should(ConsistOf(HaveField("Id", ptr.To("NIC//"+preparedNicName))))
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.NetworkInterfaceStatus).To(ConsistOf(SatisfyAll(
+					HaveField("Name", preparedNicName),
+					HaveField("State", api.NetworkInterfaceStateAttached),
+				)))
+			}).Should(Succeed())
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Power State Paused", func() {
+		It("should pause the vm and resume it once powered on again", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a running machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var (
+				apiSocket string
+				bootedAt  time.Time
+			)
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+				booted, ok := api.FindMachineCondition(machine.Status, api.MachineConditionBooted)
+				g.Expect(ok).To(BeTrue())
+				g.Expect(booted.Status).To(Equal(api.ConditionTrue))
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+				bootedAt = booted.LastTransitionTime
+			}).Should(Succeed())
+
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+
+			vmState := func(g Gomega) client.VmInfoState {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return resp.JSON200.State
+			}
+			Eventually(vmState).Should(Equal(client.Running))
+
+			setPower := func(power api.PowerState) {
+				Eventually(func() error {
+					machine, err := machineStore.Get(ctx, machineID)
+					if err != nil {
+						return err
+					}
+					machine.Spec.Power = power
+					_, err = machineStore.Update(ctx, machine)
+					return err
+				}).Should(Succeed())
+			}
+
+			By("pausing the machine")
+			setPower(api.PowerStatePaused)
+			Eventually(vmState).Should(Equal(client.Paused))
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateSuspended))
+			}).Should(Succeed())
+
+			By("reconciling the paused machine again")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				if err := api.SetLabelsAnnotation(machine, map[string]string{"reconcile": "again"}); err != nil {
+					return err
+				}
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+			Consistently(func(g Gomega) {
+				g.Expect(vmState(g)).To(Equal(client.Paused))
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.Message).To(BeEmpty())
+			}).WithTimeout(2 * time.Second).Should(Succeed())
+
+			By("powering the machine on again")
+			setPower(api.PowerStatePowerOn)
+			Eventually(vmState).Should(Equal(client.Running))
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionBooted),
+					HaveField("Status", api.ConditionTrue),
+					HaveField("LastTransitionTime", BeTemporally("==", bootedAt)),
+				)))
+			}).Should(Succeed())
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Guest Shutdown", func() {
+		It("should power off a machine whose guest shut down instead of restarting it", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a running machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var apiSocket string
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionBooted),
+					HaveField("Status", api.ConditionTrue),
+				)))
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+			}).Should(Succeed())
+
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+
+			vmState := func(g Gomega) client.VmInfoState {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return resp.JSON200.State
+			}
+
+			By("shutting the vm down from the guest")
+			shutdownResp, err := chClient.ShutdownVMWithResponse(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(shutdownResp.StatusCode()).To(Equal(http.StatusNoContent))
+
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				metautils.SetAnnotation(machine, "test/guest-shutdown", "true")
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the machine is powered off")
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Spec.Power).To(Equal(api.PowerStatePowerOff))
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateTerminated))
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionBooted),
+					HaveField("Status", api.ConditionFalse),
+					HaveField("Reason", "GuestShutdown"),
+				)))
+			}).Should(Succeed())
+			Expect(eventRecorder.ListEvents()).To(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "GuestShutdown"),
+			)))
+
+			By("ensuring the vm is not restarted")
+			Consistently(vmState).WithTimeout(2 * time.Second).Should(Equal(client.Shutdown))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Power Off", func() {
+		It("should shut down a paused vm and boot it again on power on", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a running machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var apiSocket string
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+			}).Should(Succeed())
+
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+
+			vmInfo := func(g Gomega) *client.VmInfo {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return resp.JSON200
+			}
+
+			By("pausing the vm")
+			Eventually(vmInfo).Should(HaveField("State", client.Running))
+			pauseResp, err := chClient.PauseVMWithResponse(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pauseResp.StatusCode()).To(Equal(http.StatusNoContent))
+
+			setPower := func(power api.PowerState) {
+				Eventually(func() error {
+					machine, err := machineStore.Get(ctx, machineID)
+					if err != nil {
+						return err
+					}
+					machine.Spec.Power = power
+					_, err = machineStore.Update(ctx, machine)
+					return err
+				}).Should(Succeed())
+			}
+
+			By("powering off the machine")
+			setPower(api.PowerStatePowerOff)
+
+			By("ensuring the vm is shut down but kept configured")
+			Eventually(vmInfo).Should(SatisfyAll(
+				HaveField("State", client.Shutdown),
+				HaveField("Config.Platform.Uuid", ptr.To(machineID)),
+			))
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateTerminated))
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionBooted),
+					HaveField("Status", api.ConditionFalse),
+					HaveField("Reason", "PoweredOff"),
+				)))
+			}).Should(Succeed())
+
+			By("powering the machine on again")
+			setPower(api.PowerStatePowerOn)
+
+			Eventually(vmInfo).Should(HaveField("State", client.Running))
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+				g.Expect(machine.Spec.ApiSocketPath).To(Equal(ptr.To(apiSocket)))
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionBooted),
+					HaveField("Status", api.ConditionTrue),
+				)))
+			}).Should(Succeed())
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Lifecycle Events", func() {
+		It("should record events for the lifecycle transitions of the vm", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			eventReasons := func() []string {
+				var reasons []string
+				for _, evt := range eventRecorder.ListEvents() {
+					if evt.InvolvedObjectMeta.ID == machineID {
+						reasons = append(reasons, evt.Reason)
+					}
+				}
+				return reasons
+			}
+
+			By("creating a powered on machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "root",
+							Device:     api.BootDevice,
+							Connection: &api.VolumeConnection{Driver: pendingDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(machineStore.Delete, machineID)
+
+			Eventually(eventReasons).Should(ContainElements("CreatedVM", "PoweredOn"))
+			Expect(eventReasons()).NotTo(ContainElement("PoweredOff"))
+
+			By("powering off the machine")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				machine.Spec.Power = api.PowerStatePowerOff
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			Eventually(eventReasons).Should(ContainElement("PoweredOff"))
+		})
+	})
+
+	Context("Volume Probe", func() {
+		It("should defer attaching a volume until its backend is reachable", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+			DeferCleanup(flakyDisks.unhealthy.Store, false)
+
+			By("creating a running machine")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "root",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: flakyDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func(ctx SpecContext) {
+				Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			})
+
+			vmDisks := func(g Gomega) []client.DiskConfig {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				chClient, err := vmm.NewUnixSocketClient(ptr.Deref(machine.Spec.ApiSocketPath, ""))
+				g.Expect(err).NotTo(HaveOccurred())
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return ptr.Deref(resp.JSON200.Config.Disks, nil)
+			}
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+				g.Expect(vmDisks(g)).To(HaveLen(1))
+			}).Should(Succeed())
+
+			By("adding a volume while its backend is unreachable")
+			flakyDisks.unhealthy.Store(true)
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				machine.Spec.Volumes = append(machine.Spec.Volumes, &api.VolumeSpec{
+					Name:       "data",
+					Device:     "odb",
+					Connection: &api.VolumeConnection{Driver: flakyDiskDriver},
+				})
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			Eventually(func() []*recorder.Event {
+				return eventRecorder.ListEvents()
+			}).Should(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "VolumeUnreachable"),
+			)))
+			Consistently(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.VolumeStatus).To(ContainElement(SatisfyAll(
+					HaveField("Name", "data"),
+					HaveField("State", api.VolumeStatePrepared),
+				)))
+				g.Expect(vmDisks(g)).To(HaveLen(1))
+			}).WithTimeout(time.Second).Should(Succeed())
+
+			By("recovering the volume backend")
+			flakyDisks.unhealthy.Store(false)
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.VolumeStatus).To(ContainElement(SatisfyAll(
+					HaveField("Name", "data"),
+					HaveField("State", api.VolumeStateAttached),
+				)))
+				g.Expect(vmDisks(g)).To(HaveLen(2))
+			}).Should(Succeed())
+		})
+	})
+
+	Context("Volume Stats", func() {
+		It("should report the io stats of the attached volumes as metrics", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			readBytes := func(g Gomega) []float64 {
+				families, err := metrics.Registry.Gather()
+				g.Expect(err).NotTo(HaveOccurred())
+
+				var values []float64
+				for _, family := range families {
+					if family.GetName() != "volume_read_bytes_total" {
+						continue
+					}
+					for _, metric := range family.GetMetric() {
+						for _, label := range metric.GetLabel() {
+							if label.GetName() == "machine_id" && label.GetValue() == machineID {
+								values = append(values, metric.GetCounter().GetValue())
+							}
+						}
+					}
+				}
+				return values
+			}
+
+			By("creating a machine with a volume")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "data",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: flakyDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(readBytes).Should(ConsistOf(float64(flakyDiskStats.ReadBytes)))
+
+			By("deleting the machine")
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			Eventually(readBytes).Should(BeEmpty())
+		})
+	})
+
+	Context("Volume Health", func() {
+		It("should report an attached volume with a dead backend as unhealthy", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+			DeferCleanup(flakyDisks.unhealthy.Store, false)
+
+			By("creating a machine with a volume")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "data",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: flakyDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("State", api.VolumeStateAttached)))
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionVolumesHealthy),
+					HaveField("Status", api.ConditionTrue),
+				)))
+			}).Should(Succeed())
+
+			By("breaking the volume backend")
+			flakyDisks.unhealthy.Store(true)
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				metautils.SetAnnotation(machine, "test/volume-unhealthy", "true")
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the machine reports the unhealthy volume")
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("State", api.VolumeStateAttached)))
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionVolumesHealthy),
+					HaveField("Status", api.ConditionFalse),
+					HaveField("Reason", "VolumeUnhealthy"),
+					HaveField("Message", ContainSubstring("data")),
+				)))
+			}).Should(Succeed())
+			Expect(eventRecorder.ListEvents()).To(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "VolumeUnhealthy"),
+			)))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+	Context("Disk Pressure", func() {
+		It("should back off preparing volumes while the disk is full", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+			fullDisks.full.Store(true)
+			DeferCleanup(fullDisks.full.Store, false)
+
+			By("creating a machine with a volume on a full disk")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "root",
+							Device:     "oda",
+							Connection: &api.VolumeConnection{Driver: fullDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("ensuring the machine reports the disk pressure")
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionDiskPressure),
+					HaveField("Status", api.ConditionTrue),
+					HaveField("Reason", "DiskPressure"),
+					HaveField("Message", ContainSubstring("root")),
+				)))
+			}).Should(Succeed())
+			Expect(eventRecorder.ListEvents()).To(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "DiskPressure"),
+			)))
+
+			By("ensuring the volume is not retried in a hot loop")
+			applies := fullDisks.applies.Load()
+			Consistently(fullDisks.applies.Load).Should(BeNumerically("<=", applies+1))
+
+			By("freeing disk space")
+			fullDisks.full.Store(false)
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				metautils.SetAnnotation(machine, "test/disk-freed", "true")
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionDiskPressure),
+					HaveField("Status", api.ConditionFalse),
+				)))
+				g.Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("Name", "root")))
+			}).Should(Succeed())
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+	Context("Boot Disk", func() {
+		It("should attach the boot disk before powering on the vm", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+			pendingDisks.pending.Store(true)
+			DeferCleanup(pendingDisks.pending.Store, false)
+
+			By("creating a machine with a pending boot disk")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "root",
+							Device:     api.BootDevice,
+							Connection: &api.VolumeConnection{Driver: pendingDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var apiSocket string
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("State", api.VolumeStatePending)))
+				apiSocket = ptr.Deref(machine.Spec.ApiSocketPath, "")
+				g.Expect(apiSocket).NotTo(BeEmpty())
+			}).Should(Succeed())
+
+			chClient, err := vmm.NewUnixSocketClient(apiSocket)
+			Expect(err).NotTo(HaveOccurred())
+
+			vmInfo := func(g Gomega) *client.VmInfo {
+				resp, err := chClient.GetVmInfoWithResponse(ctx)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(resp.JSON200).NotTo(BeNil())
+				return resp.JSON200
+			}
+
+			By("ensuring the vm is not powered on without its boot disk")
+			Eventually(vmInfo).Should(HaveField("State", client.Created))
+			Consistently(vmInfo).Should(HaveField("State", client.Created))
+
+			By("preparing the boot disk")
+			pendingDisks.pending.Store(false)
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				metautils.SetAnnotation(machine, "test/boot-disk-prepared", "true")
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the vm boots with the boot disk attached")
+			Eventually(vmInfo).Should(SatisfyAll(
+				HaveField("State", client.Running),
+				HaveField("Config.Disks", HaveValue(ConsistOf(HaveField("Id", HaveValue(Equal("root")))))),
+			))
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("State", api.VolumeStateAttached)))
+			}).Should(Succeed())
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+	Context("Preparing Volume", func() {
+		It("should not block the reconciliation while a volume is prepared", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+			asyncDisks.preparing.Store(true)
+			DeferCleanup(asyncDisks.preparing.Store, false)
+
+			By("creating a machine with a volume prepared in the background")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1073741824,
+					Volumes: []*api.VolumeSpec{
+						{
+							Name:       "root",
+							Device:     api.BootDevice,
+							Connection: &api.VolumeConnection{Driver: asyncDiskDriver},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("ensuring the reconciliation returns while the volume is prepared")
+			Eventually(func() []*recorder.Event {
+				return eventRecorder.ListEvents()
+			}).Should(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "PreparingVolume"),
+			)))
+			Expect(eventRecorder.ListEvents()).NotTo(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "ReconcileTimeout"),
+			)))
+
+			By("ensuring the machine is not retried until the volume is prepared")
+			applies := asyncDisks.applies.Load()
+			Consistently(asyncDisks.applies.Load).Should(Equal(applies))
+
+			By("finishing the preparation")
+			asyncDisks.Finish(machineID, "root")
+
+			By("ensuring the machine is requeued and boots with the volume")
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.VolumeStatus).To(ConsistOf(HaveField("State", api.VolumeStateAttached)))
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+			}).Should(Succeed())
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+
+	Context("Status Message", func() {
+		createMachine := func(ctx context.Context, spec api.MachineSpec) string {
+			machineID := uuid.NewString()
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{ID: machineID},
+				Spec:     spec,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func(ctx SpecContext) {
+				Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+			})
+			return machineID
+		}
+
+		message := func(ctx context.Context, machineID string) func() (string, error) {
+			return func() (string, error) {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return "", err
+				}
+				return machine.Status.Message, nil
+			}
+		}
+
+		It("should explain that the boot image is pulled", func(ctx SpecContext) {
+			const image = "127.0.0.1:1/unreachable:latest"
+			machineID := createMachine(ctx, api.MachineSpec{
+				Power:       api.PowerStatePowerOn,
+				Cpu:         1,
+				MemoryBytes: 1073741824,
+				Volumes: []*api.VolumeSpec{
+					{
+						Name:      "root",
+						Device:    api.BootDevice,
+						LocalDisk: &api.LocalDiskSpec{Image: ptr.To(image)},
+					},
+				},
+			})
+
+			Eventually(message(ctx, machineID)).Should(Equal("waiting for image " + image + " to be pulled"))
+		})
+
+		It("should explain that a volume is prepared until the machine runs", func(ctx SpecContext) {
+			asyncDisks.preparing.Store(true)
+			DeferCleanup(asyncDisks.preparing.Store, false)
+
+			machineID := createMachine(ctx, api.MachineSpec{
+				Power:       api.PowerStatePowerOn,
+				Cpu:         1,
+				MemoryBytes: 1073741824,
+				Volumes: []*api.VolumeSpec{
+					{
+						Name:       "root",
+						Device:     api.BootDevice,
+						Connection: &api.VolumeConnection{Driver: asyncDiskDriver},
+					},
+				},
+			})
+			Eventually(message(ctx, machineID)).Should(Equal("waiting for volume root to be prepared"))
+
+			By("finishing the preparation")
+			asyncDisks.Finish(machineID, "root")
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+				g.Expect(machine.Status.Message).To(BeEmpty())
+			}).Should(Succeed())
+		})
+
+		It("should explain that a network interface is not attached", func(ctx SpecContext) {
+			failingNics.failing.Store(true)
+			DeferCleanup(failingNics.failing.Store, false)
+
+			machineID := createMachine(ctx, api.MachineSpec{
+				Power:             api.PowerStatePowerOff,
+				Cpu:               1,
+				MemoryBytes:       1073741824,
+				NetworkInterfaces: []*api.NetworkInterfaceSpec{{Name: failingNicName}},
+			})
+			By("reporting the failure of the plugin as such")
+			Eventually(message(ctx, machineID)).Should(SatisfyAll(
+				HavePrefix("reconciliation failed: "),
+				ContainSubstring("failed to apply NIC "+failingNicName),
+			))
+
+			By("resolving the failure")
+			failingNics.failing.Store(false)
+			Eventually(message(ctx, machineID)).Should(Equal("network interface not attached: " + failingNicName))
+		})
+
+		It("should explain that the host lacks the capacity for the vm", func(ctx SpecContext) {
+			machineID := createMachine(ctx, api.MachineSpec{
+				Power:       api.PowerStatePowerOn,
+				Cpu:         1,
+				MemoryBytes: 1 << 45,
+			})
+			Eventually(message(ctx, machineID)).Should(HavePrefix("insufficient capacity: vm requires"))
+		})
+	})
+
+	Context("Display Name", func() {
+		It("should include the machine name in events", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a labeled machine with invalid memory")
+			machine := &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1,
+				},
+			}
+			Expect(api.SetLabelsAnnotation(machine, map[string]string{
+				api.MachineNamespaceLabel: "default",
+				api.MachineNameLabel:      "web-0",
+			})).To(Succeed())
+			_, err := machineStore.Create(ctx, machine)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("ensuring the event carries the display name")
+			Eventually(eventRecorder.ListEvents).Should(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "InvalidMemory"),
+				HaveField("Message", HavePrefix("Machine default/web-0: ")),
+			)))
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
+	Context("Workers", func() {
+		It("should reconcile with the configured number of workers", func() {
+			Eventually(func(g Gomega) float64 {
+				families, err := metrics.Registry.Gather()
+				g.Expect(err).NotTo(HaveOccurred())
+				for _, family := range families {
+					if family.GetName() != "workqueue_workers" {
+						continue
+					}
+					for _, metric := range family.GetMetric() {
+						for _, label := range metric.GetLabel() {
+							if label.GetName() == "name" && label.GetValue() == "machine" {
+								return metric.GetGauge().GetValue()
+							}
+						}
+					}
+				}
+				return 0
+			}).Should(BeEquivalentTo(workerSize))
+		})
+
+		It("should reject a negative number of workers", func() {
+			_, err := controllers.NewMachineReconciler(
+				GinkgoLogr,
+				machineStore,
+				machineEvents,
+				eventRecorder,
+				nil,
+				nil,
+				nil,
+				controllers.MachineReconcilerOptions{WorkerSize: -1},
+			)
+			Expect(err).To(MatchError(ContainSubstring("worker size")))
+		})
+	})
+
+	Context("Power On Rate", func() {
+		It("should throttle the power ons of vms to the configured rate", func(ctx SpecContext) {
+			machineIDs := []string{uuid.NewString(), uuid.NewString()}
+
+			By("recording when the vms of the machines are asked to power on")
+			var (
+				mu        sync.Mutex
+				poweredOn = map[string]time.Time{}
+			)
+			registration, err := machineEvents.AddHandler(event.HandlerFunc[*api.Machine](func(evt event.Event[*api.Machine]) {
+				mu.Lock()
+				defer mu.Unlock()
+				if _, ok := poweredOn[evt.Object.ID]; !ok && !evt.Object.Status.BootStartedAt.IsZero() {
+					poweredOn[evt.Object.ID] = evt.Object.Status.BootStartedAt
+				}
+			}))
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(machineEvents.RemoveHandler, registration)
+
+			By("creating the machines at once")
+			for _, machineID := range machineIDs {
+				_, err := machineStore.Create(ctx, &api.Machine{
+					Metadata: apiutils.Metadata{
+						ID: machineID,
+					},
+					Spec: api.MachineSpec{
+						Power:       api.PowerStatePowerOn,
+						Cpu:         1,
+						MemoryBytes: 536870912,
+						Volumes: []*api.VolumeSpec{
+							{
+								Name:       "root",
+								Device:     api.BootDevice,
+								Connection: &api.VolumeConnection{Driver: pendingDiskDriver},
+							},
+						},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				DeferCleanup(machineStore.Delete, machineID)
+			}
+
+			By("ensuring the power ons are spaced by the rate")
+			Eventually(func(g Gomega) []time.Time {
+				mu.Lock()
+				defer mu.Unlock()
+				var times []time.Time
+				for _, machineID := range machineIDs {
+					if t, ok := poweredOn[machineID]; ok {
+						times = append(times, t)
+					}
+				}
+				return times
+			}).Should(HaveLen(len(machineIDs)))
+
+			mu.Lock()
+			defer mu.Unlock()
+			first, second := poweredOn[machineIDs[0]], poweredOn[machineIDs[1]]
+			Expect(first.Sub(second).Abs()).To(BeNumerically(">=", time.Duration(float64(time.Second)/powerOnRate)-
+				powerOnRateTolerance))
+		})
+	})
+
+	Context("Quarantine", func() {
+		It("should stop requeueing a machine failing repeatedly until it changes", func(ctx SpecContext) {
+			machineID := uuid.NewString()
+
+			By("creating a machine whose vm can never be created")
+			_, err := machineStore.Create(ctx, &api.Machine{
+				Metadata: apiutils.Metadata{
+					ID: machineID,
+				},
+				Spec: api.MachineSpec{
+					Power:       api.PowerStatePowerOn,
+					Cpu:         1,
+					MemoryBytes: 1,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("retriggering the failing reconciliation until the machine is quarantined")
+			retries := 0
+			Eventually(func(g Gomega) []api.MachineCondition {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				if condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionQuarantined); !found ||
+					condition.Status != api.ConditionTrue {
+					retries++
+					metautils.SetAnnotation(machine, "test/quarantine-retry", strconv.Itoa(retries))
+					_, err = machineStore.Update(ctx, machine)
+					g.Expect(err).NotTo(HaveOccurred())
+				}
+				return machine.Status.Conditions
+			}).Should(ContainElement(SatisfyAll(
+				HaveField("Type", api.MachineConditionQuarantined),
+				HaveField("Status", api.ConditionTrue),
+			)))
+			Expect(eventRecorder.ListEvents()).To(ContainElement(SatisfyAll(
+				HaveField("InvolvedObjectMeta.ID", machineID),
+				HaveField("Reason", "Quarantined"),
+			)))
+
+			By("ensuring the machine is no longer requeued")
+			failures := func() int {
+				var count int
+				for _, evt := range eventRecorder.ListEvents() {
+					if evt.InvolvedObjectMeta.ID == machineID && evt.Reason == "InvalidMemory" {
+						count++
+					}
+				}
+				return count
+			}
+			Expect(failures()).To(BeNumerically(">=", quarantineThreshold))
+			Consistently(failures).Should(Equal(failures()))
+
+			By("fixing the machine spec")
+			Eventually(func() error {
+				machine, err := machineStore.Get(ctx, machineID)
+				if err != nil {
+					return err
+				}
+				machine.Spec.MemoryBytes = 1073741824
+				_, err = machineStore.Update(ctx, machine)
+				return err
+			}).Should(Succeed())
+
+			By("ensuring the machine is reconciled again")
+			Eventually(func(g Gomega) {
+				machine, err := machineStore.Get(ctx, machineID)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(machine.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", api.MachineConditionQuarantined),
+					HaveField("Status", api.ConditionFalse),
+				)))
+				g.Expect(machine.Status.State).To(Equal(api.MachineStateRunning))
+			}).Should(Succeed())
+
+			Expect(machineStore.Delete(ctx, machineID)).To(Succeed())
+		})
+	})
 })
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/imageutils"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
+	"github.com/ironcore-dev/provider-utils/storeutils/store"
+)
+
+// blockedError stops a reconciliation that has to wait for something without failing it.
+type blockedError struct {
+	message string
+	err     error
+}
+
+func (e *blockedError) Error() string {
+	return e.message
+}
+
+func (e *blockedError) Unwrap() error {
+	return e.err
+}
+
+func blocked(format string, args ...any) error {
+	return &blockedError{message: fmt.Sprintf(format, args...)}
+}
+
+// messageCauses are the errors whose own message explains why the machine is not running, without the
+// context added while returning them.
+var messageCauses = []error{
+	vmm.ErrInsufficientCapacity,
+	vmm.ErrInvalidMemory,
+	vmm.ErrNoBootSource,
+	imageutils.ErrImagePullTimeout,
+	imageutils.ErrImageNotFound,
+	imageutils.ErrArchitectureMismatch,
+	vmm.ErrNICNotAttached,
+	vmm.ErrResizeUnsupported,
+}
+
+// statusMessage explains what keeps the machine from running, given the result of its last reconciliation.
+// It is empty if nothing does.
+func statusMessage(machine *api.Machine, err error) string {
+	if err != nil {
+		var blockedErr *blockedError
+		if errors.As(err, &blockedErr) {
+			return blockedErr.message
+		}
+		for _, cause := range messageCauses {
+			if errors.Is(err, cause) {
+				return innermost(err, cause).Error()
+			}
+		}
+		return fmt.Sprintf("reconciliation failed: %s", err)
+	}
+
+	if condition, found := api.FindMachineCondition(machine.Status, api.MachineConditionVolumesHealthy); found &&
+		condition.Status == api.ConditionFalse {
+		return condition.Message
+	}
+	return ""
+}
+
+// innermost returns the innermost error of the chain of err wrapping target.
+func innermost(err, target error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil || next == target || !errors.Is(next, target) {
+			return err
+		}
+		err = next
+	}
+}
+
+// updateStatusMessage sets the status message of the machine from the result of its last reconciliation.
+func (r *MachineReconciler) updateStatusMessage(ctx context.Context, id string, reconcileErr error) error {
+	machine, err := r.machines.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch machine from store: %w", err)
+	}
+	if machine.DeletedAt != nil {
+		return nil
+	}
+
+	message := statusMessage(machine, reconcileErr)
+	if machine.Status.Message == message {
+		return nil
+	}
+	machine.Status.Message = message
+	if _, err := r.machines.Update(ctx, machine); err != nil {
+		return fmt.Errorf("failed to update machine status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"time"
+)
+
+// reservePowerOn reserves a power on of the vm of the machine with the power on rate limit and returns the
+// time the machine has to wait for it. A machine keeps its reservation until it is due, so machines are
+// powered on in the order they asked to.
+func (r *MachineReconciler) reservePowerOn(id string) time.Duration {
+	if r.powerOnLimiter == nil {
+		return 0
+	}
+
+	r.powerOnMu.Lock()
+	defer r.powerOnMu.Unlock()
+	now := time.Now()
+	if reservation, ok := r.powerOns[id]; ok {
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			return delay
+		}
+		delete(r.powerOns, id)
+		return 0
+	}
+
+	reservation := r.powerOnLimiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		r.powerOns[id] = reservation
+	}
+	return delay
+}
+
+// cancelPowerOn cancels the pending power on reservation of the machine, returning it to the rate limit.
+func (r *MachineReconciler) cancelPowerOn(id string) {
+	if r.powerOnLimiter == nil {
+		return
+	}
+
+	r.powerOnMu.Lock()
+	defer r.powerOnMu.Unlock()
+	if reservation, ok := r.powerOns[id]; ok {
+		reservation.Cancel()
+		delete(r.powerOns, id)
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/provider-utils/storeutils/store"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	quarantinedReason = "Quarantined"
+	resumedReason     = "Resumed"
+)
+
+// machineFingerprint covers everything of a machine a user can change to resume a quarantined machine.
+func machineFingerprint(machine *api.Machine) (string, error) {
+	data, err := json.Marshal(struct {
+		Spec        api.MachineSpec
+		Labels      map[string]string
+		Annotations map[string]string
+	}{machine.Spec, machine.Labels, machine.Annotations})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// quarantine stops reconciling the machine automatically until its spec, labels or annotations change or
+// it is deleted. It reports whether the machine was quarantined.
+func (r *MachineReconciler) quarantine(ctx context.Context, log logr.Logger, id string, cause error) bool {
+	machine, err := r.machines.Get(ctx, id)
+	if err != nil {
+		log.Error(err, "Failed to get machine to quarantine")
+		return false
+	}
+
+	fingerprint, err := machineFingerprint(machine)
+	if err != nil {
+		log.Error(err, "Failed to fingerprint machine to quarantine")
+		return false
+	}
+
+	log.Info("Quarantining machine after repeated reconciliation failures", "failures", r.quarantineThreshold)
+	r.quarantinedMu.Lock()
+	r.quarantined[id] = fingerprint
+	delete(r.failures, id)
+	r.quarantinedMu.Unlock()
+
+	r.eventf(machine, corev1.EventTypeWarning, quarantinedReason,
+		"Stopped reconciling after %d failures: %s", r.quarantineThreshold, cause)
+	api.SetMachineCondition(&machine.Status, api.MachineCondition{
+		Type:    api.MachineConditionQuarantined,
+		Status:  api.ConditionTrue,
+		Reason:  quarantinedReason,
+		Message: cause.Error(),
+	})
+	if _, err := r.machines.Update(ctx, machine); err != nil {
+		log.Error(err, "Failed to update machine status")
+	}
+	return true
+}
+
+// isQuarantined reports whether the machine is quarantined, releasing it if it changed since.
+func (r *MachineReconciler) isQuarantined(ctx context.Context, log logr.Logger, id string) bool {
+	r.quarantinedMu.Lock()
+	fingerprint, ok := r.quarantined[id]
+	r.quarantinedMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	machine, err := r.machines.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Error(err, "Failed to get quarantined machine")
+			return true
+		}
+		r.release(id)
+		return false
+	}
+
+	current, err := machineFingerprint(machine)
+	if err != nil {
+		log.Error(err, "Failed to fingerprint quarantined machine")
+		return true
+	}
+	if machine.DeletedAt == nil && current == fingerprint {
+		return true
+	}
+
+	log.Info("Releasing machine from quarantine")
+	r.release(id)
+	api.SetMachineCondition(&machine.Status, api.MachineCondition{
+		Type:    api.MachineConditionQuarantined,
+		Status:  api.ConditionFalse,
+		Reason:  resumedReason,
+		Message: "machine changed since it was quarantined",
+	})
+	if _, err := r.machines.Update(ctx, machine); err != nil {
+		log.Error(err, "Failed to update machine status")
+	}
+	return false
+}
+
+// recordFailure counts a failed reconciliation of the machine and reports whether the machine reached the
+// quarantine threshold.
+func (r *MachineReconciler) recordFailure(id string) bool {
+	if r.quarantineThreshold <= 0 {
+		return false
+	}
+
+	r.quarantinedMu.Lock()
+	defer r.quarantinedMu.Unlock()
+	r.failures[id]++
+	return r.failures[id] >= r.quarantineThreshold
+}
+
+func (r *MachineReconciler) resetFailures(id string) {
+	r.quarantinedMu.Lock()
+	defer r.quarantinedMu.Unlock()
+	delete(r.failures, id)
+}
+
+func (r *MachineReconciler) release(id string) {
+	r.quarantinedMu.Lock()
+	defer r.quarantinedMu.Unlock()
+	delete(r.quarantined, id)
+	delete(r.failures, id)
+}
@@ -17,10 +17,16 @@ const (
 	DefaultMachineVolumesDir           = "volumes"
 	DefaultMachineIgnitionsDir         = "ignitions"
 	DefaultMachineIgnitionFile         = "data.ign"
+	DefaultMachineConfigDriveFile      = "config-drive.iso"
+	DefaultMachineConsoleSocket        = "console.sock"
+	DefaultMachineSerialSocket         = "serial.sock"
+	DefaultMachineVsockSocket          = "vsock.sock"
+	DefaultMachineSerialLogFile        = "serial.log"
 	DefaultMachineRootFSDir            = "rootfs"
 	DefaultMachineRootFSFile           = "rootfs"
 	DefaultMachinePluginsDir           = "plugins"
 	DefaultMachineNetworkInterfacesDir = "networkinterfaces"
+	DefaultMachineSnapshotDir          = "snapshot"
 )
 
 type Paths interface {
@@ -47,10 +53,28 @@ type Paths interface {
 
 	MachineIgnitionsDir(machineUID string) string
 	MachineIgnitionFile(machineUID string) string
+
+	MachineConfigDriveFile(machineUID string) string
+
+	// MachineSnapshotDir holds the vm snapshot of the machine. It is created when the vm is snapshotted.
+	MachineSnapshotDir(machineUID string) string
+
+	// MachinesSocketsDir holds the socket dirs of all machines.
+	MachinesSocketsDir() string
+	// MachineSocketsDir holds the sockets of the machine. It is the machine dir unless the machines are kept
+	// in a separate data dir, in which case the sockets are kept below the root dir.
+	MachineSocketsDir(machineUID string) string
+	MachineConsoleSocket(machineUID string) string
+	MachineSerialSocket(machineUID string) string
+	// MachineVsockSocket is the unix socket the vsock connections of the vm are proxied to.
+	MachineVsockSocket(machineUID string) string
+	MachineSerialLogFile(machineUID string) string
+	MachineVolumeSocket(machineUID string, pluginName, volumeName string) string
 }
 
 type paths struct {
 	rootDir string
+	dataDir string
 }
 
 func (p *paths) RootDir() string {
@@ -58,11 +82,11 @@ func (p *paths) RootDir() string {
 }
 
 func (p *paths) MachinesDir() string {
-	return filepath.Join(p.rootDir, DefaultMachinesDir)
+	return filepath.Join(p.dataDir, DefaultMachinesDir)
 }
 
 func (p *paths) ImagesDir() string {
-	return filepath.Join(p.rootDir, DefaultImagesDir)
+	return filepath.Join(p.dataDir, DefaultImagesDir)
 }
 
 func (p *paths) PluginsDir() string {
@@ -121,11 +145,67 @@ func (p *paths) MachineIgnitionFile(machineUID string) string {
 	return filepath.Join(p.MachineIgnitionsDir(machineUID), DefaultMachineIgnitionFile)
 }
 
+func (p *paths) MachineConfigDriveFile(machineUID string) string {
+	return filepath.Join(p.MachineDir(machineUID), DefaultMachineConfigDriveFile)
+}
+
+func (p *paths) MachineSnapshotDir(machineUID string) string {
+	return filepath.Join(p.MachineDir(machineUID), DefaultMachineSnapshotDir)
+}
+
+func (p *paths) MachinesSocketsDir() string {
+	return filepath.Join(p.rootDir, DefaultMachinesDir)
+}
+
+func (p *paths) MachineSocketsDir(machineUID string) string {
+	return filepath.Join(p.MachinesSocketsDir(), machineUID)
+}
+
+func (p *paths) MachineConsoleSocket(machineUID string) string {
+	return filepath.Join(p.MachineSocketsDir(machineUID), DefaultMachineConsoleSocket)
+}
+
+func (p *paths) MachineSerialSocket(machineUID string) string {
+	return filepath.Join(p.MachineSocketsDir(machineUID), DefaultMachineSerialSocket)
+}
+
+func (p *paths) MachineVsockSocket(machineUID string) string {
+	return filepath.Join(p.MachineSocketsDir(machineUID), DefaultMachineVsockSocket)
+}
+
+func (p *paths) MachineVolumeSocket(machineUID string, pluginName, volumeName string) string {
+	return filepath.Join(p.MachineSocketsDir(machineUID), DefaultMachineVolumesDir, pluginName, volumeName, "socket")
+}
+
+func (p *paths) MachineSerialLogFile(machineUID string) string {
+	return filepath.Join(p.MachineDir(machineUID), DefaultMachineSerialLogFile)
+}
+
+type PathsOptions struct {
+	// DataDir keeps the machines and images in a separate directory, e.g. the mount of a data disk, instead of
+	// the root dir. It has to exist and be writable. The sockets of the machines are kept below the root dir
+	// regardless, as the length of socket paths is limited.
+	DataDir string
+}
+
 func PathsAt(rootDir string) (Paths, error) {
-	p := &paths{rootDir}
+	return PathsAtWithOptions(rootDir, PathsOptions{})
+}
+
+func PathsAtWithOptions(rootDir string, opts PathsOptions) (Paths, error) {
+	p := &paths{rootDir: rootDir, dataDir: rootDir}
 	if err := os.MkdirAll(p.RootDir(), os.ModePerm); err != nil {
 		return nil, fmt.Errorf("error creating root directory: %w", err)
 	}
+	if opts.DataDir != "" {
+		if err := validateDataDir(opts.DataDir); err != nil {
+			return nil, err
+		}
+		p.dataDir = opts.DataDir
+		if err := os.MkdirAll(filepath.Join(p.rootDir, DefaultMachinesDir), os.ModePerm); err != nil {
+			return nil, fmt.Errorf("error creating machine sockets directory: %w", err)
+		}
+	}
 	if err := os.MkdirAll(p.ImagesDir(), os.ModePerm); err != nil {
 		return nil, fmt.Errorf("error creating images directory: %w", err)
 	}
@@ -135,10 +215,35 @@ func PathsAt(rootDir string) (Paths, error) {
 	return p, nil
 }
 
+// validateDataDir ensures the data dir exists and is writable, so a missing mount is not silently replaced
+// by a directory on the root disk.
+func validateDataDir(dataDir string) error {
+	info, err := os.Stat(dataDir)
+	if err != nil {
+		return fmt.Errorf("error checking data directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("data directory %s is not a directory", dataDir)
+	}
+
+	f, err := os.CreateTemp(dataDir, ".write-check-")
+	if err != nil {
+		return fmt.Errorf("data directory %s is not writable: %w", dataDir, err)
+	}
+	_ = f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return fmt.Errorf("error removing write check file: %w", err)
+	}
+	return nil
+}
+
 func MakeMachineDirs(paths Paths, machineUID string) error {
 	if err := os.MkdirAll(paths.MachineDir(machineUID), os.ModePerm); err != nil {
 		return fmt.Errorf("error creating machine directory: %w", err)
 	}
+	if err := os.MkdirAll(paths.MachineSocketsDir(machineUID), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating machine sockets directory: %w", err)
+	}
 	if err := os.MkdirAll(paths.MachineRootFSDir(machineUID), os.ModePerm); err != nil {
 		return fmt.Errorf("error creating machine rootfs directory: %w", err)
 	}
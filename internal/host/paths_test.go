@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package host_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Paths", func() {
+	It("should place the console and serial sockets of a machine in its machine directory", func() {
+		paths, err := host.PathsAt(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+
+		machineDir := paths.MachineDir("machine-1")
+		consoleSocket := paths.MachineConsoleSocket("machine-1")
+		serialSocket := paths.MachineSerialSocket("machine-1")
+
+		Expect(filepath.IsAbs(consoleSocket)).To(BeTrue())
+		Expect(filepath.Dir(consoleSocket)).To(Equal(machineDir))
+		Expect(filepath.Dir(serialSocket)).To(Equal(machineDir))
+		Expect(consoleSocket).NotTo(Equal(serialSocket))
+		Expect(consoleSocket).NotTo(Equal(paths.MachineConsoleSocket("machine-2")))
+	})
+
+	Describe("PathsAtWithOptions", func() {
+		var rootDir, dataDir string
+
+		BeforeEach(func() {
+			rootDir = GinkgoT().TempDir()
+			dataDir = GinkgoT().TempDir()
+		})
+
+		It("should keep the machines and images in the data dir and the sockets in the root dir", func() {
+			paths, err := host.PathsAtWithOptions(rootDir, host.PathsOptions{DataDir: dataDir})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(host.MakeMachineDirs(paths, "machine-1")).To(Succeed())
+
+			Expect(paths.ImagesDir()).To(BeADirectory())
+			Expect(paths.ImagesDir()).To(HavePrefix(dataDir))
+			Expect(paths.MachineRootFSFile("machine-1")).To(HavePrefix(dataDir))
+			Expect(paths.MachineVolumeDir("machine-1", "plugin", "data")).To(HavePrefix(dataDir))
+			Expect(paths.MachineSnapshotDir("machine-1")).To(HavePrefix(paths.MachineDir("machine-1")))
+			Expect(paths.MachineRootFSDir("machine-1")).To(BeADirectory())
+
+			Expect(paths.MachineSocketsDir("machine-1")).To(BeADirectory())
+			for _, socket := range []string{
+				paths.MachineConsoleSocket("machine-1"),
+				paths.MachineSerialSocket("machine-1"),
+				paths.MachineVsockSocket("machine-1"),
+				paths.MachineVolumeSocket("machine-1", "plugin", "data"),
+			} {
+				Expect(socket).To(HavePrefix(rootDir))
+			}
+		})
+
+		It("should keep everything in the root dir without a data dir", func() {
+			paths, err := host.PathsAtWithOptions(rootDir, host.PathsOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(paths.MachineSocketsDir("machine-1")).To(Equal(paths.MachineDir("machine-1")))
+			Expect(paths.MachineVolumeSocket("machine-1", "plugin", "data")).
+				To(Equal(filepath.Join(paths.MachineVolumeDir("machine-1", "plugin", "data"), "socket")))
+		})
+
+		It("should reject a data dir that does not exist", func() {
+			_, err := host.PathsAtWithOptions(rootDir, host.PathsOptions{DataDir: filepath.Join(dataDir, "missing")})
+			Expect(err).To(MatchError(ContainSubstring("data directory")))
+		})
+
+		It("should reject a data dir that is not writable", func() {
+			if os.Geteuid() == 0 {
+				Skip("root can write to read-only directories")
+			}
+			Expect(os.Chmod(dataDir, 0555)).To(Succeed())
+			DeferCleanup(os.Chmod, dataDir, os.FileMode(0755))
+
+			_, err := host.PathsAtWithOptions(rootDir, host.PathsOptions{DataDir: dataDir})
+			Expect(err).To(MatchError(ContainSubstring("not writable")))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package imageutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	ociutils "github.com/ironcore-dev/provider-utils/ociutils/oci"
+)
+
+const (
+	ArchitectureAMD64 = "amd64"
+	ArchitectureARM64 = "arm64"
+)
+
+var (
+	ErrArchitectureUnknown  = errors.New("image architecture unknown")
+	ErrArchitectureMismatch = errors.New("image architecture mismatch")
+
+	// arm64 kernel images carry the magic "ARM\x64" at offset 0x38.
+	arm64KernelMagic       = []byte("ARM\x64")
+	arm64KernelMagicOffset = int64(0x38)
+
+	// x86 boot protocol kernels carry the signature "HdrS" at offset 0x202.
+	x86KernelMagic       = []byte("HdrS")
+	x86KernelMagicOffset = int64(0x202)
+)
+
+// Architecture determines the architecture of an image from its layer platforms or, as a fallback,
+// from the boot header of its kernel.
+func Architecture(img *ociutils.Image) (string, error) {
+	for _, layer := range []*ociutils.FileLayer{img.RootFS, img.Kernel, img.InitRAMFs, img.SquashFS} {
+		if layer != nil && layer.Descriptor.Platform != nil && layer.Descriptor.Platform.Architecture != "" {
+			return layer.Descriptor.Platform.Architecture, nil
+		}
+	}
+
+	if img.Kernel != nil {
+		return kernelArchitecture(img.Kernel.Path)
+	}
+
+	return "", ErrArchitectureUnknown
+}
+
+func ValidateArchitecture(img *ociutils.Image, architecture string) error {
+	imageArchitecture, err := Architecture(img)
+	if err != nil {
+		return err
+	}
+
+	if imageArchitecture != architecture {
+		return fmt.Errorf("%w: image is %s, host is %s", ErrArchitectureMismatch, imageArchitecture, architecture)
+	}
+	return nil
+}
+
+func kernelArchitecture(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error opening kernel: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	for arch, magic := range map[string]struct {
+		value  []byte
+		offset int64
+	}{
+		ArchitectureARM64: {arm64KernelMagic, arm64KernelMagicOffset},
+		ArchitectureAMD64: {x86KernelMagic, x86KernelMagicOffset},
+	} {
+		buf := make([]byte, len(magic.value))
+		if _, err := f.ReadAt(buf, magic.offset); err != nil {
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+			return "", fmt.Errorf("error reading kernel header: %w", err)
+		}
+		if bytes.Equal(buf, magic.value) {
+			return arch, nil
+		}
+	}
+
+	return "", ErrArchitectureUnknown
+}
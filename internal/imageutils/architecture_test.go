@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package imageutils_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/imageutils"
+	ociutils "github.com/ironcore-dev/provider-utils/ociutils/oci"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var _ = Describe("Architecture", func() {
+	writeKernel := func(magic string, offset int) string {
+		data := make([]byte, 4096)
+		copy(data[offset:], magic)
+
+		path := filepath.Join(GinkgoT().TempDir(), "kernel")
+		Expect(os.WriteFile(path, data, 0600)).To(Succeed())
+		return path
+	}
+
+	It("should detect an arm64 kernel", func() {
+		img := &ociutils.Image{Kernel: &ociutils.FileLayer{Path: writeKernel("ARM\x64", 0x38)}}
+		Expect(imageutils.Architecture(img)).To(Equal(imageutils.ArchitectureARM64))
+	})
+
+	It("should detect an amd64 kernel", func() {
+		img := &ociutils.Image{Kernel: &ociutils.FileLayer{Path: writeKernel("HdrS", 0x202)}}
+		Expect(imageutils.Architecture(img)).To(Equal(imageutils.ArchitectureAMD64))
+	})
+
+	It("should prefer the layer platform", func() {
+		img := &ociutils.Image{RootFS: &ociutils.FileLayer{
+			Descriptor: ocispecv1.Descriptor{Platform: &ocispecv1.Platform{Architecture: "arm64"}},
+		}}
+		Expect(imageutils.Architecture(img)).To(Equal(imageutils.ArchitectureARM64))
+	})
+
+	It("should fail validating an image of a different architecture", func() {
+		img := &ociutils.Image{Kernel: &ociutils.FileLayer{Path: writeKernel("HdrS", 0x202)}}
+		Expect(imageutils.ValidateArchitecture(img, imageutils.ArchitectureARM64)).
+			To(MatchError(imageutils.ErrArchitectureMismatch))
+		Expect(imageutils.ValidateArchitecture(img, imageutils.ArchitectureAMD64)).To(Succeed())
+	})
+
+	It("should report an unknown architecture", func() {
+		img := &ociutils.Image{RootFS: &ociutils.FileLayer{Path: "/dev/null"}}
+		Expect(imageutils.ValidateArchitecture(img, imageutils.ArchitectureAMD64)).
+			To(MatchError(imageutils.ErrArchitectureUnknown))
+	})
+})
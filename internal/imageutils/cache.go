@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package imageutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/go-logr/logr"
+	ironcoreimage "github.com/ironcore-dev/ironcore-image"
+	"github.com/ironcore-dev/ironcore-image/oci/image"
+	"github.com/ironcore-dev/ironcore-image/oci/indexer"
+	"github.com/ironcore-dev/ironcore-image/oci/remote"
+	"github.com/ironcore-dev/ironcore-image/oci/store"
+	ociutils "github.com/ironcore-dev/provider-utils/ociutils/oci"
+)
+
+var (
+	ErrImagePullTimeout = errors.New("image pull timed out")
+	// ErrImageNotFound is returned for images neither present in the store nor in their registry.
+	ErrImageNotFound = errors.New("image not found")
+)
+
+type pull struct {
+	err error
+}
+
+// PullTimeoutCache pulls missing images itself, cancelling every pull that exceeds the pull timeout,
+// and serves images present in the store from the wrapped cache.
+type PullTimeoutCache struct {
+	log logr.Logger
+
+	cache    *ociutils.LocalCache
+	registry *remote.Registry
+	store    *store.Store
+	timeout  time.Duration
+
+	mu        sync.Mutex
+	ctx       context.Context
+	pulls     map[string]*pull
+	listeners []ociutils.Listener
+}
+
+func NewPullTimeoutCache(
+	log logr.Logger,
+	cache *ociutils.LocalCache,
+	registry *remote.Registry,
+	store *store.Store,
+	timeout time.Duration,
+) *PullTimeoutCache {
+	return &PullTimeoutCache{
+		log:      log,
+		cache:    cache,
+		registry: registry,
+		store:    store,
+		timeout:  timeout,
+		pulls:    make(map[string]*pull),
+	}
+}
+
+func (c *PullTimeoutCache) Start(ctx context.Context) error {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.mu.Unlock()
+
+	return c.cache.Start(ctx)
+}
+
+func (c *PullTimeoutCache) Get(ctx context.Context, ref string) (*ociutils.Image, error) {
+	c.mu.Lock()
+	if c.ctx == nil {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("need to start cache first")
+	}
+	if p, ok := c.pulls[ref]; ok {
+		if p.err != nil {
+			// Report the failed pull once, the next request starts a new pull.
+			delete(c.pulls, ref)
+			c.mu.Unlock()
+			return nil, p.err
+		}
+		c.mu.Unlock()
+		return nil, ociutils.ErrImagePulling
+	}
+	c.mu.Unlock()
+
+	if _, err := c.store.Resolve(ctx, ref); err != nil {
+		if !errors.Is(err, indexer.ErrNotFound) {
+			return nil, fmt.Errorf("error resolving %s: %w", ref, err)
+		}
+		c.startPull(ref)
+		return nil, ociutils.ErrImagePulling
+	}
+
+	img, err := c.cache.Get(ctx, ref)
+	if err != nil {
+		if !errdefs.IsNotFound(err) {
+			return nil, err
+		}
+	} else if !isIncomplete(img) {
+		return img, nil
+	}
+
+	// The content of the image was deleted from the store while its reference was kept, pull it again.
+	c.log.V(1).Info("Image content is missing in the store, pulling again", "Ref", ref)
+	if err := c.store.Delete(ctx, ref); err != nil {
+		return nil, fmt.Errorf("error deleting incomplete image %s from local store: %w", ref, err)
+	}
+	c.startPull(ref)
+	return nil, ociutils.ErrImagePulling
+}
+
+// isIncomplete reports whether a layer file of the image is missing in the store.
+func isIncomplete(img *ociutils.Image) bool {
+	for _, layer := range []*ociutils.FileLayer{img.RootFS, img.Kernel, img.InitRAMFs, img.SquashFS} {
+		if layer == nil {
+			continue
+		}
+		if _, err := os.Stat(layer.Path); errors.Is(err, os.ErrNotExist) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *PullTimeoutCache) startPull(ref string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.pulls[ref]; ok {
+		return
+	}
+	p := &pull{}
+	c.pulls[ref] = p
+
+	ctx := withMediaTypeKeyPrefixes(c.ctx)
+	go func() {
+		err := c.pullImage(ctx, ref)
+
+		c.mu.Lock()
+		if err != nil {
+			p.err = err
+		} else {
+			delete(c.pulls, ref)
+		}
+		listeners := c.listeners
+		c.mu.Unlock()
+
+		for _, listener := range listeners {
+			listener.HandlePullDone(ociutils.PullDoneEvent{Ref: ref})
+		}
+	}()
+}
+
+func (c *PullTimeoutCache) pullImage(ctx context.Context, ref string) error {
+	log := c.log.WithValues("Ref", ref)
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	log.V(1).Info("Start pulling", "timeout", c.timeout)
+	sourceImg, err := image.Copy(ctx, c.store, c.registry, ref)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w: %s not pulled within %s", ErrImagePullTimeout, ref, c.timeout)
+		} else if errdefs.IsNotFound(err) {
+			err = fmt.Errorf("%w: %s is missing in the store and its registry", ErrImageNotFound, ref)
+		} else {
+			err = fmt.Errorf("error pulling %s: %w", ref, err)
+		}
+		log.Error(err, "Failed to pull image")
+		return err
+	}
+
+	ociImg, err := c.store.Resolve(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("error resolving ref locally %s: %w", ref, err)
+	}
+	if srcDigest, copiedDigest := sourceImg.Descriptor().Digest, ociImg.Descriptor().Digest; srcDigest != copiedDigest {
+		if err := c.store.Delete(ctx, ref); err != nil {
+			return fmt.Errorf("error deleting image %s from local store: %w", ref, err)
+		}
+		return fmt.Errorf("digest verification failed for %s: source digest %s, copied digest %s",
+			ref, srcDigest, copiedDigest)
+	}
+
+	log.V(1).Info("Successfully pulled")
+	return nil
+}
+
+func (c *PullTimeoutCache) AddListener(listener ociutils.Listener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+// withMediaTypeKeyPrefixes registers the ironcore media types with the content fetcher, the same way
+// the wrapped cache does for its pulls.
+func withMediaTypeKeyPrefixes(ctx context.Context) context.Context {
+	mediaTypeToPrefix := map[string]string{
+		ironcoreimage.ConfigMediaType:         "config",
+		ironcoreimage.InitRAMFSLayerMediaType: "layer",
+		ironcoreimage.KernelLayerMediaType:    "layer",
+		ironcoreimage.RootFSLayerMediaType:    "layer",
+		ironcoreimage.SquashFSLayerMediaType:  "layer",
+	}
+	for mediaType, prefix := range mediaTypeToPrefix {
+		ctx = remotes.WithMediaTypeKeyPrefix(ctx, mediaType, prefix)
+	}
+	return ctx
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package imageutils_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/imageutils"
+	ironcoreimage "github.com/ironcore-dev/ironcore-image"
+	"github.com/ironcore-dev/ironcore-image/oci/imageutil"
+	"github.com/ironcore-dev/ironcore-image/oci/remote"
+	"github.com/ironcore-dev/ironcore-image/oci/store"
+	ociutils "github.com/ironcore-dev/provider-utils/ociutils/oci"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PullTimeoutCache", func() {
+	It("should cancel a pull from a stalled registry at the timeout", func(ctx SpecContext) {
+		By("starting a registry that accepts connections but never answers")
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(listener.Close)
+
+		var closeOnce sync.Once
+		disconnected := make(chan struct{})
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer func() { _ = conn.Close() }()
+					_, _ = io.Copy(io.Discard, conn)
+					closeOnce.Do(func() { close(disconnected) })
+				}()
+			}
+		}()
+
+		By("starting the cache")
+		reg, err := remote.DockerRegistry()
+		Expect(err).NotTo(HaveOccurred())
+		st, err := store.New(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		localCache, err := ociutils.NewLocalCache(logr.Discard(), reg, st, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		const timeout = 500 * time.Millisecond
+		cache := imageutils.NewPullTimeoutCache(logr.Discard(), localCache, reg, st, timeout)
+		pullDone := make(chan string, 1)
+		cache.AddListener(ociutils.ListenerFuncs{
+			HandlePullDoneFunc: func(evt ociutils.PullDoneEvent) {
+				pullDone <- evt.Ref
+			},
+		})
+		go func() {
+			defer GinkgoRecover()
+			Expect(cache.Start(ctx)).To(Succeed())
+		}()
+
+		By("requesting an image from the stalled registry")
+		ref := fmt.Sprintf("%s/stalled:latest", listener.Addr())
+		Eventually(func() error {
+			_, err := cache.Get(ctx, ref)
+			return err
+		}).Should(MatchError(ociutils.ErrImagePulling))
+		start := time.Now()
+
+		By("ensuring the pull is reported as pulling until the timeout")
+		_, err = cache.Get(ctx, ref)
+		Expect(err).To(MatchError(ociutils.ErrImagePulling))
+
+		By("ensuring the pull is cancelled at the timeout")
+		Eventually(pullDone).Should(Receive(Equal(ref)))
+		Expect(time.Since(start)).To(BeNumerically(">=", timeout))
+		Eventually(disconnected).Should(BeClosed())
+
+		By("ensuring the pull is marked failed once")
+		_, err = cache.Get(ctx, ref)
+		Expect(err).To(MatchError(imageutils.ErrImagePullTimeout))
+		_, err = cache.Get(ctx, ref)
+		Expect(err).To(MatchError(ociutils.ErrImagePulling))
+	})
+	It("should pull an image again whose content was deleted from the store", func(ctx SpecContext) {
+		By("starting a registry that no longer has any image")
+		registry := httptest.NewServer(http.NotFoundHandler())
+		DeferCleanup(registry.Close)
+
+		By("starting the cache")
+		reg, err := remote.DockerRegistry()
+		Expect(err).NotTo(HaveOccurred())
+		st, err := store.New(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		localCache, err := ociutils.NewLocalCache(logr.Discard(), reg, st, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		cache := imageutils.NewPullTimeoutCache(logr.Discard(), localCache, reg, st, time.Minute)
+		pullDone := make(chan string, 1)
+		cache.AddListener(ociutils.ListenerFuncs{
+			HandlePullDoneFunc: func(evt ociutils.PullDoneEvent) {
+				pullDone <- evt.Ref
+			},
+		})
+		go func() {
+			defer GinkgoRecover()
+			Expect(cache.Start(ctx)).To(Succeed())
+		}()
+
+		By("storing the image as if it was pulled before")
+		ref := fmt.Sprintf("%s/deleted:latest", strings.TrimPrefix(registry.URL, "http://"))
+		img, err := imageutil.NewJSONConfigBuilder(ironcoreimage.Config{},
+			imageutil.WithMediaType(ironcoreimage.ConfigMediaType)).
+			BytesLayer([]byte("rootfs"), imageutil.WithMediaType(ironcoreimage.RootFSLayerMediaType)).
+			Complete()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(st.Push(ctx, ref, img)).To(Succeed())
+
+		var cached *ociutils.Image
+		Eventually(func() error {
+			cached, err = cache.Get(ctx, ref)
+			return err
+		}).Should(Succeed())
+
+		By("deleting the content of the image from the store")
+		Expect(os.Remove(cached.RootFS.Path)).To(Succeed())
+
+		By("ensuring the image is pulled again")
+		_, err = cache.Get(ctx, ref)
+		Expect(err).To(MatchError(ociutils.ErrImagePulling))
+		Eventually(pullDone).Should(Receive(Equal(ref)))
+
+		By("ensuring the image is reported missing in the registry")
+		_, err = cache.Get(ctx, ref)
+		Expect(err).To(MatchError(imageutils.ErrImageNotFound))
+	})
+})
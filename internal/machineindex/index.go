@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package machineindex
+
+import (
+	"sync"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/provider-utils/eventutils/event"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+)
+
+type entry struct {
+	image  string
+	socket string
+}
+
+// Index maps boot images and api sockets to the machines referencing them. It is kept up to date by
+// handling the events of the machine store.
+type Index struct {
+	mu       sync.RWMutex
+	machines map[string]entry
+	images   map[string]sets.Set[string]
+	sockets  map[string]string
+}
+
+func New() *Index {
+	return &Index{
+		machines: make(map[string]entry),
+		images:   make(map[string]sets.Set[string]),
+		sockets:  make(map[string]string),
+	}
+}
+
+func (i *Index) Handle(evt event.Event[*api.Machine]) {
+	if evt.Type == event.TypeDeleted {
+		i.Remove(evt.Object.ID)
+		return
+	}
+	i.Set(evt.Object)
+}
+
+// Set indexes the machine, replacing its previous image and socket.
+func (i *Index) Set(machine *api.Machine) {
+	e := entry{
+		image:  ptr.Deref(api.HasBootImage(machine), ""),
+		socket: ptr.Deref(machine.Spec.ApiSocketPath, ""),
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(machine.ID)
+	i.machines[machine.ID] = e
+	if e.image != "" {
+		ids, ok := i.images[e.image]
+		if !ok {
+			ids = sets.New[string]()
+			i.images[e.image] = ids
+		}
+		ids.Insert(machine.ID)
+	}
+	if e.socket != "" {
+		i.sockets[e.socket] = machine.ID
+	}
+}
+
+func (i *Index) Remove(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(id)
+}
+
+func (i *Index) remove(id string) {
+	e, ok := i.machines[id]
+	if !ok {
+		return
+	}
+	delete(i.machines, id)
+
+	if ids, ok := i.images[e.image]; ok {
+		ids.Delete(id)
+		if ids.Len() == 0 {
+			delete(i.images, e.image)
+		}
+	}
+	if i.sockets[e.socket] == id {
+		delete(i.sockets, e.socket)
+	}
+}
+
+// MachinesByImage returns the ids of the machines booting from the image.
+func (i *Index) MachinesByImage(image string) []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return sets.List(i.images[image])
+}
+
+// MachineBySocket returns the id of the machine holding the api socket.
+func (i *Index) MachineBySocket(socket string) (string, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	id, ok := i.sockets[socket]
+	return id, ok
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package machineindex_test
+
+import (
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/machineindex"
+	apiutils "github.com/ironcore-dev/provider-utils/apiutils/api"
+	"github.com/ironcore-dev/provider-utils/eventutils/event"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+func newMachine(id, image, socket string) *api.Machine {
+	machine := &api.Machine{Metadata: apiutils.Metadata{ID: id}}
+	if image != "" {
+		machine.Spec.Volumes = []*api.VolumeSpec{{
+			Name:      "root",
+			LocalDisk: &api.LocalDiskSpec{Image: ptr.To(image)},
+		}}
+	}
+	if socket != "" {
+		machine.Spec.ApiSocketPath = ptr.To(socket)
+	}
+	return machine
+}
+
+var _ = Describe("Index", func() {
+	It("should return the right machines after create, update and delete churn", func() {
+		index := machineindex.New()
+		machineBySocket := func(socket string) string {
+			id, _ := index.MachineBySocket(socket)
+			return id
+		}
+
+		By("creating machines")
+		index.Handle(event.Event[*api.Machine]{Type: event.TypeCreated, Object: newMachine("a", "image-1", "")})
+		index.Handle(event.Event[*api.Machine]{Type: event.TypeCreated, Object: newMachine("b", "image-1", "")})
+		index.Handle(event.Event[*api.Machine]{Type: event.TypeCreated, Object: newMachine("c", "image-2", "")})
+		Expect(index.MachinesByImage("image-1")).To(ConsistOf("a", "b"))
+		Expect(index.MachinesByImage("image-2")).To(ConsistOf("c"))
+
+		By("assigning sockets")
+		index.Handle(event.Event[*api.Machine]{Type: event.TypeUpdated, Object: newMachine("a", "image-1", "sock-1")})
+		index.Handle(event.Event[*api.Machine]{Type: event.TypeGeneric, Object: newMachine("c", "image-2", "sock-2")})
+		Expect(machineBySocket("sock-1")).To(Equal("a"))
+		Expect(machineBySocket("sock-2")).To(Equal("c"))
+
+		By("moving a machine to another image and socket")
+		index.Handle(event.Event[*api.Machine]{Type: event.TypeUpdated, Object: newMachine("a", "image-2", "sock-3")})
+		Expect(index.MachinesByImage("image-1")).To(ConsistOf("b"))
+		Expect(index.MachinesByImage("image-2")).To(ConsistOf("a", "c"))
+		Expect(machineBySocket("sock-1")).To(BeEmpty())
+		Expect(machineBySocket("sock-3")).To(Equal("a"))
+
+		By("deleting machines")
+		index.Handle(event.Event[*api.Machine]{Type: event.TypeDeleted, Object: newMachine("b", "image-1", "")})
+		index.Handle(event.Event[*api.Machine]{Type: event.TypeDeleted, Object: newMachine("c", "image-2", "sock-2")})
+		Expect(index.MachinesByImage("image-1")).To(BeEmpty())
+		Expect(index.MachinesByImage("image-2")).To(ConsistOf("a"))
+		Expect(machineBySocket("sock-2")).To(BeEmpty())
+		Expect(machineBySocket("sock-3")).To(Equal("a"))
+	})
+})
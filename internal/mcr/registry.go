@@ -5,6 +5,8 @@ package mcr
 
 import (
 	"fmt"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
 )
 
 type MachineClassRegistry interface {
@@ -16,6 +18,8 @@ type MachineClass struct {
 	Name        string
 	Cpu         int64
 	MemoryBytes int64
+	QoSClass    api.QoSClass
+	NumaNode    *int
 }
 
 func NewMachineClassRegistry(classes []MachineClass) (*Mcr, error) {
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const socketsSubsystem = "machine_sockets"
+
+var (
+	socketsActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Subsystem: socketsSubsystem,
+		Name:      "active",
+		Help:      "Number of sockets in the machine dirs owned by a live machine, as of the last scan.",
+	})
+
+	socketsLeaked = prometheus.NewGauge(prometheus.GaugeOpts{
+		Subsystem: socketsSubsystem,
+		Name:      "leaked",
+		Help:      "Number of sockets in the machine dirs whose machine is gone, as of the last scan.",
+	})
+
+	socketsRemoved = prometheus.NewCounter(prometheus.CounterOpts{
+		Subsystem: socketsSubsystem,
+		Name:      "removed_total",
+		Help:      "Total number of leaked sockets removed from the machine dirs.",
+	})
+)
+
+// SetMachineSockets reports the number of active and leaked sockets found by a scan of the machine dirs.
+func SetMachineSockets(active, leaked int) {
+	socketsActive.Set(float64(active))
+	socketsLeaked.Set(float64(leaked))
+}
+
+// AddRemovedMachineSockets counts leaked sockets removed from the machine dirs.
+func AddRemovedMachineSockets(removed int) {
+	socketsRemoved.Add(float64(removed))
+}
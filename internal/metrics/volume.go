@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"sync"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	volumeSubsystem = "volume"
+	machineIDLabel  = "machine_id"
+	volumeNameLabel = "volume"
+)
+
+var (
+	volumeReadBytes = prometheus.NewDesc(
+		prometheus.BuildFQName("", volumeSubsystem, "read_bytes_total"),
+		"Bytes the guest read from the volume, as last reported by the volume backend.",
+		[]string{machineIDLabel, volumeNameLabel}, nil,
+	)
+	volumeWrittenBytes = prometheus.NewDesc(
+		prometheus.BuildFQName("", volumeSubsystem, "written_bytes_total"),
+		"Bytes the guest wrote to the volume, as last reported by the volume backend.",
+		[]string{machineIDLabel, volumeNameLabel}, nil,
+	)
+	volumeReadOperations = prometheus.NewDesc(
+		prometheus.BuildFQName("", volumeSubsystem, "read_operations_total"),
+		"Read operations of the guest on the volume, as last reported by the volume backend.",
+		[]string{machineIDLabel, volumeNameLabel}, nil,
+	)
+	volumeWriteOperations = prometheus.NewDesc(
+		prometheus.BuildFQName("", volumeSubsystem, "write_operations_total"),
+		"Write operations of the guest on the volume, as last reported by the volume backend.",
+		[]string{machineIDLabel, volumeNameLabel}, nil,
+	)
+)
+
+var volumeStats = &volumeStatsCollector{stats: make(map[string]map[string]volume.Stats)}
+
+// SetMachineVolumeStats replaces the reported io stats of the volumes of the machine. Volumes missing from
+// stats are no longer reported.
+func SetMachineVolumeStats(machineID string, stats map[string]volume.Stats) {
+	volumeStats.mu.Lock()
+	defer volumeStats.mu.Unlock()
+
+	if len(stats) == 0 {
+		delete(volumeStats.stats, machineID)
+		return
+	}
+	volumeStats.stats[machineID] = stats
+}
+
+// DeleteMachineVolumeStats stops reporting the io stats of the volumes of the machine.
+func DeleteMachineVolumeStats(machineID string) {
+	SetMachineVolumeStats(machineID, nil)
+}
+
+// volumeStatsCollector reports the io stats of the volumes last polled from their backends.
+type volumeStatsCollector struct {
+	mu    sync.Mutex
+	stats map[string]map[string]volume.Stats
+}
+
+func (c *volumeStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- volumeReadBytes
+	ch <- volumeWrittenBytes
+	ch <- volumeReadOperations
+	ch <- volumeWriteOperations
+}
+
+func (c *volumeStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for machineID, volumes := range c.stats {
+		for name, stats := range volumes {
+			for desc, value := range map[*prometheus.Desc]int64{
+				volumeReadBytes:       stats.ReadBytes,
+				volumeWrittenBytes:    stats.WrittenBytes,
+				volumeReadOperations:  stats.ReadOperations,
+				volumeWriteOperations: stats.WriteOperations,
+			} {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), machineID, name)
+			}
+		}
+	}
+}
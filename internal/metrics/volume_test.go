@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics_test
+
+import (
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/metrics"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// volumeMetrics returns the values of the counter by volume name for the machine.
+func volumeMetrics(metricName, machineID string) map[string]float64 {
+	families, err := metrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != metricName {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["machine_id"] == machineID {
+				values[labels["volume"]] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return values
+}
+
+var _ = Describe("SetMachineVolumeStats", func() {
+	It("should report the io stats of the volumes of the machine", func() {
+		const machineID = "machine-stats"
+		DeferCleanup(metrics.DeleteMachineVolumeStats, machineID)
+
+		metrics.SetMachineVolumeStats(machineID, map[string]volume.Stats{
+			"root": {ReadBytes: 4096, WrittenBytes: 8192, ReadOperations: 1, WriteOperations: 2},
+			"data": {ReadBytes: 512},
+		})
+		Expect(volumeMetrics("volume_read_bytes_total", machineID)).To(Equal(map[string]float64{"root": 4096, "data": 512}))
+		Expect(volumeMetrics("volume_written_bytes_total", machineID)).To(Equal(map[string]float64{"root": 8192, "data": 0}))
+		Expect(volumeMetrics("volume_read_operations_total", machineID)).To(HaveKeyWithValue("root", 1.0))
+		Expect(volumeMetrics("volume_write_operations_total", machineID)).To(HaveKeyWithValue("root", 2.0))
+
+		By("dropping a volume no longer reported")
+		metrics.SetMachineVolumeStats(machineID, map[string]volume.Stats{
+			"root": {ReadBytes: 16384},
+		})
+		Expect(volumeMetrics("volume_read_bytes_total", machineID)).To(Equal(map[string]float64{"root": 16384}))
+
+		By("deleting the stats of the machine")
+		metrics.DeleteMachineVolumeStats(machineID)
+		Expect(volumeMetrics("volume_read_bytes_total", machineID)).To(BeEmpty())
+	})
+})
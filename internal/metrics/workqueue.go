@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	workqueueSubsystem = "workqueue"
+	queueNameLabel     = "name"
+)
+
+var (
+	workqueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "depth",
+		Help:      "Current number of items waiting in the workqueue.",
+	}, []string{queueNameLabel})
+
+	workqueueAdds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "adds_total",
+		Help:      "Total number of items added to the workqueue.",
+	}, []string{queueNameLabel})
+
+	workqueueRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "retries_total",
+		Help:      "Total number of items requeued after a delay.",
+	}, []string{queueNameLabel})
+
+	workqueueLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "queue_duration_seconds",
+		Help:      "Duration items wait in the workqueue before they are processed.",
+		Buckets:   prometheus.ExponentialBuckets(10e-6, 10, 9),
+	}, []string{queueNameLabel})
+
+	workqueueWorkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "work_duration_seconds",
+		Help:      "Duration of processing an item of the workqueue.",
+		Buckets:   prometheus.ExponentialBuckets(10e-6, 10, 9),
+	}, []string{queueNameLabel})
+
+	workqueueUnfinishedWork = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "unfinished_work_seconds",
+		Help:      "Seconds of work in progress that has not been observed by work_duration_seconds yet.",
+	}, []string{queueNameLabel})
+
+	workqueueLongestRunningProcessor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "longest_running_processor_seconds",
+		Help:      "Seconds the longest running processor of the workqueue has been running.",
+	}, []string{queueNameLabel})
+
+	workqueueWorkers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: workqueueSubsystem,
+		Name:      "workers",
+		Help:      "Current number of workers processing the items of the workqueue.",
+	}, []string{queueNameLabel})
+
+	workqueueOldestItemAge = prometheus.NewDesc(
+		prometheus.BuildFQName("", workqueueSubsystem, "oldest_item_age_seconds"),
+		"Seconds the oldest item has been waiting in the workqueue.",
+		[]string{queueNameLabel}, nil,
+	)
+)
+
+func registerWorkqueueMetrics(registerer prometheus.Registerer) {
+	registerer.MustRegister(
+		workqueueDepth,
+		workqueueAdds,
+		workqueueRetries,
+		workqueueLatency,
+		workqueueWorkDuration,
+		workqueueUnfinishedWork,
+		workqueueLongestRunningProcessor,
+		workqueueWorkers,
+		oldestItemAges,
+	)
+}
+
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workqueueLatency.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workqueueWorkDuration.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueLongestRunningProcessor.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(name)
+}
+
+// NewRateLimitingQueue returns a rate limiting queue reporting its depth, adds, retries, latencies and the
+// age of its oldest waiting item, labelled with the queue name.
+func NewRateLimitingQueue[T comparable](
+	name string,
+	rateLimiter workqueue.TypedRateLimiter[T],
+) workqueue.TypedRateLimitingInterface[T] {
+	return NewRateLimitingQueueWithQueue(name, rateLimiter, workqueue.DefaultQueue[T]())
+}
+
+// NewRateLimitingQueueWithQueue is NewRateLimitingQueue ordering the waiting items by the given queue.
+func NewRateLimitingQueueWithQueue[T comparable](
+	name string,
+	rateLimiter workqueue.TypedRateLimiter[T],
+	underlying workqueue.Queue[T],
+) workqueue.TypedRateLimitingInterface[T] {
+	provider := workqueueMetricsProvider{}
+
+	queue := &ageTrackingQueue[T]{
+		TypedInterface: workqueue.NewTypedWithConfig(workqueue.TypedQueueConfig[T]{
+			Name:            name,
+			MetricsProvider: provider,
+			Queue:           underlying,
+		}),
+		addedAt: make(map[T]time.Time),
+	}
+	oldestItemAges.set(name, queue.oldestItemAge)
+
+	return workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter, workqueue.TypedRateLimitingQueueConfig[T]{
+		Name:            name,
+		MetricsProvider: provider,
+		DelayingQueue: workqueue.NewTypedDelayingQueueWithConfig(workqueue.TypedDelayingQueueConfig[T]{
+			Name:            name,
+			MetricsProvider: provider,
+			Queue:           queue,
+		}),
+	})
+}
+
+// TrackWorker reports a worker processing the items of the queue with the name until the returned func is
+// called.
+func TrackWorker(name string) func() {
+	workers := workqueueWorkers.WithLabelValues(name)
+	workers.Inc()
+	return workers.Dec
+}
+
+// ageTrackingQueue records when items become ready to be processed. Items delayed by the rate limiter are
+// added once their delay passed, so their backoff does not count towards their age.
+type ageTrackingQueue[T comparable] struct {
+	workqueue.TypedInterface[T]
+
+	mu      sync.Mutex
+	addedAt map[T]time.Time
+}
+
+func (q *ageTrackingQueue[T]) Add(item T) {
+	if q.ShuttingDown() {
+		return
+	}
+
+	q.mu.Lock()
+	if _, ok := q.addedAt[item]; !ok {
+		q.addedAt[item] = time.Now()
+	}
+	q.mu.Unlock()
+
+	q.TypedInterface.Add(item)
+}
+
+func (q *ageTrackingQueue[T]) Get() (T, bool) {
+	item, shutdown := q.TypedInterface.Get()
+	if !shutdown {
+		q.mu.Lock()
+		delete(q.addedAt, item)
+		q.mu.Unlock()
+	}
+	return item, shutdown
+}
+
+func (q *ageTrackingQueue[T]) oldestItemAge() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var oldest time.Duration
+	now := time.Now()
+	for _, addedAt := range q.addedAt {
+		oldest = max(oldest, now.Sub(addedAt))
+	}
+	return oldest
+}
+
+var oldestItemAges = &oldestItemAgeCollector{ages: make(map[string]func() time.Duration)}
+
+// oldestItemAgeCollector reports the age of the oldest waiting item of every queue at collection time.
+type oldestItemAgeCollector struct {
+	mu   sync.Mutex
+	ages map[string]func() time.Duration
+}
+
+func (c *oldestItemAgeCollector) set(name string, age func() time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ages[name] = age
+}
+
+func (c *oldestItemAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workqueueOldestItemAge
+}
+
+func (c *oldestItemAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, age := range c.ages {
+		ch <- prometheus.MustNewConstMetric(workqueueOldestItemAge, prometheus.GaugeValue, age().Seconds(), name)
+	}
+}
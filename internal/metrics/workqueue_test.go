@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// queueMetric returns the value of the gauge or counter of the queue, or zero if it was not reported yet.
+func queueMetric(metricName, queueName string) float64 {
+	families, err := metrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != metricName {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() != "name" || label.GetValue() != queueName {
+					continue
+				}
+				if metric.GetGauge() != nil {
+					return metric.GetGauge().GetValue()
+				}
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+var _ = Describe("NewRateLimitingQueue", func() {
+	It("should report a growing depth if items are added faster than processed", func() {
+		queue := metrics.NewRateLimitingQueue("test-depth", workqueue.DefaultTypedControllerRateLimiter[string]())
+		DeferCleanup(queue.ShutDown)
+
+		By("processing an item every 100ms")
+		go func() {
+			for {
+				item, shutdown := queue.Get()
+				if shutdown {
+					return
+				}
+				time.Sleep(100 * time.Millisecond)
+				queue.Done(item)
+			}
+		}()
+
+		By("adding an item every 10ms")
+		var depths []float64
+		for i := range 20 {
+			queue.Add(fmt.Sprintf("item-%d", i))
+			time.Sleep(10 * time.Millisecond)
+			depths = append(depths, queueMetric("workqueue_depth", "test-depth"))
+		}
+
+		Expect(depths[len(depths)-1]).To(BeNumerically(">", depths[0]))
+		Expect(queueMetric("workqueue_adds_total", "test-depth")).To(BeEquivalentTo(20))
+		Expect(queueMetric("workqueue_oldest_item_age_seconds", "test-depth")).To(BeNumerically(">", 0))
+	})
+
+	It("should count rate limited items as retries and not age them during their backoff", func() {
+		queue := metrics.NewRateLimitingQueue("test-retries", workqueue.NewTypedItemFastSlowRateLimiter[string](time.Hour, time.Hour, 1))
+		DeferCleanup(queue.ShutDown)
+
+		queue.AddRateLimited("item")
+
+		Eventually(func() float64 {
+			return queueMetric("workqueue_retries_total", "test-retries")
+		}).Should(BeEquivalentTo(1))
+		Expect(queueMetric("workqueue_depth", "test-retries")).To(BeZero())
+		Expect(queueMetric("workqueue_oldest_item_age_seconds", "test-retries")).To(BeZero())
+	})
+})
+
+var _ = Describe("TrackWorker", func() {
+	It("should report the workers of the queue until they stop", func() {
+		var stops []func()
+		for range 3 {
+			stops = append(stops, metrics.TrackWorker("test-workers"))
+		}
+		Expect(queueMetric("workqueue_workers", "test-workers")).To(BeEquivalentTo(3))
+
+		for _, stop := range stops {
+			stop()
+		}
+		Expect(queueMetric("workqueue_workers", "test-workers")).To(BeZero())
+	})
+})
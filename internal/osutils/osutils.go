@@ -4,11 +4,22 @@
 package osutils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
+// DefaultSocketWaitTimeout is the time waited for the socket of a daemon started alongside the provider.
+const DefaultSocketWaitTimeout = 2 * time.Second
+
+const socketPollInterval = 50 * time.Millisecond
+
 func checkStatExists(filename string, check func(stat os.FileInfo) error) (bool, error) {
 	stat, err := os.Stat(filename)
 	if err != nil {
@@ -31,3 +42,152 @@ func RegularFileExists(filename string) (bool, error) {
 		return nil
 	})
 }
+
+func SocketExists(filename string) (bool, error) {
+	return checkStatExists(filename, func(stat os.FileInfo) error {
+		if stat.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("no socket at %s", filename)
+		}
+		return nil
+	})
+}
+
+// WaitForSocket waits for at most timeout until a socket exists at filename.
+func WaitForSocket(ctx context.Context, filename string, timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(ctx, socketPollInterval, timeout, true, func(context.Context) (bool, error) {
+		return SocketExists(filename)
+	})
+	if wait.Interrupted(err) && ctx.Err() == nil {
+		return fmt.Errorf("socket %s did not appear within %s", filename, timeout)
+	}
+	return err
+}
+
+func AllocatedSize(filename string) (int64, error) {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	sys, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("unable to determine allocated blocks of %s", filename)
+	}
+
+	// st_blocks is always reported in 512-byte units, independent of the filesystem block size.
+	return sys.Blocks * 512, nil
+}
+
+func SupportsSparseFiles(dir string) (bool, error) {
+	const probeSize = 16 * 1024 * 1024
+
+	probe, err := os.CreateTemp(dir, ".sparse-probe-*")
+	if err != nil {
+		return false, fmt.Errorf("error creating sparse probe file: %w", err)
+	}
+	defer func() {
+		_ = probe.Close()
+		_ = os.Remove(probe.Name())
+	}()
+
+	if err := probe.Truncate(probeSize); err != nil {
+		return false, fmt.Errorf("error truncating sparse probe file: %w", err)
+	}
+
+	allocated, err := AllocatedSize(probe.Name())
+	if err != nil {
+		return false, err
+	}
+
+	return allocated < probeSize, nil
+}
+
+// SupportsPunchHole reports whether the filesystem of dir can deallocate ranges of files, which is how discards
+// of the guest free the blocks of file backed disks.
+func SupportsPunchHole(dir string) (bool, error) {
+	const probeSize = 64 * 1024
+
+	probe, err := os.CreateTemp(dir, ".punch-hole-probe-*")
+	if err != nil {
+		return false, fmt.Errorf("error creating punch hole probe file: %w", err)
+	}
+	defer func() {
+		_ = probe.Close()
+		_ = os.Remove(probe.Name())
+	}()
+
+	if _, err := probe.Write(make([]byte, probeSize)); err != nil {
+		return false, fmt.Errorf("error writing punch hole probe file: %w", err)
+	}
+
+	if err := unix.Fallocate(int(probe.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, 0, probeSize); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error punching hole into probe file: %w", err)
+	}
+	return true, nil
+}
+
+// IsNoSpace reports whether err was caused by the filesystem running out of space.
+func IsNoSpace(err error) bool {
+	return errors.Is(err, unix.ENOSPC) || errors.Is(err, unix.EDQUOT)
+}
+
+// FreeDiskSpace returns the bytes available to unprivileged users on the filesystem of path.
+func FreeDiskSpace(path string) (int64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, fmt.Errorf("error stat-ing filesystem of %s: %w", path, err)
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// SupportsReflink reports whether the filesystem of dir supports cloning files with Reflink.
+func SupportsReflink(dir string) (bool, error) {
+	src, err := os.CreateTemp(dir, ".reflink-probe-*")
+	if err != nil {
+		return false, fmt.Errorf("error creating reflink probe file: %w", err)
+	}
+	defer func() {
+		_ = src.Close()
+		_ = os.Remove(src.Name())
+	}()
+
+	if _, err := src.Write([]byte("reflink probe")); err != nil {
+		return false, fmt.Errorf("error writing reflink probe file: %w", err)
+	}
+
+	dst := src.Name() + ".clone"
+	if err := Reflink(src.Name(), dst); err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EXDEV) ||
+			errors.Is(err, unix.ENOTTY) {
+			return false, nil
+		}
+		return false, err
+	}
+	_ = os.Remove(dst)
+	return true, nil
+}
+
+// Reflink creates dst as a copy-on-write clone of src. It fails if the filesystem does not support
+// sharing extents between files, in which case dst is not left behind.
+func Reflink(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = srcFile.Close() }()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+
+	if err := unix.IoctlFileClone(int(dstFile.Fd()), int(srcFile.Fd())); err != nil {
+		_ = dstFile.Close()
+		_ = os.Remove(dst)
+		return fmt.Errorf("error cloning %s: %w", src, err)
+	}
+	return dstFile.Close()
+}
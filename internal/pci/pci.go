@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pci
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	DefaultSysfsDevicesPath = "/sys/bus/pci/devices"
+
+	vfioDriver = "vfio-pci"
+)
+
+var (
+	ErrNotFound       = errors.New("pci device not found")
+	ErrAlreadyInUse   = errors.New("pci device already in use")
+	ErrNotBoundToVFIO = errors.New("pci device not bound to vfio-pci")
+
+	bdfRegex = regexp.MustCompile(`^[0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-7]$`)
+)
+
+func ValidateBDF(bdf string) error {
+	if !bdfRegex.MatchString(bdf) {
+		return fmt.Errorf("invalid pci address %q: expected format dddd:bb:dd.f", bdf)
+	}
+	return nil
+}
+
+func DevicePath(sysfsDevicesPath, bdf string) string {
+	return filepath.Join(sysfsDevicesPath, bdf) + "/"
+}
+
+type ManagerOptions struct {
+	SysfsDevicesPath string
+	// Devices are the pci addresses available for passthrough.
+	Devices []string
+}
+
+func setOptionsDefaults(o *ManagerOptions) {
+	if o.SysfsDevicesPath == "" {
+		o.SysfsDevicesPath = DefaultSysfsDevicesPath
+	}
+}
+
+func NewManager(log logr.Logger, opts ManagerOptions) (*Manager, error) {
+	setOptionsDefaults(&opts)
+
+	m := &Manager{
+		log:              log,
+		sysfsDevicesPath: opts.SysfsDevicesPath,
+		assigned:         make(map[string]string),
+	}
+
+	for _, bdf := range opts.Devices {
+		if err := ValidateBDF(bdf); err != nil {
+			return nil, err
+		}
+		m.assigned[bdf] = ""
+	}
+
+	return m, nil
+}
+
+type Manager struct {
+	log logr.Logger
+
+	sysfsDevicesPath string
+
+	// assigned maps the available pci addresses to the machine they are assigned to, or to an empty string.
+	assigned map[string]string
+	mu       sync.Mutex
+}
+
+func (m *Manager) SysfsDevicesPath() string {
+	return m.sysfsDevicesPath
+}
+
+// Assign claims the given pci devices for the machine. Either all or none of the devices get assigned.
+func (m *Manager) Assign(machineID string, bdfs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, bdf := range bdfs {
+		owner, ok := m.assigned[bdf]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrNotFound, bdf)
+		}
+		if owner != "" && owner != machineID {
+			return fmt.Errorf("%w: %s assigned to machine %s", ErrAlreadyInUse, bdf, owner)
+		}
+
+		if err := m.checkVFIO(bdf); err != nil {
+			return err
+		}
+	}
+
+	for _, bdf := range bdfs {
+		m.assigned[bdf] = machineID
+	}
+	m.log.V(1).Info("Assigned pci devices", "machineID", machineID, "bdfs", bdfs)
+
+	return nil
+}
+
+// Reserve records devices already assigned to a machine, e.g. when restoring state on startup.
+func (m *Manager) Reserve(machineID string, bdfs []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, bdf := range bdfs {
+		if _, ok := m.assigned[bdf]; !ok {
+			m.log.V(1).Info("Reserved pci device is not available for passthrough", "bdf", bdf, "machineID", machineID)
+			continue
+		}
+		m.assigned[bdf] = machineID
+	}
+}
+
+func (m *Manager) Release(machineID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for bdf, owner := range m.assigned {
+		if owner == machineID {
+			m.assigned[bdf] = ""
+			m.log.V(1).Info("Released pci device", "machineID", machineID, "bdf", bdf)
+		}
+	}
+}
+
+func (m *Manager) checkVFIO(bdf string) error {
+	driver, err := os.Readlink(filepath.Join(m.sysfsDevicesPath, bdf, "driver"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrNotBoundToVFIO, bdf)
+		}
+		return fmt.Errorf("error reading driver of pci device %s: %w", bdf, err)
+	}
+
+	if filepath.Base(driver) != vfioDriver {
+		return fmt.Errorf("%w: %s bound to %s", ErrNotBoundToVFIO, bdf, filepath.Base(driver))
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pci_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/pci"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Manager", func() {
+	const (
+		gpu  = "0000:3b:00.0"
+		nic  = "0000:5e:00.1"
+		nvme = "0000:af:00.0"
+	)
+
+	var (
+		sysfsDir string
+		manager  *pci.Manager
+	)
+
+	bindDevice := func(bdf, driver string) {
+		driverDir := filepath.Join(sysfsDir, "drivers", driver)
+		Expect(os.MkdirAll(driverDir, 0755)).To(Succeed())
+
+		deviceDir := filepath.Join(sysfsDir, "devices", bdf)
+		Expect(os.MkdirAll(deviceDir, 0755)).To(Succeed())
+		Expect(os.Symlink(driverDir, filepath.Join(deviceDir, "driver"))).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		sysfsDir = GinkgoT().TempDir()
+		bindDevice(gpu, "vfio-pci")
+		bindDevice(nic, "vfio-pci")
+		bindDevice(nvme, "nvme")
+
+		var err error
+		manager, err = pci.NewManager(logr.Discard(), pci.ManagerOptions{
+			SysfsDevicesPath: filepath.Join(sysfsDir, "devices"),
+			Devices:          []string{gpu, nic, nvme},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject invalid pci addresses", func() {
+		_, err := pci.NewManager(logr.Discard(), pci.ManagerOptions{Devices: []string{"3b:00.0"}})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should assign vfio bound devices", func() {
+		Expect(manager.Assign("machine-a", []string{gpu, nic})).To(Succeed())
+		Expect(manager.Assign("machine-a", []string{gpu, nic})).To(Succeed())
+	})
+
+	It("should prevent double assignment until the devices are released", func() {
+		Expect(manager.Assign("machine-a", []string{gpu})).To(Succeed())
+
+		Expect(manager.Assign("machine-b", []string{nic, gpu})).To(MatchError(pci.ErrAlreadyInUse))
+
+		By("ensuring the failed assignment did not claim any device")
+		Expect(manager.Assign("machine-c", []string{nic})).To(Succeed())
+
+		manager.Release("machine-a")
+		Expect(manager.Assign("machine-b", []string{gpu})).To(Succeed())
+	})
+
+	It("should respect reserved devices", func() {
+		manager.Reserve("machine-a", []string{gpu})
+		Expect(manager.Assign("machine-b", []string{gpu})).To(MatchError(pci.ErrAlreadyInUse))
+	})
+
+	It("should reject unknown devices", func() {
+		Expect(manager.Assign("machine-a", []string{"0000:00:1f.0"})).To(MatchError(pci.ErrNotFound))
+	})
+
+	It("should reject devices not bound to vfio-pci", func() {
+		Expect(manager.Assign("machine-a", []string{nvme})).To(MatchError(pci.ErrNotBoundToVFIO))
+	})
+})
@@ -5,6 +5,8 @@ package ceph
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
@@ -15,7 +17,9 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"k8s.io/utils/lru"
 	"k8s.io/utils/ptr"
 )
 
@@ -26,11 +30,15 @@ const (
 
 	volumeAttributeImageKey     = "image"
 	volumeAttributesMonitorsKey = "monitors"
+	// volumeAttributeSecretNameKey references the secret holding the user of a volume without secret data.
+	volumeAttributeSecretNameKey = "secretName"
 
 	secretUserIDKey  = "userID"
 	secretUserKeyKey = "userKey"
 
 	secretEncryptionKey = "encryptionKey"
+
+	validatedVolumeCacheSize = 1024
 )
 
 type validatedVolume struct {
@@ -42,22 +50,80 @@ type validatedVolume struct {
 	userID        string
 	userKey       string
 	encryptionKey *string
+
+	// readaheadBytes overrides the librbd readahead if set.
+	readaheadBytes int64
+
+	// confPath is set once the ceph conf and key of the volume were written by the provider.
+	confPath string
+}
+
+// cachedVolume is a validated volume along with the hash of the connection it was validated from.
+type cachedVolume struct {
+	connectionHash [sha256.Size]byte
+	volume         *validatedVolume
 }
 
 type Provider interface {
-	Mount(ctx context.Context, machineID string, volume *validatedVolume) (string, error)
+	Mount(ctx context.Context, machineID string, volume *validatedVolume) (string, string, error)
 	Unmount(ctx context.Context, machineID string, volumeID string) error
+	Snapshot(ctx context.Context, machineID string, volumeID string, snapshotName string) error
+	IsHealthy(ctx context.Context, machineID string, volumeID string) (bool, error)
+	Flush(ctx context.Context, machineID string, volume *validatedVolume) error
+	Stats(ctx context.Context, machineID string, volumeID string) (*volume.Stats, error)
+	Migrate(ctx context.Context, machineID string, target *validatedVolume) error
+}
+
+// Options configure the librbd clients of the volumes. Zero timeouts keep the ceph defaults.
+type Options struct {
+	// OSDOpTimeout fails requests to osds not answering in time instead of blocking the io of the vm.
+	OSDOpTimeout time.Duration
+	// MonOpTimeout fails requests to monitors not answering in time.
+	MonOpTimeout time.Duration
+	// ConnectTimeout bounds the time to connect to the cluster.
+	ConnectTimeout time.Duration
+	// SocketWaitTimeout is the time waited for the qmp socket of the qemu-storage-daemon to accept connections.
+	// Defaults to osutils.DefaultSocketWaitTimeout.
+	SocketWaitTimeout time.Duration
 }
 
-func QMPProvider(ctx context.Context, log logr.Logger, paths host.Paths, socket string) (Provider, error) {
-	monitor, err := qmp.NewSocketMonitor("unix", socket, 2*time.Second)
+func validateOptions(opts Options) error {
+	for _, option := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"osd op timeout", opts.OSDOpTimeout},
+		{"mon op timeout", opts.MonOpTimeout},
+		{"connect timeout", opts.ConnectTimeout},
+	} {
+		if option.timeout != 0 && option.timeout < time.Second {
+			return fmt.Errorf("%s must be zero or at least 1s, got %s", option.name, option.timeout)
+		}
+	}
+	return nil
+}
+
+func QMPProvider(ctx context.Context, log logr.Logger, paths host.Paths, socket string, opts Options) (Provider, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, err
+	}
+	if opts.SocketWaitTimeout == 0 {
+		opts.SocketWaitTimeout = osutils.DefaultSocketWaitTimeout
+	}
+
+	if err := osutils.WaitForSocket(ctx, socket, opts.SocketWaitTimeout); err != nil {
+		return nil, fmt.Errorf("failed to wait for qmp socket: %w", err)
+	}
+	monitor, err := qmp.NewSocketMonitor("unix", socket, opts.SocketWaitTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to qmp monitor: %w", err)
 	}
 
+	if err := monitor.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to handshake with qmp monitor: %w", err)
+	}
+
 	go func() {
-		// TODO
-		_ = monitor.Connect()
 		defer func() {
 			// TODO
 			_ = monitor.Disconnect()
@@ -73,18 +139,45 @@ func QMPProvider(ctx context.Context, log logr.Logger, paths host.Paths, socket
 		log:     log,
 		paths:   paths,
 		monitor: monitor,
+		opts:    opts,
 	}, nil
 }
 
 type plugin struct {
 	provider Provider
 	host     volume.Host
+	secrets  *secretCache
+
+	// validatedVolumes caches validated volumes by machine and volume name so steady-state
+	// reconciliations neither validate the connection nor rewrite the ceph key again.
+	validatedVolumes *lru.Cache
+}
+
+type PluginOptions struct {
+	// Secrets resolves the secrets referenced by volumes instead of passing their secret data. Volumes
+	// referencing a secret are rejected without.
+	Secrets SecretGetter
+	// SecretCacheTTL is the time a resolved secret is cached. Defaults to DefaultSecretCacheTTL.
+	SecretCacheTTL time.Duration
 }
 
 func NewPlugin(provider Provider) volume.Plugin {
-	return &plugin{
-		provider: provider,
+	return NewPluginWithOptions(provider, PluginOptions{})
+}
+
+func NewPluginWithOptions(provider Provider, opts PluginOptions) volume.Plugin {
+	if opts.SecretCacheTTL == 0 {
+		opts.SecretCacheTTL = DefaultSecretCacheTTL
+	}
+
+	p := &plugin{
+		provider:         provider,
+		validatedVolumes: lru.New(validatedVolumeCacheSize),
 	}
+	if opts.Secrets != nil {
+		p.secrets = newSecretCache(opts.Secrets, opts.SecretCacheTTL)
+	}
+	return p
 }
 
 func (p *plugin) Init(host volume.Host) error {
@@ -175,27 +268,100 @@ func readVolumeAttributes(attrs map[string]string, volumeData *validatedVolume)
 	return nil
 }
 
+func validatedVolumeKey(machineID, computeVolumeName string) string {
+	return machineID + "/" + computeVolumeName
+}
+
+// connectionHash hashes the connection of the volume along with its resolved secret data, so a rotated
+// referenced secret changes the hash just like rotated inline secret data.
+func connectionHash(spec *api.VolumeSpec, secretData map[string][]byte) ([sha256.Size]byte, error) {
+	data, err := json.Marshal(struct {
+		Connection *api.VolumeConnection
+		Tuning     *api.VolumeTuning
+		SecretData map[string][]byte
+	}{spec.Connection, spec.Tuning, secretData})
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// secretData returns the secret data of the volume connection, resolving the referenced secret if the
+// connection does not carry any.
+func (p *plugin) secretData(ctx context.Context, spec *api.VolumeSpec) (map[string][]byte, error) {
+	connection := spec.Connection
+	if connection == nil || len(connection.SecretData) > 0 {
+		return nil, nil
+	}
+
+	secretName := connection.Attributes[volumeAttributeSecretNameKey]
+	if secretName == "" {
+		return nil, nil
+	}
+	if p.secrets == nil {
+		return nil, fmt.Errorf("volume references secret %s but no secret source is configured", secretName)
+	}
+
+	data, err := p.secrets.Get(ctx, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secret of volume: %w", err)
+	}
+	return data, nil
+}
+
 func (p *plugin) Apply(ctx context.Context, spec *api.VolumeSpec, machineID string) (*api.VolumeStatus, error) {
-	volumeData, err := p.validateVolume(spec)
+	key := validatedVolumeKey(machineID, spec.Name)
+	secretData, err := p.secretData(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := connectionHash(spec, secretData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash volume connection: %w", err)
+	}
+
+	volumeData, err := p.cachedOrValidateVolume(key, hash, spec, secretData)
 	if err != nil {
+		p.validatedVolumes.Remove(key)
 		return nil, fmt.Errorf("failed to get volume data: %w", err)
 	}
 
-	path, err := p.provider.Mount(ctx, machineID, volumeData)
+	path, exportHandle, err := p.provider.Mount(ctx, machineID, volumeData)
 	if err != nil {
+		p.validatedVolumes.Remove(key)
 		return nil, fmt.Errorf("failed to mount volume: %w", err)
 	}
+	p.validatedVolumes.Add(key, &cachedVolume{connectionHash: hash, volume: volumeData})
 
-	return &api.VolumeStatus{
+	status := &api.VolumeStatus{
 		Name:   spec.Name,
 		Type:   api.VolumeSocketType,
 		Path:   path,
-		Handle: volumeData.handle,
+		Handle: exportHandle,
 		State:  api.VolumeStatePrepared,
-	}, nil
+	}
+	// The vm keeps knowing a migrated volume by the handle and socket of its export.
+	if exportHandle != volumeData.handle {
+		status.BackingHandle = volumeData.handle
+	}
+	return status, nil
 }
 
-func (p *plugin) validateVolume(spec *api.VolumeSpec) (vData *validatedVolume, err error) {
+func (p *plugin) cachedOrValidateVolume(
+	key string,
+	hash [sha256.Size]byte,
+	spec *api.VolumeSpec,
+	secretData map[string][]byte,
+) (*validatedVolume, error) {
+	if cached, ok := p.validatedVolumes.Get(key); ok && cached.(*cachedVolume).connectionHash == hash {
+		return cached.(*cachedVolume).volume, nil
+	}
+	return p.validateVolume(spec, secretData)
+}
+
+// validateVolume validates the connection of the volume. The resolved secret data is used if the connection
+// does not carry secret data itself.
+func (p *plugin) validateVolume(spec *api.VolumeSpec, secretData map[string][]byte) (vData *validatedVolume, err error) {
 	connection := spec.Connection
 	if connection == nil {
 		return nil, fmt.Errorf("volume does not specify connection")
@@ -206,7 +372,10 @@ func (p *plugin) validateVolume(spec *api.VolumeSpec) (vData *validatedVolume, e
 	if connection.Attributes == nil {
 		return nil, fmt.Errorf("volume connection does not specify attributes")
 	}
-	if connection.SecretData == nil {
+	if len(connection.SecretData) > 0 {
+		secretData = connection.SecretData
+	}
+	if secretData == nil {
 		return nil, fmt.Errorf("volume connection does not specify secret data")
 	}
 	if connection.Handle == "" {
@@ -217,12 +386,15 @@ func (p *plugin) validateVolume(spec *api.VolumeSpec) (vData *validatedVolume, e
 		name:   spec.Name,
 		handle: connection.Handle,
 	}
+	if spec.Tuning != nil {
+		vData.readaheadBytes = spec.Tuning.ReadaheadBytes
+	}
 
 	if err := readVolumeAttributes(connection.Attributes, vData); err != nil {
 		return nil, fmt.Errorf("error reading volume attributes: %w", err)
 	}
 
-	vData.userID, vData.userKey, err = readSecretData(connection.SecretData)
+	vData.userID, vData.userKey, err = readSecretData(secretData)
 	if err != nil {
 		return nil, fmt.Errorf("error reading secret data: %w", err)
 	}
@@ -238,9 +410,98 @@ func (p *plugin) validateVolume(spec *api.VolumeSpec) (vData *validatedVolume, e
 }
 
 func (p *plugin) Delete(ctx context.Context, computeVolumeName string, machineID string) error {
+	// Drop the cached secrets even if unmounting fails, the next apply validates the volume again.
+	p.validatedVolumes.Remove(validatedVolumeKey(machineID, computeVolumeName))
+
 	if err := p.provider.Unmount(ctx, machineID, computeVolumeName); err != nil {
 		return fmt.Errorf("failed to unmount volume %q: %w", computeVolumeName, err)
 	}
 
 	return os.RemoveAll(p.host.MachineVolumeDir(machineID, cephDriverName, computeVolumeName))
 }
+
+func (p *plugin) IsHealthy(ctx context.Context, computeVolumeName string, machineID string) (bool, error) {
+	healthy, err := p.provider.IsHealthy(ctx, machineID, computeVolumeName)
+	if err != nil {
+		return false, fmt.Errorf("failed to check health of volume %q: %w", computeVolumeName, err)
+	}
+	return healthy, nil
+}
+
+func (p *plugin) Stats(ctx context.Context, computeVolumeName string, machineID string) (*volume.Stats, error) {
+	stats, err := p.provider.Stats(ctx, machineID, computeVolumeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats of volume %q: %w", computeVolumeName, err)
+	}
+	return stats, nil
+}
+
+func (p *plugin) Snapshot(ctx context.Context, computeVolumeName string, machineID string, snapshotName string) error {
+	if err := volume.ValidateSnapshotName(snapshotName); err != nil {
+		return err
+	}
+
+	if err := p.provider.Snapshot(ctx, machineID, computeVolumeName, snapshotName); err != nil {
+		return fmt.Errorf("failed to snapshot volume %q: %w", computeVolumeName, err)
+	}
+	return nil
+}
+
+func (p *plugin) Flush(ctx context.Context, spec *api.VolumeSpec, machineID string) error {
+	secretData, err := p.secretData(ctx, spec)
+	if err != nil {
+		return err
+	}
+	hash, err := connectionHash(spec, secretData)
+	if err != nil {
+		return fmt.Errorf("failed to hash volume connection: %w", err)
+	}
+
+	volumeData, err := p.cachedOrValidateVolume(validatedVolumeKey(machineID, spec.Name), hash, spec, secretData)
+	if err != nil {
+		return fmt.Errorf("failed to get volume data: %w", err)
+	}
+
+	if err := p.provider.Flush(ctx, machineID, volumeData); err != nil {
+		return fmt.Errorf("failed to flush volume %q: %w", spec.Name, err)
+	}
+	return nil
+}
+
+func (p *plugin) Migrate(
+	ctx context.Context,
+	spec *api.VolumeSpec,
+	machineID string,
+	status *api.VolumeStatus,
+) (*api.VolumeStatus, error) {
+	backingHandle := status.BackingHandle
+	if backingHandle == "" {
+		backingHandle = status.Handle
+	}
+	if spec.Connection == nil || spec.Connection.Handle == backingHandle {
+		return nil, nil
+	}
+
+	secretData, err := p.secretData(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	target, err := p.validateVolume(spec, secretData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume data: %w", err)
+	}
+
+	if err := p.provider.Migrate(ctx, machineID, target); err != nil {
+		return nil, fmt.Errorf("failed to migrate volume %q: %w", spec.Name, err)
+	}
+	p.validatedVolumes.Remove(validatedVolumeKey(machineID, spec.Name))
+
+	return &api.VolumeStatus{
+		Name:          spec.Name,
+		Type:          api.VolumeSocketType,
+		Path:          status.Path,
+		Handle:        status.Handle,
+		BackingHandle: target.handle,
+		State:         status.State,
+	}, nil
+}
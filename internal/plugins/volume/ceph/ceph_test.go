@@ -0,0 +1,775 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ceph_test
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume/ceph"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+type qmpCommand struct {
+	Execute   string          `json:"execute"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// fakeQMP is a minimal qmp monitor keeping track of block nodes, exports and mirror jobs.
+type fakeQMP struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	nodes    []ceph.BlockDevice
+	exports  []ceph.BlockExportNode
+	jobs     []ceph.BlockJob
+	mirrors  map[string]ceph.BlockdevMirrorArguments
+	stats    map[string]ceph.BlockStats
+	commands []qmpCommand
+	// failing holds the commands answered with an error.
+	failing map[string]bool
+}
+
+func newFakeQMP(socket string) (*fakeQMP, error) {
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fakeQMP{listener: listener}
+	go f.serve()
+	return f, nil
+}
+
+func (f *fakeQMP) serve() {
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	enc := json.NewEncoder(conn)
+	dec := json.NewDecoder(conn)
+
+	if err := enc.Encode(map[string]any{
+		"QMP": map[string]any{"version": map[string]any{}, "capabilities": []string{}},
+	}); err != nil {
+		return
+	}
+
+	for {
+		var cmd qmpCommand
+		if err := dec.Decode(&cmd); err != nil {
+			return
+		}
+		if f.fails(cmd.Execute) {
+			if err := enc.Encode(map[string]any{
+				"error": map[string]any{"class": "GenericError", "desc": cmd.Execute + " failed"},
+			}); err != nil {
+				return
+			}
+			continue
+		}
+		if err := enc.Encode(map[string]any{"return": f.handle(cmd)}); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeQMP) handle(cmd qmpCommand) any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, cmd)
+
+	switch cmd.Execute {
+	case "query-named-block-nodes":
+		return f.nodes
+	case "query-block-exports":
+		return f.exports
+	case "query-blockstats":
+		var stats []ceph.BlockNodeStats
+		for _, node := range f.nodes {
+			stats = append(stats, ceph.BlockNodeStats{NodeName: node.NodeName, Stats: f.stats[node.NodeName]})
+		}
+		return stats
+	case "blockdev-add":
+		var args ceph.BlockdevAddArguments
+		_ = json.Unmarshal(cmd.Arguments, &args)
+		f.nodes = append(f.nodes, ceph.BlockDevice{
+			NodeName: args.NodeName,
+			Drv:      args.Driver,
+			Cache:    ceph.BlockCache{Direct: args.Cache.Direct, Writeback: true},
+		})
+	case "block-export-add":
+		var args ceph.BlockExportAddArguments
+		_ = json.Unmarshal(cmd.Arguments, &args)
+		f.exports = append(f.exports, ceph.BlockExportNode{ID: args.ID, NodeName: args.NodeName})
+	case "block-export-del":
+		var args ceph.DeleteExportBlockDevArguments
+		_ = json.Unmarshal(cmd.Arguments, &args)
+		f.exports = slices.DeleteFunc(f.exports, func(export ceph.BlockExportNode) bool { return export.ID == args.ID })
+	case "blockdev-del":
+		var args ceph.DeleteBlockDevArguments
+		_ = json.Unmarshal(cmd.Arguments, &args)
+		f.nodes = slices.DeleteFunc(f.nodes, func(node ceph.BlockDevice) bool { return node.NodeName == args.Node })
+	case "blockdev-snapshot-internal-sync":
+		var args ceph.BlockdevSnapshotInternalArguments
+		_ = json.Unmarshal(cmd.Arguments, &args)
+		for i := range f.nodes {
+			if f.nodes[i].NodeName == args.Device {
+				f.nodes[i].Image.Snapshots = append(f.nodes[i].Image.Snapshots, ceph.BlockSnapshot{Name: args.Name})
+			}
+		}
+	case "query-block-jobs":
+		return f.jobs
+	case "blockdev-mirror":
+		var args ceph.BlockdevMirrorArguments
+		_ = json.Unmarshal(cmd.Arguments, &args)
+		if f.mirrors == nil {
+			f.mirrors = make(map[string]ceph.BlockdevMirrorArguments)
+		}
+		f.mirrors[args.JobID] = args
+		f.jobs = append(f.jobs, ceph.BlockJob{Type: "mirror", Device: args.JobID, Status: "running"})
+	case "job-complete":
+		// Completing a mirror replaces the source node by the target node in the export.
+		var args ceph.JobCompleteArguments
+		_ = json.Unmarshal(cmd.Arguments, &args)
+		mirror := f.mirrors[args.ID]
+		for i := range f.exports {
+			if f.exports[i].NodeName == mirror.Device {
+				f.exports[i].NodeName = mirror.Target
+			}
+		}
+		f.jobs = slices.DeleteFunc(f.jobs, func(job ceph.BlockJob) bool { return job.Device == args.ID })
+	}
+	return map[string]any{}
+}
+
+func (f *fakeQMP) Commands(execute string) []qmpCommand {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var res []qmpCommand
+	for _, cmd := range f.commands {
+		if cmd.Execute == execute {
+			res = append(res, cmd)
+		}
+	}
+	return res
+}
+
+func (f *fakeQMP) fails(execute string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failing[execute]
+}
+
+func (f *fakeQMP) SetFailing(execute string, failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failing == nil {
+		f.failing = make(map[string]bool)
+	}
+	f.failing[execute] = failing
+}
+
+func (f *fakeQMP) SetDirect(nodeName string, direct bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.nodes {
+		if f.nodes[i].NodeName == nodeName {
+			f.nodes[i].Cache.Direct = direct
+		}
+	}
+}
+
+func (f *fakeQMP) SetStats(nodeName string, stats ceph.BlockStats) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stats == nil {
+		f.stats = make(map[string]ceph.BlockStats)
+	}
+	f.stats[nodeName] = stats
+}
+
+func (f *fakeQMP) SetJobsReady() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.jobs {
+		f.jobs[i].Ready = true
+		f.jobs[i].Status = "ready"
+	}
+}
+
+func (f *fakeQMP) Exports() []ceph.BlockExportNode {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.exports)
+}
+
+func (f *fakeQMP) NodeNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var names []string
+	for _, node := range f.nodes {
+		names = append(names, node.NodeName)
+	}
+	return names
+}
+
+func (f *fakeQMP) Close() error {
+	return f.listener.Close()
+}
+
+var _ = Describe("Ceph", func() {
+	const machineID = "machine-1"
+
+	var (
+		paths  host.Paths
+		qmp    *fakeQMP
+		plugin volume.Plugin
+	)
+
+	volumeSpec := func(userKey string) *api.VolumeSpec {
+		return &api.VolumeSpec{
+			Name: "data",
+			Connection: &api.VolumeConnection{
+				Driver: "ceph",
+				Handle: "volume-1",
+				Attributes: map[string]string{
+					"monitors": "10.0.0.1:6789",
+					"image":    "pool/image",
+				},
+				SecretData: map[string][]byte{
+					"userID":  []byte("admin"),
+					"userKey": []byte(userKey),
+				},
+			},
+		}
+	}
+
+	// newPluginWithOptions serves a new fake qmp monitor to a new plugin with the given options.
+	newPluginWithOptions := func(
+		ctx SpecContext,
+		opts ceph.Options,
+		pluginOpts ceph.PluginOptions,
+	) (*fakeQMP, volume.Plugin) {
+		// Unix socket paths are length limited, keep the socket in a short temp dir.
+		socketDir, err := os.MkdirTemp("", "qmp")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, socketDir)
+
+		fake, err := newFakeQMP(filepath.Join(socketDir, "qmp.sock"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(fake.Close)
+
+		provider, err := ceph.QMPProvider(ctx, logr.Discard(), paths, filepath.Join(socketDir, "qmp.sock"), opts)
+		Expect(err).NotTo(HaveOccurred())
+
+		plugin := ceph.NewPluginWithOptions(provider, pluginOpts)
+		Expect(plugin.Init(paths)).To(Succeed())
+		return fake, plugin
+	}
+
+	newPlugin := func(ctx SpecContext, opts ceph.Options) (*fakeQMP, volume.Plugin) {
+		return newPluginWithOptions(ctx, opts, ceph.PluginOptions{})
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		var err error
+		paths, err = host.PathsAt(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+
+		qmp, plugin = newPlugin(ctx, ceph.Options{})
+	})
+
+	It("should rewrite the key file and reconnect the block device when the key rotates", func(ctx SpecContext) {
+		By("mounting the volume")
+		status, err := plugin.Apply(ctx, volumeSpec("old-key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Handle).To(Equal("volume-1"))
+		Expect(qmp.Commands("blockdev-add")).To(HaveLen(1))
+		Expect(qmp.Commands("block-export-add")).To(HaveLen(1))
+
+		keyPath := filepath.Join(paths.MachineVolumeDir(machineID, "ceph", "volume-1"), "ceph.key")
+		Expect(os.ReadFile(keyPath)).To(BeEquivalentTo("[client.admin]\nkey = old-key\n"))
+
+		By("reconciling the volume with an unchanged key")
+		_, err = plugin.Apply(ctx, volumeSpec("old-key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(qmp.Commands("blockdev-reopen")).To(BeEmpty())
+
+		By("rotating the key")
+		_, err = plugin.Apply(ctx, volumeSpec("new"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(keyPath)).To(BeEquivalentTo("[client.admin]\nkey = new\n"))
+
+		reopens := qmp.Commands("blockdev-reopen")
+		Expect(reopens).To(HaveLen(1))
+		var args ceph.BlockdevReopenArguments
+		Expect(json.Unmarshal(reopens[0].Arguments, &args)).To(Succeed())
+		Expect(args.Options).To(ConsistOf(SatisfyAll(
+			HaveField("NodeName", "ceph-data"),
+			HaveField("Driver", "rbd"),
+			HaveField("User", "admin"),
+			HaveField("Conf", filepath.Join(paths.MachineVolumeDir(machineID, "ceph", "volume-1"), "ceph.conf")),
+		)))
+
+		Expect(qmp.Commands("blockdev-add")).To(HaveLen(1))
+		Expect(qmp.Commands("block-export-add")).To(HaveLen(1))
+	})
+
+	Context("with a volume referencing a secret", func() {
+		const (
+			secretNamespace = "ceph"
+			secretName      = "ceph-user"
+		)
+
+		var (
+			secrets client.Client
+			keyPath string
+		)
+
+		referencingSpec := func() *api.VolumeSpec {
+			spec := volumeSpec("")
+			spec.Connection.SecretData = nil
+			spec.Connection.Attributes["secretName"] = secretName
+			return spec
+		}
+
+		setUserKey := func(ctx SpecContext, userKey string) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: secretNamespace, Name: secretName},
+			}
+			_, err := controllerutil.CreateOrUpdate(ctx, secrets, secret, func() error {
+				secret.Data = map[string][]byte{
+					"userID":  []byte("admin"),
+					"userKey": []byte(userKey),
+				}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			secrets = fake.NewClientBuilder().Build()
+			keyPath = filepath.Join(paths.MachineVolumeDir(machineID, "ceph", "volume-1"), "ceph.key")
+		})
+
+		It("should write the key of the referenced secret", func(ctx SpecContext) {
+			setUserKey(ctx, "secret-key")
+			_, plugin := newPluginWithOptions(ctx, ceph.Options{}, ceph.PluginOptions{
+				Secrets: ceph.NewKubernetesSecretGetter(secrets, secretNamespace),
+			})
+
+			_, err := plugin.Apply(ctx, referencingSpec(), machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.ReadFile(keyPath)).To(BeEquivalentTo("[client.admin]\nkey = secret-key\n"))
+		})
+
+		It("should rewrite the key file and reconnect the block device when the secret rotates", func(ctx SpecContext) {
+			setUserKey(ctx, "old-key")
+			qmp, plugin := newPluginWithOptions(ctx, ceph.Options{}, ceph.PluginOptions{
+				Secrets:        ceph.NewKubernetesSecretGetter(secrets, secretNamespace),
+				SecretCacheTTL: time.Nanosecond,
+			})
+
+			_, err := plugin.Apply(ctx, referencingSpec(), machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(qmp.Commands("blockdev-reopen")).To(BeEmpty())
+
+			By("rotating the key of the secret")
+			setUserKey(ctx, "new-key")
+			_, err = plugin.Apply(ctx, referencingSpec(), machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.ReadFile(keyPath)).To(BeEquivalentTo("[client.admin]\nkey = new-key\n"))
+			Expect(qmp.Commands("blockdev-reopen")).To(HaveLen(1))
+			Expect(qmp.Commands("blockdev-add")).To(HaveLen(1))
+		})
+
+		It("should keep using the cached secret until it expires", func(ctx SpecContext) {
+			setUserKey(ctx, "old-key")
+			qmp, plugin := newPluginWithOptions(ctx, ceph.Options{}, ceph.PluginOptions{
+				Secrets: ceph.NewKubernetesSecretGetter(secrets, secretNamespace),
+			})
+
+			_, err := plugin.Apply(ctx, referencingSpec(), machineID)
+			Expect(err).NotTo(HaveOccurred())
+
+			setUserKey(ctx, "new-key")
+			_, err = plugin.Apply(ctx, referencingSpec(), machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.ReadFile(keyPath)).To(BeEquivalentTo("[client.admin]\nkey = old-key\n"))
+			Expect(qmp.Commands("blockdev-reopen")).To(BeEmpty())
+		})
+
+		It("should return a retryable error if the secret does not exist", func(ctx SpecContext) {
+			qmp, plugin := newPluginWithOptions(ctx, ceph.Options{}, ceph.PluginOptions{
+				Secrets: ceph.NewKubernetesSecretGetter(secrets, secretNamespace),
+			})
+
+			_, err := plugin.Apply(ctx, referencingSpec(), machineID)
+			Expect(err).To(MatchError(volume.ErrSecretNotFound))
+			Expect(qmp.Commands("blockdev-add")).To(BeEmpty())
+
+			By("mounting the volume once the secret is created")
+			setUserKey(ctx, "key")
+			_, err = plugin.Apply(ctx, referencingSpec(), machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(qmp.Commands("blockdev-add")).To(HaveLen(1))
+		})
+
+		It("should reject the volume if no secret source is configured", func(ctx SpecContext) {
+			_, err := plugin.Apply(ctx, referencingSpec(), machineID)
+			Expect(err).To(MatchError(ContainSubstring("no secret source is configured")))
+		})
+	})
+
+	It("should configure the readahead of the block device", func(ctx SpecContext) {
+		spec := volumeSpec("key")
+		spec.Tuning = &api.VolumeTuning{ReadaheadBytes: 4 * 1024 * 1024}
+
+		_, err := plugin.Apply(ctx, spec, machineID)
+		Expect(err).NotTo(HaveOccurred())
+
+		confPath := filepath.Join(paths.MachineVolumeDir(machineID, "ceph", "volume-1"), "ceph.conf")
+		adds := qmp.Commands("blockdev-add")
+		Expect(adds).To(HaveLen(1))
+		var args ceph.BlockdevAddArguments
+		Expect(json.Unmarshal(adds[0].Arguments, &args)).To(Succeed())
+		Expect(args.Conf).To(Equal(confPath))
+
+		conf, err := os.ReadFile(confPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(conf)).To(ContainSubstring("rbd_readahead_max_bytes = 4194304\n"))
+		Expect(string(conf)).To(ContainSubstring("rbd_readahead_disable_after_bytes = 0\n"))
+	})
+
+	It("should keep the librbd readahead defaults without tuning", func(ctx SpecContext) {
+		_, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+
+		conf, err := os.ReadFile(filepath.Join(paths.MachineVolumeDir(machineID, "ceph", "volume-1"), "ceph.conf"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(conf)).NotTo(ContainSubstring("rbd_readahead"))
+	})
+
+	It("should configure the client timeouts", func(ctx SpecContext) {
+		_, plugin := newPlugin(ctx, ceph.Options{
+			OSDOpTimeout:   30 * time.Second,
+			MonOpTimeout:   15 * time.Second,
+			ConnectTimeout: 2 * time.Minute,
+		})
+		_, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+
+		conf, err := os.ReadFile(filepath.Join(paths.MachineVolumeDir(machineID, "ceph", "volume-1"), "ceph.conf"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(conf)).To(ContainSubstring("rados_osd_op_timeout = 30\n"))
+		Expect(string(conf)).To(ContainSubstring("rados_mon_op_timeout = 15\n"))
+		Expect(string(conf)).To(ContainSubstring("client_mount_timeout = 120\n"))
+	})
+
+	It("should keep the ceph timeout defaults without options", func(ctx SpecContext) {
+		_, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+
+		conf, err := os.ReadFile(filepath.Join(paths.MachineVolumeDir(machineID, "ceph", "volume-1"), "ceph.conf"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(conf)).NotTo(ContainSubstring("timeout"))
+	})
+
+	It("should reject timeouts below a second", func(ctx SpecContext) {
+		_, err := ceph.QMPProvider(ctx, logr.Discard(), paths, "unused.sock", ceph.Options{OSDOpTimeout: 500 * time.Millisecond})
+		Expect(err).To(MatchError(ContainSubstring("osd op timeout must be zero or at least 1s")))
+	})
+
+	Context("with a qmp socket created late", func() {
+		var socket string
+
+		BeforeEach(func() {
+			// Unix socket paths are length limited, keep the socket in a short temp dir.
+			socketDir, err := os.MkdirTemp("", "qmp")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, socketDir)
+			socket = filepath.Join(socketDir, "qmp.sock")
+
+			fakes := make(chan *fakeQMP, 1)
+			timer := time.AfterFunc(500*time.Millisecond, func() {
+				defer GinkgoRecover()
+				fake, err := newFakeQMP(socket)
+				Expect(err).NotTo(HaveOccurred())
+				fakes <- fake
+			})
+			DeferCleanup(func() {
+				if timer.Stop() {
+					return
+				}
+				Expect((<-fakes).Close()).To(Succeed())
+			})
+		})
+
+		It("should fail fast if the socket does not appear within the timeout", func(ctx SpecContext) {
+			start := time.Now()
+			_, err := ceph.QMPProvider(ctx, logr.Discard(), paths, socket, ceph.Options{
+				SocketWaitTimeout: 100 * time.Millisecond,
+			})
+			Expect(err).To(MatchError(ContainSubstring("did not appear within 100ms")))
+			Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+		})
+
+		It("should connect once the socket appears within the timeout", func(ctx SpecContext) {
+			_, err := ceph.QMPProvider(ctx, logr.Discard(), paths, socket, ceph.Options{
+				SocketWaitTimeout: 5 * time.Second,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("should not read the key file again while the volume connection is unchanged", func(ctx SpecContext) {
+		_, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+
+		keyPath := filepath.Join(paths.MachineVolumeDir(machineID, "ceph", "volume-1"), "ceph.key")
+		Expect(os.Remove(keyPath)).To(Succeed())
+
+		By("reconciling the volume from the cache")
+		_, err = plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyPath).NotTo(BeAnExistingFile())
+
+		By("deleting the volume and clearing the cache")
+		Expect(plugin.Delete(ctx, "data", machineID)).To(Succeed())
+
+		_, err = plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(keyPath)).To(BeEquivalentTo("[client.admin]\nkey = key\n"))
+	})
+
+	DescribeTable("should add and delete block nodes and exports with the expected commands",
+		func(ctx SpecContext, execute string, arguments func(volumeDir string) string) {
+			_, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plugin.Delete(ctx, "data", machineID)).To(Succeed())
+
+			commands := qmp.Commands(execute)
+			Expect(commands).To(HaveLen(1))
+			Expect(commands[0].Arguments).To(MatchJSON(arguments(paths.MachineVolumeDir(machineID, "ceph", "volume-1"))))
+			Expect(qmp.NodeNames()).To(BeEmpty())
+			Expect(qmp.Exports()).To(BeEmpty())
+		},
+		Entry("adding the block node", "blockdev-add", func(volumeDir string) string {
+			return `{"node-name":"ceph-data","driver":"rbd","pool":"pool","image":"image","user":"admin",` +
+				`"conf":"` + filepath.Join(volumeDir, "ceph.conf") + `","discard":"unmap","cache":{"direct":true}}`
+		}),
+		Entry("exporting the block node", "block-export-add", func(volumeDir string) string {
+			return `{"id":"ceph-data","node-name":"ceph-data","type":"vhost-user-blk",` +
+				`"addr":{"type":"unix","path":"` + filepath.Join(volumeDir, "socket") + `"},"writable":true}`
+		}),
+		Entry("deleting the export", "block-export-del", func(string) string {
+			return `{"id":"ceph-data"}`
+		}),
+		Entry("deleting the block node", "blockdev-del", func(string) string {
+			return `{"node-name":"ceph-data"}`
+		}),
+	)
+
+	Describe("Unmount", func() {
+		// Deleting a volume unmounts it before removing its directory.
+		BeforeEach(func(ctx SpecContext) {
+			_, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should delete the export and the block node of a mounted volume", func(ctx SpecContext) {
+			Expect(plugin.Delete(ctx, "data", machineID)).To(Succeed())
+			Expect(qmp.Commands("block-export-del")).To(HaveLen(1))
+			Expect(qmp.Commands("blockdev-del")).To(HaveLen(1))
+			Expect(qmp.Exports()).To(BeEmpty())
+			Expect(qmp.NodeNames()).To(BeEmpty())
+		})
+
+		It("should do nothing for a volume that is not mounted", func(ctx SpecContext) {
+			Expect(plugin.Delete(ctx, "data", machineID)).To(Succeed())
+			Expect(plugin.Delete(ctx, "data", machineID)).To(Succeed())
+			Expect(plugin.Delete(ctx, "other", machineID)).To(Succeed())
+			Expect(qmp.Commands("block-export-del")).To(HaveLen(1))
+			Expect(qmp.Commands("blockdev-del")).To(HaveLen(1))
+		})
+
+		It("should keep the volume if querying it fails", func(ctx SpecContext) {
+			qmp.SetFailing("query-named-block-nodes", true)
+
+			Expect(plugin.Delete(ctx, "data", machineID)).To(MatchError(ContainSubstring("error querying block device")))
+			Expect(qmp.Commands("blockdev-del")).To(BeEmpty())
+			Expect(qmp.NodeNames()).To(ConsistOf("ceph-data"))
+		})
+	})
+
+	It("should report a volume as healthy only while its block node is exported", func(ctx SpecContext) {
+		healthy, err := plugin.IsHealthy(ctx, "data", machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(healthy).To(BeFalse())
+
+		_, err = plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+
+		healthy, err = plugin.IsHealthy(ctx, "data", machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(healthy).To(BeTrue())
+	})
+
+	It("should report the io stats of the block node of a mounted volume", func(ctx SpecContext) {
+		statsPlugin, ok := plugin.(volume.StatsPlugin)
+		Expect(ok).To(BeTrue())
+
+		By("getting the stats of a volume not mounted")
+		stats, err := statsPlugin.Stats(ctx, "data", machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats).To(BeNil())
+
+		By("getting the stats of a mounted volume")
+		_, err = plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+		qmp.SetStats("ceph-data", ceph.BlockStats{RdBytes: 4096, WrBytes: 8192, RdOperations: 1, WrOperations: 2})
+
+		stats, err = statsPlugin.Stats(ctx, "data", machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stats).To(Equal(&volume.Stats{ReadBytes: 4096, WrittenBytes: 8192, ReadOperations: 1, WriteOperations: 2}))
+
+		statsCmds := qmp.Commands("query-blockstats")
+		Expect(statsCmds).NotTo(BeEmpty())
+		Expect(statsCmds[0].Arguments).To(MatchJSON(`{"query-nodes":true}`))
+	})
+
+	It("should flush only block devices caching writes", func(ctx SpecContext) {
+		flushable, ok := plugin.(volume.FlushablePlugin)
+		Expect(ok).To(BeTrue())
+
+		By("flushing a volume not mounted")
+		Expect(flushable.Flush(ctx, volumeSpec("key"), machineID)).To(Succeed())
+
+		By("flushing a volume bypassing the cache")
+		_, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flushable.Flush(ctx, volumeSpec("key"), machineID)).To(Succeed())
+		Expect(qmp.Commands("blockdev-reopen")).To(BeEmpty())
+
+		By("flushing a writeback cached volume")
+		qmp.SetDirect("ceph-data", false)
+		Expect(flushable.Flush(ctx, volumeSpec("key"), machineID)).To(Succeed())
+
+		reopens := qmp.Commands("blockdev-reopen")
+		Expect(reopens).To(HaveLen(1))
+		var args ceph.BlockdevReopenArguments
+		Expect(json.Unmarshal(reopens[0].Arguments, &args)).To(Succeed())
+		Expect(args.Options).To(ConsistOf(SatisfyAll(
+			HaveField("NodeName", "ceph-data"),
+			HaveField("Cache.Direct", false),
+		)))
+	})
+
+	It("should snapshot a mounted volume only once under the same name", func(ctx SpecContext) {
+		_, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(plugin.Snapshot(ctx, "data", machineID, "snap")).To(Succeed())
+		snapshots := qmp.Commands("blockdev-snapshot-internal-sync")
+		Expect(snapshots).To(HaveLen(1))
+		Expect(snapshots[0].Arguments).To(MatchJSON(`{"device":"ceph-data","name":"snap"}`))
+
+		By("retrying the snapshot")
+		Expect(plugin.Snapshot(ctx, "data", machineID, "snap")).To(Succeed())
+		Expect(qmp.Commands("blockdev-snapshot-internal-sync")).To(HaveLen(1))
+	})
+
+	It("should migrate an attached volume to another image", func(ctx SpecContext) {
+		migratable, ok := plugin.(volume.MigratablePlugin)
+		Expect(ok).To(BeTrue())
+
+		By("mounting the volume")
+		status, err := plugin.Apply(ctx, volumeSpec("key"), machineID)
+		Expect(err).NotTo(HaveOccurred())
+		status.State = api.VolumeStateAttached
+
+		By("ensuring a volume on its backing is not migrated")
+		Expect(migratable.Migrate(ctx, volumeSpec("key"), machineID, status)).To(BeNil())
+		Expect(qmp.Commands("blockdev-mirror")).To(BeEmpty())
+
+		By("pointing the volume to another image")
+		target := volumeSpec("key")
+		target.Connection.Handle = "volume-2"
+		target.Connection.Attributes["image"] = "pool/image-2"
+
+		By("mirroring the volume until the mirror is in sync")
+		_, err = migratable.Migrate(ctx, target, machineID, status)
+		Expect(err).To(MatchError(volume.ErrMigrating))
+		_, err = migratable.Migrate(ctx, target, machineID, status)
+		Expect(err).To(MatchError(volume.ErrMigrating))
+
+		mirrors := qmp.Commands("blockdev-mirror")
+		Expect(mirrors).To(HaveLen(1))
+		var mirror ceph.BlockdevMirrorArguments
+		Expect(json.Unmarshal(mirrors[0].Arguments, &mirror)).To(Succeed())
+		Expect(mirror.Device).To(Equal("ceph-data"))
+		Expect(mirror.Sync).To(Equal("full"))
+		Expect(qmp.NodeNames()).To(ConsistOf("ceph-data", mirror.Target))
+		Expect(qmp.Commands("job-complete")).To(BeEmpty())
+
+		By("switching over once the mirror is in sync")
+		qmp.SetJobsReady()
+		migrated, err := migratable.Migrate(ctx, target, machineID, status)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(migrated).To(SatisfyAll(
+			HaveField("Handle", "volume-1"),
+			HaveField("Path", status.Path),
+			HaveField("BackingHandle", "volume-2"),
+			HaveField("State", api.VolumeStateAttached),
+		))
+		Expect(qmp.Commands("job-complete")).To(HaveLen(1))
+		Expect(qmp.Exports()).To(ConsistOf(SatisfyAll(
+			HaveField("ID", "ceph-data"),
+			HaveField("NodeName", mirror.Target),
+		)))
+		Expect(qmp.NodeNames()).To(ConsistOf(mirror.Target))
+
+		By("ensuring the migrated volume stays on its new backing")
+		Expect(migratable.Migrate(ctx, target, machineID, migrated)).To(BeNil())
+		applied, err := plugin.Apply(ctx, target, machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(qmp.Commands("blockdev-add")).To(HaveLen(2))
+
+		By("resolving the socket of the existing export")
+		Expect(applied).To(SatisfyAll(
+			HaveField("Handle", "volume-1"),
+			HaveField("Path", status.Path),
+			HaveField("BackingHandle", "volume-2"),
+		))
+		Expect(qmp.Commands("block-export-add")).To(HaveLen(1))
+
+		healthy, err := plugin.IsHealthy(ctx, "data", machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(healthy).To(BeTrue())
+	})
+})
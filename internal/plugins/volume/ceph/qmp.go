@@ -5,97 +5,371 @@ package ceph
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/digitalocean/go-qemu/qmp"
 	"github.com/go-logr/logr"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	blockJobPollInterval = 100 * time.Millisecond
+	blockJobTimeout      = 30 * time.Second
 )
 
 type QMP struct {
 	log     logr.Logger
 	paths   host.Paths
 	monitor *qmp.SocketMonitor
+	opts    Options
 }
 
-func (q *QMP) Mount(_ context.Context, machineID string, volume *validatedVolume) (string, error) {
+// Mount adds the block node of the volume and exports it. It returns the socket of the export along with the
+// handle the export was created for, which is the original handle of a volume migrated since.
+func (q *QMP) Mount(_ context.Context, machineID string, volume *validatedVolume) (string, string, error) {
 	volumeDir := q.volumeDir(machineID, volume.handle)
 	if err := os.MkdirAll(volumeDir, os.ModePerm); err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	log := q.log.WithValues("machineID", machineID, "volumeID", volume.handle)
-	socketPath := filepath.Join(volumeDir, "socket")
 
-	log.V(2).Info("Checking ceph conf")
-	confPath, err := q.createCephConf(log, machineID, volume)
-	if err != nil {
-		return "", fmt.Errorf("error creating ceph conf: %w", err)
+	// A volume with a conf path was already written with its current connection.
+	confPath, keyRotated := volume.confPath, false
+	if confPath == "" {
+		log.V(2).Info("Checking ceph conf")
+		var err error
+		confPath, keyRotated, err = q.createCephConf(log, machineID, volume)
+		if err != nil {
+			return "", "", fmt.Errorf("error creating ceph conf: %w", err)
+		}
 	}
 
 	handle := fmt.Sprintf("ceph-%s", volume.name)
+	nodeName, err := q.blockNodeName(volume.name)
+	if err != nil {
+		return "", "", err
+	}
 
-	if _, err := q.queryBlockNode(handle); err != nil {
+	if _, err := q.queryBlockNode(nodeName); err != nil {
 		if !errors.Is(err, ErrNotFound) {
-			return "", fmt.Errorf("error querying block device: %w", err)
+			return "", "", fmt.Errorf("error querying block device: %w", err)
 		}
 
-		if err := q.addBlockDev(volume, confPath); err != nil {
-			return "", fmt.Errorf("error adding block device: %w", err)
+		if err := q.addBlockDev(nodeName, volume, confPath); err != nil {
+			return "", "", fmt.Errorf("error adding block device: %w", err)
+		}
+	} else if keyRotated {
+		// librbd only reads the keyring when connecting, reopen the node to pick up the new key.
+		log.V(1).Info("Ceph key rotated, reconnecting block device", "handle", handle)
+		if err := q.reopenBlockDev(nodeName, volume, confPath, true); err != nil {
+			return "", "", fmt.Errorf("error reopening block device: %w", err)
 		}
 	}
 
+	exportHandle, err := q.exportHandle(machineID, volume)
+	if err != nil {
+		return "", "", err
+	}
+	socketPath := q.paths.MachineVolumeSocket(machineID, cephDriverName, exportHandle)
+
 	if _, err := q.queryBlockExports(handle); err != nil {
 		if !errors.Is(err, ErrNotFound) {
-			return "", fmt.Errorf("error querying block device: %w", err)
+			return "", "", fmt.Errorf("error querying block device: %w", err)
 		}
 
+		if err := os.MkdirAll(filepath.Dir(socketPath), os.ModePerm); err != nil {
+			return "", "", err
+		}
 		if err := q.exportBlockDev(handle, socketPath); err != nil {
-			return "", fmt.Errorf("error adding block device: %w", err)
+			return "", "", fmt.Errorf("error adding block device: %w", err)
+		}
+		if err := os.WriteFile(q.exportHandleFilename(machineID, volume.name), []byte(exportHandle), 0644); err != nil {
+			return "", "", fmt.Errorf("error recording handle of block device export: %w", err)
 		}
 	}
 
-	return socketPath, nil
+	volume.confPath = confPath
+	return socketPath, exportHandle, nil
+}
+
+// exportHandleFilename records the handle the export of the volume was created for. A migrated volume keeps
+// its export, and with it the socket named after the original handle, while its handle points to the new image.
+func (q *QMP) exportHandleFilename(machineID string, volumeName string) string {
+	return filepath.Join(q.paths.MachineVolumesPluginDir(machineID, cephDriverName), volumeName+".export")
+}
+
+// exportHandle returns the handle the existing export of the volume was created for, the handle of the volume
+// if it has no export yet.
+func (q *QMP) exportHandle(machineID string, volume *validatedVolume) (string, error) {
+	data, err := os.ReadFile(q.exportHandleFilename(machineID, volume.name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return volume.handle, nil
+		}
+		return "", fmt.Errorf("error reading handle of block device export: %w", err)
+	}
+	return string(data), nil
 }
 
+// Unmount deletes the export and the block node of the volume if they exist, unmounting a volume twice is
+// a no-op.
 func (q *QMP) Unmount(_ context.Context, machineID string, volumeName string) error {
+	handle := fmt.Sprintf("ceph-%s", volumeName)
+	nodeName, err := q.blockNodeName(volumeName)
+	if err != nil {
+		return err
+	}
 
+	switch _, err := q.queryBlockExports(handle); {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("error querying block device export: %w", err)
+	default:
+		if err := q.deleteExportBlockDev(handle); err != nil {
+			return fmt.Errorf("error deleting block device export: %w", err)
+		}
+	}
+	if err := os.Remove(q.exportHandleFilename(machineID, volumeName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing handle of block device export: %w", err)
+	}
+
+	switch _, err := q.queryBlockNode(nodeName); {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("error querying block device: %w", err)
+	default:
+		if err := q.deleteBlockDev(nodeName); err != nil {
+			return fmt.Errorf("error deleting block device: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (q *QMP) Snapshot(_ context.Context, _ string, volumeName string, snapshotName string) error {
+	nodeName, err := q.blockNodeName(volumeName)
+	if err != nil {
+		return err
+	}
+
+	node, err := q.queryBlockNode(nodeName)
+	if err != nil {
+		return fmt.Errorf("error querying block device: %w", err)
+	}
+
+	// A snapshot of the machine retried after it failed on another volume finds the snapshot of this one.
+	if slices.ContainsFunc(node.Image.Snapshots, func(snapshot BlockSnapshot) bool {
+		return snapshot.Name == snapshotName
+	}) {
+		return nil
+	}
+
+	// The rbd block driver maps internal snapshots to rbd image snapshots.
+	if err := q.snapshotBlockDev(nodeName, snapshotName); err != nil {
+		return fmt.Errorf("error snapshotting block device: %w", err)
+	}
+
+	return nil
+}
+
+// IsHealthy checks that the block node and its export of the volume are still served by the qemu storage daemon.
+func (q *QMP) IsHealthy(_ context.Context, _ string, volumeName string) (bool, error) {
 	handle := fmt.Sprintf("ceph-%s", volumeName)
+	nodeName, err := q.blockNodeName(volumeName)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := q.queryBlockNode(nodeName); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error querying block device: %w", err)
+	}
 
 	if _, err := q.queryBlockExports(handle); err != nil {
-		if !errors.Is(err, ErrNotFound) {
-			return fmt.Errorf("error querying block device: %w", err)
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
 		}
-	} else {
-		if err := q.deleteExportBlockDev(handle); err != nil {
-			return fmt.Errorf("error deleting block device export: %w", err)
+		return false, fmt.Errorf("error querying block device export: %w", err)
+	}
+
+	return true, nil
+}
+
+// Stats returns the io counters of the block node of the volume, nil if the node is not mounted.
+func (q *QMP) Stats(_ context.Context, _ string, volumeName string) (*volume.Stats, error) {
+	nodeName, err := q.blockNodeName(volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := q.queryBlockStats(nodeName)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("error querying block stats: %w", err)
 	}
 
-	if _, err := q.queryBlockNode(handle); err != nil {
-		if !errors.Is(err, ErrNotFound) {
-			return fmt.Errorf("error querying block device: %w", err)
+	return &volume.Stats{
+		ReadBytes:       stats.RdBytes,
+		WrittenBytes:    stats.WrBytes,
+		ReadOperations:  stats.RdOperations,
+		WriteOperations: stats.WrOperations,
+	}, nil
+}
+
+// Flush writes the cache of the block node of the volume back to the cluster. Read-only nodes and
+// nodes bypassing the librbd cache have nothing to flush and are skipped.
+func (q *QMP) Flush(_ context.Context, machineID string, volume *validatedVolume) error {
+	nodeName, err := q.blockNodeName(volume.name)
+	if err != nil {
+		return err
+	}
+
+	node, err := q.queryBlockNode(nodeName)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
 		}
-	} else {
-		if err := q.deleteBlockDev(handle); err != nil {
-			return fmt.Errorf("error deleting block device: %w", err)
+		return fmt.Errorf("error querying block device: %w", err)
+	}
+	if node.RO || node.Cache.Direct || !node.Cache.Writeback {
+		return nil
+	}
+
+	confPath := volume.confPath
+	if confPath == "" {
+		confPath = filepath.Join(q.volumeDir(machineID, volume.handle), "ceph.conf")
+	}
+
+	// The storage daemon offers no flush command, reopening a node flushes it before the node is reopened.
+	q.log.V(1).Info("Flushing block device", "machineID", machineID, "node", nodeName)
+	if err := q.reopenBlockDev(nodeName, volume, confPath, node.Cache.Direct); err != nil {
+		return fmt.Errorf("error reopening block device: %w", err)
+	}
+	return nil
+}
+
+// Migrate mirrors the block node serving the export of the volume to a node of the target image. Once the
+// mirror is in sync, completing the mirror job replaces the source node by the target node at once, the
+// export and with it the connection of the vm are kept.
+func (q *QMP) Migrate(ctx context.Context, machineID string, target *validatedVolume) error {
+	handle := fmt.Sprintf("ceph-%s", target.name)
+	log := q.log.WithValues("machineID", machineID, "volumeID", target.handle)
+
+	export, err := q.queryBlockExports(handle)
+	if err != nil {
+		return fmt.Errorf("error querying block device export: %w", err)
+	}
+	targetNode := migrationNodeName(target)
+	if export.NodeName == targetNode {
+		return nil
+	}
+
+	jobID := fmt.Sprintf("mirror-%s", target.name)
+	job, err := q.queryBlockJob(jobID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		if err := os.MkdirAll(q.volumeDir(machineID, target.handle), os.ModePerm); err != nil {
+			return err
 		}
+		confPath, _, err := q.createCephConf(log, machineID, target)
+		if err != nil {
+			return fmt.Errorf("error creating ceph conf: %w", err)
+		}
+		if _, err := q.queryBlockNode(targetNode); err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("error querying block device: %w", err)
+			}
+			if err := q.addBlockDev(targetNode, target, confPath); err != nil {
+				return fmt.Errorf("error adding block device: %w", err)
+			}
+		}
+
+		log.V(1).Info("Mirroring block device", "source", export.NodeName, "target", targetNode)
+		if err := q.mirrorBlockDev(jobID, export.NodeName, targetNode); err != nil {
+			return fmt.Errorf("error mirroring block device: %w", err)
+		}
+		return volume.ErrMigrating
+	case err != nil:
+		return fmt.Errorf("error querying block job: %w", err)
+	case !job.Ready:
+		return volume.ErrMigrating
+	}
+
+	log.V(1).Info("Switching over to mirrored block device", "source", export.NodeName, "target", targetNode)
+	if err := q.completeJob(jobID); err != nil {
+		return fmt.Errorf("error completing block job: %w", err)
+	}
+	if err := q.waitForBlockJob(ctx, jobID); err != nil {
+		return err
 	}
 
+	if current, err := q.queryBlockExports(handle); err != nil {
+		return fmt.Errorf("error querying block device export: %w", err)
+	} else if current.NodeName != targetNode {
+		// A failed mirror job is dismissed without switching over, the next migration attempt starts over.
+		return fmt.Errorf("block device export was not switched over to %s", targetNode)
+	}
+
+	if err := q.deleteBlockDev(export.NodeName); err != nil {
+		return fmt.Errorf("error deleting source block device: %w", err)
+	}
 	return nil
+}
 
+// migrationNodeName returns the name of the block node a volume is migrated to, unique per target image
+// within the length limit of node names.
+func migrationNodeName(target *validatedVolume) string {
+	sum := sha256.Sum256([]byte(target.handle))
+	return fmt.Sprintf("ceph-%s-%x", target.name, sum[:4])
+}
+
+// blockNodeName returns the block node serving the export of the volume, which is no longer named after
+// the volume once the volume was migrated.
+func (q *QMP) blockNodeName(volumeName string) (string, error) {
+	handle := fmt.Sprintf("ceph-%s", volumeName)
+
+	export, err := q.queryBlockExports(handle)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return handle, nil
+		}
+		return "", fmt.Errorf("error querying block device export: %w", err)
+	}
+	return export.NodeName, nil
+}
+
+func (q *QMP) waitForBlockJob(ctx context.Context, jobID string) error {
+	return wait.PollUntilContextTimeout(ctx, blockJobPollInterval, blockJobTimeout, true, func(context.Context) (bool, error) {
+		_, err := q.queryBlockJob(jobID)
+		if errors.Is(err, ErrNotFound) {
+			return true, nil
+		}
+		return false, err
+	})
 }
 
 func (q *QMP) volumeDir(machineID string, volumeHandle string) string {
 	return q.paths.MachineVolumeDir(machineID, cephDriverName, volumeHandle)
 }
 
-func (q *QMP) createCephConf(log logr.Logger, machineID string, volume *validatedVolume) (string, error) {
+// createCephConf writes the ceph conf and key of the volume and reports whether
+// an already existing key file contained a different key.
+func (q *QMP) createCephConf(log logr.Logger, machineID string, volume *validatedVolume) (string, bool, error) {
 	confPath := filepath.Join(
 		q.volumeDir(machineID, volume.handle),
 		"ceph.conf",
@@ -106,35 +380,53 @@ func (q *QMP) createCephConf(log logr.Logger, machineID string, volume *validate
 	)
 
 	log.V(2).Info("Creating ceph conf", "confPath", confPath)
-	confFile, err := os.OpenFile(confPath, os.O_CREATE|os.O_WRONLY, os.ModePerm)
-	if err != nil {
-		return "", fmt.Errorf("error opening conf file %s: %w", confPath, err)
-	}
-
 	confData := fmt.Sprintf(
 		"[global]\nmon_host = %s \n\n[client.%s]\nkeyring = %s\n",
 		strings.Join(volume.monitors, ","),
 		volume.userID,
 		keyPath,
 	)
-	_, err = confFile.WriteString(confData)
-	if err != nil {
-		return "", fmt.Errorf("error writing to conf file %s: %w", confPath, err)
+	if volume.readaheadBytes > 0 {
+		// librbd stops reading ahead after the first 50MiB by default, keep it enabled for sequential workloads.
+		confData += fmt.Sprintf("rbd_readahead_max_bytes = %d\nrbd_readahead_disable_after_bytes = 0\n",
+			volume.readaheadBytes)
 	}
-
-	log.V(1).Info("Creating ceph key", "keyPath", keyPath)
-	keyFile, err := os.OpenFile(keyPath, os.O_CREATE|os.O_WRONLY, os.ModePerm)
-	if err != nil {
-		return "", fmt.Errorf("error opening key file %s: %w", keyPath, err)
+	for _, option := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"rados_osd_op_timeout", q.opts.OSDOpTimeout},
+		{"rados_mon_op_timeout", q.opts.MonOpTimeout},
+		{"client_mount_timeout", q.opts.ConnectTimeout},
+	} {
+		if option.timeout > 0 {
+			confData += fmt.Sprintf("%s = %g\n", option.name, option.timeout.Seconds())
+		}
+	}
+	if err := os.WriteFile(confPath, []byte(confData), os.ModePerm); err != nil {
+		return "", false, fmt.Errorf("error writing to conf file %s: %w", confPath, err)
 	}
 
 	keyData := fmt.Sprintf("[client.%s]\nkey = %s\n", volume.userID, volume.userKey)
-	_, err = keyFile.WriteString(keyData)
-	if err != nil {
-		return "", fmt.Errorf("error writing to key file %s: %w", keyPath, err)
+
+	keyRotated := false
+	currentKeyData, err := os.ReadFile(keyPath)
+	switch {
+	case err == nil:
+		if string(currentKeyData) == keyData {
+			return confPath, false, nil
+		}
+		keyRotated = true
+	case !errors.Is(err, os.ErrNotExist):
+		return "", false, fmt.Errorf("error reading key file %s: %w", keyPath, err)
+	}
+
+	log.V(1).Info("Creating ceph key", "keyPath", keyPath, "rotated", keyRotated)
+	if err := os.WriteFile(keyPath, []byte(keyData), os.ModePerm); err != nil {
+		return "", false, fmt.Errorf("error writing to key file %s: %w", keyPath, err)
 	}
 
-	return confPath, nil
+	return confPath, keyRotated, nil
 }
 
 type BlockdevAddArguments struct {
@@ -150,6 +442,10 @@ type BlockdevAddArguments struct {
 	} `json:"cache"`
 }
 
+type BlockdevReopenArguments struct {
+	Options []BlockdevAddArguments `json:"options"`
+}
+
 type BlockExportAddArguments struct {
 	ID       string `json:"id"`
 	NodeName string `json:"node-name"`
@@ -169,6 +465,26 @@ type DeleteBlockDevArguments struct {
 	Node string `json:"node-name"`
 }
 
+type BlockdevSnapshotInternalArguments struct {
+	Device string `json:"device"`
+	Name   string `json:"name"`
+}
+
+type BlockdevMirrorArguments struct {
+	JobID  string `json:"job-id"`
+	Device string `json:"device"`
+	Target string `json:"target"`
+	Sync   string `json:"sync"`
+}
+
+type JobCompleteArguments struct {
+	ID string `json:"id"`
+}
+
+type QueryBlockstatsArguments struct {
+	QueryNodes bool `json:"query-nodes"`
+}
+
 type QMPRequest[T any] struct {
 	Execute   string `json:"execute"`
 	Arguments T      `json:"arguments,omitempty"`
@@ -232,20 +548,69 @@ func (q *QMP) queryBlockExports(nodeName string) (*BlockExportNode, error) {
 	return nil, ErrNotFound
 }
 
-func (q *QMP) addBlockDev(volume *validatedVolume, confPath string) error {
+func (q *QMP) queryBlockStats(nodeName string) (*BlockStats, error) {
+	cmd, err := json.Marshal(QMPRequest[QueryBlockstatsArguments]{
+		Execute:   "query-blockstats",
+		Arguments: QueryBlockstatsArguments{QueryNodes: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling cmd: %w", err)
+	}
+
+	res, err := q.monitor.Run(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("error executing cmd: %w", err)
+	}
+
+	var stats BlockStatsResponse
+	if err := json.Unmarshal(res, &stats); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+
+	for _, stat := range stats.Data {
+		if stat.NodeName == nodeName {
+			return &stat.Stats, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func blockDevArguments(nodeName string, volume *validatedVolume, confPath string, direct bool) BlockdevAddArguments {
+	return BlockdevAddArguments{
+		NodeName: nodeName,
+		Driver:   "rbd",
+		Pool:     volume.pool,
+		Image:    volume.image,
+		User:     volume.userID,
+		Conf:     confPath,
+		Discard:  "unmap",
+		Cache: struct {
+			Direct bool `json:"direct"`
+		}{Direct: direct},
+	}
+}
+
+func (q *QMP) addBlockDev(nodeName string, volume *validatedVolume, confPath string) error {
 	cmd, err := json.Marshal(QMPRequest[BlockdevAddArguments]{
-		Execute: "blockdev-add",
-		Arguments: BlockdevAddArguments{
-			NodeName: fmt.Sprintf("ceph-%s", volume.name),
-			Driver:   "rbd",
-			Pool:     volume.pool,
-			Image:    volume.image,
-			User:     volume.userID,
-			Conf:     confPath,
-			Discard:  "unmap",
-			Cache: struct {
-				Direct bool `json:"direct"`
-			}{Direct: true},
+		Execute:   "blockdev-add",
+		Arguments: blockDevArguments(nodeName, volume, confPath, true),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling cmd: %w", err)
+	}
+
+	if _, err := q.monitor.Run(cmd); err != nil {
+		return fmt.Errorf("error executing cmd: %w", err)
+	}
+
+	return nil
+}
+
+func (q *QMP) reopenBlockDev(nodeName string, volume *validatedVolume, confPath string, direct bool) error {
+	cmd, err := json.Marshal(QMPRequest[BlockdevReopenArguments]{
+		Execute: "blockdev-reopen",
+		Arguments: BlockdevReopenArguments{
+			Options: []BlockdevAddArguments{blockDevArguments(nodeName, volume, confPath, direct)},
 		},
 	})
 	if err != nil {
@@ -323,6 +688,104 @@ func (q *QMP) deleteExportBlockDev(nodeName string) error {
 	return nil
 }
 
+func (q *QMP) snapshotBlockDev(handle string, snapshotName string) error {
+	cmd, err := json.Marshal(QMPRequest[BlockdevSnapshotInternalArguments]{
+		Execute: "blockdev-snapshot-internal-sync",
+		Arguments: BlockdevSnapshotInternalArguments{
+			Device: handle,
+			Name:   snapshotName,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling cmd: %w", err)
+	}
+
+	if _, err := q.monitor.Run(cmd); err != nil {
+		return fmt.Errorf("error executing cmd: %w", err)
+	}
+
+	return nil
+}
+
+func (q *QMP) mirrorBlockDev(jobID string, source string, target string) error {
+	cmd, err := json.Marshal(QMPRequest[BlockdevMirrorArguments]{
+		Execute: "blockdev-mirror",
+		Arguments: BlockdevMirrorArguments{
+			JobID:  jobID,
+			Device: source,
+			Target: target,
+			Sync:   "full",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling cmd: %w", err)
+	}
+
+	if _, err := q.monitor.Run(cmd); err != nil {
+		return fmt.Errorf("error executing cmd: %w", err)
+	}
+
+	return nil
+}
+
+func (q *QMP) completeJob(jobID string) error {
+	cmd, err := json.Marshal(QMPRequest[JobCompleteArguments]{
+		Execute: "job-complete",
+		Arguments: JobCompleteArguments{
+			ID: jobID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling cmd: %w", err)
+	}
+
+	if _, err := q.monitor.Run(cmd); err != nil {
+		return fmt.Errorf("error executing cmd: %w", err)
+	}
+
+	return nil
+}
+
+func (q *QMP) queryBlockJob(jobID string) (*BlockJob, error) {
+	cmd, err := json.Marshal(QMPRequest[any]{
+		Execute: "query-block-jobs",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling cmd: %w", err)
+	}
+
+	res, err := q.monitor.Run(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("error executing cmd: %w", err)
+	}
+
+	var jobs BlockJobsResponse
+	if err := json.Unmarshal(res, &jobs); err != nil {
+		return nil, fmt.Errorf("error unmarshalling response: %w", err)
+	}
+
+	for _, job := range jobs.Data {
+		if job.Device == jobID {
+			return &job, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+type BlockJobsResponse struct {
+	Data []BlockJob `json:"return"`
+}
+
+// BlockJob is a running block job, its device is the id of the job.
+type BlockJob struct {
+	Type   string `json:"type"`
+	Device string `json:"device"`
+	Len    int64  `json:"len"`
+	Offset int64  `json:"offset"`
+	Ready  bool   `json:"ready"`
+	Status string `json:"status"`
+}
+
 type BlockExportResponse struct {
 	Data []BlockExportNode `json:"return"`
 }
@@ -357,6 +820,22 @@ type BlockDevice struct {
 	Cache            BlockCache `json:"cache"`
 }
 
+type BlockStatsResponse struct {
+	Data []BlockNodeStats `json:"return"`
+}
+
+type BlockNodeStats struct {
+	NodeName string     `json:"node-name"`
+	Stats    BlockStats `json:"stats"`
+}
+
+type BlockStats struct {
+	RdBytes      int64 `json:"rd_bytes"`
+	WrBytes      int64 `json:"wr_bytes"`
+	RdOperations int64 `json:"rd_operations"`
+	WrOperations int64 `json:"wr_operations"`
+}
+
 type BlockImage struct {
 	VirtualSize    int64                `json:"virtual-size"`
 	Filename       string               `json:"filename"`
@@ -364,6 +843,12 @@ type BlockImage struct {
 	Format         string               `json:"format"`
 	DirtyFlag      bool                 `json:"dirty-flag"`
 	FormatSpecific FormatSpecificDetail `json:"format-specific"`
+	Snapshots      []BlockSnapshot      `json:"snapshots,omitempty"`
+}
+
+type BlockSnapshot struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
 }
 
 type FormatSpecificDetail struct {
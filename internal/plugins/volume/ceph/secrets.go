@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ceph
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultSecretCacheTTL is the time a resolved secret is used before it is fetched again, bounding the time
+// until a rotated key is picked up.
+const DefaultSecretCacheTTL = time.Minute
+
+// SecretGetter resolves the secrets referenced by the connections of volumes. It returns an error wrapping
+// volume.ErrSecretNotFound if the secret does not exist.
+type SecretGetter interface {
+	GetSecret(ctx context.Context, name string) (map[string][]byte, error)
+}
+
+type kubernetesSecretGetter struct {
+	client    client.Reader
+	namespace string
+}
+
+// NewKubernetesSecretGetter resolves the referenced secrets from the kubernetes secrets in the namespace.
+func NewKubernetesSecretGetter(c client.Reader, namespace string) SecretGetter {
+	return &kubernetesSecretGetter{client: c, namespace: namespace}
+}
+
+func (g *kubernetesSecretGetter) GetSecret(ctx context.Context, name string) (map[string][]byte, error) {
+	secret := &corev1.Secret{}
+	if err := g.client.Get(ctx, client.ObjectKey{Namespace: g.namespace, Name: name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %s/%s", volume.ErrSecretNotFound, g.namespace, name)
+		}
+		return nil, fmt.Errorf("error getting secret %s/%s: %w", g.namespace, name, err)
+	}
+	return secret.Data, nil
+}
+
+type cachedSecret struct {
+	data    map[string][]byte
+	expires time.Time
+}
+
+// secretCache keeps the resolved secrets in memory, so the volumes are not resolved on every reconciliation.
+type secretCache struct {
+	getter SecretGetter
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	secrets map[string]cachedSecret
+}
+
+func newSecretCache(getter SecretGetter, ttl time.Duration) *secretCache {
+	return &secretCache{
+		getter:  getter,
+		ttl:     ttl,
+		secrets: make(map[string]cachedSecret),
+	}
+}
+
+func (c *secretCache) Get(ctx context.Context, name string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.secrets[name]; ok && time.Now().Before(cached.expires) {
+		return cached.data, nil
+	}
+
+	data, err := c.getter.GetSecret(ctx, name)
+	if err != nil {
+		delete(c.secrets, name)
+		return nil, err
+	}
+	data = maps.Clone(data)
+	c.secrets[name] = cachedSecret{data: data, expires: time.Now().Add(c.ttl)}
+	return data, nil
+}
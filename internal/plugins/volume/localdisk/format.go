@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package localdisk
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+)
+
+// mkfsArgs force formatting the disk file, which mkfs would otherwise refuse as it is no block device.
+var mkfsArgs = map[api.Filesystem][]string{
+	api.FilesystemExt4: {"-F", "-q"},
+	api.FilesystemXFS:  {"-f", "-q"},
+}
+
+// format creates the filesystem on the disk file. mkfs writes to regular files directly, so the disk
+// does not need to be attached to a loop device.
+func format(ctx context.Context, filename string, filesystem api.Filesystem) error {
+	if err := api.ValidateFilesystem(filesystem); err != nil {
+		return err
+	}
+
+	mkfs := "mkfs." + string(filesystem)
+	if _, err := exec.LookPath(mkfs); err != nil {
+		return fmt.Errorf("filesystem %s is not supported by the host: %w", filesystem, err)
+	}
+
+	out, err := exec.CommandContext(ctx, mkfs, append(mkfsArgs[filesystem], filename)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error running %s: %w: %s", mkfs, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -12,12 +12,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/raw"
 	ociutils "github.com/ironcore-dev/provider-utils/ociutils/oci"
+	utilssync "github.com/ironcore-dev/provider-utils/storeutils/sync"
 	utilstrings "k8s.io/utils/strings"
 )
 
@@ -27,22 +30,86 @@ const (
 	defaultSize = 500 * 1024 * 1024 // 500Mi by default
 )
 
+type Options struct {
+	// Sparse creates thin backing files which only allocate blocks that actually hold data. The blocks
+	// discarded by the guest are deallocated again.
+	Sparse bool
+	// ImageCache clones machine disks from a shared read-only base per image digest instead of copying the
+	// rootfs for every machine. The bases are removed once no disk references them anymore. It has no effect
+	// if the filesystem does not support reflinks, a base would just be another copy of the rootfs.
+	ImageCache bool
+	// ImageOverlays attaches a shared read-only base per image digest as backing file of a private qcow2
+	// overlay receiving the writes of the machine. Takes precedence over ImageCache.
+	ImageOverlays bool
+}
+
 type plugin struct {
 	host volume.Host
 	raw  raw.Raw
 
 	imageCache ociutils.Cache
+
+	sparse        bool
+	cacheImages   bool
+	imageOverlays bool
+	imageBasesMu  *utilssync.MutexMap[string]
+
+	// reflinks reports whether the filesystem clones files by sharing their blocks.
+	reflinks bool
+
+	preparations   map[string]*preparation
+	listeners      []volume.Listener
+	preparationsMu sync.Mutex
 }
 
-func NewPlugin(raw raw.Raw, osImages ociutils.Cache) volume.Plugin {
+func NewPlugin(raw raw.Raw, osImages ociutils.Cache, opts Options) volume.Plugin {
 	return &plugin{
-		raw:        raw,
-		imageCache: osImages,
+		raw:           raw,
+		imageCache:    osImages,
+		sparse:        opts.Sparse,
+		cacheImages:   opts.ImageCache,
+		imageOverlays: opts.ImageOverlays,
+		imageBasesMu:  utilssync.NewMutexMap[string](),
+		preparations:  make(map[string]*preparation),
 	}
 }
 
 func (p *plugin) Init(host volume.Host) error {
 	p.host = host
+
+	pluginDir := host.PluginDir(utilstrings.EscapeQualifiedName(pluginName))
+	if err := os.MkdirAll(pluginDir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating plugin directory: %w", err)
+	}
+
+	if p.sparse {
+		ok, err := osutils.SupportsSparseFiles(pluginDir)
+		if err != nil {
+			return fmt.Errorf("error checking sparse file support: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("filesystem at %s does not support sparse files", pluginDir)
+		}
+
+		// cloud-hypervisor serves the discards of the guest on raw disks by punching holes into the file, that is
+		// what keeps sparse disks thin.
+		ok, err = osutils.SupportsPunchHole(pluginDir)
+		if err != nil {
+			return fmt.Errorf("error checking punch hole support: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("filesystem at %s does not support punching holes", pluginDir)
+		}
+	}
+
+	// Snapshots and cached image disks are cloned if the filesystem supports it.
+	ok, err := osutils.SupportsReflink(pluginDir)
+	if err != nil {
+		return fmt.Errorf("error checking reflink support: %w", err)
+	}
+	p.reflinks = ok
+	p.cacheImages = p.cacheImages && ok
+
 	return nil
 }
 
@@ -61,69 +128,359 @@ func (p *plugin) CanSupport(volume *api.VolumeSpec) bool {
 	return volume.LocalDisk != nil
 }
 
+func (p *plugin) volumeDir(computeVolumeName string, machineID string) string {
+	return p.host.MachineVolumeDir(machineID, utilstrings.EscapeQualifiedName(pluginName), computeVolumeName)
+}
+
 func (p *plugin) diskFilename(computeVolumeName string, machineID string) string {
-	return filepath.Join(p.host.MachineVolumeDir(machineID, utilstrings.EscapeQualifiedName(pluginName), computeVolumeName), "disk.raw")
+	return filepath.Join(p.volumeDir(computeVolumeName, machineID), "disk.raw")
+}
+
+func (p *plugin) overlayFilename(computeVolumeName string, machineID string) string {
+	return filepath.Join(p.volumeDir(computeVolumeName, machineID), "disk.qcow2")
+}
+
+// sharedBaseRefFilename holds the directory of the shared base an overlay disk is backed by.
+func (p *plugin) sharedBaseRefFilename(computeVolumeName string, machineID string) string {
+	return filepath.Join(p.volumeDir(computeVolumeName, machineID), "base")
 }
 
 func (p *plugin) Apply(ctx context.Context, spec *api.VolumeSpec, machineID string) (*api.VolumeStatus, error) {
 	log := logr.FromContextOrDiscard(ctx)
 
-	volumeDir := p.host.MachineVolumeDir(machineID, utilstrings.EscapeQualifiedName(pluginName), spec.Name)
+	// An existing disk is never created anew, whatever layout it was created with.
+	if status, err := p.existingStatus(spec.Name, machineID); !errors.Is(err, volume.ErrVolumeNotFound) {
+		return status, err
+	}
+
+	volumeDir := p.volumeDir(spec.Name, machineID)
 
 	log.V(2).Info("Creating volume directory", "directory", volumeDir)
 	if err := os.MkdirAll(volumeDir, os.ModePerm); err != nil {
 		return nil, err
 	}
 
+	if imgRef := spec.LocalDisk.Image; imgRef != nil && p.imageOverlays {
+		return p.applyOverlay(ctx, spec, *imgRef, machineID)
+	}
+
 	size := spec.LocalDisk.Size
 	if size == 0 {
 		size = defaultSize
 	}
 
 	diskFilename := p.diskFilename(spec.Name, machineID)
-	if _, err := os.Stat(diskFilename); err != nil {
-		if !errors.Is(err, os.ErrNotExist) {
-			return nil, fmt.Errorf("error stat-ing disk: %w", err)
+	createOptions := []raw.CreateOption{raw.WithSparse(p.sparse)}
+	if imgRef := spec.LocalDisk.Image; imgRef != nil {
+		img, err := p.imageCache.Get(ctx, *imgRef)
+		if err != nil {
+			return nil, err
 		}
 
-		var createOption raw.CreateOption
-		if imgRef := spec.LocalDisk.Image; imgRef != nil {
-			img, err := p.imageCache.Get(ctx, *imgRef)
-			if err != nil {
-				return nil, err
+		if err := p.prepare(ctx, diskFilename, spec.Name, machineID, func(ctx context.Context, filename string) error {
+			source := img.RootFS.Path
+			if p.cacheImages {
+				base, err := p.referenceSharedBase(ctx, img, spec.Name, machineID)
+				if err != nil {
+					return fmt.Errorf("error referencing shared image base: %w", err)
+				}
+				source = base
 			}
 
-			log.V(2).Info("Create disk with rootfs from img", "file", img.RootFS.Path)
-			createOption = raw.WithSourceFile(img.RootFS.Path)
-		} else {
-			size := spec.LocalDisk.Size
-			if size == 0 {
-				size = defaultSize
+			log.V(2).Info("Create disk with rootfs from img", "file", source)
+			if err := p.createDisk(ctx, filename, append(createOptions, raw.WithSourceFile(source))); err != nil {
+				return fmt.Errorf("error creating disk %w", err)
 			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		return p.volumeStatus(spec.Name, diskFilename, machineID)
+	}
+
+	log.V(2).Info("Create disk", "size", size)
+	createOptions = append(createOptions, raw.WithSize(size))
 
-			log.V(2).Info("Create disk", "size", size)
-			createOption = raw.WithSize(size)
+	// A formatted disk is only moved into place once the filesystem was created completely.
+	filesystem := spec.LocalDisk.Filesystem
+	createFilename := diskFilename
+	if filesystem != "" {
+		createFilename = diskFilename + ".tmp"
+		_ = os.Remove(createFilename)
+	}
+
+	if err := p.createDisk(ctx, createFilename, createOptions); err != nil {
+		return nil, fmt.Errorf("error creating disk %w", err)
+	}
+	if createFilename != diskFilename {
+		log.V(2).Info("Format disk", "filesystem", filesystem)
+		if err := format(ctx, createFilename, filesystem); err != nil {
+			return nil, fmt.Errorf("error formatting disk: %w", err)
 		}
+		if err := os.Rename(createFilename, diskFilename); err != nil {
+			return nil, fmt.Errorf("error moving formatted disk into place: %w", err)
+		}
+	}
+	if err := os.Chmod(diskFilename, os.FileMode(0666)); err != nil {
+		return nil, fmt.Errorf("error changing disk file mode: %w", err)
+	}
 
-		if err := p.raw.Create(diskFilename, createOption); err != nil {
-			return nil, fmt.Errorf("error creating disk %w", err)
+	return p.volumeStatus(spec.Name, diskFilename, machineID)
+}
+
+func (p *plugin) volumeStatus(computeVolumeName, diskFilename, machineID string) (*api.VolumeStatus, error) {
+	stat, err := os.Stat(diskFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error stat-ing disk: %w", err)
+	}
+
+	allocatedSize, err := osutils.AllocatedSize(diskFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error getting allocated disk size: %w", err)
+	}
+
+	return &api.VolumeStatus{
+		Name:          computeVolumeName,
+		Type:          api.VolumeFileType,
+		Path:          diskFilename,
+		Handle:        generateWWN(machineID, computeVolumeName),
+		State:         api.VolumeStatePrepared,
+		Size:          stat.Size(),
+		AllocatedSize: allocatedSize,
+	}, nil
+}
+
+func (p *plugin) applyOverlay(ctx context.Context, spec *api.VolumeSpec, imgRef string, machineID string) (*api.VolumeStatus, error) {
+	log := logr.FromContextOrDiscard(ctx)
+
+	overlayFilename := p.overlayFilename(spec.Name, machineID)
+	img, err := p.imageCache.Get(ctx, imgRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.prepare(ctx, overlayFilename, spec.Name, machineID, func(ctx context.Context, filename string) error {
+		baseFilename, err := p.referenceSharedBase(ctx, img, spec.Name, machineID)
+		if err != nil {
+			return fmt.Errorf("error referencing shared image base: %w", err)
 		}
-		if err := os.Chmod(diskFilename, os.FileMode(0666)); err != nil {
-			return nil, fmt.Errorf("error changing disk file mode: %w", err)
+
+		baseStat, err := os.Stat(baseFilename)
+		if err != nil {
+			return fmt.Errorf("error stat-ing shared image base: %w", err)
 		}
+
+		log.V(2).Info("Create overlay disk", "base", baseFilename)
+		size := max(spec.LocalDisk.Size, baseStat.Size())
+		if err := p.raw.Create(filename, raw.WithBackingFile(baseFilename), raw.WithSize(size)); err != nil {
+			return fmt.Errorf("error creating overlay disk: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
+
+	return p.overlayStatus(spec.Name, overlayFilename, machineID)
+}
+
+func (p *plugin) overlayStatus(computeVolumeName, overlayFilename, machineID string) (*api.VolumeStatus, error) {
+	allocatedSize, err := osutils.AllocatedSize(overlayFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error getting allocated disk size: %w", err)
+	}
+
 	return &api.VolumeStatus{
-		Name:   spec.Name,
-		Type:   api.VolumeFileType,
-		Path:   diskFilename,
-		Handle: generateWWN(machineID, spec.Name),
-		State:  api.VolumeStatePrepared,
-		Size:   size,
+		Name:          computeVolumeName,
+		Type:          api.VolumeFileType,
+		Path:          overlayFilename,
+		Handle:        generateWWN(machineID, computeVolumeName),
+		State:         api.VolumeStatePrepared,
+		AllocatedSize: allocatedSize,
 	}, nil
 }
 
+// Reattach returns the status of the existing disk of the volume without touching the disk, so its data is
+// preserved. Disks of another handle or missing disks are reported as not found.
+func (p *plugin) Reattach(_ context.Context, spec *api.VolumeSpec, machineID string, handle string) (*api.VolumeStatus, error) {
+	if handle != generateWWN(machineID, spec.Name) {
+		return nil, volume.ErrVolumeNotFound
+	}
+	return p.existingStatus(spec.Name, machineID)
+}
+
+// existingStatus returns the status of the existing overlay or raw disk of the volume, or
+// volume.ErrVolumeNotFound if there is none. Apply and Reattach report existing disks the same way.
+func (p *plugin) existingStatus(computeVolumeName, machineID string) (*api.VolumeStatus, error) {
+	overlayFilename := p.overlayFilename(computeVolumeName, machineID)
+	ok, err := osutils.RegularFileExists(overlayFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error checking overlay disk: %w", err)
+	}
+	if ok {
+		return p.overlayStatus(computeVolumeName, overlayFilename, machineID)
+	}
+
+	diskFilename := p.diskFilename(computeVolumeName, machineID)
+	ok, err = osutils.RegularFileExists(diskFilename)
+	if err != nil {
+		return nil, fmt.Errorf("error checking disk: %w", err)
+	}
+	if !ok {
+		return nil, volume.ErrVolumeNotFound
+	}
+	return p.volumeStatus(computeVolumeName, diskFilename, machineID)
+}
+
+func (p *plugin) createDisk(ctx context.Context, filename string, createOptions []raw.CreateOption) error {
+	log := logr.FromContextOrDiscard(ctx)
+
+	o := &raw.CreateOptions{}
+	o.ApplyOptions(createOptions)
+
+	if p.cacheImages && o.SourceFile != "" {
+		err := osutils.Reflink(o.SourceFile, filename)
+		if err == nil {
+			return nil
+		}
+		log.V(1).Info("Unable to clone image base, falling back to copy", "error", err)
+	}
+
+	return p.raw.Create(filename, createOptions...)
+}
+
+func (p *plugin) sharedBasesDir() string {
+	return filepath.Join(p.host.PluginDir(utilstrings.EscapeQualifiedName(pluginName)), "shared-bases")
+}
+
+func sharedBaseRef(computeVolumeName, machineID string) string {
+	return fmt.Sprintf("%s_%s", machineID, computeVolumeName)
+}
+
+// referenceSharedBase returns the shared base of the image digest, creating it if it does not exist, and
+// records the volume as user of the base. Shared bases are immutable, an updated image gets a new base. Overlay
+// disks are backed by the base, cached image disks are cloned from it.
+func (p *plugin) referenceSharedBase(ctx context.Context, img *ociutils.Image, computeVolumeName, machineID string) (string, error) {
+	log := logr.FromContextOrDiscard(ctx)
+
+	digest := img.RootFS.Descriptor.Digest.Encoded()
+	p.imageBasesMu.Lock(digest)
+	defer p.imageBasesMu.Unlock(digest)
+
+	baseDir := filepath.Join(p.sharedBasesDir(), digest)
+	baseFilename := filepath.Join(baseDir, "base.raw")
+
+	ok, err := osutils.RegularFileExists(baseFilename)
+	if err != nil {
+		return "", fmt.Errorf("error checking shared image base: %w", err)
+	}
+	if !ok {
+		log.V(1).Info("Creating shared image base", "digest", digest)
+		if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
+			return "", fmt.Errorf("error creating shared image base directory: %w", err)
+		}
+
+		tmpFilename := baseFilename + ".tmp"
+		_ = os.Remove(tmpFilename)
+		if err := p.raw.Create(tmpFilename, raw.WithSourceFile(img.RootFS.Path), raw.WithSparse(p.sparse)); err != nil {
+			return "", fmt.Errorf("error creating shared image base: %w", err)
+		}
+		if err := os.Chmod(tmpFilename, os.FileMode(0444)); err != nil {
+			return "", fmt.Errorf("error changing shared image base file mode: %w", err)
+		}
+		if err := os.Rename(tmpFilename, baseFilename); err != nil {
+			return "", fmt.Errorf("error moving shared image base into place: %w", err)
+		}
+	}
+
+	refsDir := filepath.Join(baseDir, "refs")
+	if err := os.MkdirAll(refsDir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("error creating shared image base references directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(refsDir, sharedBaseRef(computeVolumeName, machineID)), nil, 0644); err != nil {
+		return "", fmt.Errorf("error writing shared image base reference: %w", err)
+	}
+	if err := os.WriteFile(p.sharedBaseRefFilename(computeVolumeName, machineID), []byte(baseDir), 0644); err != nil {
+		return "", fmt.Errorf("error writing shared image base of volume: %w", err)
+	}
+
+	return baseFilename, nil
+}
+
+// releaseSharedBase drops the reference of the volume to its shared base and removes the base once no
+// volume references it anymore.
+func (p *plugin) releaseSharedBase(computeVolumeName, machineID string) error {
+	baseDir, err := os.ReadFile(p.sharedBaseRefFilename(computeVolumeName, machineID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error reading shared image base of volume: %w", err)
+	}
+
+	digest := filepath.Base(string(baseDir))
+	p.imageBasesMu.Lock(digest)
+	defer p.imageBasesMu.Unlock(digest)
+
+	refsDir := filepath.Join(string(baseDir), "refs")
+	if err := os.Remove(filepath.Join(refsDir, sharedBaseRef(computeVolumeName, machineID))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing shared image base reference: %w", err)
+	}
+
+	refs, err := os.ReadDir(refsDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error listing shared image base references: %w", err)
+	}
+	if len(refs) > 0 {
+		return nil
+	}
+
+	if err := os.RemoveAll(string(baseDir)); err != nil {
+		return fmt.Errorf("error removing unreferenced shared image base: %w", err)
+	}
+	return nil
+}
+
 func (p *plugin) Delete(_ context.Context, computeVolumeName string, machineID string) error {
-	return os.RemoveAll(p.host.MachineVolumeDir(machineID, utilstrings.EscapeQualifiedName(pluginName), computeVolumeName))
+	p.forgetPreparations(p.diskFilename(computeVolumeName, machineID), p.overlayFilename(computeVolumeName, machineID))
+	if err := p.releaseSharedBase(computeVolumeName, machineID); err != nil {
+		return err
+	}
+	return os.RemoveAll(p.volumeDir(computeVolumeName, machineID))
+}
+
+func (p *plugin) IsHealthy(_ context.Context, computeVolumeName string, machineID string) (bool, error) {
+	if _, err := os.Stat(p.diskPath(computeVolumeName, machineID)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error stat-ing disk: %w", err)
+	}
+	return true, nil
+}
+
+// Flush syncs the disk of the volume, cloud-hypervisor writes the disks through the page cache of the host.
+func (p *plugin) Flush(_ context.Context, spec *api.VolumeSpec, machineID string) error {
+	f, err := os.Open(p.diskPath(spec.Name, machineID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("error opening disk: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("error syncing disk: %w", err)
+	}
+	return nil
+}
+
+// diskPath returns the overlay of the volume if it has one, the raw disk otherwise.
+func (p *plugin) diskPath(computeVolumeName string, machineID string) string {
+	if ok, _ := osutils.RegularFileExists(p.overlayFilename(computeVolumeName, machineID)); ok {
+		return p.overlayFilename(computeVolumeName, machineID)
+	}
+	return p.diskFilename(computeVolumeName, machineID)
 }
 
 func generateWWN(machineID, diskName string) string {
@@ -135,3 +492,54 @@ func generateWWN(machineID, diskName string) string {
 
 	return strings.ToUpper(hex.EncodeToString(wwnBytes))
 }
+
+// Snapshot clones the disk of the volume into its snapshot directory. Overlay disks are cloned as overlays,
+// the clone is backed by the same shared base, which is kept as long as the volume. On filesystems supporting
+// reflinks the clone shares the blocks of the disk and takes no time; otherwise, the disk is copied. Snapshots
+// are only moved into place once complete, an existing snapshot of the name is left as is, so a snapshot
+// of the machine can be retried after it failed on another volume.
+func (p *plugin) Snapshot(ctx context.Context, computeVolumeName string, machineID string, snapshotName string) error {
+	log := logr.FromContextOrDiscard(ctx)
+
+	if err := volume.ValidateSnapshotName(snapshotName); err != nil {
+		return err
+	}
+
+	diskFilename := p.diskPath(computeVolumeName, machineID)
+	if _, err := os.Stat(diskFilename); err != nil {
+		return fmt.Errorf("error stat-ing disk: %w", err)
+	}
+
+	snapshotDir := filepath.Join(p.volumeDir(computeVolumeName, machineID), "snapshots")
+	if err := os.MkdirAll(snapshotDir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+
+	snapshotFilename := filepath.Join(snapshotDir, snapshotName+filepath.Ext(diskFilename))
+	ok, err := osutils.RegularFileExists(snapshotFilename)
+	if err != nil {
+		return fmt.Errorf("error checking snapshot: %w", err)
+	}
+	if ok {
+		log.V(1).Info("Snapshot already exists", "snapshot", snapshotName)
+		return nil
+	}
+
+	tmpFilename := snapshotFilename + ".tmp"
+	_ = os.Remove(tmpFilename)
+	if p.reflinks {
+		if err := osutils.Reflink(diskFilename, tmpFilename); err != nil {
+			return fmt.Errorf("error cloning disk: %w", err)
+		}
+	} else {
+		log.V(1).Info("Filesystem does not support reflinks, copying disk", "snapshot", snapshotName)
+		if err := p.raw.Create(tmpFilename, raw.WithSourceFile(diskFilename), raw.WithSparse(true)); err != nil {
+			return fmt.Errorf("error creating snapshot: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmpFilename, snapshotFilename); err != nil {
+		return fmt.Errorf("error moving snapshot into place: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,408 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package localdisk_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume/localdisk"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/raw"
+	ociutils "github.com/ironcore-dev/provider-utils/ociutils/oci"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sys/unix"
+	"k8s.io/utils/ptr"
+	utilstrings "k8s.io/utils/strings"
+)
+
+const imageRef = "example.org/os-image:latest"
+
+type fakeImageCache struct {
+	images map[string]*ociutils.Image
+}
+
+func (c *fakeImageCache) Get(_ context.Context, ref string) (*ociutils.Image, error) {
+	img, ok := c.images[ref]
+	if !ok {
+		return nil, fmt.Errorf("image %s not found", ref)
+	}
+	return img, nil
+}
+
+func (c *fakeImageCache) AddListener(ociutils.Listener) {}
+
+// blockingRaw creates disks once it is released.
+type blockingRaw struct {
+	release chan struct{}
+	creates atomic.Int32
+}
+
+func (r *blockingRaw) Create(filename string, opts ...raw.CreateOption) error {
+	r.creates.Add(1)
+	<-r.release
+	return raw.Exec{}.Create(filename, opts...)
+}
+
+var _ = Describe("LocalDisk", func() {
+	var (
+		tempDir    string
+		paths      host.Paths
+		imageCache *fakeImageCache
+		plugin     volume.Plugin
+	)
+
+	writeImage := func(content string) {
+		rootFSDigest := digest.FromString(content)
+		rootFS := filepath.Join(tempDir, "rootfs-"+rootFSDigest.Encoded())
+		Expect(os.WriteFile(rootFS, []byte(content), 0644)).To(Succeed())
+		imageCache.images[imageRef] = &ociutils.Image{
+			RootFS: &ociutils.FileLayer{
+				Descriptor: ocispecv1.Descriptor{Digest: rootFSDigest},
+				Path:       rootFS,
+			},
+		}
+	}
+
+	sharedBases := func() []string {
+		bases, err := filepath.Glob(filepath.Join(
+			paths.PluginDir(utilstrings.EscapeQualifiedName(plugin.Name())), "shared-bases", "*", "base.raw",
+		))
+		Expect(err).NotTo(HaveOccurred())
+		return bases
+	}
+
+	// applyImageDisk applies the image disk until its background preparation finished.
+	applyImageDisk := func(machineID string) *api.VolumeStatus {
+		var status *api.VolumeStatus
+		Eventually(func() error {
+			var err error
+			status, err = plugin.Apply(context.TODO(), &api.VolumeSpec{
+				Name:      "root",
+				LocalDisk: &api.LocalDiskSpec{Image: ptr.To(imageRef)},
+			}, machineID)
+			return err
+		}).Should(Succeed())
+		return status
+	}
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+
+		var err error
+		paths, err = host.PathsAt(filepath.Join(tempDir, "root"))
+		Expect(err).NotTo(HaveOccurred())
+
+		imageCache = &fakeImageCache{images: map[string]*ociutils.Image{}}
+		plugin = localdisk.NewPlugin(raw.Exec{}, imageCache, localdisk.Options{ImageCache: true})
+		Expect(plugin.Init(paths)).To(Succeed())
+	})
+
+	Context("with an image cache", func() {
+		var supportsReflink bool
+
+		BeforeEach(func() {
+			pluginDir := paths.PluginDir(utilstrings.EscapeQualifiedName(plugin.Name()))
+			Expect(os.MkdirAll(pluginDir, os.ModePerm)).To(Succeed())
+			var err error
+			supportsReflink, err = osutils.SupportsReflink(pluginDir)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should clone the disks from a single shared base until no disk references it", func(ctx SpecContext) {
+			if !supportsReflink {
+				Skip("the filesystem does not support reflinks")
+			}
+			writeImage("rootfs v1")
+
+			By("creating the disks of several machines")
+			var disks []string
+			for _, machineID := range []string{"machine-1", "machine-2", "machine-3"} {
+				status := applyImageDisk(machineID)
+				Expect(status.Path).To(BeARegularFile())
+				Expect(os.ReadFile(status.Path)).To(Equal([]byte("rootfs v1")))
+				disks = append(disks, status.Path)
+			}
+			Expect(disks[0]).NotTo(Equal(disks[1]))
+
+			By("ensuring exactly one shared base exists")
+			bases := sharedBases()
+			Expect(bases).To(HaveLen(1))
+
+			By("writing to a machine disk")
+			Expect(os.WriteFile(disks[0], []byte("modified"), 0666)).To(Succeed())
+			Expect(os.ReadFile(bases[0])).To(Equal([]byte("rootfs v1")))
+			Expect(os.ReadFile(disks[1])).To(Equal([]byte("rootfs v1")))
+
+			By("updating the image")
+			writeImage("rootfs v2")
+			status := applyImageDisk("machine-4")
+			Expect(os.ReadFile(status.Path)).To(Equal([]byte("rootfs v2")))
+			Expect(sharedBases()).To(HaveLen(2))
+
+			By("removing the base of the previous image once its disks are deleted")
+			for _, machineID := range []string{"machine-1", "machine-2", "machine-3"} {
+				Expect(plugin.Delete(ctx, "root", machineID)).To(Succeed())
+			}
+			Expect(sharedBases()).To(SatisfyAll(HaveLen(1), Not(ContainElement(bases[0]))))
+		})
+
+		It("should copy the rootfs without a base if the filesystem does not support reflinks", func() {
+			if supportsReflink {
+				Skip("the filesystem supports reflinks")
+			}
+			writeImage("rootfs v1")
+
+			for _, machineID := range []string{"machine-1", "machine-2"} {
+				status := applyImageDisk(machineID)
+				Expect(os.ReadFile(status.Path)).To(Equal([]byte("rootfs v1")))
+			}
+			Expect(sharedBases()).To(BeEmpty())
+		})
+	})
+
+	It("should create the rootfs of an image disk in the background", func(ctx SpecContext) {
+		writeImage("rootfs v1")
+		slowRaw := &blockingRaw{release: make(chan struct{})}
+		plugin = localdisk.NewPlugin(slowRaw, imageCache, localdisk.Options{})
+		Expect(plugin.Init(paths)).To(Succeed())
+
+		prepared := make(chan volume.PreparedEvent, 1)
+		plugin.(volume.AsyncPlugin).AddListener(volume.ListenerFuncs{
+			HandlePreparedFunc: func(evt volume.PreparedEvent) {
+				prepared <- evt
+			},
+		})
+
+		spec := &api.VolumeSpec{Name: "root", LocalDisk: &api.LocalDiskSpec{Image: ptr.To(imageRef)}}
+
+		By("applying the volume while the rootfs is copied")
+		start := time.Now()
+		_, err := plugin.Apply(ctx, spec, "machine-1")
+		Expect(err).To(MatchError(volume.ErrPreparing))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+		By("ensuring a second apply does not start another copy")
+		_, err = plugin.Apply(ctx, spec, "machine-1")
+		Expect(err).To(MatchError(volume.ErrPreparing))
+		Consistently(prepared).ShouldNot(Receive())
+		Expect(slowRaw.creates.Load()).To(Equal(int32(1)))
+
+		By("finishing the copy")
+		close(slowRaw.release)
+		Eventually(prepared).Should(Receive(Equal(volume.PreparedEvent{MachineID: "machine-1", VolumeName: "root"})))
+
+		status, err := plugin.Apply(ctx, spec, "machine-1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(status.Path)).To(Equal([]byte("rootfs v1")))
+		Expect(slowRaw.creates.Load()).To(Equal(int32(1)))
+	})
+
+	It("should reattach an existing data disk as is", func(ctx SpecContext) {
+		spec := &api.VolumeSpec{Name: "data", LocalDisk: &api.LocalDiskSpec{Size: 1024 * 1024}}
+		status, err := plugin.Apply(ctx, spec, "machine")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(status.Path, []byte("data"), 0666)).To(Succeed())
+
+		reattachable := plugin.(volume.ReattachablePlugin)
+		By("reattaching the disk of the handle")
+		reattached, err := reattachable.Reattach(ctx, spec, "machine", status.Handle)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reattached).To(SatisfyAll(
+			HaveField("Path", status.Path),
+			HaveField("Handle", status.Handle),
+			HaveField("State", api.VolumeStatePrepared),
+		))
+		Expect(os.ReadFile(status.Path)).To(BeEquivalentTo("data"))
+
+		By("applying the volume again without touching the disk")
+		applied, err := plugin.Apply(ctx, spec, "machine")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(applied).To(Equal(reattached))
+		Expect(os.ReadFile(status.Path)).To(BeEquivalentTo("data"))
+
+		By("rejecting another handle")
+		_, err = reattachable.Reattach(ctx, spec, "other-machine", status.Handle)
+		Expect(err).To(MatchError(volume.ErrVolumeNotFound))
+
+		By("reporting a removed disk as not found")
+		Expect(os.Remove(status.Path)).To(Succeed())
+		_, err = reattachable.Reattach(ctx, spec, "machine", status.Handle)
+		Expect(err).To(MatchError(volume.ErrVolumeNotFound))
+	})
+
+	It("should snapshot a disk only under a valid name", func(ctx SpecContext) {
+		spec := &api.VolumeSpec{Name: "data", LocalDisk: &api.LocalDiskSpec{Size: 1024 * 1024}}
+		status, err := plugin.Apply(ctx, spec, "machine")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(status.Path, []byte("data"), 0666)).To(Succeed())
+
+		By("snapshotting the disk")
+		Expect(plugin.Snapshot(ctx, "data", "machine", "snap-1")).To(Succeed())
+		snapshotDir := filepath.Join(filepath.Dir(status.Path), "snapshots")
+		Expect(os.ReadFile(filepath.Join(snapshotDir, "snap-1.raw"))).To(BeEquivalentTo("data"))
+
+		By("keeping the existing snapshot when the snapshot is retried")
+		Expect(os.WriteFile(status.Path, []byte("changed"), 0666)).To(Succeed())
+		Expect(plugin.Snapshot(ctx, "data", "machine", "snap-1")).To(Succeed())
+		Expect(os.ReadFile(filepath.Join(snapshotDir, "snap-1.raw"))).To(BeEquivalentTo("data"))
+
+		By("rejecting a name escaping the snapshot directory")
+		Expect(plugin.Snapshot(ctx, "data", "machine", "../../escaped")).To(MatchError(ContainSubstring("snapshot name")))
+		Expect(filepath.Join(filepath.Dir(status.Path), "..", "escaped.raw")).NotTo(BeAnExistingFile())
+	})
+
+	Context("with sparse disks", func() {
+		BeforeEach(func() {
+			plugin = localdisk.NewPlugin(raw.Exec{}, imageCache, localdisk.Options{Sparse: true})
+			Expect(plugin.Init(paths)).To(Succeed())
+		})
+
+		It("should report the blocks freed by a discard of the guest", func(ctx SpecContext) {
+			const mib = 1024 * 1024
+			spec := &api.VolumeSpec{Name: "data", LocalDisk: &api.LocalDiskSpec{Size: 64 * mib}}
+			status, err := plugin.Apply(ctx, spec, "machine")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.AllocatedSize).To(BeNumerically("<", status.Size))
+
+			By("writing data to the disk")
+			disk, err := os.OpenFile(status.Path, os.O_WRONLY, 0)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(disk.Close)
+			_, err = disk.WriteAt(bytes.Repeat([]byte{0xff}, 8*mib), 16*mib)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(disk.Sync()).To(Succeed())
+
+			reattachable := plugin.(volume.ReattachablePlugin)
+			written, err := reattachable.Reattach(ctx, spec, "machine", status.Handle)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(written.AllocatedSize).To(BeNumerically(">=", status.AllocatedSize+8*mib))
+
+			By("discarding the data like cloud-hypervisor does for the guest")
+			Expect(unix.Fallocate(int(disk.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, 16*mib, 8*mib)).To(Succeed())
+
+			discarded, err := reattachable.Reattach(ctx, spec, "machine", status.Handle)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(discarded.AllocatedSize).To(Equal(status.AllocatedSize))
+			Expect(discarded.Size).To(Equal(int64(64 * mib)))
+		})
+	})
+
+	Context("with a filesystem", func() {
+		applyEmptyDisk := func(ctx context.Context, filesystem api.Filesystem) (*api.VolumeStatus, error) {
+			return plugin.Apply(ctx, &api.VolumeSpec{
+				Name:      "data",
+				LocalDisk: &api.LocalDiskSpec{Size: 64 * 1024 * 1024, Filesystem: filesystem},
+			}, "machine-1")
+		}
+
+		It("should pre-format an empty disk", func(ctx SpecContext) {
+			if _, err := exec.LookPath("mkfs.ext4"); err != nil {
+				Skip("mkfs.ext4 is not available")
+			}
+
+			status, err := applyEmptyDisk(ctx, api.FilesystemExt4)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Size).To(Equal(int64(64 * 1024 * 1024)))
+
+			By("ensuring the disk holds the ext4 superblock magic")
+			disk, err := os.Open(status.Path)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(disk.Close)
+			magic := make([]byte, 2)
+			_, err = disk.ReadAt(magic, 1024+0x38)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(magic).To(Equal([]byte{0x53, 0xef}))
+		})
+
+		It("should reject an unsupported filesystem", func(ctx SpecContext) {
+			_, err := applyEmptyDisk(ctx, "btrfs")
+			Expect(err).To(MatchError(ContainSubstring(`invalid filesystem "btrfs"`)))
+			Expect(filepath.Join(paths.MachineVolumeDir("machine-1", utilstrings.EscapeQualifiedName(plugin.Name()), "data"), "disk.raw")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Context("with image overlays", func() {
+		BeforeEach(func() {
+			plugin = localdisk.NewPlugin(raw.Exec{}, imageCache, localdisk.Options{ImageOverlays: true})
+			Expect(plugin.Init(paths)).To(Succeed())
+		})
+
+		It("should keep a shared base until no machine references it anymore", func(ctx SpecContext) {
+			writeImage("rootfs v1")
+
+			By("creating the overlay disks of two machines")
+			status1 := applyImageDisk("machine-1")
+			status2 := applyImageDisk("machine-2")
+			Expect(status1.Path).To(HaveSuffix("disk.qcow2"))
+			Expect(status1.Path).NotTo(Equal(status2.Path))
+
+			bases := sharedBases()
+			Expect(bases).To(HaveLen(1))
+			Expect(os.ReadFile(bases[0])).To(Equal([]byte("rootfs v1")))
+			baseStat, err := os.Stat(bases[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(baseStat.Mode().Perm()).To(Equal(os.FileMode(0444)))
+
+			overlay, err := os.ReadFile(status1.Path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(overlay)).To(ContainSubstring(bases[0]))
+
+			By("deleting the first machine")
+			Expect(plugin.Delete(ctx, "root", "machine-1")).To(Succeed())
+			Expect(status1.Path).NotTo(BeAnExistingFile())
+			Expect(sharedBases()).To(Equal(bases))
+
+			healthy, err := plugin.IsHealthy(ctx, "root", "machine-2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(healthy).To(BeTrue())
+
+			By("deleting the second machine")
+			Expect(plugin.Delete(ctx, "root", "machine-2")).To(Succeed())
+			Expect(sharedBases()).To(BeEmpty())
+		})
+
+		It("should keep applying a disk created before overlays were enabled", func() {
+			writeImage("rootfs v1")
+			plugin = localdisk.NewPlugin(raw.Exec{}, imageCache, localdisk.Options{})
+			Expect(plugin.Init(paths)).To(Succeed())
+			status := applyImageDisk("machine-1")
+			Expect(status.Path).To(HaveSuffix("disk.raw"))
+
+			plugin = localdisk.NewPlugin(raw.Exec{}, imageCache, localdisk.Options{ImageOverlays: true})
+			Expect(plugin.Init(paths)).To(Succeed())
+			Expect(applyImageDisk("machine-1")).To(Equal(status))
+			Expect(os.ReadFile(status.Path)).To(Equal([]byte("rootfs v1")))
+			Expect(filepath.Join(filepath.Dir(status.Path), "disk.qcow2")).NotTo(BeAnExistingFile())
+		})
+
+		It("should snapshot overlay disks as overlays of the same shared base", func(ctx SpecContext) {
+			writeImage("rootfs v1")
+			status := applyImageDisk("machine-1")
+			bases := sharedBases()
+			Expect(bases).To(HaveLen(1))
+
+			Expect(plugin.Snapshot(ctx, "root", "machine-1", "snap")).To(Succeed())
+			snapshotFilename := filepath.Join(filepath.Dir(status.Path), "snapshots", "snap.qcow2")
+			overlay, err := os.ReadFile(status.Path)
+			Expect(err).NotTo(HaveOccurred())
+			snapshot, err := os.ReadFile(snapshotFilename)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshot).To(Equal(overlay))
+			Expect(string(snapshot)).To(ContainSubstring(bases[0]))
+		})
+	})
+})
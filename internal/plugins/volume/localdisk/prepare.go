@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package localdisk
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+)
+
+type preparation struct {
+	done bool
+	err  error
+}
+
+// prepare creates the disk file in the background, as copying an image may take long. It returns
+// volume.ErrPreparing while the disk is created and reports a failed creation once, the next call retries.
+// The disk is created next to its final location and only moved into place once it is complete.
+func (p *plugin) prepare(
+	ctx context.Context,
+	filename, volumeName, machineID string,
+	create func(ctx context.Context, filename string) error,
+) error {
+	p.preparationsMu.Lock()
+	defer p.preparationsMu.Unlock()
+
+	if prep, ok := p.preparations[filename]; ok {
+		if !prep.done {
+			return volume.ErrPreparing
+		}
+		delete(p.preparations, filename)
+		return prep.err
+	}
+
+	if ok, err := osutils.RegularFileExists(filename); err != nil || ok {
+		return err
+	}
+
+	log := logr.FromContextOrDiscard(ctx)
+	log.V(1).Info("Preparing disk in the background", "file", filename)
+
+	prep := &preparation{}
+	p.preparations[filename] = prep
+
+	// The preparation outlives the reconciliation starting it.
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		err := createInPlace(ctx, filename, create)
+		if err != nil {
+			log.V(1).Info("Failed to prepare disk", "file", filename, "error", err.Error())
+		}
+
+		p.preparationsMu.Lock()
+		prep.done, prep.err = true, err
+		if err == nil {
+			delete(p.preparations, filename)
+		}
+		listeners := p.listeners
+		p.preparationsMu.Unlock()
+
+		for _, listener := range listeners {
+			listener.HandlePrepared(volume.PreparedEvent{MachineID: machineID, VolumeName: volumeName})
+		}
+	}()
+
+	return volume.ErrPreparing
+}
+
+func createInPlace(ctx context.Context, filename string, create func(ctx context.Context, filename string) error) error {
+	tmpFilename := filename + ".tmp"
+	_ = os.Remove(tmpFilename)
+
+	if err := create(ctx, tmpFilename); err != nil {
+		_ = os.Remove(tmpFilename)
+		return err
+	}
+	if err := os.Chmod(tmpFilename, os.FileMode(0666)); err != nil {
+		return fmt.Errorf("error changing disk file mode: %w", err)
+	}
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		return fmt.Errorf("error moving disk into place: %w", err)
+	}
+	return nil
+}
+
+// forgetPreparations drops the finished preparations of the disk files. Running preparations fail once
+// the volume directory is removed.
+func (p *plugin) forgetPreparations(filenames ...string) {
+	p.preparationsMu.Lock()
+	defer p.preparationsMu.Unlock()
+
+	for _, filename := range filenames {
+		if prep, ok := p.preparations[filename]; ok && prep.done {
+			delete(p.preparations, filename)
+		}
+	}
+}
+
+func (p *plugin) AddListener(listener volume.Listener) {
+	p.preparationsMu.Lock()
+	defer p.preparationsMu.Unlock()
+	p.listeners = append(p.listeners, listener)
+}
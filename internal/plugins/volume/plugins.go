@@ -5,7 +5,9 @@ package volume
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"sync"
 
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
@@ -16,6 +18,9 @@ type Host interface {
 	PluginDir(pluginName string) string
 	MachinePluginDir(machineID string, pluginName string) string
 	MachineVolumeDir(machineID string, pluginName, volumeName string) string
+	// MachineVolumeSocket is the path of the socket a volume is served on, kept short to fit the socket path
+	// limit.
+	MachineVolumeSocket(machineID string, pluginName, volumeName string) string
 }
 
 type Plugin interface {
@@ -26,6 +31,114 @@ type Plugin interface {
 
 	Apply(ctx context.Context, spec *api.VolumeSpec, machineID string) (*api.VolumeStatus, error)
 	Delete(ctx context.Context, computeVolumeName string, machineID string) error
+	// Snapshot snapshots the volume under the name. An existing snapshot of the name counts as success, so a
+	// snapshot of the machine can be retried after it failed on another volume.
+	Snapshot(ctx context.Context, computeVolumeName string, machineID string, snapshotName string) error
+	// IsHealthy reports whether the backend of an applied volume is still usable. An error is returned if
+	// the health could not be determined.
+	IsHealthy(ctx context.Context, computeVolumeName string, machineID string) (bool, error)
+}
+
+var (
+	// ErrPreparing is returned by Apply while the volume is prepared in the background.
+	ErrPreparing = errors.New("volume is being prepared")
+	// ErrVolumeNotFound is returned by Reattach if the backing of the volume does not exist anymore.
+	ErrVolumeNotFound = errors.New("volume not found")
+	// ErrMigrating is returned by Migrate while the data of the volume is moved to its new backing.
+	ErrMigrating = errors.New("volume is being migrated")
+	// ErrPluginNotFound is returned by FindPluginBySpec if no plugin of the host supports the volume.
+	ErrPluginNotFound = errors.New("no volume plugin found")
+	// ErrSecretNotFound is returned by Apply if the secret referenced by the volume does not exist (yet).
+	ErrSecretNotFound = errors.New("volume secret not found")
+)
+
+var snapshotNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// maxSnapshotNameLength keeps snapshot names usable as file names and rbd snapshot names.
+const maxSnapshotNameLength = 63
+
+// ValidateSnapshotName validates that the name of a snapshot is safe to use as file or image name.
+func ValidateSnapshotName(name string) error {
+	if len(name) > maxSnapshotNameLength {
+		return fmt.Errorf("snapshot name %q is longer than %d characters", name, maxSnapshotNameLength)
+	}
+	if !snapshotNameRegex.MatchString(name) {
+		return fmt.Errorf("snapshot name %q must consist of alphanumeric characters, '.', '_' or '-' and start "+
+			"with an alphanumeric character", name)
+	}
+	return nil
+}
+
+// PreparedEvent reports that the background preparation of a volume finished, successfully or not.
+type PreparedEvent struct {
+	MachineID  string
+	VolumeName string
+}
+
+type Listener interface {
+	HandlePrepared(evt PreparedEvent)
+}
+
+type ListenerFuncs struct {
+	HandlePreparedFunc func(evt PreparedEvent)
+}
+
+func (l ListenerFuncs) HandlePrepared(evt PreparedEvent) {
+	if l.HandlePreparedFunc != nil {
+		l.HandlePreparedFunc(evt)
+	}
+}
+
+// AsyncPlugin is implemented by plugins preparing volumes in the background. Apply returns ErrPreparing
+// until the preparation finished, the listeners are notified once it did.
+type AsyncPlugin interface {
+	Plugin
+	AddListener(listener Listener)
+}
+
+// ReattachablePlugin is implemented by plugins able to reattach the existing backing of an applied volume,
+// so it is attached again as is instead of provisioned by Apply. Reattach must report the same status Apply
+// reports for an existing backing, it only differs in never creating one.
+type ReattachablePlugin interface {
+	Plugin
+	// Reattach returns the status of the existing backing of the volume with the handle, or
+	// ErrVolumeNotFound if there is none.
+	Reattach(ctx context.Context, spec *api.VolumeSpec, machineID string, handle string) (*api.VolumeStatus, error)
+}
+
+// FlushablePlugin is implemented by plugins whose backends may cache writes of the guest, so they are
+// flushed to durable storage before the vm is powered off.
+type FlushablePlugin interface {
+	Plugin
+	// Flush writes the cached writes of the volume back. Volumes not caching writes are skipped.
+	Flush(ctx context.Context, spec *api.VolumeSpec, machineID string) error
+}
+
+// MigratablePlugin is implemented by plugins able to move the data of an attached volume to another backing
+// of the same plugin while the vm keeps running, e.g. for storage maintenance. Volumes are not migrated across
+// plugins. Apply has to keep reporting the handle and path the vm knows a migrated volume by.
+type MigratablePlugin interface {
+	Plugin
+	// Migrate moves the data of the attached volume with the status to the backing of the spec and switches the
+	// vm over to it at once. It returns ErrMigrating until the switchover happened, the status of the migrated
+	// volume afterward, keeping the handle and path the vm knows the volume by, and nil if the volume already
+	// is on the backing of the spec.
+	Migrate(ctx context.Context, spec *api.VolumeSpec, machineID string, status *api.VolumeStatus) (*api.VolumeStatus, error)
+}
+
+// StatsPlugin is implemented by plugins whose backends count the io of the guest on their volumes.
+type StatsPlugin interface {
+	Plugin
+	// Stats returns the io stats of the attached volume, or nil if the backend has none for it.
+	Stats(ctx context.Context, computeVolumeName string, machineID string) (*Stats, error)
+}
+
+// Stats are the io counters of a volume since it was attached.
+type Stats struct {
+	ReadBytes       int64
+	WrittenBytes    int64
+	ReadOperations  int64
+	WriteOperations int64
 }
 
 type PluginManager struct {
@@ -65,6 +178,18 @@ func (m *PluginManager) InitPlugins(host Host, plugins []Plugin) error {
 	return nil
 }
 
+// AddListener registers the listener with every plugin preparing volumes in the background.
+func (m *PluginManager) AddListener(listener Listener) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, plugin := range m.plugins {
+		if asyncPlugin, ok := plugin.(AsyncPlugin); ok {
+			asyncPlugin.AddListener(listener)
+		}
+	}
+}
+
 func (m *PluginManager) FindPluginByName(name string) (Plugin, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -88,7 +213,10 @@ func (m *PluginManager) FindPluginBySpec(volume *api.VolumeSpec) (Plugin, error)
 	}
 	switch len(matching) {
 	case 0:
-		return nil, fmt.Errorf("no plugin found supporting %#+v", volume)
+		if volume.Connection != nil {
+			return nil, fmt.Errorf("%w for volume %s with driver %s", ErrPluginNotFound, volume.Name, volume.Connection.Driver)
+		}
+		return nil, fmt.Errorf("%w for volume %s", ErrPluginNotFound, volume.Name)
 	case 1:
 		return matching[0], nil
 	default:
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package volume
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Factory creates a plugin registered via Register.
+type Factory func() (Plugin, error)
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a plugin available in addition to the built-in plugins. It is meant to be called from
+// the init function of the package providing the plugin and panics if a factory is registered twice
+// under the same name.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("volume plugin %s registered without factory", name))
+	}
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("volume plugin %s registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// RegisteredPlugins creates the plugins of all registered factories, ordered by their registration name.
+func RegisteredPlugins() ([]Plugin, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var plugins []Plugin
+	for _, name := range slices.Sorted(maps.Keys(registry)) {
+		plugin, err := registry[name]()
+		if err != nil {
+			return nil, fmt.Errorf("error creating registered plugin %s: %w", name, err)
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, nil
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package volume_test
+
+import (
+	"context"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const customDriver = "custom"
+
+type customPlugin struct{}
+
+func (customPlugin) Init(volume.Host) error { return nil }
+
+func (customPlugin) Name() string { return "example.org/custom" }
+
+func (customPlugin) GetBackingVolumeID(spec *api.VolumeSpec) (string, error) { return spec.Name, nil }
+
+func (customPlugin) CanSupport(spec *api.VolumeSpec) bool {
+	return spec.Connection != nil && spec.Connection.Driver == customDriver
+}
+
+func (customPlugin) Apply(_ context.Context, spec *api.VolumeSpec, _ string) (*api.VolumeStatus, error) {
+	return &api.VolumeStatus{Name: spec.Name, State: api.VolumeStatePrepared}, nil
+}
+
+func (customPlugin) Delete(context.Context, string, string) error { return nil }
+
+func (customPlugin) Snapshot(context.Context, string, string, string) error { return nil }
+
+func (customPlugin) IsHealthy(context.Context, string, string) (bool, error) { return true, nil }
+
+func init() {
+	volume.Register("custom", func() (volume.Plugin, error) {
+		return customPlugin{}, nil
+	})
+}
+
+var _ = Describe("Registry", func() {
+	It("should make registered plugins selectable by spec", func() {
+		paths, err := host.PathsAt(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+
+		plugins, err := volume.RegisteredPlugins()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plugins).To(ConsistOf(customPlugin{}))
+
+		manager := volume.NewPluginManager()
+		Expect(manager.InitPlugins(paths, plugins)).To(Succeed())
+
+		plugin, err := manager.FindPluginBySpec(&api.VolumeSpec{
+			Name:       "data",
+			Connection: &api.VolumeConnection{Driver: customDriver},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plugin.Name()).To(Equal("example.org/custom"))
+	})
+
+	It("should reject registering a plugin twice", func() {
+		Expect(func() {
+			volume.Register("custom", func() (volume.Plugin, error) { return customPlugin{}, nil })
+		}).To(Panic())
+	})
+})
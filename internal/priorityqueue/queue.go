@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package priorityqueue provides a workqueue.Queue handing out prioritized items ahead of all others.
+package priorityqueue
+
+import (
+	"slices"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Queue holds two FIFO queues: prioritized items and all others. Items are popped from the prioritized queue
+// first. It is meant as the underlying queue of a workqueue, which keeps items unique and calls it under its
+// own lock.
+type Queue[T comparable] struct {
+	mu sync.Mutex
+	// prioritized holds the items whose next push goes to the high queue.
+	prioritized sets.Set[T]
+	high, low   []T
+}
+
+func New[T comparable]() *Queue[T] {
+	return &Queue[T]{prioritized: sets.New[T]()}
+}
+
+// Prioritize marks the item to be handed out ahead of non-prioritized items. It has to be called before the
+// item is added to the workqueue. An item waiting in the queue already is moved ahead.
+func (q *Queue[T]) Prioritize(item T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prioritized.Insert(item)
+}
+
+func (q *Queue[T]) Touch(item T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.prioritized.Has(item) {
+		return
+	}
+	if idx := slices.Index(q.low, item); idx >= 0 {
+		q.low = slices.Delete(q.low, idx, idx+1)
+		q.high = append(q.high, item)
+	}
+}
+
+func (q *Queue[T]) Push(item T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.prioritized.Has(item) {
+		q.high = append(q.high, item)
+		return
+	}
+	q.low = append(q.low, item)
+}
+
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.high) + len(q.low)
+}
+
+func (q *Queue[T]) Pop() T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var item T
+	if len(q.high) > 0 {
+		item, q.high = q.high[0], q.high[1:]
+	} else {
+		item, q.low = q.low[0], q.low[1:]
+	}
+	q.prioritized.Delete(item)
+	return item
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package priorityqueue_test
+
+import (
+	"fmt"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/priorityqueue"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var _ = Describe("Queue", func() {
+	var (
+		prio  *priorityqueue.Queue[string]
+		queue workqueue.TypedInterface[string]
+	)
+
+	BeforeEach(func() {
+		prio = priorityqueue.New[string]()
+		queue = workqueue.NewTypedWithConfig(workqueue.TypedQueueConfig[string]{Queue: prio})
+		DeferCleanup(queue.ShutDown)
+	})
+
+	get := func() string {
+		item, shutdown := queue.Get()
+		Expect(shutdown).To(BeFalse())
+		queue.Done(item)
+		return item
+	}
+
+	It("should hand out a prioritized item ahead of a backlog of resyncs", func() {
+		for i := range 10 {
+			queue.Add(fmt.Sprintf("resync-%d", i))
+		}
+
+		prio.Prioritize("created")
+		queue.Add("created")
+
+		Expect(get()).To(Equal("created"))
+		Expect(get()).To(Equal("resync-0"))
+		Expect(queue.Len()).To(Equal(9))
+	})
+
+	It("should move a waiting item ahead once it is prioritized", func() {
+		queue.Add("first")
+		queue.Add("second")
+
+		prio.Prioritize("second")
+		queue.Add("second")
+
+		Expect(get()).To(Equal("second"))
+		Expect(get()).To(Equal("first"))
+	})
+
+	It("should prioritize an item only once", func() {
+		queue.Add("first")
+		prio.Prioritize("second")
+		queue.Add("second")
+		Expect(get()).To(Equal("second"))
+
+		queue.Add("second")
+		Expect(get()).To(Equal("first"))
+		Expect(get()).To(Equal("second"))
+	})
+
+	It("should prioritize an item added while it is processed once it is done", func() {
+		queue.Add("processing")
+		item, _ := queue.Get()
+		queue.Add("waiting")
+
+		prio.Prioritize("processing")
+		queue.Add("processing")
+		queue.Done(item)
+
+		Expect(get()).To(Equal("processing"))
+		Expect(get()).To(Equal("waiting"))
+	})
+})
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package raw
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+const (
+	qcow2Magic       = 0x514649fb
+	qcow2Version     = 3
+	qcow2ClusterBits = 16
+	qcow2ClusterSize = 1 << qcow2ClusterBits
+	qcow2HeaderLen   = 104
+
+	// qcow2RefcountOrder selects 16 bit refcounts.
+	qcow2RefcountOrder = 4
+
+	qcow2ExtBackingFormat = 0xe2792aca
+
+	// qcow2BytesPerL1Entry is the guest data mapped by a single l2 table.
+	qcow2BytesPerL1Entry = qcow2ClusterSize / 8 * qcow2ClusterSize
+
+	maxBackingFileLen = 1023
+)
+
+// createQcow2Overlay writes an empty qcow2 image of the given size with a raw backing file. The image
+// consists of the header, a refcount table, a single refcount block and the zeroed l1 table, so all
+// reads are served by the backing file until the guest writes.
+func createQcow2Overlay(filename, backingFile string, size int64) error {
+	if len(backingFile) > maxBackingFileLen {
+		return fmt.Errorf("backing file path exceeds %d bytes", maxBackingFileLen)
+	}
+	if size <= 0 {
+		return fmt.Errorf("size must be positive, got %d", size)
+	}
+
+	l1Size := (size + qcow2BytesPerL1Entry - 1) / qcow2BytesPerL1Entry
+	l1Clusters := (l1Size*8 + qcow2ClusterSize - 1) / qcow2ClusterSize
+
+	const (
+		refcountTableCluster = 1
+		refcountBlockCluster = 2
+		l1TableCluster       = 3
+	)
+	clusters := l1TableCluster + l1Clusters
+	if clusters > qcow2ClusterSize/2 {
+		return fmt.Errorf("size %d exceeds the supported overlay size", size)
+	}
+
+	metadata := make([]byte, l1TableCluster*qcow2ClusterSize)
+
+	header := metadata[:qcow2ClusterSize]
+	be := binary.BigEndian
+	be.PutUint32(header[0:], qcow2Magic)
+	be.PutUint32(header[4:], qcow2Version)
+	be.PutUint32(header[20:], qcow2ClusterBits)
+	be.PutUint64(header[24:], uint64(size))
+	be.PutUint32(header[36:], uint32(l1Size))
+	be.PutUint64(header[40:], l1TableCluster*qcow2ClusterSize)
+	be.PutUint64(header[48:], refcountTableCluster*qcow2ClusterSize)
+	be.PutUint32(header[56:], 1)
+	be.PutUint32(header[96:], qcow2RefcountOrder)
+	be.PutUint32(header[100:], qcow2HeaderLen)
+
+	// Header extensions: the backing file format, padded to 8 bytes, followed by the end marker.
+	ext := header[qcow2HeaderLen:]
+	be.PutUint32(ext[0:], qcow2ExtBackingFormat)
+	be.PutUint32(ext[4:], uint32(len("raw")))
+	copy(ext[8:], "raw")
+	backingFileOffset := qcow2HeaderLen + 16 + 8
+
+	copy(header[backingFileOffset:], backingFile)
+	be.PutUint64(header[8:], uint64(backingFileOffset))
+	be.PutUint32(header[16:], uint32(len(backingFile)))
+
+	be.PutUint64(metadata[refcountTableCluster*qcow2ClusterSize:], refcountBlockCluster*qcow2ClusterSize)
+
+	refcountBlock := metadata[refcountBlockCluster*qcow2ClusterSize:]
+	for cluster := range clusters {
+		be.PutUint16(refcountBlock[cluster*2:], 1)
+	}
+
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed opening destination file: %w", err)
+	}
+
+	if _, err := file.Write(metadata); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write qcow2 metadata: %w", err)
+	}
+	// The l1 table is all zeros, extending the file keeps it a hole.
+	if err := file.Truncate(clusters * qcow2ClusterSize); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to allocate qcow2 l1 table: %w", err)
+	}
+
+	return file.Close()
+}
@@ -28,9 +28,82 @@ func (s WithSourceFile) ApplyToCreate(o *CreateOptions) {
 	o.SourceFile = string(s)
 }
 
+// WithBackingFile creates a qcow2 overlay reading unwritten clusters from the given raw backing file,
+// which is never modified and may be shared between disks.
+type WithBackingFile string
+
+func (s WithBackingFile) ApplyToCreate(o *CreateOptions) {
+	o.BackingFile = string(s)
+}
+
+type WithSparse bool
+
+func (s WithSparse) ApplyToCreate(o *CreateOptions) {
+	o.Sparse = bool(s)
+}
+
+type Preallocation string
+
+const (
+	// PreallocationNone does not allocate any blocks up front.
+	PreallocationNone Preallocation = "none"
+	// PreallocationMetadata reserves all blocks of the disk without writing them.
+	PreallocationMetadata Preallocation = "metadata"
+	// PreallocationFull allocates all blocks of the disk by writing zeros to them.
+	PreallocationFull Preallocation = "full"
+)
+
+func ValidatePreallocation(mode Preallocation) error {
+	switch mode {
+	case "", PreallocationNone, PreallocationMetadata, PreallocationFull:
+		return nil
+	default:
+		return fmt.Errorf("invalid preallocation mode %q, must be one of %s, %s, %s",
+			mode, PreallocationNone, PreallocationMetadata, PreallocationFull)
+	}
+}
+
+type WithPreallocation Preallocation
+
+func (s WithPreallocation) ApplyToCreate(o *CreateOptions) {
+	o.Preallocation = Preallocation(s)
+}
+
+type withZeroFill struct{}
+
+func (withZeroFill) ApplyToCreate(o *CreateOptions) {
+	o.ZeroFill = true
+}
+
+// WithZeroFill explicitly overwrites the whole disk with zeros on creation.
+func WithZeroFill() CreateOption {
+	return withZeroFill{}
+}
+
 type CreateOptions struct {
-	Size       *int64
-	SourceFile string
+	Size          *int64
+	SourceFile    string
+	BackingFile   string
+	Sparse        bool
+	Preallocation Preallocation
+	ZeroFill      bool
+}
+
+func (o *CreateOptions) Validate() error {
+	if err := ValidatePreallocation(o.Preallocation); err != nil {
+		return err
+	}
+	preallocate := o.Preallocation != "" && o.Preallocation != PreallocationNone
+	if o.Sparse && (preallocate || o.ZeroFill) {
+		return fmt.Errorf("sparse disks cannot be preallocated or zero-filled")
+	}
+	if o.SourceFile != "" && o.ZeroFill {
+		return fmt.Errorf("disks created from a source file cannot be zero-filled")
+	}
+	if o.BackingFile != "" && (o.SourceFile != "" || preallocate || o.ZeroFill) {
+		return fmt.Errorf("overlay disks cannot be created from a source file, preallocated or zero-filled")
+	}
+	return nil
 }
 
 func (o *CreateOptions) ApplyToCreate(o2 *CreateOptions) {
@@ -40,6 +113,18 @@ func (o *CreateOptions) ApplyToCreate(o2 *CreateOptions) {
 	if o.SourceFile != "" {
 		o2.SourceFile = o.SourceFile
 	}
+	if o.BackingFile != "" {
+		o2.BackingFile = o.BackingFile
+	}
+	if o.Sparse {
+		o2.Sparse = o.Sparse
+	}
+	if o.Preallocation != "" {
+		o2.Preallocation = o.Preallocation
+	}
+	if o.ZeroFill {
+		o2.ZeroFill = o.ZeroFill
+	}
 }
 
 func (o *CreateOptions) ApplyOptions(opts []CreateOption) {
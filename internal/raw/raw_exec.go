@@ -4,36 +4,67 @@
 package raw
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 
 	"github.com/go-logr/logr"
+	"golang.org/x/sys/unix"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 type Exec struct{}
 
-const filePerm = 0660
+const (
+	filePerm = 0660
+
+	sparseChunkSize = 64 * 1024
+)
 
 func (Exec) Create(filename string, opts ...CreateOption) error {
 	o := &CreateOptions{}
 	o.ApplyOptions(opts)
 	log := ctrl.Log.WithName("raw-disk").WithValues("filename", filename)
 
-	if o.SourceFile == "" {
+	if err := o.Validate(); err != nil {
+		return fmt.Errorf("invalid create options: %w", err)
+	}
+
+	if o.BackingFile != "" {
+		if o.Size == nil {
+			return fmt.Errorf("must specify Size when creating an overlay")
+		}
+		if err := createQcow2Overlay(filename, o.BackingFile, *o.Size); err != nil {
+			return fmt.Errorf("failed creating overlay disk at %s, backing file: %s: %w", filename, o.BackingFile, err)
+		}
+	} else if o.SourceFile == "" {
 		if o.Size == nil {
 			return fmt.Errorf("must specify Size when creating without source file")
 		}
-		seek := *o.Size
-		// Position the file cursor one byte before the desired seek position to write a single byte,
-		// to ensure that data is written at the exact byte position specified by seek.
-		if err := createEmptyFileWithSeek(log, filename, seek-1); err != nil {
+
+		var err error
+		switch {
+		case o.ZeroFill || o.Preallocation == PreallocationFull:
+			err = createZeroFilledFile(log, filename, *o.Size)
+		case o.Preallocation == PreallocationMetadata:
+			err = createFallocatedFile(log, filename, *o.Size)
+		default:
+			// Position the file cursor one byte before the desired seek position to write a single byte,
+			// to ensure that data is written at the exact byte position specified by seek.
+			err = createEmptyFileWithSeek(log, filename, *o.Size-1)
+		}
+		if err != nil {
 			return fmt.Errorf("failed creating the empty ephemeral disk at %s: %w", filename, err)
 		}
 	} else {
-		if err := copyFile(log, o.SourceFile, filename); err != nil {
+		copyFn := copyFile
+		if o.Sparse {
+			copyFn = copySparseFile
+		}
+		if err := copyFn(log, o.SourceFile, filename); err != nil {
 			return fmt.Errorf("failed creating virtual disk image, source: %s, destination: %s: %w", o.SourceFile, filename, err)
 		}
 	}
@@ -64,6 +95,51 @@ func createEmptyFileWithSeek(log logr.Logger, filename string, seek int64) error
 	return nil
 }
 
+func createZeroFilledFile(log logr.Logger, filename string, size int64) error {
+	dstFile, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed opening destination file: %w", err)
+	}
+
+	defer func() {
+		if err := dstFile.Close(); err != nil {
+			log.Error(err, "error closing file in createZeroFilledFile")
+		}
+	}()
+
+	if _, err := io.CopyN(dstFile, zeroReader{}, size); err != nil {
+		return fmt.Errorf("failed to write zeros to destination file: %w", err)
+	}
+
+	return dstFile.Sync()
+}
+
+func createFallocatedFile(log logr.Logger, filename string, size int64) error {
+	dstFile, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed opening destination file: %w", err)
+	}
+
+	defer func() {
+		if err := dstFile.Close(); err != nil {
+			log.Error(err, "error closing file in createFallocatedFile")
+		}
+	}()
+
+	if err := unix.Fallocate(int(dstFile.Fd()), 0, 0, size); err != nil {
+		return fmt.Errorf("failed to allocate destination file: %w", err)
+	}
+
+	return nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
 func copyFile(log logr.Logger, src, dst string) error {
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -93,6 +169,62 @@ func copyFile(log logr.Logger, src, dst string) error {
 	return nil
 }
 
+func copySparseFile(log logr.Logger, src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed opening source file: %w", err)
+	}
+	defer func() {
+		if err := srcFile.Close(); err != nil {
+			log.Error(err, "error closing source file in copySparseFile", "path", src)
+		}
+	}()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePerm)
+	if err != nil {
+		return fmt.Errorf("failed opening destination file: %w", err)
+	}
+
+	defer func() {
+		if err := dstFile.Close(); err != nil {
+			log.Error(err, "error closing destination file in copySparseFile")
+		}
+	}()
+
+	var (
+		buf    = make([]byte, sparseChunkSize)
+		zeroes = make([]byte, sparseChunkSize)
+		offset int64
+	)
+	for {
+		n, err := io.ReadFull(srcFile, buf)
+		if n > 0 {
+			// Skip all-zero chunks so that the destination keeps a hole instead of allocated blocks.
+			if bytes.Equal(buf[:n], zeroes[:n]) {
+				if _, err := dstFile.Seek(int64(n), io.SeekCurrent); err != nil {
+					return fmt.Errorf("failed seeking destination file: %w", err)
+				}
+			} else if _, err := dstFile.Write(buf[:n]); err != nil {
+				return fmt.Errorf("failed to write data to destination file: %w", err)
+			}
+			offset += int64(n)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return fmt.Errorf("failed to read data from source file: %w", err)
+		}
+	}
+
+	// A trailing hole is not materialized by seeking alone, so the file has to be truncated to its final size.
+	if err := dstFile.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to truncate destination file: %w", err)
+	}
+
+	return nil
+}
+
 func init() {
 	utilruntime.Must(impls.Add("exec", 0, Exec{}))
 }
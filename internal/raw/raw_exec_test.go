@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package raw_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/raw"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exec", func() {
+	var (
+		tempDir string
+		rawInst raw.Exec
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+
+		supported, err := osutils.SupportsSparseFiles(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		if !supported {
+			Skip("filesystem does not support sparse files")
+		}
+	})
+
+	It("should create a sparse copy of a source file", func() {
+		By("creating a mostly empty source file")
+		src := filepath.Join(tempDir, "src.raw")
+		data := make([]byte, 8*1024*1024)
+		copy(data[1024*1024:], "some data")
+		Expect(os.WriteFile(src, data, 0600)).To(Succeed())
+
+		By("creating the sparse disk")
+		dst := filepath.Join(tempDir, "disk.raw")
+		Expect(rawInst.Create(dst, raw.WithSourceFile(src), raw.WithSparse(true))).To(Succeed())
+
+		By("inspecting the disk")
+		content, err := os.ReadFile(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal(data))
+
+		allocated, err := osutils.AllocatedSize(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocated).To(BeNumerically("<", int64(len(data))))
+	})
+
+	It("should create an empty sparse disk of the given size", func() {
+		dst := filepath.Join(tempDir, "disk.raw")
+		Expect(rawInst.Create(dst, raw.WithSize(16*1024*1024), raw.WithSparse(true))).To(Succeed())
+
+		stat, err := os.Stat(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stat.Size()).To(Equal(int64(16 * 1024 * 1024)))
+
+		allocated, err := osutils.AllocatedSize(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocated).To(BeNumerically("<", stat.Size()))
+	})
+})
+
+var _ = Describe("Exec preallocation", func() {
+	var (
+		tempDir string
+		rawInst raw.Exec
+	)
+
+	const size = 8 * 1024 * 1024
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+	})
+
+	It("should create a fully preallocated disk occupying its full size", func() {
+		dst := filepath.Join(tempDir, "disk.raw")
+		Expect(rawInst.Create(dst, raw.WithSize(size), raw.WithPreallocation(raw.PreallocationFull))).To(Succeed())
+
+		stat, err := os.Stat(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stat.Size()).To(Equal(int64(size)))
+
+		allocated, err := osutils.AllocatedSize(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allocated).To(BeNumerically(">=", int64(size)))
+	})
+
+	It("should create a zero-filled disk", func() {
+		dst := filepath.Join(tempDir, "disk.raw")
+		Expect(rawInst.Create(dst, raw.WithSize(size), raw.WithZeroFill())).To(Succeed())
+
+		content, err := os.ReadFile(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(Equal(make([]byte, size)))
+	})
+
+	It("should reject invalid preallocation modes", func() {
+		dst := filepath.Join(tempDir, "disk.raw")
+		Expect(rawInst.Create(dst, raw.WithSize(size), raw.WithPreallocation("falloc"))).
+			To(MatchError(ContainSubstring("invalid preallocation mode")))
+		Expect(dst).NotTo(BeAnExistingFile())
+	})
+
+	It("should reject preallocating sparse disks", func() {
+		dst := filepath.Join(tempDir, "disk.raw")
+		Expect(rawInst.Create(
+			dst,
+			raw.WithSize(size),
+			raw.WithSparse(true),
+			raw.WithPreallocation(raw.PreallocationFull),
+		)).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("Exec overlay", func() {
+	var rawInst raw.Exec
+
+	It("should create a qcow2 overlay referencing the raw backing file", func() {
+		tempDir := GinkgoT().TempDir()
+		base := filepath.Join(tempDir, "base.raw")
+		Expect(os.WriteFile(base, []byte("base"), 0444)).To(Succeed())
+
+		dst := filepath.Join(tempDir, "disk.qcow2")
+		const size = 10 * 1024 * 1024 * 1024
+		Expect(rawInst.Create(dst, raw.WithBackingFile(base), raw.WithSize(size))).To(Succeed())
+
+		content, err := os.ReadFile(dst)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(content).To(HaveLen(4 * 64 * 1024))
+
+		header := content[:104]
+		Expect(binary.BigEndian.Uint32(header[0:])).To(BeEquivalentTo(0x514649fb))
+		Expect(binary.BigEndian.Uint32(header[4:])).To(BeEquivalentTo(3))
+		Expect(binary.BigEndian.Uint64(header[24:])).To(BeEquivalentTo(size))
+		Expect(binary.BigEndian.Uint32(header[36:])).To(BeEquivalentTo(20))
+
+		backingFileOffset := binary.BigEndian.Uint64(header[8:])
+		backingFileSize := binary.BigEndian.Uint32(header[16:])
+		Expect(string(content[backingFileOffset : backingFileOffset+uint64(backingFileSize)])).To(Equal(base))
+
+		By("ensuring the backing file is untouched")
+		Expect(os.ReadFile(base)).To(Equal([]byte("base")))
+	})
+
+	It("should reject overlays created from a source file", func() {
+		tempDir := GinkgoT().TempDir()
+		Expect(rawInst.Create(
+			filepath.Join(tempDir, "disk.qcow2"),
+			raw.WithBackingFile(filepath.Join(tempDir, "base.raw")),
+			raw.WithSourceFile(filepath.Join(tempDir, "src.raw")),
+			raw.WithSize(1024*1024),
+		)).To(MatchError(ContainSubstring("overlay disks cannot be created from a source file")))
+	})
+})
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/audit"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ActorMetadataKey is the request metadata naming the actor of an audited request. Requests without it are
+// attributed to their user agent.
+const ActorMetadataKey = "x-actor"
+
+// auditedOperations maps the requests affecting vms to the operation they are audited as.
+var auditedOperations = map[string]string{
+	iri.MachineRuntime_CreateMachine_FullMethodName:          "create",
+	iri.MachineRuntime_DeleteMachine_FullMethodName:          "delete",
+	iri.MachineRuntime_UpdateMachinePower_FullMethodName:     "power-on",
+	iri.MachineRuntime_AttachVolume_FullMethodName:           "attach-volume",
+	iri.MachineRuntime_DetachVolume_FullMethodName:           "detach-volume",
+	iri.MachineRuntime_UpdateVolume_FullMethodName:           "update-volume",
+	iri.MachineRuntime_AttachNetworkInterface_FullMethodName: "attach-network-interface",
+	iri.MachineRuntime_DetachNetworkInterface_FullMethodName: "detach-network-interface",
+}
+
+// AuditRequests returns a unary interceptor writing an audit record for every request affecting vms.
+func AuditRequests(auditLog *audit.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		operation, ok := auditedOperations[info.FullMethod]
+		if auditLog == nil || !ok {
+			return handler(ctx, req)
+		}
+		if powerReq, ok := req.(*iri.UpdateMachinePowerRequest); ok && powerReq.GetPower() == iri.Power_POWER_OFF {
+			operation = "power-off"
+		}
+
+		resp, err := handler(ctx, req)
+
+		var machineID string
+		switch r := req.(type) {
+		case interface{ GetMachineId() string }:
+			machineID = r.GetMachineId()
+		case *iri.CreateMachineRequest:
+			if createResp, ok := resp.(*iri.CreateMachineResponse); ok {
+				machineID = createResp.GetMachine().GetMetadata().GetId()
+			}
+		}
+
+		if auditErr := auditLog.Record(operation, machineID, actorFrom(ctx), err); auditErr != nil {
+			ctrl.LoggerFrom(ctx).Error(auditErr, "Failed to audit request", "operation", operation)
+		}
+		return resp, err
+	}
+}
+
+func actorFrom(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if actor := md.Get(ActorMetadataKey); len(actor) > 0 {
+		return actor[0]
+	}
+	if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
+		return userAgent[0]
+	}
+	return ""
+}
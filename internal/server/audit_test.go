@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/audit"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var _ = Describe("AuditRequests", func() {
+	var (
+		auditBuf    *bytes.Buffer
+		interceptor grpc.UnaryServerInterceptor
+	)
+
+	BeforeEach(func() {
+		auditBuf = &bytes.Buffer{}
+		interceptor = server.AuditRequests(audit.NewLogger(auditBuf))
+	})
+
+	auditRecords := func() []audit.Record {
+		var records []audit.Record
+		scanner := bufio.NewScanner(bytes.NewReader(auditBuf.Bytes()))
+		for scanner.Scan() {
+			var record audit.Record
+			Expect(json.Unmarshal(scanner.Bytes(), &record)).To(Succeed())
+			records = append(records, record)
+		}
+		return records
+	}
+
+	It("should audit creating and powering off a machine", func(ctx SpecContext) {
+		actorCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(server.ActorMetadataKey, "machinepoollet"))
+
+		By("creating a machine")
+		resp, err := interceptor(actorCtx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		}, &grpc.UnaryServerInfo{FullMethod: iri.MachineRuntime_CreateMachine_FullMethodName},
+			func(ctx context.Context, req any) (any, error) {
+				return machineServer.CreateMachine(ctx, req.(*iri.CreateMachineRequest))
+			})
+		Expect(err).NotTo(HaveOccurred())
+		machineID := resp.(*iri.CreateMachineResponse).Machine.Metadata.Id
+		DeferCleanup(machineClient.DeleteMachine, &iri.DeleteMachineRequest{MachineId: machineID})
+
+		By("powering off the machine")
+		powerOff := func(machineID string) error {
+			_, err := interceptor(actorCtx, &iri.UpdateMachinePowerRequest{
+				MachineId: machineID,
+				Power:     iri.Power_POWER_OFF,
+			}, &grpc.UnaryServerInfo{FullMethod: iri.MachineRuntime_UpdateMachinePower_FullMethodName},
+				func(ctx context.Context, req any) (any, error) {
+					return machineServer.UpdateMachinePower(ctx, req.(*iri.UpdateMachinePowerRequest))
+				})
+			return err
+		}
+		Expect(powerOff(machineID)).To(Succeed())
+
+		By("failing to power off an unknown machine")
+		Expect(powerOff("unknown")).NotTo(Succeed())
+
+		Expect(auditRecords()).To(HaveExactElements(
+			SatisfyAll(
+				HaveField("Operation", "create"),
+				HaveField("MachineID", machineID),
+				HaveField("Actor", "machinepoollet"),
+				HaveField("Result", audit.ResultSuccess),
+				HaveField("Time", Not(BeZero())),
+			),
+			SatisfyAll(
+				HaveField("Operation", "power-off"),
+				HaveField("MachineID", machineID),
+				HaveField("Actor", "machinepoollet"),
+				HaveField("Result", audit.ResultSuccess),
+			),
+			SatisfyAll(
+				HaveField("Operation", "power-off"),
+				HaveField("MachineID", "unknown"),
+				HaveField("Result", audit.ResultFailure),
+				HaveField("Error", Not(BeEmpty())),
+			),
+		))
+	})
+
+	It("should not audit requests not affecting vms", func(ctx SpecContext) {
+		_, err := interceptor(ctx, &iri.ListMachinesRequest{},
+			&grpc.UnaryServerInfo{FullMethod: iri.MachineRuntime_ListMachines_FullMethodName},
+			func(ctx context.Context, req any) (any, error) {
+				return machineServer.ListMachines(ctx, req.(*iri.ListMachinesRequest))
+			})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(auditBuf.Len()).To(BeZero())
+	})
+})
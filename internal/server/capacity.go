@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
+)
+
+const unlimitedQuantity = 1000
+
+// claimLock serializes checking the capacity for and creating machines. With capacity accounting, the lock
+// is shared with the servers of the other pools.
+func (s *Server) claimLock() sync.Locker {
+	if s.capacity != nil {
+		return s.capacity
+	}
+	return &s.claimMu
+}
+
+// diskPressure reports whether the free space of the disk filesystem fell below the configured minimum.
+func (s *Server) diskPressure() (bool, error) {
+	if s.minFreeDisk <= 0 || s.diskDir == "" {
+		return false, nil
+	}
+
+	free, err := osutils.FreeDiskSpace(s.diskDir)
+	if err != nil {
+		return false, err
+	}
+	return free < s.minFreeDisk, nil
+}
+
+func (s *Server) classQuantity(ctx context.Context, class mcr.MachineClass) (int64, error) {
+	if s.Draining() {
+		return 0, nil
+	}
+	if pressure, err := s.diskPressure(); err != nil {
+		return 0, fmt.Errorf("error checking disk pressure: %w", err)
+	} else if pressure {
+		return 0, nil
+	}
+	if s.capacity == nil {
+		return unlimitedQuantity, nil
+	}
+
+	free, err := s.capacity.Free(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return capacity.Quantity(free, class), nil
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+// SetDraining puts the server into (or out of) drain mode. While draining, no capacity is reported and
+// new machines are rejected, existing machines are still served and reconciled.
+func (s *Server) SetDraining(draining bool) {
+	s.draining.Store(draining)
+}
+
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// Shutdown permanently stops the server from accepting new machines. It is the first stage of shutting
+// down the provider, before in-flight requests and reconciliations are drained.
+func (s *Server) Shutdown() {
+	s.shuttingDown.Store(true)
+}
+
+func (s *Server) ShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Drain", func() {
+	newMachine := func() *iri.Machine {
+		return &iri.Machine{
+			Metadata: &irimeta.ObjectMetadata{},
+			Spec: &iri.MachineSpec{
+				Power: iri.Power_POWER_ON,
+				Class: machineClassName,
+			},
+		}
+	}
+
+	It("should reject new machines while keeping existing ones managed", func(ctx SpecContext) {
+		By("creating a machine")
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{Machine: newMachine()})
+		Expect(err).NotTo(HaveOccurred())
+		machineID := createResp.Machine.Metadata.Id
+
+		By("draining the host")
+		machineServer.SetDraining(true)
+		DeferCleanup(machineServer.SetDraining, false)
+
+		By("ensuring no capacity is reported")
+		statusResp, err := machineClient.Status(ctx, &iri.StatusRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statusResp.MachineClassStatus).To(ConsistOf(HaveField("Quantity", int64(0))))
+
+		By("ensuring new machines are rejected")
+		_, err = machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{Machine: newMachine()})
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+
+		By("updating the existing machine")
+		Expect(machineClient.UpdateMachinePower(ctx, &iri.UpdateMachinePowerRequest{
+			MachineId: machineID,
+			Power:     iri.Power_POWER_OFF,
+		})).Error().NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Power).To(Equal(api.PowerStatePowerOff))
+
+		By("leaving drain mode")
+		machineServer.SetDraining(false)
+		Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{Machine: newMachine()})).Error().
+			NotTo(HaveOccurred())
+	})
+})
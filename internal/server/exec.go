@@ -5,12 +5,177 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-logr/logr"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	"github.com/ironcore-dev/provider-utils/storeutils/store"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-func (s *Server) Exec(ctx context.Context, req *iri.ExecRequest) (*iri.ExecResponse, error) {
-	return &iri.ExecResponse{
-		Url: "",
+const (
+	// DefaultExecTokenTTL is the time an exec url handed out by Exec can be connected to.
+	DefaultExecTokenTTL = time.Minute
+
+	// ExecPath is the path the exec sessions are served under, followed by their token.
+	ExecPath = "/exec/"
+)
+
+type StreamerOptions struct {
+	// URL is the url the streaming server is reachable at, prefixing the exec urls.
+	URL string
+	// TokenTTL is the time an exec url can be connected to. Defaults to DefaultExecTokenTTL.
+	TokenTTL time.Duration
+	// SerialSocket returns the serial socket of the vm of the machine the sessions are bridged to.
+	SerialSocket func(machineID string) string
+}
+
+// Streamer serves the exec sessions handed out by Exec. Every session is reachable at a url with a one-time token
+// that is upgraded to a websocket bridging the serial socket of the vm.
+type Streamer struct {
+	log          logr.Logger
+	url          string
+	tokenTTL     time.Duration
+	serialSocket func(machineID string) string
+
+	mu       sync.Mutex
+	sessions map[string]execSession
+}
+
+type execSession struct {
+	machineID string
+	expires   time.Time
+}
+
+func NewStreamer(log logr.Logger, opts StreamerOptions) (*Streamer, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("URL option is required")
+	}
+	if opts.SerialSocket == nil {
+		return nil, fmt.Errorf("SerialSocket option is required")
+	}
+	if opts.TokenTTL == 0 {
+		opts.TokenTTL = DefaultExecTokenTTL
+	}
+
+	return &Streamer{
+		log:          log,
+		url:          strings.TrimSuffix(opts.URL, "/"),
+		tokenTTL:     opts.TokenTTL,
+		serialSocket: opts.SerialSocket,
+		sessions:     make(map[string]execSession),
 	}, nil
 }
+
+// register returns the url of a new session of the machine.
+func (s *Streamer) register(machineID string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate exec token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for t, session := range s.sessions {
+		if now.After(session.expires) {
+			delete(s.sessions, t)
+		}
+	}
+	s.sessions[token] = execSession{machineID: machineID, expires: now.Add(s.tokenTTL)}
+	return s.url + ExecPath + token, nil
+}
+
+// take removes the session of the token, returning its machine if the token has not expired.
+func (s *Streamer) take(token string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[token]
+	if !ok {
+		return "", false
+	}
+	delete(s.sessions, token)
+	if time.Now().After(session.expires) {
+		return "", false
+	}
+	return session.machineID, true
+}
+
+func (s *Streamer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	token, ok := strings.CutPrefix(req.URL.Path, ExecPath)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	machineID, ok := s.take(token)
+	if !ok {
+		http.Error(w, "exec session not found or expired", http.StatusNotFound)
+		return
+	}
+
+	log := s.log.WithValues("machineID", machineID)
+	conn, err := net.Dial("unix", s.serialSocket(machineID))
+	if err != nil {
+		log.Error(err, "Failed to connect to serial socket")
+		http.Error(w, "serial console of machine is not available", http.StatusServiceUnavailable)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	// The token authenticates the session, so the origin is not checked.
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		ws.PayloadType = websocket.BinaryFrame
+		log.V(1).Info("Bridging exec session")
+		bridge(ws, conn)
+		log.V(1).Info("Closed exec session")
+	}}.ServeHTTP(w, req)
+}
+
+// bridge copies between both connections until either of them is closed.
+func bridge(a, b io.ReadWriteCloser) {
+	done := make(chan struct{}, 2)
+	copyAndClose := func(dst, src io.ReadWriteCloser) {
+		_, _ = io.Copy(dst, src)
+		_ = dst.Close()
+		_ = src.Close()
+		done <- struct{}{}
+	}
+	go copyAndClose(a, b)
+	go copyAndClose(b, a)
+	<-done
+	<-done
+}
+
+func (s *Server) Exec(ctx context.Context, req *iri.ExecRequest) (*iri.ExecResponse, error) {
+	log := s.loggerFrom(ctx, "MachineID", req.MachineId)
+
+	if s.streamer == nil {
+		return nil, status.Errorf(codes.Unimplemented, "exec is not enabled")
+	}
+
+	if _, err := s.machineStore.Get(ctx, req.MachineId); err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("error getting machine: %w", err)
+		}
+		return nil, status.Errorf(codes.NotFound, "machine %s not found", req.MachineId)
+	}
+
+	log.V(1).Info("Registering exec session")
+	url, err := s.streamer.register(req.MachineId)
+	if err != nil {
+		return nil, err
+	}
+	return &iri.ExecResponse{Url: url}, nil
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
+	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Exec", func() {
+	var (
+		srv       *server.Server
+		streaming *httptest.Server
+		socketDir string
+	)
+
+	newServer := func(tokenTTL time.Duration) {
+		classRegistry, err := mcr.NewMachineClassRegistry([]mcr.MachineClass{
+			{Name: machineClassName, Cpu: 1, MemoryBytes: 1024 * 1024 * 1024},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		store, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+			Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+			NewFunc:        func() *api.Machine { return &api.Machine{} },
+			CreateStrategy: strategy.MachineStrategy,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Unix socket paths are length limited, keep the sockets in a short temp dir.
+		socketDir, err = os.MkdirTemp("", "exec")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, socketDir)
+
+		streamer, err := server.NewStreamer(GinkgoLogr, server.StreamerOptions{
+			URL:      "http://streaming.local/",
+			TokenTTL: tokenTTL,
+			SerialSocket: func(machineID string) string {
+				return filepath.Join(socketDir, machineID[:8]+".sock")
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		streaming = httptest.NewServer(streamer)
+		DeferCleanup(streaming.Close)
+
+		srv, err = server.New(store, server.Options{
+			MachineClassRegistry: classRegistry,
+			Streamer:             streamer,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	createMachine := func(ctx SpecContext) string {
+		createResp, err := srv.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{},
+				Spec:     &iri.MachineSpec{Power: iri.Power_POWER_ON, Class: machineClassName},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return createResp.Machine.Metadata.Id
+	}
+
+	// localURL returns the exec url served by the test streaming server.
+	localURL := func(execURL string) string {
+		return strings.Replace(execURL, "http://streaming.local", streaming.URL, 1)
+	}
+
+	It("should return an exec url bridged to the serial socket of the machine", func(ctx SpecContext) {
+		newServer(time.Minute)
+		machineID := createMachine(ctx)
+
+		By("serving the serial socket like cloud-hypervisor")
+		listener, err := net.Listen("unix", filepath.Join(socketDir, machineID[:8]+".sock"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(listener.Close)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+			_, _ = io.Copy(conn, conn)
+		}()
+
+		By("getting the exec url")
+		resp, err := srv.Exec(ctx, &iri.ExecRequest{MachineId: machineID})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Url).To(HavePrefix("http://streaming.local/exec/"))
+
+		By("connecting to the exec url")
+		wsURL := strings.Replace(localURL(resp.Url), "http://", "ws://", 1)
+		ws, err := websocket.Dial(wsURL, "", streaming.URL)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(ws.Close)
+
+		Expect(websocket.Message.Send(ws, []byte("login\n"))).To(Succeed())
+		var msg []byte
+		Expect(websocket.Message.Receive(ws, &msg)).To(Succeed())
+		Expect(string(msg)).To(Equal("login\n"))
+
+		By("rejecting a second connection with the same token")
+		Expect(http.Get(localURL(resp.Url))).To(HaveField("StatusCode", http.StatusNotFound))
+	})
+
+	It("should reject an exec url once its token expired", func(ctx SpecContext) {
+		newServer(time.Nanosecond)
+		machineID := createMachine(ctx)
+
+		resp, err := srv.Exec(ctx, &iri.ExecRequest{MachineId: machineID})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(http.Get(localURL(resp.Url))).To(HaveField("StatusCode", http.StatusNotFound))
+	})
+
+	It("should return not found for a machine that does not exist", func(ctx SpecContext) {
+		newServer(time.Minute)
+
+		_, err := srv.Exec(ctx, &iri.ExecRequest{MachineId: "unknown"})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should return unimplemented if exec is not enabled", func(ctx SpecContext) {
+		_, err := machineServer.Exec(ctx, &iri.ExecRequest{MachineId: "unknown"})
+		Expect(status.Code(err)).To(Equal(codes.Unimplemented))
+	})
+})
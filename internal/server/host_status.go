@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Features of the host advertised in the HostStatus.
+const (
+	FeatureConfigDrive            = "config-drive"
+	FeaturePciPassthrough         = "pci-passthrough"
+	FeatureQoSCgroups             = "qos-cgroups"
+	FeatureSparseLocalDisks       = "sparse-local-disks"
+	FeatureLocalDiskImageOverlays = "local-disk-image-overlays"
+)
+
+// HostStatus complements the machine classes reported by Status with host level information for
+// placement decisions, as the iri status response is limited to the machine classes.
+type HostStatus struct {
+	// Capacity and Allocatable are only reported if capacity accounting is enabled.
+	Capacity    corev1.ResourceList `json:"capacity,omitempty"`
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
+
+	Features []string `json:"features,omitempty"`
+
+	CloudHypervisorVersion    string `json:"cloudHypervisorVersion,omitempty"`
+	CloudHypervisorAPIVersion string `json:"cloudHypervisorAPIVersion,omitempty"`
+
+	MachineClasses []MachineClassQuantity `json:"machineClasses"`
+}
+
+type MachineClassQuantity struct {
+	Name     string `json:"name"`
+	Quantity int64  `json:"quantity"`
+}
+
+// HostStatus returns the status of the host the server manages machines on.
+func (s *Server) HostStatus(ctx context.Context) (*HostStatus, error) {
+	status := &HostStatus{
+		Features:                  slices.Sorted(slices.Values(s.features)),
+		CloudHypervisorVersion:    s.versionInfo.CloudHypervisorVersion,
+		CloudHypervisorAPIVersion: s.versionInfo.CloudHypervisorAPIVersion,
+	}
+
+	if s.capacity != nil {
+		allocatable := s.capacity.Allocatable()
+		if s.Draining() {
+			allocatable = capacity.Resources{}
+		}
+		status.Capacity = resourceList(s.capacity.Capacity())
+		status.Allocatable = resourceList(allocatable)
+	}
+
+	for _, class := range s.machineClassRegistry.List() {
+		quantity, err := s.classQuantity(ctx, class)
+		if err != nil {
+			return nil, fmt.Errorf("error getting quantity of class %s: %w", class.Name, err)
+		}
+		status.MachineClasses = append(status.MachineClasses, MachineClassQuantity{
+			Name:     class.Name,
+			Quantity: quantity,
+		})
+	}
+
+	return status, nil
+}
+
+// HostStatusHandler serves the HostStatus of the server as json.
+func (s *Server) HostStatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, err := s.HostStatus(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			s.loggerFrom(r.Context()).Error(err, "Failed to write host status")
+		}
+	})
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server/version"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HostStatus", func() {
+	const gib = 1024 * 1024 * 1024
+
+	It("should report the host resources, features and versions next to the machine classes", func(ctx SpecContext) {
+		classRegistry, err := mcr.NewMachineClassRegistry([]mcr.MachineClass{
+			{
+				Name:        machineClassName,
+				Cpu:         2,
+				MemoryBytes: 4 * gib,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		store, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+			Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+			NewFunc:        func() *api.Machine { return &api.Machine{} },
+			CreateStrategy: strategy.MachineStrategy,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		srv, err := server.New(store, server.Options{
+			MachineClassRegistry: classRegistry,
+			HostResources:        &capacity.Resources{Cpu: 8, MemoryBytes: 16 * gib},
+			MemoryReserve:        4 * gib,
+			Features:             []string{server.FeaturePciPassthrough, server.FeatureConfigDrive},
+			VersionInfo: version.Info{
+				CloudHypervisorVersion:    "v41.0",
+				CloudHypervisorAPIVersion: "0.3.0",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		status, err := srv.HostStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Capacity.Cpu().Value()).To(Equal(int64(8)))
+		Expect(status.Capacity.Memory().Value()).To(Equal(int64(16 * gib)))
+		Expect(status.Allocatable.Cpu().Value()).To(Equal(int64(8)))
+		Expect(status.Allocatable.Memory().Value()).To(Equal(int64(12 * gib)))
+		Expect(status.Features).To(Equal([]string{server.FeatureConfigDrive, server.FeaturePciPassthrough}))
+		Expect(status.CloudHypervisorVersion).To(Equal("v41.0"))
+		Expect(status.CloudHypervisorAPIVersion).To(Equal("0.3.0"))
+		Expect(status.MachineClasses).To(ConsistOf(server.MachineClassQuantity{Name: machineClassName, Quantity: 3}))
+
+		By("keeping the machine classes of the iri status")
+		iriStatus, err := srv.Status(ctx, &iri.StatusRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(iriStatus.MachineClassStatus).To(ConsistOf(HaveField("Quantity", int64(3))))
+
+		By("serving the status as json")
+		rec := httptest.NewRecorder()
+		srv.HostStatusHandler().ServeHTTP(rec, httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		var served server.HostStatus
+		Expect(json.Unmarshal(rec.Body.Bytes(), &served)).To(Succeed())
+		Expect(served.Features).To(Equal(status.Features))
+		Expect(served.Allocatable.Memory().Value()).To(Equal(int64(12 * gib)))
+	})
+})
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RequestLimits bounds the requests served by the grpc server. Zero values disable the respective limit.
+type RequestLimits struct {
+	// Timeout is the maximum duration a request is served before it fails with codes.DeadlineExceeded.
+	Timeout time.Duration
+	// MaxConcurrent is the maximum number of requests served at once. Excess requests are rejected with
+	// codes.ResourceExhausted.
+	MaxConcurrent int
+}
+
+type handlerResult struct {
+	resp any
+	err  error
+}
+
+// LimitRequests returns a unary interceptor enforcing the given limits.
+func LimitRequests(limits RequestLimits) grpc.UnaryServerInterceptor {
+	var slots chan struct{}
+	if limits.MaxConcurrent > 0 {
+		slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		release := func() {}
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+				release = func() { <-slots }
+			default:
+				return nil, status.Errorf(codes.ResourceExhausted, "too many concurrent requests (limit %d)", limits.MaxConcurrent)
+			}
+		}
+
+		if limits.Timeout <= 0 {
+			defer release()
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+
+		// The handler keeps its concurrency slot until it actually returns, even if the request timed out.
+		done := make(chan handlerResult, 1)
+		go func() {
+			defer release()
+			resp, err := handler(ctx, req)
+			done <- handlerResult{resp, err}
+		}()
+
+		select {
+		case res := <-done:
+			if res.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && status.Code(res.err) == codes.Unknown {
+				return nil, status.Errorf(codes.DeadlineExceeded, "%s timed out after %s", info.FullMethod, limits.Timeout)
+			}
+			return res.resp, res.err
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, status.Errorf(codes.DeadlineExceeded, "%s timed out after %s", info.FullMethod, limits.Timeout)
+			}
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("LimitRequests", func() {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Method"}
+
+	It("should reject requests exceeding the concurrency limit", func(ctx SpecContext) {
+		interceptor := server.LimitRequests(server.RequestLimits{MaxConcurrent: 1})
+
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			_, err := interceptor(ctx, nil, info, func(context.Context, any) (any, error) {
+				close(started)
+				<-unblock
+				return "ok", nil
+			})
+			done <- err
+		}()
+		Eventually(started).Should(BeClosed())
+
+		By("issuing an excess request")
+		_, err := interceptor(ctx, nil, info, func(context.Context, any) (any, error) { return "ok", nil })
+		Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+
+		By("finishing the first request and retrying")
+		close(unblock)
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(interceptor(ctx, nil, info, func(context.Context, any) (any, error) { return "ok", nil })).
+			To(Equal("ok"))
+	})
+
+	It("should fail slow requests with deadline exceeded", func(ctx SpecContext) {
+		interceptor := server.LimitRequests(server.RequestLimits{Timeout: 50 * time.Millisecond})
+
+		unblock := make(chan struct{})
+		DeferCleanup(func() { close(unblock) })
+		_, err := interceptor(ctx, nil, info, func(context.Context, any) (any, error) {
+			<-unblock
+			return "ok", nil
+		})
+		Expect(status.Code(err)).To(Equal(codes.DeadlineExceeded))
+	})
+})
@@ -4,10 +4,14 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/utils/ptr"
 )
 
@@ -146,6 +150,20 @@ func (s *Server) getIRINICStatus(machine *api.Machine) ([]*iri.NetworkInterfaceS
 	return nics, nil
 }
 
+func (s *Server) getIRIMachineConditions(machine *api.Machine) []*iri.Conditions {
+	var conditions []*iri.Conditions
+	for _, condition := range machine.Status.Conditions {
+		conditions = append(conditions, &iri.Conditions{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.UnixNano(),
+		})
+	}
+	return conditions
+}
+
 func (s *Server) getIRIMachineStatus(machine *api.Machine) (*iri.MachineStatus, error) {
 	state, err := s.getIRIState(machine.Status.State)
 	if err != nil {
@@ -168,6 +186,7 @@ func (s *Server) getIRIMachineStatus(machine *api.Machine) (*iri.MachineStatus,
 		ImageRef:           machine.Status.ImageRef,
 		Volumes:            volumes,
 		NetworkInterfaces:  nics,
+		MachineConditions:  s.getIRIMachineConditions(machine),
 	}, nil
 }
 
@@ -216,7 +235,8 @@ func (s *Server) getIRIState(state api.MachineState) (iri.MachineState, error) {
 
 func (s *Server) getIRIPower(state api.PowerState) (iri.Power, error) {
 	switch state {
-	case api.PowerStatePowerOn:
+	// The iri has no paused power, a paused machine is still powered on.
+	case api.PowerStatePowerOn, api.PowerStatePaused:
 		return iri.Power_POWER_ON, nil
 	case api.PowerStatePowerOff:
 		return iri.Power_POWER_OFF, nil
@@ -272,6 +292,18 @@ func (s *Server) getVolumeFromIRIVolume(iriVolume *iri.Volume) (*api.VolumeSpec,
 	return volumeSpec, nil
 }
 
+// checkVolumePlugin rejects the volume if no plugin of the host supports it, as it could never be attached.
+func (s *Server) checkVolumePlugin(volumeSpec *api.VolumeSpec) error {
+	if s.volumePlugins == nil {
+		return nil
+	}
+
+	if _, err := s.volumePlugins.FindPluginBySpec(volumeSpec); errors.Is(err, volume.ErrPluginNotFound) {
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	return nil
+}
+
 func (s *Server) getNICFromIRINIC(iriNIC *iri.NetworkInterface) (*api.NetworkInterfaceSpec, error) {
 	if iriNIC == nil {
 		return nil, fmt.Errorf("networkInterface is nil")
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
 	"github.com/ironcore-dev/provider-utils/storeutils/store"
 	"google.golang.org/grpc/codes"
@@ -19,6 +20,7 @@ func (s *Server) updateAnnotations(ctx context.Context, machine *api.Machine, an
 	if err := api.SetAnnotationsAnnotation(machine, annotations); err != nil {
 		return fmt.Errorf("failed to set machine annotations: %w", err)
 	}
+	machine.Spec.Snapshot = annotations[api.SnapshotAnnotation]
 
 	if _, err := s.machineStore.Update(ctx, machine); err != nil {
 		return fmt.Errorf("failed to update machine: %w", err)
@@ -41,6 +43,12 @@ func (s *Server) UpdateMachineAnnotations(
 		return nil, status.Errorf(codes.NotFound, "machine %s not found", req.MachineId)
 	}
 
+	if name := req.Annotations[api.SnapshotAnnotation]; name != "" {
+		if err := volume.ValidateSnapshotName(name); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid snapshot: %v", err)
+		}
+	}
+
 	if err := s.updateAnnotations(ctx, machine, req.Annotations); err != nil {
 		return nil, fmt.Errorf("failed to update machine annotations: %w", err)
 	}
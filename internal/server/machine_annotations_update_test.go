@@ -4,11 +4,14 @@
 package server_test
 
 import (
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
 	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
 	machinepoolletv1alpha1 "github.com/ironcore-dev/ironcore/poollet/machinepoollet/api/v1alpha1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var _ = Describe("UpdateMachineAnnotations", func() {
@@ -46,4 +49,40 @@ var _ = Describe("UpdateMachineAnnotations", func() {
 		Expect(updatedMachine.Machines).To(HaveLen(1))
 		Expect(updatedMachine.Machines[0].Metadata.Annotations).To(HaveKeyWithValue("foo", "bar"))
 	})
+
+	It("should request a snapshot of the machine volumes", func(ctx SpecContext) {
+		By("creating a machine")
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Labels: map[string]string{
+						machinepoolletv1alpha1.MachineUIDLabel: "foobar",
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		machineID := createResp.Machine.Metadata.Id
+
+		By("rejecting an invalid snapshot name")
+		_, err = machineClient.UpdateMachineAnnotations(ctx, &iri.UpdateMachineAnnotationsRequest{
+			MachineId:   machineID,
+			Annotations: map[string]string{api.SnapshotAnnotation: "../escaped"},
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+		By("requesting a snapshot")
+		Expect(machineClient.UpdateMachineAnnotations(ctx, &iri.UpdateMachineAnnotationsRequest{
+			MachineId:   machineID,
+			Annotations: map[string]string{api.SnapshotAnnotation: "snap-1"},
+		})).Error().NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Snapshot).To(Equal("snap-1"))
+	})
 })
@@ -5,13 +5,22 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/configdrive"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/pci"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
 	apiutils "github.com/ironcore-dev/provider-utils/apiutils/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (s *Server) createMachineFromIRIMachine(
@@ -30,9 +39,34 @@ func (s *Server) createMachineFromIRIMachine(
 		return nil, fmt.Errorf("iri machine metadata is nil")
 	}
 
-	class, found := s.machineClassRegistry.Get(iriMachine.Spec.Class)
+	className := iriMachine.Spec.Class
+	if className == "" {
+		log.V(1).Info("No machine class specified, using default", "class", s.defaultMachineClass)
+		className = s.defaultMachineClass
+	}
+
+	class, found := s.machineClassRegistry.Get(className)
 	if !found {
-		return nil, fmt.Errorf("machine class %s not supported", iriMachine.Spec.Class)
+		return nil, fmt.Errorf("machine class %s not supported", className)
+	}
+
+	claimLock := s.claimLock()
+	claimLock.Lock()
+	defer claimLock.Unlock()
+
+	if s.ShuttingDown() {
+		return nil, status.Errorf(codes.Unavailable, "server is shutting down, not accepting new machines")
+	}
+	if s.Draining() {
+		return nil, status.Errorf(codes.Unavailable, "host is draining, not accepting new machines")
+	}
+
+	quantity, err := s.classQuantity(ctx, class)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get machine class quantity: %w", err)
+	}
+	if quantity < 1 {
+		return nil, status.Errorf(codes.ResourceExhausted, "insufficient resources for machine class %s", class.Name)
 	}
 
 	power, err := s.getPowerStateFromIRI(iriMachine.Spec.Power)
@@ -40,12 +74,39 @@ func (s *Server) createMachineFromIRIMachine(
 		return nil, fmt.Errorf("failed to get power state: %w", err)
 	}
 
+	volumeSerials, err := getVolumeSerials(iriMachine.Metadata.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume serials: %w", err)
+	}
+
+	volumeTunings, err := getVolumeTunings(iriMachine.Metadata.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume tuning: %w", err)
+	}
+
+	volumeFilesystems, err := getVolumeFilesystems(iriMachine.Metadata.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume filesystems: %w", err)
+	}
+
 	var volumes []*api.VolumeSpec
 	for _, iriVolume := range iriMachine.Spec.Volumes {
 		volumeSpec, err := s.getVolumeFromIRIVolume(iriVolume)
 		if err != nil {
 			return nil, fmt.Errorf("error converting volume: %w", err)
 		}
+		// Volumes are matched with their status by name.
+		if slices.ContainsFunc(volumes, func(volume *api.VolumeSpec) bool { return volume.Name == volumeSpec.Name }) {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate volume name %s", volumeSpec.Name)
+		}
+		if err := s.checkVolumePlugin(volumeSpec); err != nil {
+			return nil, err
+		}
+		volumeSpec.Serial = volumeSerials[volumeSpec.Name]
+		volumeSpec.Tuning = volumeTunings[volumeSpec.Name]
+		if err := setVolumeFilesystem(volumeSpec, volumeFilesystems[volumeSpec.Name]); err != nil {
+			return nil, err
+		}
 
 		volumes = append(volumes, volumeSpec)
 	}
@@ -61,6 +122,31 @@ func (s *Server) createMachineFromIRIMachine(
 		networkInterfaces = append(networkInterfaces, networkInterfaceSpec)
 	}
 
+	pciDevices, err := getPciDevicesFromIRIMachine(iriMachine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pci devices: %w", err)
+	}
+
+	bootTimeout, err := getBootTimeoutFromIRIMachine(iriMachine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get boot timeout: %w", err)
+	}
+
+	configDrive, err := getConfigDriveFromIRIMachine(iriMachine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config drive: %w", err)
+	}
+
+	boot, err := getBootFromIRIMachine(iriMachine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get boot: %w", err)
+	}
+
+	vsock, err := getVsockFromIRIMachine(iriMachine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vsock: %w", err)
+	}
+
 	machine := &api.Machine{
 		Metadata: apiutils.Metadata{
 			ID: s.idGen.Generate(),
@@ -69,16 +155,23 @@ func (s *Server) createMachineFromIRIMachine(
 			Power:             power,
 			Cpu:               int64(math.Max(float64(class.Cpu), 1)),
 			MemoryBytes:       class.MemoryBytes,
+			QoSClass:          class.QoSClass,
+			NumaNode:          class.NumaNode,
 			Volumes:           volumes,
 			Ignition:          iriMachine.Spec.IgnitionData,
 			NetworkInterfaces: networkInterfaces,
+			PciDevices:        pciDevices,
+			BootTimeout:       bootTimeout,
+			ConfigDrive:       configDrive,
+			Boot:              boot,
+			Vsock:             vsock,
 		},
 	}
 
 	if err := api.SetObjectMetadata(machine, iriMachine.Metadata); err != nil {
 		return nil, fmt.Errorf("failed to set metadata: %w", err)
 	}
-	api.SetClassLabel(machine, iriMachine.Spec.Class)
+	api.SetClassLabel(machine, class.Name)
 	api.SetManagerLabel(machine, api.MachineManager)
 
 	apiMachine, err := s.machineStore.Create(ctx, machine)
@@ -89,6 +182,201 @@ func (s *Server) createMachineFromIRIMachine(
 	return apiMachine, nil
 }
 
+func getPciDevicesFromIRIMachine(iriMachine *iri.Machine) ([]string, error) {
+	value := iriMachine.Metadata.Annotations[api.PciDevicesAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	var bdfs []string
+	for _, bdf := range strings.Split(value, ",") {
+		bdf = strings.TrimSpace(bdf)
+		if err := pci.ValidateBDF(bdf); err != nil {
+			return nil, err
+		}
+		if slices.Contains(bdfs, bdf) {
+			return nil, fmt.Errorf("duplicate pci address %s", bdf)
+		}
+		bdfs = append(bdfs, bdf)
+	}
+	return bdfs, nil
+}
+
+func getBootTimeoutFromIRIMachine(iriMachine *iri.Machine) (time.Duration, error) {
+	value := iriMachine.Metadata.Annotations[api.BootTimeoutAnnotation]
+	if value == "" {
+		return 0, nil
+	}
+
+	bootTimeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid boot timeout %q: %w", value, err)
+	}
+	if bootTimeout <= 0 {
+		return 0, fmt.Errorf("boot timeout must be positive, got %s", bootTimeout)
+	}
+	return bootTimeout, nil
+}
+
+// maxVolumeSerialLength is the length of the virtio-blk device id.
+const maxVolumeSerialLength = 20
+
+func getVolumeSerials(annotations map[string]string) (map[string]string, error) {
+	value := annotations[api.VolumeSerialsAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	var serials map[string]string
+	if err := json.Unmarshal([]byte(value), &serials); err != nil {
+		return nil, fmt.Errorf("invalid volume serials: %w", err)
+	}
+	for name, serial := range serials {
+		if err := validateVolumeSerial(serial); err != nil {
+			return nil, fmt.Errorf("invalid serial of volume %s: %w", name, err)
+		}
+	}
+	return serials, nil
+}
+
+func validateVolumeSerial(serial string) error {
+	if serial == "" || len(serial) > maxVolumeSerialLength {
+		return fmt.Errorf("serial must be 1-%d characters long, got %d", maxVolumeSerialLength, len(serial))
+	}
+	for _, c := range serial {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '_' && c != '.' {
+			return fmt.Errorf("serial %q contains invalid character %q", serial, c)
+		}
+	}
+	return nil
+}
+
+const (
+	minVolumeReadaheadBytes = 4 * 1024
+	maxVolumeReadaheadBytes = 64 * 1024 * 1024
+
+	// maxVolumeQueueDepth is the maximum size of a virtio queue.
+	maxVolumeQueueDepth = 32768
+
+	maxVolumeFlushIntervalSeconds = 60 * 60
+)
+
+func getVolumeTunings(annotations map[string]string) (map[string]*api.VolumeTuning, error) {
+	value := annotations[api.VolumeTuningAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	var tunings map[string]*api.VolumeTuning
+	if err := json.Unmarshal([]byte(value), &tunings); err != nil {
+		return nil, fmt.Errorf("invalid volume tuning: %w", err)
+	}
+	for name, tuning := range tunings {
+		if err := validateVolumeTuning(tuning); err != nil {
+			return nil, fmt.Errorf("invalid tuning of volume %s: %w", name, err)
+		}
+	}
+	return tunings, nil
+}
+
+func validateVolumeTuning(tuning *api.VolumeTuning) error {
+	if tuning == nil {
+		return nil
+	}
+	if readahead := tuning.ReadaheadBytes; readahead != 0 &&
+		(readahead < minVolumeReadaheadBytes || readahead > maxVolumeReadaheadBytes) {
+		return fmt.Errorf("readahead must be between %d and %d bytes, got %d",
+			minVolumeReadaheadBytes, maxVolumeReadaheadBytes, readahead)
+	}
+	if depth := tuning.QueueDepth; depth != 0 && (depth < 0 || depth > maxVolumeQueueDepth || depth&(depth-1) != 0) {
+		return fmt.Errorf("queue depth must be a power of two up to %d, got %d", maxVolumeQueueDepth, depth)
+	}
+	if interval := tuning.FlushIntervalSeconds; interval < 0 || interval > maxVolumeFlushIntervalSeconds {
+		return fmt.Errorf("flush interval must be 0 to disable or between 1 and %d seconds, got %d",
+			maxVolumeFlushIntervalSeconds, interval)
+	}
+	return nil
+}
+
+func getVolumeFilesystems(annotations map[string]string) (map[string]api.Filesystem, error) {
+	value := annotations[api.VolumeFilesystemsAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	var filesystems map[string]api.Filesystem
+	if err := json.Unmarshal([]byte(value), &filesystems); err != nil {
+		return nil, fmt.Errorf("invalid volume filesystems: %w", err)
+	}
+	for name, filesystem := range filesystems {
+		if err := api.ValidateFilesystem(filesystem); err != nil {
+			return nil, fmt.Errorf("invalid filesystem of volume %s: %w", name, err)
+		}
+	}
+	return filesystems, nil
+}
+
+// setVolumeFilesystem pre-provisions the volume with the filesystem. Only empty disks can be formatted.
+func setVolumeFilesystem(volume *api.VolumeSpec, filesystem api.Filesystem) error {
+	if filesystem == "" {
+		return nil
+	}
+	if volume.LocalDisk == nil || volume.LocalDisk.Image != nil {
+		return fmt.Errorf("volume %s is not an empty disk and cannot be formatted", volume.Name)
+	}
+	volume.LocalDisk.Filesystem = filesystem
+	return nil
+}
+
+func getConfigDriveFromIRIMachine(iriMachine *iri.Machine) (*api.ConfigDriveSpec, error) {
+	value := iriMachine.Metadata.Annotations[api.ConfigDriveAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	configDrive := &api.ConfigDriveSpec{}
+	if err := json.Unmarshal([]byte(value), configDrive); err != nil {
+		return nil, fmt.Errorf("invalid config drive: %w", err)
+	}
+	if err := configdrive.Validate(configDrive); err != nil {
+		return nil, err
+	}
+	return configDrive, nil
+}
+
+func getBootFromIRIMachine(iriMachine *iri.Machine) (*api.BootSpec, error) {
+	value := iriMachine.Metadata.Annotations[api.BootAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	boot := &api.BootSpec{}
+	if err := json.Unmarshal([]byte(value), boot); err != nil {
+		return nil, fmt.Errorf("invalid boot: %w", err)
+	}
+	if err := api.ValidateBootSpec(boot); err != nil {
+		return nil, err
+	}
+	return boot, nil
+}
+
+func getVsockFromIRIMachine(iriMachine *iri.Machine) (*api.VsockSpec, error) {
+	value := iriMachine.Metadata.Annotations[api.VsockAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	cid, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vsock cid %q: %w", value, err)
+	}
+	vsock := &api.VsockSpec{CID: cid}
+	if err := api.ValidateVsockSpec(vsock); err != nil {
+		return nil, err
+	}
+	return vsock, nil
+}
+
 func (s *Server) CreateMachine(
 	ctx context.Context,
 	req *iri.CreateMachineRequest,
@@ -4,13 +4,49 @@
 package server_test
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
 	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
 	machinepoolletv1alpha1 "github.com/ironcore-dev/ironcore/poollet/machinepoollet/api/v1alpha1"
+	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// localDiskPlugin supports local disks only, like a host whose ceph plugin is missing.
+type localDiskPlugin struct{}
+
+func (localDiskPlugin) Init(volume.Host) error { return nil }
+
+func (localDiskPlugin) Name() string { return "test/local-disk" }
+
+func (localDiskPlugin) GetBackingVolumeID(spec *api.VolumeSpec) (string, error) {
+	return spec.Name, nil
+}
+
+func (localDiskPlugin) CanSupport(spec *api.VolumeSpec) bool { return spec.LocalDisk != nil }
+
+func (localDiskPlugin) Apply(_ context.Context, spec *api.VolumeSpec, _ string) (*api.VolumeStatus, error) {
+	return &api.VolumeStatus{Name: spec.Name, State: api.VolumeStatePrepared}, nil
+}
+
+func (localDiskPlugin) Delete(context.Context, string, string) error { return nil }
+
+func (localDiskPlugin) Snapshot(context.Context, string, string, string) error { return nil }
+
+func (localDiskPlugin) IsHealthy(context.Context, string, string) (bool, error) { return true, nil }
+
 var _ = Describe("CreateMachine", func() {
 	It("should create simple machine ", func(ctx SpecContext) {
 		By("creating a machine with power on and machine class")
@@ -45,4 +81,451 @@ var _ = Describe("CreateMachine", func() {
 		))
 	})
 
+	It("should apply the boot timeout annotation", func(ctx SpecContext) {
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.BootTimeoutAnnotation: "10m",
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, createResp.Machine.Metadata.Id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.BootTimeout).To(Equal(10 * time.Minute))
+
+		By("rejecting an invalid boot timeout")
+		Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.BootTimeoutAnnotation: "soon",
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})).Error().To(HaveOccurred())
+	})
+
+	It("should apply the config drive annotation", func(ctx SpecContext) {
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.ConfigDriveAnnotation: `{"hostname":"web-1","sshKeys":["ssh-ed25519 AAAA user@host"],"userData":"I2Nsb3VkLWNvbmZpZw=="}`,
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, createResp.Machine.Metadata.Id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.ConfigDrive).To(Equal(&api.ConfigDriveSpec{
+			Hostname: "web-1",
+			SSHKeys:  []string{"ssh-ed25519 AAAA user@host"},
+			UserData: []byte("#cloud-config"),
+		}))
+
+		By("rejecting a config drive without hostname")
+		Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.ConfigDriveAnnotation: `{"sshKeys":["ssh-ed25519 AAAA user@host"]}`,
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})).Error().To(MatchError(ContainSubstring("hostname is required")))
+	})
+
+	It("should apply the boot annotation", func(ctx SpecContext) {
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.BootAnnotation: `{"kernel":"/var/lib/chp/vmlinux","initramfs":"/var/lib/chp/initramfs","cmdline":"console=hvc0"}`,
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, createResp.Machine.Metadata.Id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Boot).To(Equal(&api.BootSpec{
+			Kernel:    "/var/lib/chp/vmlinux",
+			Initramfs: "/var/lib/chp/initramfs",
+			Cmdline:   "console=hvc0",
+		}))
+
+		By("rejecting a boot with both a firmware and a kernel")
+		Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.BootAnnotation: `{"firmware":"/var/lib/chp/uefi-fw","kernel":"/var/lib/chp/vmlinux"}`,
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})).Error().To(MatchError(ContainSubstring("mutually exclusive")))
+	})
+
+	It("should apply the vsock annotation", func(ctx SpecContext) {
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.VsockAnnotation: "42",
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, createResp.Machine.Metadata.Id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Vsock).To(Equal(&api.VsockSpec{CID: 42}))
+
+		By("rejecting a reserved cid")
+		Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.VsockAnnotation: "2",
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+				},
+			},
+		})).Error().To(MatchError(ContainSubstring("out of range")))
+	})
+
+	It("should apply the volume tuning annotation", func(ctx SpecContext) {
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.VolumeTuningAnnotation: `{"root":{"readaheadBytes":1048576,"queueDepth":256}}`,
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+					Volumes: []*iri.Volume{
+						{Name: "root", Device: "oda", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+					},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, createResp.Machine.Metadata.Id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Volumes).To(ConsistOf(HaveField("Tuning", &api.VolumeTuning{
+			ReadaheadBytes: 1048576,
+			QueueDepth:     256,
+		})))
+
+		By("rejecting out of range tuning")
+		for _, tuning := range []string{
+			`{"readaheadBytes":1024}`,
+			`{"queueDepth":100}`,
+			`{"queueDepth":65536}`,
+			`{"flushIntervalSeconds":-1}`,
+			`{"flushIntervalSeconds":3601}`,
+		} {
+			Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{
+						Annotations: map[string]string{
+							api.VolumeTuningAnnotation: fmt.Sprintf(`{"root":%s}`, tuning),
+						},
+					},
+					Spec: &iri.MachineSpec{
+						Power: iri.Power_POWER_ON,
+						Class: machineClassName,
+					},
+				},
+			})).Error().To(MatchError(ContainSubstring("invalid tuning of volume root")), tuning)
+		}
+	})
+
+	It("should apply the volume serials annotation", func(ctx SpecContext) {
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.VolumeSerialsAnnotation: `{"root":"disk-root.01"}`,
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+					Volumes: []*iri.Volume{
+						{Name: "root", Device: "oda", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+						{Name: "data", Device: "odb", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+					},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, createResp.Machine.Metadata.Id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Volumes).To(ConsistOf(
+			HaveField("Serial", "disk-root.01"),
+			HaveField("Serial", ""),
+		))
+
+		By("rejecting invalid serials")
+		for _, serial := range []string{"", "serial-longer-than-twenty", "disk root", "disk/root"} {
+			Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{
+						Annotations: map[string]string{
+							api.VolumeSerialsAnnotation: fmt.Sprintf(`{"root":%q}`, serial),
+						},
+					},
+					Spec: &iri.MachineSpec{
+						Power: iri.Power_POWER_ON,
+						Class: machineClassName,
+					},
+				},
+			})).Error().To(MatchError(ContainSubstring("invalid serial of volume root")), serial)
+		}
+	})
+
+	It("should apply the volume filesystems annotation to empty disks", func(ctx SpecContext) {
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.VolumeFilesystemsAnnotation: `{"data":"xfs"}`,
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+					Volumes: []*iri.Volume{
+						{Name: "root", Device: "oda", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+						{Name: "data", Device: "odb", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+					},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, createResp.Machine.Metadata.Id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Volumes).To(ConsistOf(
+			HaveField("LocalDisk.Filesystem", api.Filesystem("")),
+			HaveField("LocalDisk.Filesystem", api.FilesystemXFS),
+		))
+
+		By("rejecting an unsupported filesystem")
+		Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.VolumeFilesystemsAnnotation: `{"data":"btrfs"}`,
+					},
+				},
+				Spec: &iri.MachineSpec{Power: iri.Power_POWER_ON, Class: machineClassName},
+			},
+		})).Error().To(MatchError(ContainSubstring("invalid filesystem of volume data")))
+
+		By("rejecting a filesystem for a disk created from an image")
+		Expect(machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Annotations: map[string]string{
+						api.VolumeFilesystemsAnnotation: `{"root":"ext4"}`,
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+					Volumes: []*iri.Volume{{
+						Name:      "root",
+						Device:    "oda",
+						LocalDisk: &iri.LocalDisk{SizeBytes: 1024, Image: &iri.ImageSpec{Image: "example.org/os:latest"}},
+					}},
+				},
+			},
+		})).Error().To(MatchError(ContainSubstring("volume root is not an empty disk")))
+	})
+
+	It("should reject duplicate volume names", func(ctx SpecContext) {
+		_, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+					Volumes: []*iri.Volume{
+						{Name: "data", Device: "oda", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+						{Name: "data", Device: "odb", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+					},
+				},
+			},
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(err).To(MatchError(ContainSubstring("duplicate volume name data")))
+	})
+
+	Context("with a default machine class", func() {
+		var classRegistry mcr.MachineClassRegistry
+
+		BeforeEach(func() {
+			var err error
+			classRegistry, err = mcr.NewMachineClassRegistry([]mcr.MachineClass{
+				{Name: machineClassName, Cpu: 1, MemoryBytes: 1024 * 1024 * 1024},
+				{Name: "default-class", Cpu: 2, MemoryBytes: 2 * 1024 * 1024 * 1024},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		newStore := func() *hostutils.Store[*api.Machine] {
+			store, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+				Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+				NewFunc:        func() *api.Machine { return &api.Machine{} },
+				CreateStrategy: strategy.MachineStrategy,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return store
+		}
+
+		It("should apply the default class to machines without a class", func(ctx SpecContext) {
+			store := newStore()
+			srv, err := server.New(store, server.Options{
+				MachineClassRegistry: classRegistry,
+				DefaultMachineClass:  "default-class",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			createResp, err := srv.CreateMachine(ctx, &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{},
+					Spec:     &iri.MachineSpec{Power: iri.Power_POWER_ON},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(createResp.Machine.Spec.Class).To(Equal("default-class"))
+
+			machine, err := store.Get(ctx, createResp.Machine.Metadata.Id)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(machine.Spec.Cpu).To(Equal(int64(2)))
+			Expect(machine.Spec.MemoryBytes).To(Equal(int64(2 * 1024 * 1024 * 1024)))
+		})
+
+		It("should reject a default class that is not registered", func() {
+			_, err := server.New(newStore(), server.Options{
+				MachineClassRegistry: classRegistry,
+				DefaultMachineClass:  "unknown",
+			})
+			Expect(err).To(MatchError(ContainSubstring("default machine class unknown is not registered")))
+		})
+	})
+
+	Context("with volume plugins", func() {
+		var srv *server.Server
+
+		BeforeEach(func() {
+			classRegistry, err := mcr.NewMachineClassRegistry([]mcr.MachineClass{
+				{Name: machineClassName, Cpu: 1, MemoryBytes: 1024 * 1024 * 1024},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			volumePlugins := volume.NewPluginManager()
+			Expect(volumePlugins.InitPlugins(nil, []volume.Plugin{localDiskPlugin{}})).To(Succeed())
+
+			store, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+				Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+				NewFunc:        func() *api.Machine { return &api.Machine{} },
+				CreateStrategy: strategy.MachineStrategy,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			srv, err = server.New(store, server.Options{
+				MachineClassRegistry: classRegistry,
+				VolumePlugins:        volumePlugins,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject a ceph volume if the host has no ceph plugin", func(ctx SpecContext) {
+			_, err := srv.CreateMachine(ctx, &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{},
+					Spec: &iri.MachineSpec{
+						Power: iri.Power_POWER_ON,
+						Class: machineClassName,
+						Volumes: []*iri.Volume{
+							{Name: "root", Device: "oda", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+							{
+								Name:   "data",
+								Device: "odb",
+								Connection: &iri.VolumeConnection{
+									Driver:     "ceph",
+									Handle:     "pool/image",
+									SecretData: map[string][]byte{"userKey": []byte("secret")},
+								},
+							},
+						},
+					},
+				},
+			})
+			Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+			Expect(err).To(MatchError(ContainSubstring("no volume plugin found for volume data with driver ceph")))
+			Expect(err).NotTo(MatchError(ContainSubstring("secret")))
+
+			By("accepting volumes the host supports")
+			_, err = srv.CreateMachine(ctx, &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{},
+					Spec: &iri.MachineSpec{
+						Power: iri.Power_POWER_ON,
+						Class: machineClassName,
+						Volumes: []*iri.Volume{
+							{Name: "root", Device: "oda", LocalDisk: &iri.LocalDisk{SizeBytes: 1024}},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
 })
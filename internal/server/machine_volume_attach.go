@@ -7,7 +7,10 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (s *Server) AttachVolume(ctx context.Context, req *iri.AttachVolumeRequest) (*iri.AttachVolumeResponse, error) {
@@ -27,6 +30,41 @@ func (s *Server) AttachVolume(ctx context.Context, req *iri.AttachVolumeRequest)
 	if err != nil {
 		return nil, fmt.Errorf("error converting volume: %w", err)
 	}
+	if err := s.checkVolumePlugin(volumeSpec); err != nil {
+		return nil, err
+	}
+
+	if annotations, err := api.GetAnnotationsAnnotation(apiMachine.Metadata); err == nil {
+		volumeSerials, err := getVolumeSerials(annotations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get volume serials: %w", err)
+		}
+		volumeSpec.Serial = volumeSerials[volumeSpec.Name]
+
+		volumeTunings, err := getVolumeTunings(annotations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get volume tuning: %w", err)
+		}
+		volumeSpec.Tuning = volumeTunings[volumeSpec.Name]
+
+		volumeFilesystems, err := getVolumeFilesystems(annotations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get volume filesystems: %w", err)
+		}
+		if err := setVolumeFilesystem(volumeSpec, volumeFilesystems[volumeSpec.Name]); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, volume := range apiMachine.Spec.Volumes {
+		if volume.Name != volumeSpec.Name {
+			continue
+		}
+		if volume.DeletedAt != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "volume %s is still being detached", volumeSpec.Name)
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "duplicate volume name %s", volumeSpec.Name)
+	}
 
 	apiMachine.Spec.Volumes = append(apiMachine.Spec.Volumes, volumeSpec)
 
@@ -11,6 +11,8 @@ import (
 	machinepoolletv1alpha1 "github.com/ironcore-dev/ironcore/poollet/machinepoollet/api/v1alpha1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var _ = Describe("AttachVolume", func() {
@@ -61,4 +63,30 @@ var _ = Describe("AttachVolume", func() {
 			}, Equal(fmt.Sprintf("%s-%s-%d", volume.Name, volume.Device, volume.LocalDisk.SizeBytes))),
 		))
 	})
+
+	It("should reject a volume whose name is attached already", func(ctx SpecContext) {
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{},
+				Spec: &iri.MachineSpec{
+					Power: iri.Power_POWER_ON,
+					Class: machineClassName,
+					Volumes: []*iri.Volume{
+						{Name: "disk-1", Device: "oda", LocalDisk: &iri.LocalDisk{SizeBytes: emptyDiskSize}},
+					},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = machineClient.AttachVolume(ctx, &iri.AttachVolumeRequest{
+			MachineId: createResp.Machine.Metadata.Id,
+			Volume:    &iri.Volume{Name: "disk-1", Device: "odb", LocalDisk: &iri.LocalDisk{SizeBytes: emptyDiskSize}},
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+		machine, err := machineStore.Get(ctx, createResp.Machine.Metadata.Id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Volumes).To(HaveLen(1))
+	})
 })
@@ -5,11 +5,55 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	"github.com/ironcore-dev/provider-utils/storeutils/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-func (s *Server) UpdateVolume(ctx context.Context, request *iri.UpdateVolumeRequest) (*iri.UpdateVolumeResponse, error) {
-	//TODO implement me
+// UpdateVolume updates the connection of an attached volume, e.g. after its ceph key was rotated. The
+// reconciler applies the volume again with the new connection.
+func (s *Server) UpdateVolume(ctx context.Context, req *iri.UpdateVolumeRequest) (*iri.UpdateVolumeResponse, error) {
+	log := s.loggerFrom(ctx)
+	log.V(1).Info("Updating volume of machine")
+
+	if req == nil || req.MachineId == "" || req.Volume == nil || req.Volume.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request")
+	}
+
+	apiMachine, err := s.machineStore.Get(ctx, req.MachineId)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("error getting machine: %w", err)
+		}
+		return nil, status.Errorf(codes.NotFound, "machine %s not found", req.MachineId)
+	}
+
+	volumeSpec, err := s.getVolumeFromIRIVolume(req.Volume)
+	if err != nil {
+		return nil, fmt.Errorf("error converting volume: %w", err)
+	}
+
+	found := false
+	for _, volume := range apiMachine.Spec.Volumes {
+		if volume.Name != volumeSpec.Name || volume.DeletedAt != nil {
+			continue
+		}
+		if volumeSpec.Connection != nil {
+			volume.Connection = volumeSpec.Connection
+		}
+		found = true
+	}
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "volume %s not found in machine %s", volumeSpec.Name, req.MachineId)
+	}
+
+	if _, err := s.machineStore.Update(ctx, apiMachine); err != nil {
+		return nil, fmt.Errorf("failed to update machine with updated volume: %w", err)
+	}
+
 	return &iri.UpdateVolumeResponse{}, nil
 }
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2023 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
+	machinepoolletv1alpha1 "github.com/ironcore-dev/ironcore/poollet/machinepoollet/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("UpdateVolume", func() {
+	cephVolume := func(userKey string) *iri.Volume {
+		return &iri.Volume{
+			Name:   "disk-1",
+			Device: "oda",
+			Connection: &iri.VolumeConnection{
+				Driver: "ceph",
+				Handle: "volume-1",
+				Attributes: map[string]string{
+					"monitors": "10.0.0.1:6789",
+					"image":    "pool/image",
+				},
+				SecretData: map[string][]byte{
+					"userID":  []byte("admin"),
+					"userKey": []byte(userKey),
+				},
+			},
+		}
+	}
+
+	var machineID string
+
+	BeforeEach(func(ctx SpecContext) {
+		By("creating a machine with a ceph volume")
+		createResp, err := machineClient.CreateMachine(ctx, &iri.CreateMachineRequest{
+			Machine: &iri.Machine{
+				Metadata: &irimeta.ObjectMetadata{
+					Labels: map[string]string{
+						machinepoolletv1alpha1.MachineUIDLabel: "foobar",
+					},
+				},
+				Spec: &iri.MachineSpec{
+					Power:   iri.Power_POWER_ON,
+					Class:   machineClassName,
+					Volumes: []*iri.Volume{cephVolume("old-key")},
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		machineID = createResp.Machine.Metadata.Id
+	})
+
+	It("should update the connection of the volume", func(ctx SpecContext) {
+		Expect(machineClient.UpdateVolume(ctx, &iri.UpdateVolumeRequest{
+			MachineId: machineID,
+			Volume:    cephVolume("new-key"),
+		})).Error().NotTo(HaveOccurred())
+
+		machine, err := machineStore.Get(ctx, machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Volumes).To(ConsistOf(SatisfyAll(
+			HaveField("Name", "disk-1"),
+			HaveField("Connection.Handle", "volume-1"),
+			HaveField("Connection.SecretData", HaveKeyWithValue("userKey", []byte("new-key"))),
+		)))
+	})
+
+	It("should fail with not found for a volume the machine does not have", func(ctx SpecContext) {
+		volume := cephVolume("new-key")
+		volume.Name = "disk-2"
+		_, err := machineClient.UpdateVolume(ctx, &iri.UpdateVolumeRequest{
+			MachineId: machineID,
+			Volume:    volume,
+		})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		machine, err := machineStore.Get(ctx, machineID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(machine.Spec.Volumes).To(ConsistOf(
+			HaveField("Connection.SecretData", HaveKeyWithValue("userKey", []byte("old-key"))),
+		))
+	})
+
+	It("should fail with not found for a machine that does not exist", func(ctx SpecContext) {
+		_, err := machineClient.UpdateVolume(ctx, &iri.UpdateVolumeRequest{
+			MachineId: "does-not-exist",
+			Volume:    cephVolume("new-key"),
+		})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+})
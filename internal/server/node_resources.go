@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// NodeResources reports the machine capacity of the host in the resource format of kubernetes nodes,
+// so it can be consumed by cluster autoscalers.
+type NodeResources struct {
+	Capacity    corev1.ResourceList `json:"capacity"`
+	Allocatable corev1.ResourceList `json:"allocatable"`
+	Used        corev1.ResourceList `json:"used"`
+}
+
+func resourceList(resources capacity.Resources) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(resources.Cpu*1000, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(resources.MemoryBytes, resource.BinarySI),
+	}
+}
+
+// NodeResources returns the host resources, the resources allocatable to machines after applying the
+// overcommit and reserve settings, and the resources used by the machines of all pools sharing the host.
+func (s *Server) NodeResources(ctx context.Context) (*NodeResources, error) {
+	if s.capacity == nil {
+		return nil, fmt.Errorf("capacity accounting is disabled")
+	}
+
+	used, err := s.capacity.Used(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allocatable := s.capacity.Allocatable()
+	if s.Draining() {
+		allocatable = capacity.Resources{}
+	}
+
+	return &NodeResources{
+		Capacity:    resourceList(s.capacity.Capacity()),
+		Allocatable: resourceList(allocatable),
+		Used:        resourceList(used),
+	}, nil
+}
+
+// NodeResourcesHandler serves the NodeResources of the server as json.
+func (s *Server) NodeResourcesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resources, err := s.NodeResources(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resources); err != nil {
+			s.loggerFrom(r.Context()).Error(err, "Failed to write node resources")
+		}
+	})
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
+	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NodeResources", func() {
+	const gib = 1024 * 1024 * 1024
+
+	var srv *server.Server
+
+	BeforeEach(func() {
+		classRegistry, err := mcr.NewMachineClassRegistry([]mcr.MachineClass{
+			{
+				Name:        machineClassName,
+				Cpu:         2,
+				MemoryBytes: 4 * gib,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		store, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+			Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+			NewFunc:        func() *api.Machine { return &api.Machine{} },
+			CreateStrategy: strategy.MachineStrategy,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		srv, err = server.New(store, server.Options{
+			MachineClassRegistry: classRegistry,
+			HostResources:        &capacity.Resources{Cpu: 8, MemoryBytes: 16 * gib},
+			Overcommit:           capacity.Overcommit{Cpu: 2, Memory: 1},
+			MemoryReserve:        2 * gib,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should report the allocatable and used resources of the host", func(ctx SpecContext) {
+		for range 2 {
+			_, err := srv.CreateMachine(ctx, &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{},
+					Spec:     &iri.MachineSpec{Class: machineClassName},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		resources, err := srv.NodeResources(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(resources.Capacity.Cpu().MilliValue()).To(Equal(int64(8000)))
+		Expect(resources.Capacity.Memory().Value()).To(Equal(int64(16 * gib)))
+		Expect(resources.Allocatable.Cpu().MilliValue()).To(Equal(int64(16000)))
+		Expect(resources.Allocatable.Memory().Value()).To(Equal(int64(14 * gib)))
+		Expect(resources.Used.Cpu().MilliValue()).To(Equal(int64(4000)))
+		Expect(resources.Used.Memory().Value()).To(Equal(int64(8 * gib)))
+
+		By("serving the resources as json")
+		rec := httptest.NewRecorder()
+		srv.NodeResourcesHandler().ServeHTTP(rec, httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil))
+		Expect(rec.Code).To(Equal(http.StatusOK))
+
+		var served server.NodeResources
+		Expect(json.Unmarshal(rec.Body.Bytes(), &served)).To(Succeed())
+		Expect(served.Allocatable.Cpu().MilliValue()).To(Equal(int64(16000)))
+		Expect(served.Used.Memory().Value()).To(Equal(int64(8 * gib)))
+	})
+
+	It("should report no allocatable resources while draining", func(ctx SpecContext) {
+		srv.SetDraining(true)
+
+		resources, err := srv.NodeResources(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resources.Allocatable.Cpu().IsZero()).To(BeTrue())
+		Expect(resources.Allocatable.Memory().IsZero()).To(BeTrue())
+	})
+})
@@ -6,11 +6,16 @@ package server
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server/version"
 	"github.com/ironcore-dev/ironcore/broker/common/idgen"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
 	"github.com/ironcore-dev/provider-utils/eventutils/recorder"
@@ -27,6 +32,23 @@ type Server struct {
 	iri.UnimplementedMachineRuntimeServer
 
 	machineClassRegistry mcr.MachineClassRegistry
+	defaultMachineClass  string
+
+	capacity *capacity.Accountant
+	claimMu  sync.Mutex
+
+	diskDir     string
+	minFreeDisk int64
+
+	volumePlugins *volume.PluginManager
+
+	streamer *Streamer
+
+	features    []string
+	versionInfo version.Info
+
+	draining     atomic.Bool
+	shuttingDown atomic.Bool
 
 	machineStore store.Store[*api.Machine]
 	eventStore   recorder.EventStore
@@ -38,6 +60,36 @@ type Options struct {
 	EventStore recorder.EventStore
 
 	MachineClassRegistry mcr.MachineClassRegistry
+	// DefaultMachineClass is used for machines not specifying a class.
+	DefaultMachineClass string
+
+	// Capacity accounts the host resources shared with the servers of other pools. The machine store of the
+	// server is tracked by it. Takes precedence over HostResources.
+	Capacity *capacity.Accountant
+	// HostResources enables capacity accounting of the server alone. If neither it nor Capacity is set, class
+	// quantities are not limited.
+	HostResources *capacity.Resources
+	Overcommit    capacity.Overcommit
+	// MemoryReserve is the host memory in bytes that is never allocated to machines.
+	MemoryReserve int64
+
+	// DiskDir is the directory whose filesystem holds the machine disks.
+	DiskDir string
+	// MinFreeDisk is the free space in bytes below which the host is under disk pressure and no machines
+	// are offered. Zero disables the check.
+	MinFreeDisk int64
+
+	// VolumePlugins rejects volumes no plugin of the host supports when machines are created or volumes are
+	// attached. If unset, the volumes are not checked.
+	VolumePlugins *volume.PluginManager
+
+	// Streamer serves the exec sessions of the machines. If unset, Exec is not enabled.
+	Streamer *Streamer
+
+	// Features lists the optional features enabled on the host, reported in the HostStatus.
+	Features []string
+	// VersionInfo holds the detected cloud-hypervisor versions reported in the HostStatus.
+	VersionInfo version.Info
 }
 
 type nilEventStore struct{}
@@ -53,6 +105,12 @@ func setOptionsDefaults(o *Options) {
 	if o.EventStore == nil {
 		o.EventStore = &nilEventStore{}
 	}
+	if o.Overcommit.Cpu == 0 {
+		o.Overcommit.Cpu = 1
+	}
+	if o.Overcommit.Memory == 0 {
+		o.Overcommit.Memory = 1
+	}
 }
 
 func New(store store.Store[*api.Machine], opts Options) (*Server, error) {
@@ -62,11 +120,44 @@ func New(store store.Store[*api.Machine], opts Options) (*Server, error) {
 		return nil, fmt.Errorf("MachineClassRegistry option is required")
 	}
 
+	if opts.DefaultMachineClass != "" {
+		if _, found := opts.MachineClassRegistry.Get(opts.DefaultMachineClass); !found {
+			return nil, fmt.Errorf("default machine class %s is not registered", opts.DefaultMachineClass)
+		}
+	}
+
+	if err := opts.Overcommit.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid overcommit: %w", err)
+	}
+
+	accountant := opts.Capacity
+	if accountant == nil && opts.HostResources != nil {
+		var err error
+		accountant, err = capacity.NewAccountant(*opts.HostResources, capacity.AccountantOptions{
+			Overcommit:    opts.Overcommit,
+			MemoryReserve: opts.MemoryReserve,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if accountant != nil {
+		accountant.Track(store)
+	}
+
 	return &Server{
 		idGen:                opts.IDGen,
 		machineStore:         store,
 		eventStore:           opts.EventStore,
 		machineClassRegistry: opts.MachineClassRegistry,
+		defaultMachineClass:  opts.DefaultMachineClass,
+		capacity:             accountant,
+		diskDir:              opts.DiskDir,
+		minFreeDisk:          opts.MinFreeDisk,
+		volumePlugins:        opts.VolumePlugins,
+		streamer:             opts.Streamer,
+		features:             opts.Features,
+		versionInfo:          opts.VersionInfo,
 	}, nil
 }
 
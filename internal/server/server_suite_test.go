@@ -40,6 +40,7 @@ const (
 
 var (
 	machineClient iriv1alpha1.MachineRuntimeClient
+	machineServer *server.Server
 	machineEvents *event.ListWatchSource[*api.Machine]
 	machineStore  *hostutils.Store[*api.Machine]
 
@@ -92,7 +93,7 @@ var _ = BeforeEach(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
-	srv, err := server.New(machineStore, server.Options{
+	machineServer, err = server.New(machineStore, server.Options{
 		MachineClassRegistry: classRegistry,
 	})
 	Expect(err).NotTo(HaveOccurred())
@@ -102,7 +103,7 @@ var _ = BeforeEach(func() {
 
 	go func() {
 		defer GinkgoRecover()
-		Expect(app.RunGRPCServer(cancelCtx, log, log, srv, filepath.Join(tempDir, "test.sock"))).To(Succeed())
+		Expect(app.RunGRPCServer(cancelCtx, log, log, machineServer, filepath.Join(tempDir, "test.sock"), server.RequestLimits{}, nil, false)).To(Succeed())
 	}()
 
 	go func() {
@@ -5,6 +5,7 @@ package server
 
 import (
 	"context"
+	"fmt"
 
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
 )
@@ -14,6 +15,11 @@ func (s *Server) Status(ctx context.Context, _ *iri.StatusRequest) (*iri.StatusR
 
 	var classes []*iri.MachineClassStatus
 	for _, class := range s.machineClassRegistry.List() {
+		quantity, err := s.classQuantity(ctx, class)
+		if err != nil {
+			return nil, fmt.Errorf("error getting quantity of class %s: %w", class.Name, err)
+		}
+
 		classes = append(classes, &iri.MachineClassStatus{
 			MachineClass: &iri.MachineClass{
 				Name: class.Name,
@@ -24,8 +30,7 @@ func (s *Server) Status(ctx context.Context, _ *iri.StatusRequest) (*iri.StatusR
 					},
 				},
 			},
-			//TODO will be deprecated soon
-			Quantity: 1000,
+			Quantity: quantity,
 		})
 	}
 
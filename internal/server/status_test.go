@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/mcr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	irimeta "github.com/ironcore-dev/ironcore/iri/apis/meta/v1alpha1"
+	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Status", func() {
+	It("should return the machine classes", func(ctx SpecContext) {
+		resp, err := machineClient.Status(ctx, &iri.StatusRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.MachineClassStatus).To(ConsistOf(HaveField("MachineClass.Name", machineClassName)))
+	})
+
+	Context("with host resources", func() {
+		var (
+			hostResources = capacity.Resources{Cpu: 8, MemoryBytes: 16 * 1024 * 1024 * 1024}
+			classRegistry mcr.MachineClassRegistry
+		)
+
+		BeforeEach(func() {
+			var err error
+			classRegistry, err = mcr.NewMachineClassRegistry([]mcr.MachineClass{
+				{
+					Name:        machineClassName,
+					Cpu:         2,
+					MemoryBytes: 4 * 1024 * 1024 * 1024,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		newServer := func(overcommit capacity.Overcommit) *server.Server {
+			store, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+				Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+				NewFunc:        func() *api.Machine { return &api.Machine{} },
+				CreateStrategy: strategy.MachineStrategy,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			srv, err := server.New(store, server.Options{
+				MachineClassRegistry: classRegistry,
+				HostResources:        &hostResources,
+				Overcommit:           overcommit,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return srv
+		}
+
+		It("should scale the class quantity with the overcommit ratio", func(ctx SpecContext) {
+			resp, err := newServer(capacity.Overcommit{}).Status(ctx, &iri.StatusRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.MachineClassStatus).To(ConsistOf(HaveField("Quantity", int64(4))))
+
+			resp, err = newServer(capacity.Overcommit{Cpu: 2, Memory: 1}).Status(ctx, &iri.StatusRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.MachineClassStatus).To(ConsistOf(HaveField("Quantity", int64(4))))
+
+			resp, err = newServer(capacity.Overcommit{Cpu: 2, Memory: 2}).Status(ctx, &iri.StatusRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.MachineClassStatus).To(ConsistOf(HaveField("Quantity", int64(8))))
+		})
+
+		It("should subtract the resources of the existing machines from the class quantity", func(ctx SpecContext) {
+			srv := newServer(capacity.Overcommit{})
+
+			for range 2 {
+				_, err := srv.CreateMachine(ctx, &iri.CreateMachineRequest{
+					Machine: &iri.Machine{
+						Metadata: &irimeta.ObjectMetadata{},
+						Spec:     &iri.MachineSpec{Class: machineClassName},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resp, err := srv.Status(ctx, &iri.StatusRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.MachineClassStatus).To(ConsistOf(HaveField("Quantity", int64(2))))
+		})
+
+		It("should reject machines exceeding the capacity", func(ctx SpecContext) {
+			srv := newServer(capacity.Overcommit{Cpu: 1.5, Memory: 1.5})
+
+			for range 6 {
+				_, err := srv.CreateMachine(ctx, &iri.CreateMachineRequest{
+					Machine: &iri.Machine{
+						Metadata: &irimeta.ObjectMetadata{},
+						Spec:     &iri.MachineSpec{Class: machineClassName},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resp, err := srv.Status(ctx, &iri.StatusRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.MachineClassStatus).To(ConsistOf(HaveField("Quantity", int64(0))))
+
+			_, err = srv.CreateMachine(ctx, &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{},
+					Spec:     &iri.MachineSpec{Class: machineClassName},
+				},
+			})
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+		})
+
+		It("should account the machines of all pools sharing the host capacity", func(ctx SpecContext) {
+			hostCapacity, err := capacity.NewAccountant(hostResources, capacity.AccountantOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			newPoolServer := func() *server.Server {
+				store, err := hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+					Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+					NewFunc:        func() *api.Machine { return &api.Machine{} },
+					CreateStrategy: strategy.MachineStrategy,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				srv, err := server.New(store, server.Options{
+					MachineClassRegistry: classRegistry,
+					Capacity:             hostCapacity,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return srv
+			}
+			srvA := newPoolServer()
+			srvB := newPoolServer()
+
+			By("filling the host through pool a")
+			for range 4 {
+				_, err := srvA.CreateMachine(ctx, &iri.CreateMachineRequest{
+					Machine: &iri.Machine{
+						Metadata: &irimeta.ObjectMetadata{},
+						Spec:     &iri.MachineSpec{Class: machineClassName},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("ensuring pool b reports no capacity left")
+			resp, err := srvB.Status(ctx, &iri.StatusRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.MachineClassStatus).To(ConsistOf(HaveField("Quantity", int64(0))))
+
+			hostStatus, err := srvB.HostStatus(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hostStatus.MachineClasses).To(ConsistOf(HaveField("Quantity", int64(0))))
+
+			nodeResources, err := srvB.NodeResources(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nodeResources.Used.Cpu().Value()).To(Equal(int64(8)))
+
+			_, err = srvB.CreateMachine(ctx, &iri.CreateMachineRequest{
+				Machine: &iri.Machine{
+					Metadata: &irimeta.ObjectMetadata{},
+					Spec:     &iri.MachineSpec{Class: machineClassName},
+				},
+			})
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+		})
+
+		It("should reject overcommit ratios below 1.0", func() {
+			_, err := server.New(nil, server.Options{
+				MachineClassRegistry: classRegistry,
+				Overcommit:           capacity.Overcommit{Cpu: 0.5, Memory: 1},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -6,29 +6,13 @@ package server
 import (
 	"context"
 
-	"github.com/blang/semver/v4"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server/version"
 	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
 )
 
 func (s *Server) Version(ctx context.Context, req *iri.VersionRequest) (*iri.VersionResponse, error) {
-	var runtimeVersion string
-	switch {
-	case version.Version != "":
-		runtimeVersion = version.Version
-	case version.Commit != "":
-		v, err := semver.NewBuildVersion(version.Commit)
-		if err != nil {
-			runtimeVersion = "0.0.0"
-		} else {
-			runtimeVersion = v
-		}
-	default:
-		runtimeVersion = "0.0.0"
-	}
-
 	return &iri.VersionResponse{
 		RuntimeName:    version.RuntimeName,
-		RuntimeVersion: runtimeVersion,
+		RuntimeVersion: version.RuntimeVersion(),
 	}, nil
 }
@@ -3,6 +3,16 @@
 
 package version
 
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+)
+
 const (
 	RuntimeName = "cloud-hypervisor-provider"
 )
@@ -11,3 +21,92 @@ var (
 	Version string
 	Commit  string
 )
+
+type Info struct {
+	RuntimeName               string `json:"runtimeName"`
+	RuntimeVersion            string `json:"runtimeVersion"`
+	Commit                    string `json:"commit,omitempty"`
+	CloudHypervisorAPIVersion string `json:"cloudHypervisorAPIVersion,omitempty"`
+	CloudHypervisorVersion    string `json:"cloudHypervisorVersion,omitempty"`
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf(
+		"%s %s (commit: %s, cloud-hypervisor: %s, cloud-hypervisor api: %s)",
+		i.RuntimeName,
+		i.RuntimeVersion,
+		valueOrUnknown(i.Commit),
+		valueOrUnknown(i.CloudHypervisorVersion),
+		valueOrUnknown(i.CloudHypervisorAPIVersion),
+	)
+}
+
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+func RuntimeVersion() string {
+	switch {
+	case Version != "":
+		return Version
+	case Commit != "":
+		v, err := semver.NewBuildVersion(Commit)
+		if err != nil {
+			return "0.0.0"
+		}
+		return v
+	default:
+		return "0.0.0"
+	}
+}
+
+// CloudHypervisorAPIVersion returns the version of the vendored cloud-hypervisor openapi spec.
+func CloudHypervisorAPIVersion() (string, error) {
+	spec, err := client.GetSwagger()
+	if err != nil {
+		return "", fmt.Errorf("error loading cloud-hypervisor openapi spec: %w", err)
+	}
+	if spec.Info == nil {
+		return "", fmt.Errorf("cloud-hypervisor openapi spec has no info")
+	}
+	return spec.Info.Version, nil
+}
+
+// CloudHypervisorVersion returns the version reported by the cloud-hypervisor binary.
+func CloudHypervisorVersion(ctx context.Context, binary string) (string, error) {
+	out, err := exec.CommandContext(ctx, binary, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("error running %s --version: %w", binary, err)
+	}
+
+	// The output has the format "cloud-hypervisor v41.0.0".
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty version output of %s", binary)
+	}
+	return fields[len(fields)-1], nil
+}
+
+// Get collects the version info. Versions that cannot be detected are left empty.
+func Get(ctx context.Context, cloudHypervisorBinary string) Info {
+	info := Info{
+		RuntimeName:    RuntimeName,
+		RuntimeVersion: RuntimeVersion(),
+		Commit:         Commit,
+	}
+
+	if v, err := CloudHypervisorAPIVersion(); err == nil {
+		info.CloudHypervisorAPIVersion = v
+	}
+
+	if cloudHypervisorBinary != "" {
+		if v, err := CloudHypervisorVersion(ctx, cloudHypervisorBinary); err == nil {
+			info.CloudHypervisorVersion = v
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package version_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server/version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	It("should populate the version info", func(ctx SpecContext) {
+		By("creating a fake cloud-hypervisor binary")
+		binary := filepath.Join(GinkgoT().TempDir(), "cloud-hypervisor")
+		Expect(os.WriteFile(binary, []byte("#!/bin/sh\necho cloud-hypervisor v41.0.0\n"), 0755)).To(Succeed())
+
+		info := version.Get(ctx, binary)
+		Expect(info).To(SatisfyAll(
+			HaveField("RuntimeName", version.RuntimeName),
+			HaveField("RuntimeVersion", Not(BeEmpty())),
+			HaveField("CloudHypervisorAPIVersion", "0.3.0"),
+			HaveField("CloudHypervisorVersion", "v41.0.0"),
+		))
+		Expect(info.String()).To(ContainSubstring("v41.0.0"))
+	})
+
+	It("should leave the binary version empty if it cannot be detected", func(ctx SpecContext) {
+		info := version.Get(ctx, filepath.Join(GinkgoT().TempDir(), "missing"))
+		Expect(info.CloudHypervisorVersion).To(BeEmpty())
+		Expect(info.CloudHypervisorAPIVersion).NotTo(BeEmpty())
+	})
+})
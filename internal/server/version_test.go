@@ -0,0 +1,22 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server_test
+
+import (
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/server/version"
+	iri "github.com/ironcore-dev/ironcore/iri/apis/machine/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	It("should return the runtime version", func(ctx SpecContext) {
+		resp, err := machineClient.Version(ctx, &iri.VersionRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp).To(SatisfyAll(
+			HaveField("RuntimeName", version.RuntimeName),
+			HaveField("RuntimeVersion", "0.0.0"),
+		))
+	})
+})
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
+	"k8s.io/utils/ptr"
+)
+
+type VirtualMachineManager interface {
+	GetVM(ctx context.Context, instanceID string) (*client.VmInfo, error)
+	Pause(ctx context.Context, instanceID string) error
+	Resume(ctx context.Context, instanceID string) error
+}
+
+type VolumePluginManager interface {
+	FindPluginBySpec(volume *api.VolumeSpec) (volume.Plugin, error)
+}
+
+type Snapshotter struct {
+	log     logr.Logger
+	vmm     VirtualMachineManager
+	plugins VolumePluginManager
+}
+
+func NewSnapshotter(log logr.Logger, vmm VirtualMachineManager, plugins VolumePluginManager) *Snapshotter {
+	return &Snapshotter{
+		log:     log,
+		vmm:     vmm,
+		plugins: plugins,
+	}
+}
+
+// SnapshotMachine snapshots all attached volumes of the machine. A running vm is paused for the duration of the
+// snapshots to keep the volumes consistent with each other, the plugins are resolved beforehand so the vm is
+// only paused while the volumes are snapshotted.
+func (s *Snapshotter) SnapshotMachine(ctx context.Context, machine *api.Machine, snapshotName string) (retErr error) {
+	log := s.log.WithValues("machineID", machine.ID, "snapshot", snapshotName)
+
+	var (
+		volumes []*api.VolumeSpec
+		plugins []volume.Plugin
+	)
+	for _, vol := range machine.Spec.Volumes {
+		if vol.DeletedAt != nil {
+			continue
+		}
+
+		plugin, err := s.plugins.FindPluginBySpec(vol)
+		if err != nil {
+			return fmt.Errorf("failed to find plugin: %w", err)
+		}
+		volumes = append(volumes, vol)
+		plugins = append(plugins, plugin)
+	}
+	if len(volumes) == 0 {
+		log.V(1).Info("No volumes to snapshot")
+		return nil
+	}
+
+	apiSocket := ptr.Deref(machine.Spec.ApiSocketPath, "")
+	if apiSocket != "" {
+		vm, err := s.vmm.GetVM(ctx, apiSocket)
+		if err != nil && !errors.Is(err, vmm.ErrVmNotCreated) {
+			return fmt.Errorf("failed to get vm: %w", err)
+		}
+
+		if vm != nil && vm.State == client.Running {
+			log.V(1).Info("Pausing vm")
+			if err := s.vmm.Pause(ctx, apiSocket); err != nil {
+				return fmt.Errorf("failed to pause vm: %w", err)
+			}
+			defer func() {
+				log.V(1).Info("Resuming vm")
+				if err := s.vmm.Resume(ctx, apiSocket); err != nil {
+					retErr = errors.Join(retErr, fmt.Errorf("failed to resume vm: %w", err))
+				}
+			}()
+		}
+	}
+
+	for i, vol := range volumes {
+		plugin := plugins[i]
+		log.V(2).Info("Snapshotting volume", "name", vol.Name, "plugin", plugin.Name())
+		if err := plugin.Snapshot(ctx, vol.Name, machine.ID, snapshotName); err != nil {
+			return fmt.Errorf("failed to snapshot volume %s: %w", vol.Name, err)
+		}
+	}
+
+	log.V(1).Info("Snapshotted machine volumes", "count", len(volumes))
+	return nil
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package snapshot_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/plugins/volume"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/snapshot"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
+	apiutils "github.com/ironcore-dev/provider-utils/apiutils/api"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) record(format string, args ...any) {
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+type fakeVMM struct {
+	*recorder
+	state *client.VmInfoState
+}
+
+func (f *fakeVMM) GetVM(context.Context, string) (*client.VmInfo, error) {
+	if f.state == nil {
+		return nil, vmm.ErrVmNotCreated
+	}
+	return &client.VmInfo{State: *f.state}, nil
+}
+
+func (f *fakeVMM) Pause(context.Context, string) error {
+	f.record("pause")
+	return nil
+}
+
+func (f *fakeVMM) Resume(context.Context, string) error {
+	f.record("resume")
+	return nil
+}
+
+type fakePlugin struct {
+	volume.Plugin
+	*recorder
+	fail bool
+}
+
+func (p *fakePlugin) Name() string {
+	return "fake"
+}
+
+func (p *fakePlugin) Snapshot(_ context.Context, computeVolumeName string, _ string, snapshotName string) error {
+	if p.fail {
+		return fmt.Errorf("injected failure")
+	}
+	p.record("snapshot %s@%s", computeVolumeName, snapshotName)
+	return nil
+}
+
+type fakePluginManager struct {
+	plugin *fakePlugin
+	// unsupported is the name of the volume no plugin is found for.
+	unsupported string
+}
+
+func (m *fakePluginManager) FindPluginBySpec(spec *api.VolumeSpec) (volume.Plugin, error) {
+	if spec.Name == m.unsupported {
+		return nil, fmt.Errorf("no plugin found for volume %s", spec.Name)
+	}
+	return m.plugin, nil
+}
+
+var _ = Describe("Snapshotter", func() {
+	var (
+		calls   *recorder
+		vm      *fakeVMM
+		plugin  *fakePlugin
+		plugins *fakePluginManager
+		machine *api.Machine
+	)
+
+	BeforeEach(func() {
+		calls = &recorder{}
+		vm = &fakeVMM{recorder: calls, state: ptr.To(client.Running)}
+		plugin = &fakePlugin{recorder: calls}
+		plugins = &fakePluginManager{plugin: plugin}
+		machine = &api.Machine{
+			Metadata: apiutils.Metadata{ID: "machine"},
+			Spec: api.MachineSpec{
+				ApiSocketPath: ptr.To("/run/ch.sock"),
+				Volumes: []*api.VolumeSpec{
+					{Name: "root"},
+					{Name: "data"},
+				},
+			},
+		}
+	})
+
+	newSnapshotter := func() *snapshot.Snapshotter {
+		return snapshot.NewSnapshotter(logr.Discard(), vm, plugins)
+	}
+
+	It("should snapshot all volumes within the pause window of a running vm", func(ctx SpecContext) {
+		Expect(newSnapshotter().SnapshotMachine(ctx, machine, "snap")).To(Succeed())
+		Expect(calls.calls).To(Equal([]string{
+			"pause",
+			"snapshot root@snap",
+			"snapshot data@snap",
+			"resume",
+		}))
+	})
+
+	It("should not pause a vm that is not running", func(ctx SpecContext) {
+		vm.state = ptr.To(client.Shutdown)
+		Expect(newSnapshotter().SnapshotMachine(ctx, machine, "snap")).To(Succeed())
+		Expect(calls.calls).To(Equal([]string{
+			"snapshot root@snap",
+			"snapshot data@snap",
+		}))
+	})
+
+	It("should resume the vm if a snapshot fails", func(ctx SpecContext) {
+		plugin.fail = true
+		Expect(newSnapshotter().SnapshotMachine(ctx, machine, "snap")).NotTo(Succeed())
+		Expect(calls.calls).To(Equal([]string{"pause", "resume"}))
+	})
+
+	It("should not pause the vm if the plugin of a volume cannot be found", func(ctx SpecContext) {
+		plugins.unsupported = "data"
+		Expect(newSnapshotter().SnapshotMachine(ctx, machine, "snap")).NotTo(Succeed())
+		Expect(calls.calls).To(BeEmpty())
+	})
+})
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package sockets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/metrics"
+	"github.com/ironcore-dev/provider-utils/storeutils/store"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const DefaultInterval = 10 * time.Minute
+
+type Options struct {
+	// Interval between two scans of the machine dirs.
+	Interval time.Duration
+	// Cleanup removes the leaked sockets found by a scan.
+	Cleanup bool
+}
+
+func setOptionsDefaults(o *Options) {
+	if o.Interval == 0 {
+		o.Interval = DefaultInterval
+	}
+}
+
+// LeakScanner finds the sockets in the machine dirs whose machine is gone, e.g. the console, vsock or volume
+// sockets left behind by a failed cleanup. The machine dirs are shared by all pools, so a single scanner
+// serves the provider and tracks the machine stores of all pools.
+//
+// Whether a process is still bound to a socket cannot be told from /proc/net/unix, it only lists the sockets
+// of the network namespace of the provider, which does not run in the host network.
+type LeakScanner struct {
+	log   logr.Logger
+	paths host.Paths
+
+	interval time.Duration
+	cleanup  bool
+
+	storesMu sync.Mutex
+	stores   []store.Store[*api.Machine]
+}
+
+func NewLeakScanner(log logr.Logger, paths host.Paths, opts Options) (*LeakScanner, error) {
+	setOptionsDefaults(&opts)
+
+	if paths == nil {
+		return nil, fmt.Errorf("must specify paths")
+	}
+
+	return &LeakScanner{
+		log:      log,
+		paths:    paths,
+		interval: opts.Interval,
+		cleanup:  opts.Cleanup,
+	}, nil
+}
+
+// Track adds the machines of the store to the machines owning sockets.
+func (s *LeakScanner) Track(store store.Store[*api.Machine]) {
+	s.storesMu.Lock()
+	defer s.storesMu.Unlock()
+	s.stores = append(s.stores, store)
+}
+
+func (s *LeakScanner) Start(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		leaked, err := s.Scan(ctx)
+		if err != nil {
+			s.log.Error(err, "Failed to scan for leaked sockets")
+			return
+		}
+		if len(leaked) > 0 {
+			s.log.V(1).Info("Found leaked sockets", "sockets", leaked, "cleanup", s.cleanup)
+		}
+	}, s.interval)
+}
+
+// liveMachines returns the IDs of the machines of the tracked stores that may still own sockets. A machine
+// whose finalizer is removed after deletion has its vm and volumes torn down, even if its record is retained.
+func (s *LeakScanner) liveMachines(ctx context.Context) (sets.Set[string], error) {
+	s.storesMu.Lock()
+	stores := append([]store.Store[*api.Machine](nil), s.stores...)
+	s.storesMu.Unlock()
+
+	if len(stores) == 0 {
+		return nil, fmt.Errorf("no machine stores tracked")
+	}
+
+	live := sets.New[string]()
+	for _, st := range stores {
+		machines, err := st.List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing machines: %w", err)
+		}
+
+		for _, machine := range machines {
+			if machine.DeletedAt != nil && len(machine.Finalizers) == 0 {
+				continue
+			}
+			live.Insert(machine.ID)
+		}
+	}
+	return live, nil
+}
+
+// Scan returns the leaked sockets in the machine dirs and reports the number of active and leaked sockets
+// found. If cleanup is enabled, the leaked sockets are removed afterward.
+func (s *LeakScanner) Scan(ctx context.Context) ([]string, error) {
+	live, err := s.liveMachines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		active, leaked []string
+		socketsDir     = s.paths.MachinesSocketsDir()
+	)
+	err = filepath.WalkDir(socketsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Machine dirs may be removed while they are scanned.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.Type()&fs.ModeSocket == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(socketsDir, path)
+		if err != nil {
+			return err
+		}
+		machineID, _, _ := strings.Cut(rel, string(filepath.Separator))
+		if live.Has(machineID) {
+			active = append(active, path)
+		} else {
+			leaked = append(leaked, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning machine sockets dir: %w", err)
+	}
+
+	metrics.SetMachineSockets(len(active), len(leaked))
+	if s.cleanup {
+		s.removeSockets(leaked)
+	}
+	return leaked, nil
+}
+
+func (s *LeakScanner) removeSockets(leaked []string) {
+	removed := 0
+	for _, path := range leaked {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.log.Error(err, "Failed to remove leaked socket", "path", path)
+			continue
+		}
+		s.log.V(2).Info("Removed leaked socket", "path", path)
+		removed++
+	}
+	metrics.AddRemovedMachineSockets(removed)
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package sockets_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/metrics"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/sockets"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/strategy"
+	apiutils "github.com/ironcore-dev/provider-utils/apiutils/api"
+	hostutils "github.com/ironcore-dev/provider-utils/storeutils/host"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// metricValue returns the value of the gauge or counter of the metrics registry.
+func metricValue(metricName string) float64 {
+	families, err := metrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != metricName {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if metric.GetCounter() != nil {
+				return metric.GetCounter().GetValue()
+			}
+			return metric.GetGauge().GetValue()
+		}
+	}
+	Fail("metric " + metricName + " not found")
+	return 0
+}
+
+var _ = Describe("LeakScanner", func() {
+	var (
+		paths         host.Paths
+		machineStore  *hostutils.Store[*api.Machine]
+		liveSocket    string
+		orphanSocket  string
+		removedBefore float64
+	)
+
+	// leaveSocket creates a socket no process is bound to anymore.
+	leaveSocket := func(path string) {
+		listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+		Expect(err).NotTo(HaveOccurred())
+		listener.SetUnlinkOnClose(false)
+		Expect(listener.Close()).To(Succeed())
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		var err error
+		paths, err = host.PathsAt(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+
+		machineStore, err = hostutils.NewStore[*api.Machine](hostutils.Options[*api.Machine]{
+			Dir:            filepath.Join(GinkgoT().TempDir(), "machines"),
+			NewFunc:        func() *api.Machine { return &api.Machine{} },
+			CreateStrategy: strategy.MachineStrategy,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("creating the machine record of the live machine")
+		_, err = machineStore.Create(ctx, &api.Machine{Metadata: apiutils.Metadata{ID: "live"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("leaving the vsock socket of the live machine unbound")
+		Expect(host.MakeMachineDirs(paths, "live")).To(Succeed())
+		liveSocket = paths.MachineVsockSocket("live")
+		leaveSocket(liveSocket)
+
+		By("leaving the vsock socket of a machine without record behind")
+		Expect(host.MakeMachineDirs(paths, "gone")).To(Succeed())
+		orphanSocket = paths.MachineVsockSocket("gone")
+		leaveSocket(orphanSocket)
+
+		removedBefore = metricValue("machine_sockets_removed_total")
+	})
+
+	newScanner := func(paths host.Paths, opts sockets.Options) *sockets.LeakScanner {
+		scanner, err := sockets.NewLeakScanner(GinkgoLogr, paths, opts)
+		Expect(err).NotTo(HaveOccurred())
+		scanner.Track(machineStore)
+		return scanner
+	}
+
+	It("should report the sockets of the machines missing from the stores as leaked", func(ctx SpecContext) {
+		scanner := newScanner(paths, sockets.Options{})
+
+		Expect(scanner.Scan(ctx)).To(ConsistOf(orphanSocket))
+		Expect(metricValue("machine_sockets_active")).To(BeEquivalentTo(1))
+		Expect(metricValue("machine_sockets_leaked")).To(BeEquivalentTo(1))
+
+		By("keeping the leaked socket")
+		Expect(orphanSocket).To(BeAnExistingFile())
+		Expect(metricValue("machine_sockets_removed_total")).To(Equal(removedBefore))
+	})
+
+	It("should remove the leaked sockets if cleanup is enabled", func(ctx SpecContext) {
+		scanner := newScanner(paths, sockets.Options{Cleanup: true})
+
+		Expect(scanner.Scan(ctx)).To(ConsistOf(orphanSocket))
+		Expect(metricValue("machine_sockets_leaked")).To(BeEquivalentTo(1))
+		Expect(metricValue("machine_sockets_removed_total")).To(Equal(removedBefore + 1))
+
+		_, err := os.Stat(orphanSocket)
+		Expect(err).To(MatchError(os.ErrNotExist))
+
+		By("keeping the socket of the live machine although no process is bound to it in this namespace")
+		Expect(liveSocket).To(BeAnExistingFile())
+
+		By("reporting no leaks on the next scan")
+		Expect(scanner.Scan(ctx)).To(BeEmpty())
+		Expect(metricValue("machine_sockets_active")).To(BeEquivalentTo(1))
+		Expect(metricValue("machine_sockets_leaked")).To(BeZero())
+	})
+
+	It("should report the sockets as leaked once the machine is removed from the store", func(ctx SpecContext) {
+		scanner := newScanner(paths, sockets.Options{})
+		Expect(scanner.Scan(ctx)).To(ConsistOf(orphanSocket))
+
+		Expect(machineStore.Delete(ctx, "live")).To(Succeed())
+		Expect(scanner.Scan(ctx)).To(ConsistOf(orphanSocket, liveSocket))
+	})
+
+	It("should fail to scan without a tracked machine store", func(ctx SpecContext) {
+		scanner, err := sockets.NewLeakScanner(GinkgoLogr, paths, sockets.Options{Cleanup: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = scanner.Scan(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(liveSocket).To(BeAnExistingFile())
+		Expect(orphanSocket).To(BeAnExistingFile())
+	})
+
+	It("should scan the sockets below the root dir if the machines are kept in a data dir", func(ctx SpecContext) {
+		dataPaths, err := host.PathsAtWithOptions(paths.RootDir(), host.PathsOptions{DataDir: GinkgoT().TempDir()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Dir(dataPaths.MachineVsockSocket("gone"))).NotTo(HavePrefix(dataPaths.MachinesDir()))
+
+		scanner := newScanner(dataPaths, sockets.Options{})
+		Expect(scanner.Scan(ctx)).To(ConsistOf(orphanSocket))
+	})
+})
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package vmm
+
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"golang.org/x/sys/unix"
+	"k8s.io/utils/ptr"
+)
+
+// DiskAIO selects the asynchronous io backend cloud-hypervisor serves the file disks of vms with.
+type DiskAIO string
+
+const (
+	// DiskAIODefault leaves the backend to cloud-hypervisor, which prefers io_uring over native aio.
+	DiskAIODefault DiskAIO = ""
+	DiskAIOIoUring DiskAIO = "io_uring"
+	DiskAIONative  DiskAIO = "native"
+	// DiskAIOThreads disables asynchronous io, serving the disks synchronously from the io threads.
+	DiskAIOThreads DiskAIO = "threads"
+)
+
+// ioUringParamsSize is the size of struct io_uring_params.
+const ioUringParamsSize = 120
+
+func validateDiskAIO(aio DiskAIO) error {
+	switch aio {
+	case DiskAIODefault, DiskAIONative, DiskAIOThreads:
+		return nil
+	case DiskAIOIoUring:
+		return checkIoUring()
+	default:
+		return fmt.Errorf("unsupported disk aio %q", aio)
+	}
+}
+
+// checkIoUring checks that the host kernel supports io_uring and has not disabled it.
+func checkIoUring() error {
+	var params [ioUringParamsSize]byte
+	fd, _, errno := unix.Syscall(unix.SYS_IO_URING_SETUP, 1, uintptr(unsafe.Pointer(&params)), 0)
+	if errno != 0 {
+		return fmt.Errorf("io_uring is not supported by the host kernel: %w", errno)
+	}
+	_ = unix.Close(int(fd))
+	return nil
+}
+
+// aioDiskConfig is the disk config along with the aio options of cloud-hypervisor. Its disk config accepts the
+// options, but its api spec does not describe them, so the generated client lacks them.
+type aioDiskConfig struct {
+	client.DiskConfig
+	DisableIoUring *bool `json:"disable_io_uring,omitempty"`
+	DisableAio     *bool `json:"disable_aio,omitempty"`
+}
+
+// aioVmConfig is the vm config with the disks along with their aio options.
+type aioVmConfig struct {
+	client.VmConfig
+	Disks []aioDiskConfig `json:"disks,omitempty"`
+}
+
+// applyDiskAIO selects the aio backend of a file disk, vhost-user disks are served by their backend.
+func applyDiskAIO(disk client.DiskConfig, aio DiskAIO) aioDiskConfig {
+	config := aioDiskConfig{DiskConfig: disk}
+	if ptr.Deref(disk.VhostUser, false) {
+		return config
+	}
+
+	switch aio {
+	case DiskAIOIoUring:
+		config.DisableIoUring = ptr.To(false)
+		config.DisableAio = ptr.To(false)
+	case DiskAIONative:
+		config.DisableIoUring = ptr.To(true)
+		config.DisableAio = ptr.To(false)
+	case DiskAIOThreads:
+		config.DisableIoUring = ptr.To(true)
+		config.DisableAio = ptr.To(true)
+	}
+	return config
+}
+
+// vmConfigBody renders the vm config, selecting the aio backend of its file disks.
+func vmConfigBody(config client.VmConfig, aio DiskAIO) ([]byte, error) {
+	body := aioVmConfig{VmConfig: config}
+	for _, disk := range ptr.Deref(config.Disks, nil) {
+		body.Disks = append(body.Disks, applyDiskAIO(disk, aio))
+	}
+	return json.Marshal(body)
+}
+
+// diskConfigBody renders the disk config, selecting the aio backend of a file disk.
+func diskConfigBody(disk client.DiskConfig, aio DiskAIO) ([]byte, error) {
+	return json.Marshal(applyDiskAIO(disk, aio))
+}
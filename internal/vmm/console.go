@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package vmm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"golang.org/x/sys/unix"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+)
+
+// serialLogCapInterval is the interval the serial logs are checked against their cap.
+const serialLogCapInterval = 30 * time.Second
+
+// SerialMode selects where the serial port of vms is connected to.
+type SerialMode string
+
+const (
+	// SerialModeTty connects the serial port to the terminal of the cloud-hypervisor process.
+	SerialModeTty SerialMode = "Tty"
+	// SerialModeFile logs the serial output to a file in the machine directory, rotated on vm creation and
+	// once it exceeds its cap.
+	SerialModeFile SerialMode = "File"
+	// SerialModeSocket exposes the serial port on a unix socket in the machine directory.
+	SerialModeSocket SerialMode = "Socket"
+	SerialModeOff    SerialMode = "Off"
+)
+
+// ConsoleMode selects where the virtio console of vms is connected to.
+type ConsoleMode string
+
+const (
+	ConsoleModeOff ConsoleMode = "Off"
+	// ConsoleModePty connects the console to a pseudo terminal allocated by cloud-hypervisor.
+	ConsoleModePty ConsoleMode = "Pty"
+	ConsoleModeTty ConsoleMode = "Tty"
+)
+
+func validateConsoleModes(serial SerialMode, console ConsoleMode) error {
+	switch serial {
+	case SerialModeTty, SerialModeFile, SerialModeSocket, SerialModeOff:
+	default:
+		return fmt.Errorf("unsupported serial mode %q", serial)
+	}
+	switch console {
+	case ConsoleModeOff, ConsoleModePty, ConsoleModeTty:
+	default:
+		return fmt.Errorf("unsupported console mode %q", console)
+	}
+	if serial == SerialModeTty && console == ConsoleModeTty {
+		return fmt.Errorf("serial and console cannot both be connected to the terminal")
+	}
+	return nil
+}
+
+func (m *Manager) serialConfig(machineID string) client.ConsoleConfig {
+	config := client.ConsoleConfig{Mode: client.ConsoleConfigMode(m.serialMode)}
+	switch m.serialMode {
+	case SerialModeFile:
+		config.File = ptr.To(m.paths.MachineSerialLogFile(machineID))
+	case SerialModeSocket:
+		config.Socket = ptr.To(m.paths.MachineSerialSocket(machineID))
+	}
+	return config
+}
+
+// prepareSerial prepares the host for the serial port of a new vm.
+func prepareSerial(config client.ConsoleConfig) error {
+	if config.File != nil {
+		return rotateSerialLog(*config.File)
+	}
+	if config.Socket != nil {
+		if err := os.Remove(*config.Socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove stale serial socket: %w", err)
+		}
+	}
+	return nil
+}
+
+// rotateSerialLog keeps the serial log of the previous vm as backup, so a new vm starts with an empty log.
+func rotateSerialLog(filename string) error {
+	if err := os.Rename(filename, filename+".1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to rotate serial log: %w", err)
+	}
+	return nil
+}
+
+// SerialLog returns a reader of the serial log of the machine, starting at its oldest output.
+func (m *Manager) SerialLog(_ context.Context, machineID string) (io.ReadCloser, error) {
+	if m.serialMode != SerialModeFile {
+		return nil, fmt.Errorf("serial port is not logged in serial mode %s", m.serialMode)
+	}
+	return openSerialLog(m.paths.MachineSerialLogFile(machineID))
+}
+
+// RunSerialLogCapping caps the serial logs of all machines until ctx is done.
+func (m *Manager) RunSerialLogCapping(ctx context.Context) {
+	if m.serialMode != SerialModeFile || m.serialLogMaxBytes <= 0 {
+		return
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := m.CapSerialLogs(); err != nil {
+			m.log.Error(err, "Failed to cap serial logs")
+		}
+	}, serialLogCapInterval)
+}
+
+// CapSerialLogs moves the output of serial logs exceeding the cap to their backup.
+func (m *Manager) CapSerialLogs() error {
+	filenames, err := filepath.Glob(filepath.Join(m.paths.MachinesDir(), "*", host.DefaultMachineSerialLogFile))
+	if err != nil {
+		return fmt.Errorf("failed to list serial logs: %w", err)
+	}
+
+	var errs []error
+	for _, filename := range filenames {
+		capped, err := capSerialLog(filename, m.serialLogMaxBytes)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if capped {
+			m.log.V(1).Info("Capped serial log", "filename", filename)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// capSerialLog copies the output of the serial log to its backup and empties the log if it holds more than
+// maxBytes. cloud-hypervisor keeps writing at its offset, so the emptied log grows a leading hole instead,
+// which takes no space and is skipped by openSerialLog.
+func capSerialLog(filename string, maxBytes int64) (bool, error) {
+	var stat unix.Stat_t
+	if err := unix.Stat(filename, &stat); err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat serial log: %w", err)
+	}
+	// The size of the log includes the hole left by a previous cap, only the allocated blocks hold output.
+	if stat.Blocks*512 <= maxBytes {
+		return false, nil
+	}
+
+	src, err := openSerialLog(filename)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.Create(filename + ".1")
+	if err != nil {
+		return false, fmt.Errorf("failed to create serial log backup: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return false, fmt.Errorf("failed to copy serial log to backup: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return false, fmt.Errorf("failed to close serial log backup: %w", err)
+	}
+
+	if err := os.Truncate(filename, 0); err != nil {
+		return false, fmt.Errorf("failed to truncate serial log: %w", err)
+	}
+	return true, nil
+}
+
+type serialLogReader struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// openSerialLog opens the serial log at its first output, skipping the hole left by capping it. Holes are
+// block-aligned, so the zeros left in the block of the first output are skipped as well.
+func openSerialLog(filename string) (io.ReadCloser, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open serial log: %w", err)
+	}
+
+	if _, err := f.Seek(0, unix.SEEK_DATA); err != nil {
+		whence := io.SeekStart
+		if errors.Is(err, unix.ENXIO) {
+			// The log holds no output after the hole.
+			whence = io.SeekEnd
+		}
+		if _, err := f.Seek(0, whence); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to seek serial log: %w", err)
+		}
+	}
+
+	r := bufio.NewReader(f)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		if b != 0 {
+			_ = r.UnreadByte()
+			break
+		}
+	}
+	return serialLogReader{Reader: r, Closer: f}, nil
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package vmm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+)
+
+// ConfigDiff is a field of the config of a vm whose value rendered from the machine differs from the vm.
+type ConfigDiff struct {
+	// Path locates the field, e.g. memory.size or disks[root].path. Elements of lists with ids are located by
+	// their id, others by their index.
+	Path string
+	// Desired is the value rendered from the machine, nil if the vm has an element the machine has not.
+	Desired any
+	// Actual is the value of the vm, nil if the vm lacks the field.
+	Actual any
+}
+
+func (d ConfigDiff) String() string {
+	return fmt.Sprintf("%s: desired %v, actual %v", d.Path, d.Desired, d.Actual)
+}
+
+// DiffVMConfig compares the config rendered from the machine with the actual config of its vm. Only the fields
+// rendered from the machine are compared, the defaults cloud-hypervisor fills in are ignored. The memory of the
+// vm is compared including the memory hot plugged into it and its numa zones, as it is resized by.
+func (m *Manager) DiffVMConfig(machine *api.Machine, actual client.VmConfig) ([]ConfigDiff, error) {
+	desired, err := m.renderVMConfig(machine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render vm config: %w", err)
+	}
+
+	if actual.Memory != nil {
+		memory := *actual.Memory
+		_, memory.Size = Size(&client.VmInfo{Config: actual})
+		actual.Memory = &memory
+	}
+
+	desiredValue, err := configValue(desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode desired vm config: %w", err)
+	}
+	actualValue, err := configValue(actual)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode actual vm config: %w", err)
+	}
+
+	var diffs []ConfigDiff
+	diffValues("", desiredValue, actualValue, &diffs)
+	return diffs, nil
+}
+
+// configValue returns the json representation of the config, keeping its numbers exact.
+func configValue(config client.VmConfig) (any, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func diffValues(path string, desired, actual any, diffs *[]ConfigDiff) {
+	switch desired := desired.(type) {
+	case map[string]any:
+		actual, ok := actual.(map[string]any)
+		if !ok {
+			*diffs = append(*diffs, ConfigDiff{Path: path, Desired: desired, Actual: actual})
+			return
+		}
+		for _, key := range slices.Sorted(maps.Keys(desired)) {
+			diffValues(joinPath(path, key), desired[key], actual[key], diffs)
+		}
+	case []any:
+		if actual == nil {
+			// cloud-hypervisor omits empty lists.
+			actual = []any{}
+		}
+		actual, ok := actual.([]any)
+		if !ok {
+			*diffs = append(*diffs, ConfigDiff{Path: path, Desired: desired, Actual: actual})
+			return
+		}
+		diffLists(path, desired, actual, diffs)
+	default:
+		if !reflect.DeepEqual(desired, actual) {
+			*diffs = append(*diffs, ConfigDiff{Path: path, Desired: desired, Actual: actual})
+		}
+	}
+}
+
+func diffLists(path string, desired, actual []any, diffs *[]ConfigDiff) {
+	desiredByID, desiredIDs := elementsByID(desired)
+	actualByID, actualIDs := elementsByID(actual)
+	if desiredIDs == nil || actualIDs == nil {
+		if len(desired) != len(actual) {
+			*diffs = append(*diffs, ConfigDiff{Path: path, Desired: desired, Actual: actual})
+			return
+		}
+		for i := range desired {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), desired[i], actual[i], diffs)
+		}
+		return
+	}
+
+	for _, id := range desiredIDs {
+		elementPath := fmt.Sprintf("%s[%s]", path, id)
+		actualElement, ok := actualByID[id]
+		if !ok {
+			*diffs = append(*diffs, ConfigDiff{Path: elementPath, Desired: desiredByID[id]})
+			continue
+		}
+		diffValues(elementPath, desiredByID[id], actualElement, diffs)
+	}
+	for _, id := range actualIDs {
+		if _, ok := desiredByID[id]; !ok {
+			*diffs = append(*diffs, ConfigDiff{Path: fmt.Sprintf("%s[%s]", path, id), Actual: actualByID[id]})
+		}
+	}
+}
+
+// elementsByID indexes the elements of the list by their id. It returns nil ids if not all elements have one.
+func elementsByID(list []any) (map[string]any, []string) {
+	byID := make(map[string]any, len(list))
+	ids := make([]string, 0, len(list))
+	for _, element := range list {
+		object, ok := element.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		id, ok := object["id"].(string)
+		if !ok {
+			return nil, nil
+		}
+		byID[id] = element
+		ids = append(ids, id)
+	}
+	return byID, ids
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -4,6 +4,7 @@
 package vmm
 
 import (
+	"bytes"
 	"context"
 	b64 "encoding/base64"
 	"errors"
@@ -13,37 +14,156 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
 	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/osutils"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/pci"
 	utilssync "github.com/ironcore-dev/provider-utils/storeutils/sync"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/utils/ptr"
 )
 
 type ManagerOptions struct {
-	CHSocketsPath     string
-	FirmwarePath      string
+	CHSocketsPath string
+	FirmwarePath  string
+	// KernelPath boots the vms directly from a kernel instead of the firmware.
+	KernelPath        string
 	ReservedInstances []string
+	PciDevicesPath    string
+
+	// AvailableMemory reports the memory currently available on the host. Defaults to capacity.AvailableMemory.
+	AvailableMemory  func() (int64, error)
+	MemoryReserve    int64
+	MemoryOvercommit float64
+
+	// MaxVcpus and MaxMemoryBytes are the ceilings vms are created with and can be resized to while they run.
+	// Vms are created with memory hotplug only if MaxMemoryBytes exceeds their memory.
+	MaxVcpus       int
+	MaxMemoryBytes int64
+
+	// NumaNodes are the numa nodes vms are placed on. Placement is disabled without nodes.
+	NumaNodes []capacity.NumaNode
+	// Numa tracks the placement of the vms on the numa nodes, shared with the managers of the other pools.
+	// Takes precedence over NumaNodes.
+	Numa *NumaTracker
+	// Vsock tracks the vsock cids of the vms, shared with the managers of the other pools.
+	Vsock *VsockTracker
+
+	// SerialMode connects the serial port of vms. Defaults to SerialModeFile.
+	SerialMode SerialMode
+	// SerialLogMaxBytes caps the serial logs of SerialModeFile, larger logs are moved to their backup by
+	// RunSerialLogCapping. Zero disables the cap.
+	SerialLogMaxBytes int64
+	// ConsoleMode connects the virtio console of vms. Defaults to ConsoleModeOff.
+	ConsoleMode ConsoleMode
+
+	// DiskAIO selects the io backend of the file disks of vms. Defaults to the choice of cloud-hypervisor.
+	DiskAIO DiskAIO
+
+	// RngSource is the host entropy source of the virtio-rng device of vms. Defaults to DefaultRngSource.
+	RngSource string
+
+	// VMInfoTTL caches the vm info of an instance for the given duration, unless the vm is changed via the
+	// manager in the meantime. Zero disables the cache.
+	VMInfoTTL time.Duration
+
+	// DetachVMs keeps the vms running when the manager is closed. Otherwise, Close shuts them down.
+	DetachVMs bool
+
+	// SocketWaitTimeout bounds connecting to the api sockets of the vmms, which cloud-hypervisor may accept
+	// late on loaded hosts. Defaults to osutils.DefaultSocketWaitTimeout.
+	SocketWaitTimeout time.Duration
 }
 
 func NewManager(log logr.Logger, paths host.Paths, opts ManagerOptions) (*Manager, error) {
 	initLog := log.WithName("init")
 
+	if opts.PciDevicesPath == "" {
+		opts.PciDevicesPath = pci.DefaultSysfsDevicesPath
+	}
+	if opts.AvailableMemory == nil {
+		opts.AvailableMemory = capacity.AvailableMemory
+	}
+	if opts.MemoryOvercommit == 0 {
+		opts.MemoryOvercommit = 1
+	}
+	if opts.SerialMode == "" {
+		opts.SerialMode = SerialModeFile
+	}
+	if opts.ConsoleMode == "" {
+		opts.ConsoleMode = ConsoleModeOff
+	}
+	if opts.SocketWaitTimeout == 0 {
+		opts.SocketWaitTimeout = osutils.DefaultSocketWaitTimeout
+	}
+	if opts.Numa == nil {
+		opts.Numa = NewNumaTracker(opts.NumaNodes)
+	}
+	if opts.Vsock == nil {
+		opts.Vsock = NewVsockTracker()
+	}
+	if opts.RngSource == "" {
+		opts.RngSource = DefaultRngSource
+	}
+	if err := validateConsoleModes(opts.SerialMode, opts.ConsoleMode); err != nil {
+		return nil, err
+	}
+	if err := validateDiskAIO(opts.DiskAIO); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(opts.RngSource); err != nil {
+		return nil, fmt.Errorf("invalid rng source: %w", err)
+	}
+
+	if opts.CHSocketsPath == "" {
+		return nil, errors.New("cloud-hypervisor sockets dir is not set")
+	}
+	if err := os.MkdirAll(opts.CHSocketsPath, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create cloud-hypervisor sockets dir: %w", err)
+	}
+
 	entries, err := os.ReadDir(opts.CHSocketsPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read cloud-hypervisor sockets dir: %w", err)
 	}
 
 	m := &Manager{
-		idMu:         utilssync.NewMutexMap[string](),
-		instances:    make(map[string]*client.ClientWithResponses),
-		paths:        paths,
-		firmwarePath: opts.FirmwarePath,
-		log:          log,
-		free:         sets.New[string](),
+		idMu:           utilssync.NewMutexMap[string](),
+		instances:      make(map[string]*client.ClientWithResponses),
+		paths:          paths,
+		firmwarePath:   opts.FirmwarePath,
+		kernelPath:     opts.KernelPath,
+		log:            log,
+		pciDevicesPath: opts.PciDevicesPath,
+		free:           sets.New[string](),
+
+		availableMemory:  opts.AvailableMemory,
+		memoryReserve:    opts.MemoryReserve,
+		memoryOvercommit: opts.MemoryOvercommit,
+
+		maxVcpus:       opts.MaxVcpus,
+		maxMemoryBytes: opts.MaxMemoryBytes,
+
+		numa:  opts.Numa,
+		vsock: opts.Vsock,
+
+		serialMode:        opts.SerialMode,
+		serialLogMaxBytes: opts.SerialLogMaxBytes,
+		consoleMode:       opts.ConsoleMode,
+
+		diskAIO:   opts.DiskAIO,
+		rngSource: opts.RngSource,
+
+		detachVMs: opts.DetachVMs,
+
+		vmInfoTTL: opts.VMInfoTTL,
+		vmInfos:   make(map[string]cachedVMInfo),
 	}
 	reserved := sets.NewString(opts.ReservedInstances...)
 	for _, v := range entries {
@@ -56,13 +176,21 @@ func NewManager(log logr.Logger, paths host.Paths, opts ManagerOptions) (*Manage
 
 		socketPath := filepath.Join(opts.CHSocketsPath, v.Name())
 
-		apiClient, err := NewUnixSocketClient(socketPath)
+		apiClient, err := newUnixSocketClient(socketPath, opts.SocketWaitTimeout)
 		if err != nil {
 			initLog.V(1).Info("Failed to init cloud-hypervisor client", "path", socketPath)
 			continue
 		}
 
 		if _, err := apiClient.GetVmmPing(context.TODO()); err != nil {
+			if errors.Is(err, syscall.ECONNREFUSED) {
+				// No cloud-hypervisor listens on the socket anymore, it is recreated once one is started again.
+				initLog.Info("Removing dead cloud-hypervisor socket", "path", socketPath)
+				if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+					initLog.Error(err, "Failed to remove dead cloud-hypervisor socket", "path", socketPath)
+				}
+				continue
+			}
 			initLog.V(1).Info("Failed to ping cloud-hypervisor socket", "path", socketPath)
 			continue
 		}
@@ -70,18 +198,28 @@ func NewManager(log logr.Logger, paths host.Paths, opts ManagerOptions) (*Manage
 		initLog.V(2).Info("Created cloud-hypervisor client", "socketPath", socketPath)
 		m.instances[socketPath] = apiClient
 
-		if _, err := m.GetVM(context.TODO(), socketPath); errors.Is(err, ErrVmNotCreated) {
+		vm, err := m.GetVM(context.TODO(), socketPath)
+		switch {
+		case errors.Is(err, ErrVmNotCreated):
 			if !reserved.Has(socketPath) {
 				m.free.Insert(socketPath)
 			} else {
 				initLog.V(2).Info("Socket blocked and skipped", "socketPath", socketPath)
 			}
+		case err == nil:
+			if !reserved.Has(socketPath) {
+				platform := ptr.Deref(vm.Config.Platform, client.PlatformConfig{})
+				initLog.Info("Found vm not owned by any machine, keeping the socket in use",
+					"socketPath", socketPath, "vmID", ptr.Deref(platform.Uuid, ""), "state", vm.State)
+			}
+			m.numa.track(socketPath, vm)
+			m.vsock.track(socketPath, vm)
 		}
 	}
 
 	initLog.V(1).Info("Successfully initialized clients", "num", len(m.instances))
 	if len(m.instances) == 0 {
-		return nil, errors.New("no instances found")
+		return nil, fmt.Errorf("no instances found in %s", opts.CHSocketsPath)
 	}
 
 	return m, nil
@@ -96,14 +234,54 @@ type Manager struct {
 	free   sets.Set[string]
 	freeMu sync.Mutex
 
-	paths        host.Paths
-	firmwarePath string
+	paths          host.Paths
+	firmwarePath   string
+	kernelPath     string
+	pciDevicesPath string
+
+	availableMemory  func() (int64, error)
+	memoryReserve    int64
+	memoryOvercommit float64
+
+	maxVcpus       int
+	maxMemoryBytes int64
+
+	numa  *NumaTracker
+	vsock *VsockTracker
+
+	serialMode        SerialMode
+	serialLogMaxBytes int64
+	consoleMode       ConsoleMode
+
+	diskAIO   DiskAIO
+	rngSource string
+
+	detachVMs bool
+
+	vmInfoTTL time.Duration
+	vmInfos   map[string]cachedVMInfo
+	vmInfosMu sync.Mutex
+}
+
+type cachedVMInfo struct {
+	info    client.VmInfo
+	expires time.Time
 }
 
+// ConfigDriveDiskID is the id of the read-only config drive disk of a vm.
+const ConfigDriveDiskID = "config-drive"
+
+// DefaultRngSource is the host entropy source of the virtio-rng device of vms by default.
+const DefaultRngSource = "/dev/urandom"
+
 var (
-	ErrBrokenSocket = errors.New("broken socket")
-	ErrNotFound     = errors.New("not found")
-	ErrVmNotCreated = errors.New("vm is not created")
+	ErrBrokenSocket         = errors.New("broken socket")
+	ErrNotFound             = errors.New("not found")
+	ErrVmNotCreated         = errors.New("vm is not created")
+	ErrInsufficientCapacity = errors.New("insufficient capacity")
+	ErrNoBootSource         = errors.New("no boot source")
+	ErrNICNotAttached       = errors.New("network interface not attached")
+	ErrResizeUnsupported    = errors.New("resize unsupported")
 )
 
 func (m *Manager) Ping(ctx context.Context, instanceID string) error {
@@ -122,6 +300,8 @@ func (m *Manager) ping(ctx context.Context, instanceID string) error {
 
 	ping, err := apiClient.GetVmmPingWithResponse(ctx)
 	if err != nil {
+		// The vmm might have been restarted, dropping its vm.
+		m.invalidateVM(instanceID)
 		return wrapIfSocketClosed(fmt.Errorf("failed to ping vmm: %w", err))
 	}
 
@@ -138,6 +318,30 @@ func (m *Manager) ping(ctx context.Context, instanceID string) error {
 	return nil
 }
 
+// Pid returns the process id of the vmm serving the instance.
+func (m *Manager) Pid(ctx context.Context, instanceID string) (int, error) {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+
+	apiClient, found := m.instances[instanceID]
+	if !found {
+		return 0, ErrNotFound
+	}
+
+	ping, err := apiClient.GetVmmPingWithResponse(ctx)
+	if err != nil {
+		return 0, wrapIfSocketClosed(fmt.Errorf("failed to ping vmm: %w", err))
+	}
+	if err := validateStatus(ping.StatusCode()); err != nil {
+		return 0, err
+	}
+
+	if ping.JSON200 == nil || ping.JSON200.Pid == nil {
+		return 0, fmt.Errorf("vmm did not report its pid")
+	}
+	return int(*ping.JSON200.Pid), nil
+}
+
 func (m *Manager) GetFreeApiSocket() (*string, error) {
 	m.freeMu.Lock()
 	defer m.freeMu.Unlock()
@@ -171,7 +375,10 @@ func wrapIfSocketClosed(err error) error {
 func (m *Manager) GetVM(ctx context.Context, instanceID string) (*client.VmInfo, error) {
 	m.idMu.Lock(instanceID)
 	defer m.idMu.Unlock(instanceID)
+	return m.getVM(ctx, instanceID)
+}
 
+func (m *Manager) getVM(ctx context.Context, instanceID string) (*client.VmInfo, error) {
 	log := m.log.WithValues("instanceID", instanceID)
 
 	apiClient, found := m.instances[instanceID]
@@ -179,6 +386,10 @@ func (m *Manager) GetVM(ctx context.Context, instanceID string) (*client.VmInfo,
 		return nil, ErrNotFound
 	}
 
+	if info, ok := m.cachedVM(instanceID); ok {
+		return info, nil
+	}
+
 	log.V(2).Info("Getting vm")
 	resp, err := apiClient.GetVmInfoWithResponse(ctx)
 	if err != nil {
@@ -193,13 +404,162 @@ func (m *Manager) GetVM(ctx context.Context, instanceID string) (*client.VmInfo,
 		return nil, err
 	}
 
+	m.cacheVM(instanceID, resp.JSON200)
 	return resp.JSON200, nil
 }
 
+// cachedVM returns a copy of the cached vm info of the instance, if it did not expire yet.
+func (m *Manager) cachedVM(instanceID string) (*client.VmInfo, bool) {
+	m.vmInfosMu.Lock()
+	defer m.vmInfosMu.Unlock()
+
+	cached, ok := m.vmInfos[instanceID]
+	if !ok || time.Now().After(cached.expires) {
+		return nil, false
+	}
+	info := cached.info
+	return &info, true
+}
+
+func (m *Manager) cacheVM(instanceID string, info *client.VmInfo) {
+	if m.vmInfoTTL <= 0 || info == nil {
+		return
+	}
+
+	m.vmInfosMu.Lock()
+	defer m.vmInfosMu.Unlock()
+	m.vmInfos[instanceID] = cachedVMInfo{info: *info, expires: time.Now().Add(m.vmInfoTTL)}
+}
+
+// invalidateVM drops the cached vm info of the instance. It has to be called before changing the vm.
+func (m *Manager) invalidateVM(instanceID string) {
+	m.vmInfosMu.Lock()
+	defer m.vmInfosMu.Unlock()
+	delete(m.vmInfos, instanceID)
+}
+
+// Close shuts down the running vms of all instances, unless the manager detaches the vms. Instances failing
+// to shut down do not prevent shutting down the others.
+func (m *Manager) Close(ctx context.Context) error {
+	if m.detachVMs {
+		m.log.V(1).Info("Detaching vms")
+		return nil
+	}
+
+	states, err := m.ListVMStates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list vm states: %w", err)
+	}
+
+	var errs []error
+	for instanceID, state := range states {
+		if state != client.Running && state != client.Paused {
+			continue
+		}
+		if err := m.PowerOff(ctx, instanceID); err != nil {
+			errs = append(errs, fmt.Errorf("instance %s: %w", instanceID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListVMStates returns the state of the vm on every known instance, keyed by instance id. Instances
+// without a created vm are omitted, unresponsive instances are reported as shut down.
+func (m *Manager) ListVMStates(ctx context.Context) (map[string]client.VmInfoState, error) {
+	var (
+		states   = make(map[string]client.VmInfoState, len(m.instances))
+		statesMu sync.Mutex
+		wg       sync.WaitGroup
+	)
+	for instanceID := range m.instances {
+		wg.Go(func() {
+			m.idMu.Lock(instanceID)
+			defer m.idMu.Unlock(instanceID)
+
+			state := client.Shutdown
+			vm, err := m.getVM(ctx, instanceID)
+			switch {
+			case errors.Is(err, ErrVmNotCreated):
+				return
+			case err != nil:
+				m.log.V(1).Info("Failed to get vm state", "instanceID", instanceID, "error", err)
+			default:
+				state = vm.State
+			}
+
+			statesMu.Lock()
+			defer statesMu.Unlock()
+			states[instanceID] = state
+		})
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// ReapOrphanVM removes a vm left on the instance by a different machine. It reports whether a vm was reaped.
+func (m *Manager) ReapOrphanVM(ctx context.Context, instanceID, machineID string) (bool, error) {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+
+	log := m.log.WithValues("instanceID", instanceID)
+
+	vm, err := m.getVM(ctx, instanceID)
+	if err != nil {
+		if errors.Is(err, ErrVmNotCreated) {
+			return false, nil
+		}
+		return false, err
+	}
+	if vm == nil {
+		return false, fmt.Errorf("failed to get vm: empty response")
+	}
+
+	platform := ptr.Deref(vm.Config.Platform, client.PlatformConfig{})
+	vmID := ptr.Deref(platform.Uuid, "")
+	if vmID == machineID {
+		return false, nil
+	}
+
+	// The vm goes away, its cached info must not be served anymore.
+	m.invalidateVM(instanceID)
+	apiClient := m.instances[instanceID]
+
+	log.V(1).Info("Reaping orphan vm", "vmID", vmID, "machineID", machineID)
+	if vm.State == client.Running || vm.State == client.Paused {
+		shutdownResp, err := apiClient.ShutdownVMWithResponse(ctx)
+		if err != nil {
+			return false, wrapIfSocketClosed(fmt.Errorf("failed to shutdown orphan vm: %w", err))
+		}
+		if err := validateStatus(shutdownResp.StatusCode()); err != nil {
+			log.V(1).Info("Failed to shutdown orphan vm", "error", string(shutdownResp.Body))
+		}
+	}
+
+	deleteResp, err := apiClient.DeleteVMWithResponse(ctx)
+	if err != nil {
+		return false, wrapIfSocketClosed(fmt.Errorf("failed to delete orphan vm: %w", err))
+	}
+
+	if err := validateStatus(deleteResp.StatusCode()); err != nil {
+		log.V(1).Info("Failed to delete orphan vm", "error", string(deleteResp.Body))
+		return false, err
+	}
+	m.numa.release(instanceID)
+	m.vsock.release(instanceID)
+
+	return true, nil
+}
+
+// CreateVM configures the vm of the machine, leaving it in the created state until it is booted.
 func (m *Manager) CreateVM(ctx context.Context, machine *api.Machine) error {
 	instanceID := ptr.Deref(machine.Spec.ApiSocketPath, "")
 	m.idMu.Lock(instanceID)
 	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
 
 	log := m.log.WithValues("instanceID", instanceID)
 
@@ -208,13 +568,72 @@ func (m *Manager) CreateVM(ctx context.Context, machine *api.Machine) error {
 		return ErrNotFound
 	}
 
-	payload := client.PayloadConfig{
-		Cmdline:   nil,
-		Firmware:  ptr.To(m.firmwarePath),
-		HostData:  nil,
-		Igvm:      nil,
-		Initramfs: nil,
-		Kernel:    nil,
+	config, err := m.renderVMConfig(machine)
+	if err != nil {
+		return err
+	}
+	if config.Memory.Size != machine.Spec.MemoryBytes {
+		log.V(1).Info("Aligned vm memory", "requested", machine.Spec.MemoryBytes, "aligned", config.Memory.Size)
+	}
+
+	if err := m.checkMemory(config.Memory.Size); err != nil {
+		return err
+	}
+	if err := prepareSerial(*config.Serial); err != nil {
+		return err
+	}
+	if err := m.prepareVsock(instanceID, config.Vsock); err != nil {
+		return err
+	}
+	numaNode, err := m.numa.reserve(instanceID, machine, config.Memory.Size)
+	if err != nil {
+		m.vsock.release(instanceID)
+		return err
+	}
+	if numaNode != nil {
+		log.V(1).Info("Placing vm on numa node", "numaNode", numaNode.ID)
+		numaConfig(numaNode, config.Cpus, config.Memory)
+	} else if hotplugSize := m.hotplugMemory(config.Memory.Size); hotplugSize > 0 {
+		config.Memory.HotplugMethod = ptr.To("Acpi")
+		config.Memory.HotplugSize = ptr.To(hotplugSize)
+	}
+
+	body, err := vmConfigBody(config, m.diskAIO)
+	if err != nil {
+		m.numa.release(instanceID)
+		m.vsock.release(instanceID)
+		return fmt.Errorf("failed to render vm config: %w", err)
+	}
+
+	log.V(2).Info("Creating vm")
+	resp, err := apiClient.CreateVMWithBodyWithResponse(ctx, "application/json", bytes.NewReader(body))
+	if err != nil {
+		m.numa.release(instanceID)
+		m.vsock.release(instanceID)
+		return wrapIfSocketClosed(fmt.Errorf("failed to get vm: %w", err))
+	}
+
+	if err := validateStatus(resp.StatusCode()); err != nil {
+		m.numa.release(instanceID)
+		m.vsock.release(instanceID)
+		log.V(1).Info("Failed to create vm", "error", string(resp.Body))
+		return err
+	}
+
+	return nil
+}
+
+// renderVMConfig renders the config of the vm of the machine. It neither prepares the host for the vm nor
+// reserves host resources for it, which is left to CreateVM.
+func (m *Manager) renderVMConfig(machine *api.Machine) (client.VmConfig, error) {
+	payload, err := m.payloadConfig(machine.Spec.Boot)
+	if err != nil {
+		return client.VmConfig{}, err
+	}
+
+	memoryBytes, err := AlignMemory(machine.Spec.MemoryBytes)
+	if err != nil {
+		return client.VmConfig{}, err
 	}
 
 	platform := &client.PlatformConfig{
@@ -229,30 +648,25 @@ func (m *Manager) CreateVM(ctx context.Context, machine *api.Machine) error {
 
 	var disks []client.DiskConfig
 	for _, vol := range machine.Status.VolumeStatus {
-		if vol.State != api.VolumeStatePrepared {
+		if vol.State != api.VolumeStatePrepared && vol.State != api.VolumeStateAttached {
 			continue
 		}
 
-		disk := client.DiskConfig{
-			Id: ptr.To(vol.Handle),
-		}
-
-		switch vol.Type {
-		case api.VolumeSocketType:
-			disk.VhostUser = ptr.To(true)
-			disk.VhostSocket = ptr.To(vol.Path)
-			disk.Readonly = ptr.To(false)
-		case api.VolumeFileType:
-			disk.Path = ptr.To(vol.Path)
-		}
+		disks = append(disks, diskConfig(&vol))
+	}
 
-		disks = append(disks, disk)
+	if machine.Spec.ConfigDrive != nil {
+		disks = append(disks, client.DiskConfig{
+			Id:       ptr.To(ConfigDriveDiskID),
+			Path:     ptr.To(m.paths.MachineConfigDriveFile(machine.ID)),
+			Readonly: ptr.To(true),
+		})
 	}
 
 	var dev []client.DeviceConfig
 	for _, nic := range machine.Status.NetworkInterfaceStatus {
-		if nic.State != api.NetworkInterfaceStatePrepared {
-			return fmt.Errorf("nic %s is not attached", nic.Name)
+		if nic.State != api.NetworkInterfaceStatePrepared && nic.State != api.NetworkInterfaceStateAttached {
+			return client.VmConfig{}, fmt.Errorf("%w: %s", ErrNICNotAttached, nic.Name)
 		}
 
 		dev = append(dev, client.DeviceConfig{
@@ -261,35 +675,183 @@ func (m *Manager) CreateVM(ctx context.Context, machine *api.Machine) error {
 		})
 	}
 
-	log.V(2).Info("Creating vm")
-	resp, err := apiClient.CreateVMWithResponse(ctx, client.CreateVMJSONRequestBody{
+	for _, bdf := range machine.Spec.PciDevices {
+		dev = append(dev, client.DeviceConfig{
+			Id:   ptr.To(getPciID(bdf)),
+			Path: pci.DevicePath(m.pciDevicesPath, bdf),
+		})
+	}
+
+	vsock, err := m.vsockConfig(machine)
+	if err != nil {
+		return client.VmConfig{}, err
+	}
+
+	serial := m.serialConfig(machine.ID)
+	return client.VmConfig{
 		Cpus: &client.CpusConfig{
 			BootVcpus: int(machine.Spec.Cpu),
-			MaxVcpus:  int(machine.Spec.Cpu),
+			MaxVcpus:  max(int(machine.Spec.Cpu), m.maxVcpus),
 		},
 		Devices: &dev,
 		Disks:   &disks,
 		Memory: &client.MemoryConfig{
-			Size:   machine.Spec.MemoryBytes,
+			Size:   memoryBytes,
 			Shared: ptr.To(true),
 		},
 		Console: &client.ConsoleConfig{
-			Mode: "Off",
-		},
-		Serial: &client.ConsoleConfig{
-			Mode: "Tty",
+			Mode: client.ConsoleConfigMode(m.consoleMode),
 		},
+		Serial:   &serial,
 		Payload:  payload,
 		Platform: platform,
-	})
+		Rng:      &client.RngConfig{Src: m.rngSource},
+		Vsock:    vsock,
+	}, nil
+}
+
+func (m *Manager) payloadConfig(boot *api.BootSpec) (client.PayloadConfig, error) {
+	if boot != nil {
+		if err := api.ValidateBootSpec(boot); err != nil {
+			return client.PayloadConfig{}, fmt.Errorf("invalid boot source: %w", err)
+		}
+		if boot.Kernel == "" {
+			return client.PayloadConfig{Firmware: ptr.To(boot.Firmware)}, nil
+		}
+		payload := client.PayloadConfig{Kernel: ptr.To(boot.Kernel)}
+		if boot.Initramfs != "" {
+			payload.Initramfs = ptr.To(boot.Initramfs)
+		}
+		if boot.Cmdline != "" {
+			payload.Cmdline = ptr.To(boot.Cmdline)
+		}
+		return payload, nil
+	}
+
+	switch {
+	case m.kernelPath != "":
+		return client.PayloadConfig{Kernel: ptr.To(m.kernelPath)}, nil
+	case m.firmwarePath != "":
+		return client.PayloadConfig{Firmware: ptr.To(m.firmwarePath)}, nil
+	default:
+		return client.PayloadConfig{}, fmt.Errorf("%w: neither a firmware nor a kernel path is configured", ErrNoBootSource)
+	}
+}
+
+func (m *Manager) checkMemory(memoryBytes int64) error {
+	available, err := m.availableMemory()
 	if err != nil {
-		return wrapIfSocketClosed(fmt.Errorf("failed to get vm: %w", err))
+		return fmt.Errorf("failed to get available host memory: %w", err)
+	}
+
+	free := capacity.FreeMemory(available, m.memoryReserve, m.memoryOvercommit)
+	if memoryBytes > free {
+		return fmt.Errorf("%w: vm requires %d bytes of memory, host has %d bytes available",
+			ErrInsufficientCapacity, memoryBytes, max(free, 0))
+	}
+	return nil
+}
+
+// hotplugMemory returns the memory a vm of the given size can be grown by up to the ceiling.
+func (m *Manager) hotplugMemory(memoryBytes int64) int64 {
+	if m.maxMemoryBytes <= memoryBytes {
+		return 0
+	}
+	maxMemoryBytes, err := AlignMemory(m.maxMemoryBytes)
+	if err != nil {
+		return 0
+	}
+	return maxMemoryBytes - memoryBytes
+}
+
+// Size returns the vcpus and memory the vm currently runs with, including hot plugged memory.
+func Size(vm *client.VmInfo) (int, int64) {
+	var (
+		cpus        int
+		memoryBytes int64
+	)
+	if vm.Config.Cpus != nil {
+		cpus = vm.Config.Cpus.BootVcpus
+	}
+	if memory := vm.Config.Memory; memory != nil {
+		memoryBytes = memory.Size + ptr.Deref(memory.HotpluggedSize, 0)
+		if memory.Zones != nil {
+			for _, zone := range *memory.Zones {
+				memoryBytes += zone.Size + ptr.Deref(zone.HotpluggedSize, 0)
+			}
+		}
+	}
+	return cpus, memoryBytes
+}
+
+// Resize changes the vcpus and memory of a running vm. The vcpus are bound by the ceiling the vm was created
+// with, memory can only grow and only if the vm was created with memory hotplug.
+func (m *Manager) Resize(ctx context.Context, instanceID string, cpus int, memoryBytes int64) error {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+
+	log := m.log.WithValues("instanceID", instanceID)
+
+	apiClient, found := m.instances[instanceID]
+	if !found {
+		return ErrNotFound
+	}
+
+	vm, err := m.getVM(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	memoryBytes, err = AlignMemory(memoryBytes)
+	if err != nil {
+		return err
+	}
+
+	currentCpus, currentMemoryBytes := Size(vm)
+	if cpus == currentCpus && memoryBytes == currentMemoryBytes {
+		return nil
+	}
+
+	if vm.Config.Cpus != nil && cpus > vm.Config.Cpus.MaxVcpus {
+		return fmt.Errorf("%w: %d vcpus exceed the maximum of %d vcpus", ErrResizeUnsupported, cpus, vm.Config.Cpus.MaxVcpus)
+	}
+
+	resize := client.VmResize{
+		DesiredVcpus: ptr.To(cpus),
+	}
+	if memoryBytes != currentMemoryBytes {
+		memory := vm.Config.Memory
+		hotplugSize := int64(0)
+		if memory != nil {
+			hotplugSize = ptr.Deref(memory.HotplugSize, 0)
+		}
+		switch {
+		case hotplugSize == 0:
+			return fmt.Errorf("%w: vm is created without memory hotplug", ErrResizeUnsupported)
+		case memoryBytes < currentMemoryBytes:
+			return fmt.Errorf("%w: memory cannot shrink from %d to %d bytes", ErrResizeUnsupported, currentMemoryBytes, memoryBytes)
+		case memoryBytes > memory.Size+hotplugSize:
+			return fmt.Errorf("%w: %d bytes of memory exceed the maximum of %d bytes",
+				ErrResizeUnsupported, memoryBytes, memory.Size+hotplugSize)
+		}
+
+		if err := m.checkMemory(memoryBytes - currentMemoryBytes); err != nil {
+			return err
+		}
+		resize.DesiredRam = ptr.To(memoryBytes)
+	}
+
+	m.invalidateVM(instanceID)
+	resp, err := apiClient.PutVmResizeWithResponse(ctx, resize)
+	if err != nil {
+		return wrapIfSocketClosed(fmt.Errorf("failed to resize vm: %w", err))
 	}
 
 	if err := validateStatus(resp.StatusCode()); err != nil {
-		log.V(1).Info("Failed to create vm", "error", string(resp.Body))
+		log.V(1).Info("Failed to resize vm", "error", string(resp.Body))
 		return err
 	}
+	log.V(1).Info("Resized vm", "cpus", cpus, "memoryBytes", memoryBytes)
 
 	return nil
 }
@@ -297,6 +859,7 @@ func (m *Manager) CreateVM(ctx context.Context, machine *api.Machine) error {
 func (m *Manager) RemoveDevice(ctx context.Context, instanceID string, deviceID string) error {
 	m.idMu.Lock(instanceID)
 	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
 
 	log := m.log.WithValues("instanceID", instanceID)
 
@@ -324,11 +887,12 @@ func (m *Manager) RemoveDevice(ctx context.Context, instanceID string, deviceID
 func (m *Manager) AddNIC(ctx context.Context, instanceID string, nic *api.NetworkInterfaceStatus) error {
 	m.idMu.Lock(instanceID)
 	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
 
 	log := m.log.WithValues("instanceID", instanceID)
 
-	if nic.State != api.NetworkInterfaceStatePrepared {
-		return fmt.Errorf("nic %s is not attached", nic.Name)
+	if nic.State != api.NetworkInterfaceStatePrepared && nic.State != api.NetworkInterfaceStateAttached {
+		return fmt.Errorf("%w: %s", ErrNICNotAttached, nic.Name)
 	}
 
 	apiClient, found := m.instances[instanceID]
@@ -360,10 +924,11 @@ func (m *Manager) RemoveNIC(ctx context.Context, instanceID string, nicName stri
 func (m *Manager) AddDisk(ctx context.Context, instanceID string, volume *api.VolumeStatus) error {
 	m.idMu.Lock(instanceID)
 	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
 
 	log := m.log.WithValues("instanceID", instanceID)
 
-	if volume.State != api.VolumeStatePrepared {
+	if volume.State != api.VolumeStatePrepared && volume.State != api.VolumeStateAttached {
 		return fmt.Errorf("volume %s is not prepared", volume.Handle)
 	}
 
@@ -372,9 +937,35 @@ func (m *Manager) AddDisk(ctx context.Context, instanceID string, volume *api.Vo
 		return ErrNotFound
 	}
 
+	body, err := diskConfigBody(diskConfig(volume), m.diskAIO)
+	if err != nil {
+		return fmt.Errorf("failed to render disk config: %w", err)
+	}
+
+	resp, err := apiClient.PutVmAddDiskWithBodyWithResponse(ctx, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return wrapIfSocketClosed(fmt.Errorf("failed to add device: %w", err))
+	}
+
+	if err := validateStatus(resp.StatusCode()); err != nil {
+		log.V(1).Info("Failed to add disk", "error", string(resp.Body))
+		return err
+	}
+	log.V(1).Info("Added device", "diskName", volume.Handle)
+
+	return nil
+}
+
+func diskConfig(volume *api.VolumeStatus) client.DiskConfig {
 	disk := client.DiskConfig{
 		Id: ptr.To(volume.Handle),
 	}
+	if volume.Serial != "" {
+		disk.Serial = ptr.To(volume.Serial)
+	}
+	if volume.QueueDepth > 0 {
+		disk.QueueSize = ptr.To(volume.QueueDepth)
+	}
 
 	switch volume.Type {
 	case api.VolumeSocketType:
@@ -384,24 +975,44 @@ func (m *Manager) AddDisk(ctx context.Context, instanceID string, volume *api.Vo
 	case api.VolumeFileType:
 		disk.Path = ptr.To(volume.Path)
 	}
+	return disk
+}
 
-	resp, err := apiClient.PutVmAddDiskWithResponse(ctx, disk)
-	if err != nil {
-		return wrapIfSocketClosed(fmt.Errorf("failed to add device: %w", err))
-	}
+// PowerOn boots the vm created by CreateVM, or shut down before. A running vm is left as is, a vm that is
+// not created yet is reported by ErrVmNotCreated.
+func (m *Manager) PowerOn(ctx context.Context, instanceID string) error {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
 
-	if err := validateStatus(resp.StatusCode()); err != nil {
-		log.V(1).Info("Failed to add disk", "error", string(resp.Body))
+	vm, err := m.getVM(ctx, instanceID)
+	if err != nil {
 		return err
 	}
-	log.V(1).Info("Added device", "diskName", volume.Handle)
 
-	return nil
+	switch vm.State {
+	case client.Running:
+		return nil
+	case client.Created, client.Shutdown:
+		if err := m.bootVM(ctx, instanceID); err != nil {
+			return err
+		}
+		m.log.V(1).Info("Powered on machine", "instanceID", instanceID)
+		return nil
+	default:
+		return fmt.Errorf("cannot power on vm in state %s", vm.State)
+	}
 }
 
-func (m *Manager) PowerOn(ctx context.Context, instanceID string) error {
+// BootVM starts the vm configured by CreateVM.
+func (m *Manager) BootVM(ctx context.Context, instanceID string) error {
 	m.idMu.Lock(instanceID)
 	defer m.idMu.Unlock(instanceID)
+	return m.bootVM(ctx, instanceID)
+}
+
+func (m *Manager) bootVM(ctx context.Context, instanceID string) error {
+	m.invalidateVM(instanceID)
 
 	log := m.log.WithValues("instanceID", instanceID)
 
@@ -419,7 +1030,6 @@ func (m *Manager) PowerOn(ctx context.Context, instanceID string) error {
 		log.V(1).Info("Failed to boot vm", "error", string(resp.Body))
 		return err
 	}
-	log.V(1).Info("Powered on machine")
 
 	return nil
 }
@@ -427,6 +1037,122 @@ func (m *Manager) PowerOn(ctx context.Context, instanceID string) error {
 func (m *Manager) PowerOff(ctx context.Context, instanceID string) error {
 	m.idMu.Lock(instanceID)
 	defer m.idMu.Unlock(instanceID)
+	return m.powerOff(ctx, instanceID)
+}
+
+// statePollInterval is the interval the state of a vm is polled at while waiting for it to change.
+const statePollInterval = 250 * time.Millisecond
+
+// pauseTimeout bounds waiting for a vm to be paused before it is snapshotted.
+const pauseTimeout = 10 * time.Second
+
+// StateTimeoutError is returned if a vm does not reach a state within the timeout.
+type StateTimeoutError struct {
+	InstanceID string
+	State      client.VmInfoState
+	// LastState is the state the vm was last seen in.
+	LastState client.VmInfoState
+	Timeout   time.Duration
+}
+
+func (e *StateTimeoutError) Error() string {
+	return fmt.Sprintf("vm of instance %s did not reach state %s within %s, last state %s",
+		e.InstanceID, e.State, e.Timeout, e.LastState)
+}
+
+// WaitForState polls the vm until it reaches the state. It returns a *StateTimeoutError if the vm does not
+// reach the state within the timeout.
+func (m *Manager) WaitForState(
+	ctx context.Context,
+	instanceID string,
+	state client.VmInfoState,
+	timeout time.Duration,
+) error {
+	return m.waitForState(ctx, instanceID, state, timeout, func(ctx context.Context) (*client.VmInfo, error) {
+		m.idMu.Lock(instanceID)
+		defer m.idMu.Unlock(instanceID)
+		return m.freshVM(ctx, instanceID)
+	})
+}
+
+// waitForState polls the vm with getVM until it reaches the state, see WaitForState.
+func (m *Manager) waitForState(
+	ctx context.Context,
+	instanceID string,
+	state client.VmInfoState,
+	timeout time.Duration,
+	getVM func(ctx context.Context) (*client.VmInfo, error),
+) error {
+	var lastState client.VmInfoState
+	err := wait.PollUntilContextTimeout(ctx, statePollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		vm, err := getVM(ctx)
+		if err != nil {
+			return false, err
+		}
+		lastState = vm.State
+		return vm.State == state, nil
+	})
+	switch {
+	case err == nil:
+		return nil
+	case wait.Interrupted(err) && ctx.Err() == nil:
+		return &StateTimeoutError{InstanceID: instanceID, State: state, LastState: lastState, Timeout: timeout}
+	default:
+		return fmt.Errorf("failed to wait for vm to reach state %s: %w", state, err)
+	}
+}
+
+// freshVM gets the vm bypassing the cached vm info.
+func (m *Manager) freshVM(ctx context.Context, instanceID string) (*client.VmInfo, error) {
+	m.invalidateVM(instanceID)
+	return m.getVM(ctx, instanceID)
+}
+
+// Shutdown presses the power button of the vm and waits up to the grace period for the guest to shut it
+// down. A vm still running afterwards is powered off. A zero grace period powers the vm off right away.
+func (m *Manager) Shutdown(ctx context.Context, instanceID string, gracePeriod time.Duration) error {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
+
+	log := m.log.WithValues("instanceID", instanceID)
+
+	if gracePeriod <= 0 {
+		return m.powerOff(ctx, instanceID)
+	}
+
+	apiClient, found := m.instances[instanceID]
+	if !found {
+		return ErrNotFound
+	}
+
+	resp, err := apiClient.PowerButtonVMWithResponse(ctx)
+	if err != nil {
+		return wrapIfSocketClosed(fmt.Errorf("failed to press power button of vm: %w", err))
+	}
+	if err := validateStatus(resp.StatusCode()); err != nil {
+		log.V(1).Info("Failed to press power button of vm", "error", string(resp.Body))
+		return err
+	}
+
+	err = m.waitForState(ctx, instanceID, client.Shutdown, gracePeriod, func(ctx context.Context) (*client.VmInfo, error) {
+		return m.freshVM(ctx, instanceID)
+	})
+	var timeoutErr *StateTimeoutError
+	switch {
+	case err == nil:
+		log.V(1).Info("Shut down machine")
+		return nil
+	case errors.As(err, &timeoutErr):
+		log.V(1).Info("Machine did not shut down within the grace period, powering off", "gracePeriod", gracePeriod)
+		return m.powerOff(ctx, instanceID)
+	default:
+		return fmt.Errorf("failed to wait for vm to shut down: %w", err)
+	}
+}
+
+func (m *Manager) powerOff(ctx context.Context, instanceID string) error {
+	m.invalidateVM(instanceID)
 
 	log := m.log.WithValues("instanceID", instanceID)
 
@@ -449,9 +1175,185 @@ func (m *Manager) PowerOff(ctx context.Context, instanceID string) error {
 	return nil
 }
 
+// Pause freezes the running vm, keeping its memory. Pausing a paused vm does nothing.
+func (m *Manager) Pause(ctx context.Context, instanceID string) error {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
+
+	vm, err := m.getVM(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	if vm.State == client.Paused {
+		return nil
+	}
+	return m.pauseVM(ctx, instanceID)
+}
+
+func (m *Manager) pauseVM(ctx context.Context, instanceID string) error {
+	m.invalidateVM(instanceID)
+
+	log := m.log.WithValues("instanceID", instanceID)
+
+	apiClient, found := m.instances[instanceID]
+	if !found {
+		return ErrNotFound
+	}
+
+	resp, err := apiClient.PauseVMWithResponse(ctx)
+	if err != nil {
+		return wrapIfSocketClosed(fmt.Errorf("failed to pause vm: %w", err))
+	}
+
+	if err := validateStatus(resp.StatusCode()); err != nil {
+		log.V(1).Info("Failed to pause vm", "error", string(resp.Body))
+		return err
+	}
+	log.V(1).Info("Paused machine")
+
+	return nil
+}
+
+// Resume continues the paused vm. Resuming a running vm does nothing.
+func (m *Manager) Resume(ctx context.Context, instanceID string) error {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
+
+	vm, err := m.getVM(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+	if vm.State == client.Running {
+		return nil
+	}
+	return m.resumeVM(ctx, instanceID)
+}
+
+func (m *Manager) resumeVM(ctx context.Context, instanceID string) error {
+	m.invalidateVM(instanceID)
+
+	log := m.log.WithValues("instanceID", instanceID)
+
+	apiClient, found := m.instances[instanceID]
+	if !found {
+		return ErrNotFound
+	}
+
+	resp, err := apiClient.ResumeVMWithResponse(ctx)
+	if err != nil {
+		return wrapIfSocketClosed(fmt.Errorf("failed to resume vm: %w", err))
+	}
+
+	if err := validateStatus(resp.StatusCode()); err != nil {
+		log.V(1).Info("Failed to resume vm", "error", string(resp.Body))
+		return err
+	}
+	log.V(1).Info("Resumed machine")
+
+	return nil
+}
+
+// Snapshot writes the state of the vm, including its memory, to destDir. cloud-hypervisor only snapshots
+// paused vms, so a running vm is paused for the snapshot and resumed afterward.
+func (m *Manager) Snapshot(ctx context.Context, instanceID string, destDir string) (retErr error) {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
+
+	log := m.log.WithValues("instanceID", instanceID)
+
+	apiClient, found := m.instances[instanceID]
+	if !found {
+		return ErrNotFound
+	}
+
+	vm, err := m.getVM(ctx, instanceID)
+	if err != nil {
+		return err
+	}
+
+	switch vm.State {
+	case client.Paused:
+	case client.Running:
+		if err := m.pauseVM(ctx, instanceID); err != nil {
+			return err
+		}
+		defer func() {
+			if err := m.resumeVM(ctx, instanceID); err != nil {
+				retErr = errors.Join(retErr, err)
+			}
+		}()
+		// cloud-hypervisor rejects snapshots of vms that are not paused.
+		err := m.waitForState(ctx, instanceID, client.Paused, pauseTimeout, func(ctx context.Context) (*client.VmInfo, error) {
+			return m.freshVM(ctx, instanceID)
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot snapshot vm in state %s", vm.State)
+	}
+
+	// The snapshot holds the memory of the guest, keep it private.
+	if err := os.MkdirAll(destDir, 0700); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	resp, err := apiClient.PutVmSnapshotWithResponse(ctx, client.VmSnapshotConfig{
+		DestinationUrl: ptr.To("file://" + destDir),
+	})
+	if err != nil {
+		return wrapIfSocketClosed(fmt.Errorf("failed to snapshot vm: %w", err))
+	}
+
+	if err := validateStatus(resp.StatusCode()); err != nil {
+		log.V(1).Info("Failed to snapshot vm", "error", string(resp.Body))
+		return err
+	}
+	log.V(1).Info("Snapshotted machine", "destDir", destDir)
+
+	return nil
+}
+
+// Restore creates the vm from the snapshot in srcDir. The restored vm is paused until it is resumed.
+func (m *Manager) Restore(ctx context.Context, instanceID string, srcDir string) error {
+	m.idMu.Lock(instanceID)
+	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
+
+	log := m.log.WithValues("instanceID", instanceID)
+
+	apiClient, found := m.instances[instanceID]
+	if !found {
+		return ErrNotFound
+	}
+
+	if _, err := os.Stat(srcDir); err != nil {
+		return fmt.Errorf("failed to check snapshot directory: %w", err)
+	}
+
+	resp, err := apiClient.PutVmRestoreWithResponse(ctx, client.RestoreConfig{
+		SourceUrl: "file://" + srcDir,
+	})
+	if err != nil {
+		return wrapIfSocketClosed(fmt.Errorf("failed to restore vm: %w", err))
+	}
+
+	if err := validateStatus(resp.StatusCode()); err != nil {
+		log.V(1).Info("Failed to restore vm", "error", string(resp.Body))
+		return err
+	}
+	log.V(1).Info("Restored machine", "srcDir", srcDir)
+
+	return nil
+}
+
 func (m *Manager) Delete(ctx context.Context, instanceID string) error {
 	m.idMu.Lock(instanceID)
 	defer m.idMu.Unlock(instanceID)
+	m.invalidateVM(instanceID)
 
 	log := m.log.WithValues("instanceID", instanceID)
 
@@ -469,6 +1371,8 @@ func (m *Manager) Delete(ctx context.Context, instanceID string) error {
 		log.V(1).Info("Failed to delete vm", "error", string(resp.Body))
 		return err
 	}
+	m.numa.release(instanceID)
+	m.vsock.release(instanceID)
 	log.V(1).Info("Deleted machine")
 
 	return nil
@@ -477,3 +1381,7 @@ func (m *Manager) Delete(ctx context.Context, instanceID string) error {
 func getNicID(nicName string) string {
 	return fmt.Sprintf("%s//%s", "NIC", nicName)
 }
+
+func getPciID(bdf string) string {
+	return fmt.Sprintf("%s//%s", "PCI", bdf)
+}
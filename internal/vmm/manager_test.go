@@ -0,0 +1,1117 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package vmm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
+	apiutils "github.com/ironcore-dev/provider-utils/apiutils/api"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("Manager", func() {
+	var (
+		socketPath string
+		fake       *fakeVMM
+		manager    *vmm.Manager
+	)
+
+	BeforeEach(func() {
+		socketsDir := GinkgoT().TempDir()
+		socketPath = filepath.Join(socketsDir, "ch.sock")
+		fake = startFakeVMM(socketPath)
+		manager = newManager(socketsDir)
+	})
+
+	newMachine := func(id string) *api.Machine {
+		return &api.Machine{
+			Metadata: apiutils.Metadata{ID: id},
+			Spec: api.MachineSpec{
+				ApiSocketPath: ptr.To(socketPath),
+				Cpu:           1,
+				MemoryBytes:   1024 * 1024 * 1024,
+			},
+		}
+	}
+
+	Describe("NewManager", func() {
+		It("should fail on an invalid sockets path", func() {
+			paths, err := host.PathsAt(GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+
+			socketsFile := filepath.Join(GinkgoT().TempDir(), "sockets")
+			Expect(os.WriteFile(socketsFile, nil, 0644)).To(Succeed())
+
+			_, err = vmm.NewManager(GinkgoLogr, paths, vmm.ManagerOptions{CHSocketsPath: socketsFile})
+			Expect(err).To(MatchError(ContainSubstring("sockets dir")))
+
+			_, err = vmm.NewManager(GinkgoLogr, paths, vmm.ManagerOptions{})
+			Expect(err).To(MatchError(ContainSubstring("sockets dir")))
+		})
+
+		It("should succeed on a sockets path with instances", func() {
+			paths, err := host.PathsAt(GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = vmm.NewManager(GinkgoLogr, paths, vmm.ManagerOptions{CHSocketsPath: filepath.Dir(socketPath)})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should remove sockets no cloud-hypervisor listens on anymore", func() {
+			deadSocketPath := filepath.Join(filepath.Dir(socketPath), "dead.sock")
+			l, err := net.Listen("unix", deadSocketPath)
+			Expect(err).NotTo(HaveOccurred())
+			l.(*net.UnixListener).SetUnlinkOnClose(false)
+			Expect(l.Close()).To(Succeed())
+			Expect(deadSocketPath).To(BeAnExistingFile())
+
+			manager = newManager(filepath.Dir(socketPath))
+			Expect(deadSocketPath).NotTo(BeAnExistingFile())
+			Expect(manager.GetFreeApiSocket()).To(HaveValue(Equal(socketPath)))
+		})
+
+		It("should keep the instance of a vm not owned by any machine in use", func() {
+			fake.SetVM(&client.VmInfo{
+				Config: client.VmConfig{Platform: &client.PlatformConfig{Uuid: ptr.To("gone")}},
+				State:  client.Running,
+			})
+
+			manager = newManager(filepath.Dir(socketPath))
+			Expect(manager.GetFreeApiSocket()).Error().To(HaveOccurred())
+			Expect(fake.VM()).To(HaveField("State", client.Running))
+		})
+	})
+
+	Describe("Close", func() {
+		It("should shut down running vms", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.Close(ctx)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Shutdown))
+		})
+
+		It("should keep running vms if the vms are detached", func(ctx SpecContext) {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				DetachVMs:     true,
+			})
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.Close(ctx)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Running))
+		})
+	})
+
+	Describe("ReapOrphanVM", func() {
+		It("should reap a vm of a different machine before creating the vm", func(ctx SpecContext) {
+			By("placing an orphan vm on the socket")
+			fake.SetVM(&client.VmInfo{
+				Config: client.VmConfig{Platform: &client.PlatformConfig{Uuid: ptr.To("orphan")}},
+				State:  client.Running,
+			})
+
+			By("reaping the orphan vm")
+			reaped, err := manager.ReapOrphanVM(ctx, socketPath, "machine")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reaped).To(BeTrue())
+			Expect(fake.VM()).To(BeNil())
+			Expect(fake.Calls()).To(ContainElements("vm.shutdown", "vm.delete"))
+
+			By("creating the vm of the machine")
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			vm, err := manager.GetVM(ctx, socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vm.Config.Platform.Uuid).To(Equal(ptr.To("machine")))
+		})
+
+		It("should keep the vm of the same machine", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+
+			reaped, err := manager.ReapOrphanVM(ctx, socketPath, "machine")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reaped).To(BeFalse())
+			Expect(fake.VM()).NotTo(BeNil())
+		})
+
+		It("should do nothing if no vm is created", func(ctx SpecContext) {
+			reaped, err := manager.ReapOrphanVM(ctx, socketPath, "machine")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reaped).To(BeFalse())
+			Expect(fake.Calls()).NotTo(ContainElement("vm.delete"))
+		})
+	})
+
+	Describe("CreateVM", func() {
+		It("should pass through the requested pci devices", func(ctx SpecContext) {
+			machine := newMachine("machine")
+			machine.Spec.PciDevices = []string{"0000:3b:00.0", "0000:5e:00.1"}
+
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+
+			vm := fake.VM()
+			Expect(vm).NotTo(BeNil())
+			Expect(vm.Config.Devices).To(HaveValue(ConsistOf(
+				client.DeviceConfig{Id: ptr.To("PCI//0000:3b:00.0"), Path: "/sys/bus/pci/devices/0000:3b:00.0/"},
+				client.DeviceConfig{Id: ptr.To("PCI//0000:5e:00.1"), Path: "/sys/bus/pci/devices/0000:5e:00.1/"},
+			)))
+		})
+
+		It("should reject the vm early if the host lacks available memory", func(ctx SpecContext) {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath:   filepath.Dir(socketPath),
+				AvailableMemory: func() (int64, error) { return 1536 * 1024 * 1024, nil },
+				MemoryReserve:   1024 * 1024 * 1024,
+			})
+
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(MatchError(vmm.ErrInsufficientCapacity))
+			Expect(fake.VM()).To(BeNil())
+			Expect(fake.Calls()).NotTo(ContainElement("vm.create"))
+		})
+
+		It("should consider the memory overcommit ratio", func(ctx SpecContext) {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath:    filepath.Dir(socketPath),
+				AvailableMemory:  func() (int64, error) { return 768 * 1024 * 1024, nil },
+				MemoryOvercommit: 2,
+			})
+
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).NotTo(BeNil())
+		})
+
+		It("should boot from the kernel if configured", func(ctx SpecContext) {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				KernelPath:    "/var/lib/chp/vmlinux",
+			})
+
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Payload", client.PayloadConfig{Kernel: ptr.To("/var/lib/chp/vmlinux")}))
+		})
+
+		DescribeTable("should boot from the boot source of the machine",
+			func(ctx SpecContext, boot *api.BootSpec, payload client.PayloadConfig) {
+				machine := newMachine("machine")
+				machine.Spec.Boot = boot
+
+				Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+				Expect(fake.VM()).To(HaveField("Config.Payload", payload))
+			},
+			Entry("provider firmware", nil,
+				client.PayloadConfig{Firmware: ptr.To("/usr/local/bin/hypervisor-fw")}),
+			Entry("firmware", &api.BootSpec{Firmware: "/var/lib/chp/uefi-fw"},
+				client.PayloadConfig{Firmware: ptr.To("/var/lib/chp/uefi-fw")}),
+			Entry("kernel", &api.BootSpec{Kernel: "/var/lib/chp/vmlinux"},
+				client.PayloadConfig{Kernel: ptr.To("/var/lib/chp/vmlinux")}),
+			Entry("kernel with initramfs and cmdline", &api.BootSpec{
+				Kernel:    "/var/lib/chp/vmlinux",
+				Initramfs: "/var/lib/chp/initramfs",
+				Cmdline:   "console=hvc0 root=/dev/vda1",
+			}, client.PayloadConfig{
+				Kernel:    ptr.To("/var/lib/chp/vmlinux"),
+				Initramfs: ptr.To("/var/lib/chp/initramfs"),
+				Cmdline:   ptr.To("console=hvc0 root=/dev/vda1"),
+			}),
+		)
+
+		It("should reject a machine with both a firmware and a kernel", func(ctx SpecContext) {
+			machine := newMachine("machine")
+			machine.Spec.Boot = &api.BootSpec{Firmware: "/var/lib/chp/uefi-fw", Kernel: "/var/lib/chp/vmlinux"}
+
+			Expect(manager.CreateVM(ctx, machine)).To(MatchError(ContainSubstring("mutually exclusive")))
+			Expect(fake.Calls()).NotTo(ContainElement("vm.create"))
+		})
+
+		It("should expose volumes with their serial", func(ctx SpecContext) {
+			machine := newMachine("machine")
+			machine.Status.VolumeStatus = []api.VolumeStatus{
+				{Name: "root", Handle: "root", Type: api.VolumeFileType, Path: "/disks/root", State: api.VolumeStatePrepared, Serial: "disk-root"},
+				{Name: "data", Handle: "data", Type: api.VolumeFileType, Path: "/disks/data", State: api.VolumeStatePrepared},
+			}
+
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Disks", HaveValue(ConsistOf(
+				client.DiskConfig{Id: ptr.To("root"), Path: ptr.To("/disks/root"), Serial: ptr.To("disk-root")},
+				client.DiskConfig{Id: ptr.To("data"), Path: ptr.To("/disks/data")},
+			))))
+		})
+
+		It("should size the disk queues to the requested queue depth", func(ctx SpecContext) {
+			machine := newMachine("machine")
+			machine.Status.VolumeStatus = []api.VolumeStatus{
+				{Name: "data", Handle: "data", Type: api.VolumeFileType, Path: "/disks/data", State: api.VolumeStatePrepared, QueueDepth: 1024},
+			}
+
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Disks", HaveValue(ConsistOf(
+				client.DiskConfig{Id: ptr.To("data"), Path: ptr.To("/disks/data"), QueueSize: ptr.To(1024)},
+			))))
+		})
+
+		It("should serve the file disks with the configured aio backend", func(ctx SpecContext) {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				DiskAIO:       vmm.DiskAIOThreads,
+			})
+			machine := newMachine("machine")
+			machine.Status.VolumeStatus = []api.VolumeStatus{
+				{Name: "root", Handle: "root", Type: api.VolumeFileType, Path: "/disks/root", State: api.VolumeStatePrepared},
+				{Name: "data", Handle: "data", Type: api.VolumeSocketType, Path: "/sockets/data", State: api.VolumeStatePrepared},
+			}
+
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Disks", HaveValue(ConsistOf(
+				client.DiskConfig{Id: ptr.To("root"), Path: ptr.To("/disks/root")},
+				client.DiskConfig{
+					Id:          ptr.To("data"),
+					VhostUser:   ptr.To(true),
+					VhostSocket: ptr.To("/sockets/data"),
+					Readonly:    ptr.To(false),
+				},
+			))))
+
+			var config struct {
+				Disks []map[string]any `json:"disks"`
+			}
+			Expect(json.Unmarshal(fake.Body("vm.create"), &config)).To(Succeed())
+			Expect(config.Disks).To(ConsistOf(
+				SatisfyAll(
+					HaveKeyWithValue("id", "root"),
+					HaveKeyWithValue("disable_io_uring", true),
+					HaveKeyWithValue("disable_aio", true),
+				),
+				SatisfyAll(
+					HaveKeyWithValue("id", "data"),
+					Not(HaveKey("disable_io_uring")),
+					Not(HaveKey("disable_aio")),
+				),
+			))
+		})
+
+		It("should add an entropy device reading the rng source", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Rng", HaveValue(Equal(client.RngConfig{Src: vmm.DefaultRngSource}))))
+
+			var config client.VmConfig
+			Expect(json.Unmarshal(fake.Body("vm.create"), &config)).To(Succeed())
+			Expect(config.Rng).To(HaveValue(HaveField("Src", vmm.DefaultRngSource)))
+
+			rngSource := filepath.Join(GinkgoT().TempDir(), "random")
+			Expect(os.WriteFile(rngSource, nil, 0644)).To(Succeed())
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				RngSource:     rngSource,
+			})
+			Expect(manager.Delete(ctx, socketPath)).To(Succeed())
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Rng", HaveValue(HaveField("Src", rngSource))))
+		})
+
+		It("should reject a missing rng source", func() {
+			_, err := vmm.NewManager(GinkgoLogr, nil, vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				RngSource:     filepath.Join(GinkgoT().TempDir(), "missing"),
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid rng source")))
+			Expect(err).To(MatchError(os.ErrNotExist))
+		})
+
+		It("should reject an unsupported aio backend", func() {
+			_, err := vmm.NewManager(GinkgoLogr, nil, vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				DiskAIO:       "posix",
+			})
+			Expect(err).To(MatchError(`unsupported disk aio "posix"`))
+		})
+
+		It("should attach the config drive read-only", func(ctx SpecContext) {
+			paths, err := host.PathsAt(GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+			manager, err = vmm.NewManager(GinkgoLogr, paths, vmm.ManagerOptions{
+				CHSocketsPath:   filepath.Dir(socketPath),
+				FirmwarePath:    "/usr/local/bin/hypervisor-fw",
+				AvailableMemory: func() (int64, error) { return 64 * 1024 * 1024 * 1024, nil },
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			machine := newMachine("machine")
+			machine.Spec.ConfigDrive = &api.ConfigDriveSpec{Hostname: "machine"}
+
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Disks", HaveValue(ConsistOf(client.DiskConfig{
+				Id:       ptr.To(vmm.ConfigDriveDiskID),
+				Path:     ptr.To(paths.MachineConfigDriveFile("machine")),
+				Readonly: ptr.To(true),
+			}))))
+		})
+
+		It("should create the vm with aligned memory", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Memory.Size", int64(1024*1024*1024)))
+		})
+
+		It("should round misaligned memory up to the memory alignment", func(ctx SpecContext) {
+			machine := newMachine("machine")
+			machine.Spec.MemoryBytes = 1024*1024*1024 + 4096
+
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Memory.Size", int64(1024*1024*1024+vmm.MemoryAlignment)))
+		})
+
+		DescribeTable("should reject invalid memory sizes before creating the vm",
+			func(ctx SpecContext, memoryBytes int64) {
+				machine := newMachine("machine")
+				machine.Spec.MemoryBytes = memoryBytes
+
+				Expect(manager.CreateVM(ctx, machine)).To(MatchError(vmm.ErrInvalidMemory))
+				Expect(fake.Calls()).NotTo(ContainElement("vm.create"))
+			},
+			Entry("negative", int64(-1)),
+			Entry("zero", int64(0)),
+			Entry("below the minimum", int64(4096)),
+			Entry("above the maximum", int64(vmm.MaxMemoryBytes+1)),
+		)
+
+		It("should configure the serial log and the console independently", func(ctx SpecContext) {
+			paths, err := host.PathsAt(GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+			manager, err = vmm.NewManager(GinkgoLogr, paths, vmm.ManagerOptions{
+				CHSocketsPath:   filepath.Dir(socketPath),
+				FirmwarePath:    "/usr/local/bin/hypervisor-fw",
+				AvailableMemory: func() (int64, error) { return 64 * 1024 * 1024 * 1024, nil },
+				SerialMode:      vmm.SerialModeFile,
+				ConsoleMode:     vmm.ConsoleModePty,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("leaving a serial log of a previous vm")
+			serialLog := paths.MachineSerialLogFile("machine")
+			Expect(host.MakeMachineDirs(paths, "machine")).To(Succeed())
+			Expect(os.WriteFile(serialLog, []byte("previous boot"), 0644)).To(Succeed())
+
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).To(SatisfyAll(
+				HaveField("Config.Serial", HaveValue(Equal(client.ConsoleConfig{
+					Mode: client.ConsoleConfigModeFile,
+					File: ptr.To(serialLog),
+				}))),
+				HaveField("Config.Console", HaveValue(Equal(client.ConsoleConfig{
+					Mode: client.ConsoleConfigModePty,
+				}))),
+			))
+			Expect(filepath.Dir(serialLog)).To(Equal(paths.MachineDir("machine")))
+
+			By("ensuring the previous serial log was rotated")
+			Expect(serialLog).NotTo(BeAnExistingFile())
+			Expect(os.ReadFile(serialLog + ".1")).To(Equal([]byte("previous boot")))
+		})
+
+		It("should reject the vm early if no boot source is configured", func(ctx SpecContext) {
+			paths, err := host.PathsAt(GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+			manager, err = vmm.NewManager(GinkgoLogr, paths, vmm.ManagerOptions{
+				CHSocketsPath:   filepath.Dir(socketPath),
+				AvailableMemory: func() (int64, error) { return 64 * 1024 * 1024 * 1024, nil },
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(MatchError(vmm.ErrNoBootSource))
+			Expect(fake.Calls()).NotTo(ContainElement("vm.create"))
+		})
+	})
+
+	Describe("SerialLog", func() {
+		var paths host.Paths
+
+		BeforeEach(func() {
+			var err error
+			paths, err = host.PathsAt(GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(host.MakeMachineDirs(paths, "machine")).To(Succeed())
+		})
+
+		newSerialManager := func(opts vmm.ManagerOptions) *vmm.Manager {
+			opts.CHSocketsPath = filepath.Dir(socketPath)
+			opts.FirmwarePath = "/usr/local/bin/hypervisor-fw"
+			opts.AvailableMemory = func() (int64, error) { return 64 * 1024 * 1024 * 1024, nil }
+			manager, err := vmm.NewManager(GinkgoLogr, paths, opts)
+			Expect(err).NotTo(HaveOccurred())
+			return manager
+		}
+
+		It("should log the serial port to the machine directory by default", func(ctx SpecContext) {
+			manager := newSerialManager(vmm.ManagerOptions{})
+
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Serial", HaveValue(Equal(client.ConsoleConfig{
+				Mode: client.ConsoleConfigModeFile,
+				File: ptr.To(filepath.Join(paths.MachineDir("machine"), "serial.log")),
+			}))))
+		})
+
+		It("should cap the serial log, keeping its output readable", func(ctx SpecContext) {
+			manager := newSerialManager(vmm.ManagerOptions{SerialLogMaxBytes: 8192})
+			serialLog := paths.MachineSerialLogFile("machine")
+
+			By("writing more output than the cap like cloud-hypervisor")
+			f, err := os.Create(serialLog)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(f.Close)
+			firstBoot := bytes.Repeat([]byte("first boot\n"), 2048)
+			Expect(f.Write(firstBoot)).To(Equal(len(firstBoot)))
+
+			By("capping the serial log")
+			Expect(manager.CapSerialLogs()).To(Succeed())
+			Expect(os.ReadFile(serialLog + ".1")).To(Equal(firstBoot))
+
+			By("reading the output written after the cap")
+			Expect(f.Write([]byte("after cap\n"))).To(Equal(10))
+			log, err := manager.SerialLog(ctx, "machine")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(log.Close)
+			Expect(io.ReadAll(log)).To(Equal([]byte("after cap\n")))
+
+			By("keeping a serial log within the cap")
+			Expect(manager.CapSerialLogs()).To(Succeed())
+			Expect(os.ReadFile(serialLog + ".1")).To(Equal(firstBoot))
+		})
+
+		It("should reject reading the serial log if the serial port is not logged", func(ctx SpecContext) {
+			manager := newSerialManager(vmm.ManagerOptions{SerialMode: vmm.SerialModeSocket})
+
+			Expect(manager.SerialLog(ctx, "machine")).Error().To(MatchError(ContainSubstring("serial port is not logged")))
+		})
+	})
+
+	Describe("PowerOn", func() {
+		var calls func() []string
+
+		BeforeEach(func() {
+			initCalls := len(fake.Calls())
+			calls = func() []string { return fake.Calls()[initCalls:] }
+		})
+
+		It("should boot the created vm instead of creating it again", func(ctx SpecContext) {
+			By("creating the vm")
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Created))
+			Expect(calls()).To(HaveExactElements("vm.create"))
+
+			By("powering on the vm")
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Running))
+			Expect(calls()).To(HaveExactElements("vm.create", "vm.info", "vm.boot"))
+
+			By("powering on the running vm")
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+			Expect(calls()).To(HaveExactElements("vm.create", "vm.info", "vm.boot", "vm.info"))
+		})
+
+		It("should boot the vm again after it was shut down", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(manager.BootVM(ctx, socketPath)).To(Succeed())
+			Expect(manager.PowerOff(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Running))
+			Expect(calls()).To(HaveExactElements("vm.create", "vm.boot", "vm.shutdown", "vm.info", "vm.boot"))
+		})
+
+		It("should report a vm that is not created", func(ctx SpecContext) {
+			Expect(manager.PowerOn(ctx, socketPath)).To(MatchError(vmm.ErrVmNotCreated))
+			Expect(calls()).NotTo(ContainElement("vm.boot"))
+		})
+	})
+
+	Describe("Pause", func() {
+		var calls func() []string
+
+		BeforeEach(func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(manager.BootVM(ctx, socketPath)).To(Succeed())
+			initCalls := len(fake.Calls())
+			calls = func() []string { return fake.Calls()[initCalls:] }
+		})
+
+		It("should pause the running vm only once", func(ctx SpecContext) {
+			Expect(manager.Pause(ctx, socketPath)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Paused))
+
+			By("pausing the paused vm")
+			Expect(manager.Pause(ctx, socketPath)).To(Succeed())
+			Expect(calls()).To(HaveExactElements("vm.info", "vm.pause", "vm.info"))
+		})
+
+		It("should resume the paused vm only once", func(ctx SpecContext) {
+			Expect(manager.Pause(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.Resume(ctx, socketPath)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Running))
+
+			By("resuming the running vm")
+			Expect(manager.Resume(ctx, socketPath)).To(Succeed())
+			Expect(calls()).To(HaveExactElements("vm.info", "vm.pause", "vm.info", "vm.resume", "vm.info"))
+		})
+	})
+
+	Describe("Snapshot", func() {
+		var snapshotDir string
+
+		BeforeEach(func(ctx SpecContext) {
+			snapshotDir = filepath.Join(GinkgoT().TempDir(), "snapshot")
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+		})
+
+		It("should snapshot the running vm while it is paused", func(ctx SpecContext) {
+			Expect(manager.BootVM(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.Snapshot(ctx, socketPath, snapshotDir)).To(Succeed())
+			Expect(fake.Calls()).To(ContainElements("vm.pause", "vm.snapshot", "vm.resume"))
+			Expect(fake.VM()).To(HaveField("State", client.Running))
+
+			var config client.VmSnapshotConfig
+			Expect(json.Unmarshal(fake.Body("vm.snapshot"), &config)).To(Succeed())
+			Expect(config.DestinationUrl).To(HaveValue(Equal("file://" + snapshotDir)))
+
+			info, err := os.Stat(snapshotDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.IsDir()).To(BeTrue())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+		})
+
+		It("should keep a paused vm paused", func(ctx SpecContext) {
+			Expect(manager.BootVM(ctx, socketPath)).To(Succeed())
+			Expect(manager.Pause(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.Snapshot(ctx, socketPath, snapshotDir)).To(Succeed())
+			Expect(fake.Calls()).NotTo(ContainElement("vm.resume"))
+			Expect(fake.VM()).To(HaveField("State", client.Paused))
+		})
+
+		It("should reject a vm that is not booted", func(ctx SpecContext) {
+			Expect(manager.Snapshot(ctx, socketPath, snapshotDir)).To(MatchError(ContainSubstring("cannot snapshot vm in state Created")))
+			Expect(snapshotDir).NotTo(BeADirectory())
+		})
+	})
+
+	Describe("Restore", func() {
+		It("should restore the vm paused from the snapshot", func(ctx SpecContext) {
+			snapshotDir := GinkgoT().TempDir()
+
+			Expect(manager.Restore(ctx, socketPath, snapshotDir)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Paused))
+
+			var config client.RestoreConfig
+			Expect(json.Unmarshal(fake.Body("vm.restore"), &config)).To(Succeed())
+			Expect(config.SourceUrl).To(Equal("file://" + snapshotDir))
+		})
+
+		It("should reject a missing snapshot", func(ctx SpecContext) {
+			Expect(manager.Restore(ctx, socketPath, filepath.Join(GinkgoT().TempDir(), "missing"))).
+				To(MatchError(os.ErrNotExist))
+			Expect(fake.Calls()).NotTo(ContainElement("vm.restore"))
+		})
+	})
+
+	Describe("Shutdown", func() {
+		BeforeEach(func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+		})
+
+		It("should let the guest shut the vm down", func(ctx SpecContext) {
+			Expect(manager.Shutdown(ctx, socketPath, time.Second)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Shutdown))
+			Expect(fake.Calls()).To(ContainElement("vm.power-button"))
+			Expect(fake.Calls()).NotTo(ContainElement("vm.shutdown"))
+		})
+
+		It("should power the vm off if the guest does not shut it down within the grace period", func(ctx SpecContext) {
+			fake.SetIgnorePowerButton(true)
+
+			start := time.Now()
+			Expect(manager.Shutdown(ctx, socketPath, 500*time.Millisecond)).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 500*time.Millisecond))
+			Expect(fake.VM()).To(HaveField("State", client.Shutdown))
+			Expect(fake.Calls()).To(ContainElements("vm.power-button", "vm.shutdown"))
+		})
+
+		It("should power the vm off right away without a grace period", func(ctx SpecContext) {
+			Expect(manager.Shutdown(ctx, socketPath, 0)).To(Succeed())
+			Expect(fake.Calls()).To(ContainElement("vm.shutdown"))
+			Expect(fake.Calls()).NotTo(ContainElement("vm.power-button"))
+		})
+	})
+
+	Describe("WaitForState", func() {
+		BeforeEach(func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+		})
+
+		It("should return once the vm reaches the state", func(ctx SpecContext) {
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(300 * time.Millisecond)
+				Expect(manager.BootVM(ctx, socketPath)).To(Succeed())
+			}()
+
+			Expect(manager.WaitForState(ctx, socketPath, client.Running, 5*time.Second)).To(Succeed())
+			Expect(fake.VM()).To(HaveField("State", client.Running))
+		})
+
+		It("should time out if the vm does not reach the state", func(ctx SpecContext) {
+			start := time.Now()
+			err := manager.WaitForState(ctx, socketPath, client.Running, 500*time.Millisecond)
+			Expect(time.Since(start)).To(BeNumerically(">=", 500*time.Millisecond))
+
+			var timeoutErr *vmm.StateTimeoutError
+			Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+			Expect(timeoutErr).To(HaveField("State", client.Running))
+			Expect(timeoutErr).To(HaveField("LastState", client.Created))
+		})
+	})
+
+	Describe("AddDisk", func() {
+		It("should add the disk with its serial", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+
+			Expect(manager.AddDisk(ctx, socketPath, &api.VolumeStatus{
+				Name:   "data",
+				Handle: "data",
+				Type:   api.VolumeSocketType,
+				Path:   "/run/data.sock",
+				State:  api.VolumeStatePrepared,
+				Serial: "disk-data",
+			})).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Disks", HaveValue(ConsistOf(client.DiskConfig{
+				Id:          ptr.To("data"),
+				VhostUser:   ptr.To(true),
+				VhostSocket: ptr.To("/run/data.sock"),
+				Readonly:    ptr.To(false),
+				Serial:      ptr.To("disk-data"),
+			}))))
+		})
+	})
+
+	Describe("Resize", func() {
+		const gib = 1024 * 1024 * 1024
+
+		BeforeEach(func() {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath:  filepath.Dir(socketPath),
+				MaxVcpus:       4,
+				MaxMemoryBytes: 4 * gib,
+			})
+		})
+
+		It("should create the vm with room to grow up to the ceilings", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(fake.VM()).To(HaveField("Config.Cpus", HaveValue(HaveField("MaxVcpus", 4))))
+			Expect(fake.VM()).To(HaveField("Config.Memory", HaveValue(And(
+				HaveField("HotplugMethod", HaveValue(Equal("Acpi"))),
+				HaveField("HotplugSize", HaveValue(BeEquivalentTo(3*gib))),
+			))))
+		})
+
+		It("should resize the running vm", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.Resize(ctx, socketPath, 2, 2*gib)).To(Succeed())
+			vm, err := manager.GetVM(ctx, socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			cpus, memoryBytes := vmm.Size(vm)
+			Expect(cpus).To(Equal(2))
+			Expect(memoryBytes).To(BeEquivalentTo(2 * gib))
+
+			By("resizing the vm to its current size")
+			Expect(manager.Resize(ctx, socketPath, 2, 2*gib)).To(Succeed())
+			Expect(slices.DeleteFunc(fake.Calls(), func(call string) bool { return call != "vm.resize" })).To(HaveLen(1))
+		})
+
+		It("should reject sizes beyond the ceilings", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.Resize(ctx, socketPath, 8, gib)).To(MatchError(vmm.ErrResizeUnsupported))
+			Expect(manager.Resize(ctx, socketPath, 1, 8*gib)).To(MatchError(vmm.ErrResizeUnsupported))
+			Expect(fake.Calls()).NotTo(ContainElement("vm.resize"))
+		})
+
+		It("should reject memory changes of a vm created without memory hotplug", func(ctx SpecContext) {
+			manager = newManager(filepath.Dir(socketPath))
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+
+			Expect(manager.Resize(ctx, socketPath, 1, 2*gib)).To(MatchError(ContainSubstring("without memory hotplug")))
+			Expect(fake.Calls()).NotTo(ContainElement("vm.resize"))
+		})
+	})
+
+	Describe("GetVM", func() {
+		It("should serve cached vm info within the ttl until the vm is changed", func(ctx SpecContext) {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				VMInfoTTL:     time.Minute,
+			})
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+
+			infoCalls := func() int {
+				return len(slices.DeleteFunc(fake.Calls(), func(call string) bool { return call != "vm.info" }))
+			}
+			calls := infoCalls()
+
+			By("getting the vm repeatedly")
+			for range 3 {
+				vm, err := manager.GetVM(ctx, socketPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vm.State).To(Equal(client.Created))
+			}
+			Expect(infoCalls()).To(Equal(calls + 1))
+
+			By("powering on the vm")
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+			vm, err := manager.GetVM(ctx, socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vm.State).To(Equal(client.Running))
+			// Powering on reads the current state of the vm, booting it invalidates the cached info.
+			Expect(infoCalls()).To(Equal(calls + 3))
+		})
+
+		It("should serve the vm info read by the orphan check to the reconcile", func(ctx SpecContext) {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				VMInfoTTL:     time.Minute,
+			})
+			Expect(manager.CreateVM(ctx, newMachine("machine"))).To(Succeed())
+
+			infoCalls := func() int {
+				return len(slices.DeleteFunc(fake.Calls(), func(call string) bool { return call != "vm.info" }))
+			}
+			calls := infoCalls()
+
+			By("reconciling the machine like the controller on a newly assigned socket")
+			reaped, err := manager.ReapOrphanVM(ctx, socketPath, "machine")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reaped).To(BeFalse())
+			vm, err := manager.GetVM(ctx, socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vm.Config.Platform.Uuid).To(Equal(ptr.To("machine")))
+			Expect(infoCalls()).To(Equal(calls + 1))
+
+			By("reaping an orphan vm")
+			fake.SetVM(&client.VmInfo{
+				Config: client.VmConfig{Platform: &client.PlatformConfig{Uuid: ptr.To("orphan")}},
+				State:  client.Created,
+			})
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath: filepath.Dir(socketPath),
+				VMInfoTTL:     time.Minute,
+			})
+			reaped, err = manager.ReapOrphanVM(ctx, socketPath, "machine")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reaped).To(BeTrue())
+			// The info of the reaped vm is not served anymore.
+			_, err = manager.GetVM(ctx, socketPath)
+			Expect(err).To(MatchError(vmm.ErrVmNotCreated))
+		})
+	})
+
+	Describe("DiffVMConfig", func() {
+		const gib = 1024 * 1024 * 1024
+
+		BeforeEach(func() {
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath:  filepath.Dir(socketPath),
+				MaxVcpus:       4,
+				MaxMemoryBytes: 4 * gib,
+			})
+		})
+
+		diff := func(ctx SpecContext, machine *api.Machine) []vmm.ConfigDiff {
+			vm, err := manager.GetVM(ctx, socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			diffs, err := manager.DiffVMConfig(machine, vm.Config)
+			Expect(err).NotTo(HaveOccurred())
+			return diffs
+		}
+
+		It("should not report differences of a vm in sync with its machine", func(ctx SpecContext) {
+			machine := newMachine("machine")
+			machine.Spec.ConfigDrive = &api.ConfigDriveSpec{}
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+
+			Expect(diff(ctx, machine)).To(BeEmpty())
+		})
+
+		It("should report a changed memory size", func(ctx SpecContext) {
+			machine := newMachine("machine")
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+			Expect(manager.PowerOn(ctx, socketPath)).To(Succeed())
+
+			machine.Spec.MemoryBytes = 2 * gib
+			Expect(diff(ctx, machine)).To(ConsistOf(SatisfyAll(
+				HaveField("Path", "memory.size"),
+				HaveField("Desired", BeEquivalentTo("2147483648")),
+				HaveField("Actual", BeEquivalentTo("1073741824")),
+			)))
+
+			By("resizing the vm to the machine")
+			Expect(manager.Resize(ctx, socketPath, 1, 2*gib)).To(Succeed())
+			Expect(diff(ctx, machine)).To(BeEmpty())
+		})
+
+		It("should report disks missing in the vm by their id", func(ctx SpecContext) {
+			machine := newMachine("machine")
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+
+			machine.Spec.ConfigDrive = &api.ConfigDriveSpec{}
+			Expect(diff(ctx, machine)).To(ConsistOf(SatisfyAll(
+				HaveField("Path", "disks[config-drive]"),
+				HaveField("Desired", Not(BeNil())),
+				HaveField("Actual", BeNil()),
+			)))
+		})
+	})
+
+	Describe("NUMA placement", func() {
+		const gib = 1024 * 1024 * 1024
+
+		var (
+			socketsDir string
+			fakes      map[string]*fakeVMM
+		)
+
+		BeforeEach(func() {
+			socketsDir = GinkgoT().TempDir()
+			fakes = map[string]*fakeVMM{}
+			for _, name := range []string{"loaded", "new"} {
+				fakes[name] = startFakeVMM(filepath.Join(socketsDir, name+".sock"))
+			}
+
+			By("running a vm on numa node 0 before the manager starts")
+			fakes["loaded"].SetVM(&client.VmInfo{State: client.Running, Config: client.VmConfig{
+				Cpus: &client.CpusConfig{BootVcpus: 2, MaxVcpus: 2},
+				Memory: &client.MemoryConfig{Zones: &[]client.MemoryZoneConfig{
+					{Id: "mem0", Size: 4 * gib, HostNumaNode: ptr.To(int32(0))},
+				}},
+			}})
+
+			manager = newManagerWithOptions(vmm.ManagerOptions{
+				CHSocketsPath: socketsDir,
+				NumaNodes: []capacity.NumaNode{
+					{ID: 0, CPUs: []int{0, 1, 2, 3}, MemoryBytes: 8 * gib},
+					{ID: 1, CPUs: []int{4, 5, 6, 7}, MemoryBytes: 8 * gib},
+				},
+			})
+		})
+
+		newNumaMachine := func() *api.Machine {
+			machine := newMachine("machine")
+			machine.Spec.ApiSocketPath = ptr.To(filepath.Join(socketsDir, "new.sock"))
+			machine.Spec.Cpu = 2
+			return machine
+		}
+
+		It("should place the vm on the less loaded numa node", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newNumaMachine())).To(Succeed())
+
+			vm := fakes["new"].VM()
+			Expect(vm).NotTo(BeNil())
+			Expect(vm.Config.Cpus.Affinity).To(HaveValue(Equal([]client.CpuAffinity{
+				{Vcpu: 0, HostCpus: []int{4, 5, 6, 7}},
+				{Vcpu: 1, HostCpus: []int{4, 5, 6, 7}},
+			})))
+			Expect(vm.Config.Memory.Size).To(BeZero())
+			Expect(vm.Config.Memory.Zones).To(HaveValue(ConsistOf(client.MemoryZoneConfig{
+				Id:           "mem0",
+				Size:         gib,
+				HostNumaNode: ptr.To(int32(1)),
+				Shared:       ptr.To(true),
+			})))
+		})
+
+		It("should place the vm on the numa node pinned by its class", func(ctx SpecContext) {
+			machine := newNumaMachine()
+			machine.Spec.NumaNode = ptr.To(0)
+
+			Expect(manager.CreateVM(ctx, machine)).To(Succeed())
+			Expect(fakes["new"].VM()).To(HaveField("Config.Memory.Zones", HaveValue(ConsistOf(
+				HaveField("HostNumaNode", HaveValue(BeEquivalentTo(0))),
+			))))
+		})
+
+		It("should place the vms of managers sharing the numa tracker on different nodes", func(ctx SpecContext) {
+			numa := vmm.NewNumaTracker([]capacity.NumaNode{
+				{ID: 0, CPUs: []int{0, 1, 2, 3}, MemoryBytes: 8 * gib},
+				{ID: 1, CPUs: []int{4, 5, 6, 7}, MemoryBytes: 8 * gib},
+			})
+			otherSocketsDir := GinkgoT().TempDir()
+			otherFake := startFakeVMM(filepath.Join(otherSocketsDir, "other.sock"))
+
+			manager = newManagerWithOptions(vmm.ManagerOptions{CHSocketsPath: socketsDir, Numa: numa})
+			otherManager := newManagerWithOptions(vmm.ManagerOptions{CHSocketsPath: otherSocketsDir, Numa: numa})
+
+			By("placing a vm of the other pool on numa node 1")
+			otherMachine := newMachine("other")
+			otherMachine.Spec.ApiSocketPath = ptr.To(filepath.Join(otherSocketsDir, "other.sock"))
+			otherMachine.Spec.Cpu = 2
+			otherMachine.Spec.MemoryBytes = 6 * gib
+			Expect(otherManager.CreateVM(ctx, otherMachine)).To(Succeed())
+			Expect(otherFake.VM()).To(HaveField("Config.Memory.Zones", HaveValue(ConsistOf(
+				HaveField("HostNumaNode", HaveValue(BeEquivalentTo(1))),
+			))))
+
+			By("placing the vm on the numa node less loaded by the vms of both pools")
+			Expect(manager.CreateVM(ctx, newNumaMachine())).To(Succeed())
+			Expect(fakes["new"].VM()).To(HaveField("Config.Memory.Zones", HaveValue(ConsistOf(
+				HaveField("HostNumaNode", HaveValue(BeEquivalentTo(0))),
+			))))
+		})
+
+		It("should reject a vm pinned to an unknown numa node", func(ctx SpecContext) {
+			machine := newNumaMachine()
+			machine.Spec.NumaNode = ptr.To(2)
+
+			Expect(manager.CreateVM(ctx, machine)).To(MatchError(vmm.ErrInsufficientCapacity))
+			Expect(fakes["new"].VM()).To(BeNil())
+		})
+	})
+
+	Describe("Vsock", func() {
+		var (
+			socketsDir string
+			paths      host.Paths
+			fakes      map[string]*fakeVMM
+			vsock      *vmm.VsockTracker
+		)
+
+		BeforeEach(func() {
+			socketsDir = GinkgoT().TempDir()
+			vsock = vmm.NewVsockTracker()
+			fakes = map[string]*fakeVMM{}
+			for _, name := range []string{"existing", "new"} {
+				fakes[name] = startFakeVMM(filepath.Join(socketsDir, name+".sock"))
+			}
+
+			By("running a vm with a vsock device before the manager starts")
+			fakes["existing"].SetVM(&client.VmInfo{State: client.Running, Config: client.VmConfig{
+				Vsock: &client.VsockConfig{Cid: 3, Socket: "/run/existing/vsock.sock"},
+			}})
+
+			var err error
+			paths, err = host.PathsAt(GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(host.MakeMachineDirs(paths, "machine")).To(Succeed())
+			manager, err = vmm.NewManager(GinkgoLogr, paths, vmm.ManagerOptions{
+				CHSocketsPath:   socketsDir,
+				FirmwarePath:    "/usr/local/bin/hypervisor-fw",
+				AvailableMemory: func() (int64, error) { return 64 * 1024 * 1024 * 1024, nil },
+				Vsock:           vsock,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		newVsockMachine := func(cid int64) *api.Machine {
+			machine := newMachine("machine")
+			machine.Spec.ApiSocketPath = ptr.To(filepath.Join(socketsDir, "new.sock"))
+			machine.Spec.Vsock = &api.VsockSpec{CID: cid}
+			return machine
+		}
+
+		It("should proxy the vsock device to a socket in the machine directory", func(ctx SpecContext) {
+			By("leaving a stale vsock socket of a previous vm")
+			Expect(os.WriteFile(paths.MachineVsockSocket("machine"), nil, 0600)).To(Succeed())
+
+			Expect(manager.CreateVM(ctx, newVsockMachine(4))).To(Succeed())
+			Expect(fakes["new"].VM()).To(HaveField("Config.Vsock", HaveValue(Equal(client.VsockConfig{
+				Cid:    4,
+				Socket: paths.MachineVsockSocket("machine"),
+			}))))
+			Expect(paths.MachineVsockSocket("machine")).NotTo(BeAnExistingFile())
+		})
+
+		It("should reject a cid reserved for the host", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newVsockMachine(2))).To(MatchError(ContainSubstring("vsock cid 2 is out of range")))
+			Expect(fakes["new"].VM()).To(BeNil())
+		})
+
+		It("should reject a cid used by another vm until that vm is deleted", func(ctx SpecContext) {
+			Expect(manager.CreateVM(ctx, newVsockMachine(3))).To(MatchError(vmm.ErrVsockCIDInUse))
+			Expect(fakes["new"].VM()).To(BeNil())
+
+			By("deleting the vm using the cid")
+			Expect(manager.Delete(ctx, filepath.Join(socketsDir, "existing.sock"))).To(Succeed())
+
+			Expect(manager.CreateVM(ctx, newVsockMachine(3))).To(Succeed())
+			Expect(fakes["new"].VM()).To(HaveField("Config.Vsock.Cid", int64(3)))
+		})
+
+		It("should reject a cid used by a vm of a manager sharing the vsock tracker", func(ctx SpecContext) {
+			otherSocketsDir := GinkgoT().TempDir()
+			otherFake := startFakeVMM(filepath.Join(otherSocketsDir, "other.sock"))
+			otherManager, err := vmm.NewManager(GinkgoLogr, paths, vmm.ManagerOptions{
+				CHSocketsPath:   otherSocketsDir,
+				FirmwarePath:    "/usr/local/bin/hypervisor-fw",
+				AvailableMemory: func() (int64, error) { return 64 * 1024 * 1024 * 1024, nil },
+				Vsock:           vsock,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating a vm of the other pool with cid 4")
+			otherMachine := newMachine("other")
+			otherMachine.Spec.ApiSocketPath = ptr.To(filepath.Join(otherSocketsDir, "other.sock"))
+			otherMachine.Spec.Vsock = &api.VsockSpec{CID: 4}
+			Expect(host.MakeMachineDirs(paths, "other")).To(Succeed())
+			Expect(otherManager.CreateVM(ctx, otherMachine)).To(Succeed())
+			Expect(otherFake.VM()).To(HaveField("Config.Vsock.Cid", int64(4)))
+
+			Expect(manager.CreateVM(ctx, newVsockMachine(4))).To(MatchError(vmm.ErrVsockCIDInUse))
+			Expect(fakes["new"].VM()).To(BeNil())
+		})
+	})
+
+	Describe("ListVMStates", func() {
+		It("should aggregate the vm states of all instances", func(ctx SpecContext) {
+			socketsDir := GinkgoT().TempDir()
+			fakes := map[string]*fakeVMM{}
+			for _, name := range []string{"running", "paused", "empty", "unresponsive"} {
+				fakes[name] = startFakeVMM(filepath.Join(socketsDir, name+".sock"))
+			}
+			manager := newManager(socketsDir)
+
+			By("creating vms in different states")
+			fakes["running"].SetVM(&client.VmInfo{State: client.Running})
+			fakes["paused"].SetVM(&client.VmInfo{State: client.Paused})
+			fakes["unresponsive"].SetVM(&client.VmInfo{State: client.Running})
+			fakes["unresponsive"].Close()
+
+			By("listing the vm states")
+			states, err := manager.ListVMStates(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(states).To(Equal(map[string]client.VmInfoState{
+				filepath.Join(socketsDir, "running.sock"):      client.Running,
+				filepath.Join(socketsDir, "paused.sock"):       client.Paused,
+				filepath.Join(socketsDir, "unresponsive.sock"): client.Shutdown,
+			}))
+		})
+	})
+})
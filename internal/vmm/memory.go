@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package vmm
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MemoryAlignment is the boundary vm memory is aligned to. It is a multiple of the page and huge page
+	// sizes cloud-hypervisor maps guest memory with, and of the block size memory is hot plugged in.
+	MemoryAlignment = 128 * 1024 * 1024
+	// MinMemoryBytes is the smallest memory size a vm is created with.
+	MinMemoryBytes = MemoryAlignment
+	// MaxMemoryBytes bounds the memory of a vm to what the guest physical address space can hold.
+	MaxMemoryBytes = 1 << 46
+)
+
+var ErrInvalidMemory = errors.New("invalid memory size")
+
+// AlignMemory rounds the memory size up to the next multiple of MemoryAlignment. Sizes outside of
+// [MinMemoryBytes, MaxMemoryBytes] are rejected.
+func AlignMemory(memoryBytes int64) (int64, error) {
+	if memoryBytes <= 0 {
+		return 0, fmt.Errorf("%w: %d bytes is not positive", ErrInvalidMemory, memoryBytes)
+	}
+	if memoryBytes > MaxMemoryBytes {
+		return 0, fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes", ErrInvalidMemory, memoryBytes, int64(MaxMemoryBytes))
+	}
+
+	if memoryBytes < MinMemoryBytes {
+		return 0, fmt.Errorf("%w: %d bytes is below the minimum of %d bytes", ErrInvalidMemory, memoryBytes, int64(MinMemoryBytes))
+	}
+	return (memoryBytes + MemoryAlignment - 1) / MemoryAlignment * MemoryAlignment, nil
+}
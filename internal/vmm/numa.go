@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package vmm
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/capacity"
+	"k8s.io/utils/ptr"
+)
+
+// numaMemoryZoneID is the id of the memory zone holding the memory of a vm placed on a numa node.
+const numaMemoryZoneID = "mem0"
+
+type numaAllocation struct {
+	node        int
+	cpu         int64
+	memoryBytes int64
+}
+
+// NumaTracker tracks the cpus and memory allocated to vms on the numa nodes of the host. The managers of all
+// pools share a tracker, so they do not place their vms on the same node. The vms are tracked by their
+// instances, which are unique across the pools.
+type NumaTracker struct {
+	nodes []capacity.NumaNode
+
+	mu          sync.Mutex
+	allocations map[string]numaAllocation
+}
+
+// NewNumaTracker returns a tracker placing vms on the nodes. Placement is disabled without nodes.
+func NewNumaTracker(nodes []capacity.NumaNode) *NumaTracker {
+	return &NumaTracker{
+		nodes:       nodes,
+		allocations: make(map[string]numaAllocation),
+	}
+}
+
+// reserve picks the numa node for the vm and allocates its cpus and memory there: the node pinned by the
+// machine class or, if unpinned, the node with the most free memory, then the most free cpus. It returns nil
+// if numa placement is disabled or an unpinned vm has a single node to choose from.
+func (t *NumaTracker) reserve(instanceID string, machine *api.Machine, memoryBytes int64) (*capacity.NumaNode, error) {
+	if len(t.nodes) == 0 {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var node *capacity.NumaNode
+	switch pinned := machine.Spec.NumaNode; {
+	case pinned != nil:
+		idx := slices.IndexFunc(t.nodes, func(node capacity.NumaNode) bool { return node.ID == *pinned })
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: numa node %d does not exist", ErrInsufficientCapacity, *pinned)
+		}
+		node = &t.nodes[idx]
+	case len(t.nodes) == 1:
+		return nil, nil
+	default:
+		node = t.leastLoaded()
+	}
+
+	t.allocations[instanceID] = numaAllocation{node: node.ID, cpu: machine.Spec.Cpu, memoryBytes: memoryBytes}
+	return node, nil
+}
+
+func (t *NumaTracker) leastLoaded() *capacity.NumaNode {
+	var (
+		best                *capacity.NumaNode
+		bestMemory, bestCpu int64
+	)
+	for i := range t.nodes {
+		node := &t.nodes[i]
+		freeMemory, freeCpu := node.MemoryBytes, int64(len(node.CPUs))
+		for _, alloc := range t.allocations {
+			if alloc.node == node.ID {
+				freeMemory -= alloc.memoryBytes
+				freeCpu -= alloc.cpu
+			}
+		}
+
+		if best == nil || freeMemory > bestMemory || (freeMemory == bestMemory && freeCpu > bestCpu) {
+			best, bestMemory, bestCpu = node, freeMemory, freeCpu
+		}
+	}
+	return best
+}
+
+func (t *NumaTracker) release(instanceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.allocations, instanceID)
+}
+
+// track restores the numa allocation of a vm created before the manager started.
+func (t *NumaTracker) track(instanceID string, vm *client.VmInfo) {
+	if vm.Config.Memory == nil || vm.Config.Memory.Zones == nil {
+		return
+	}
+	for _, zone := range *vm.Config.Memory.Zones {
+		if zone.Id != numaMemoryZoneID || zone.HostNumaNode == nil {
+			continue
+		}
+		alloc := numaAllocation{node: int(*zone.HostNumaNode), memoryBytes: zone.Size}
+		if vm.Config.Cpus != nil {
+			alloc.cpu = int64(vm.Config.Cpus.BootVcpus)
+		}
+
+		t.mu.Lock()
+		t.allocations[instanceID] = alloc
+		t.mu.Unlock()
+	}
+}
+
+// numaConfig binds the vcpus to the cpus of the node and backs the guest memory by a zone on the node.
+func numaConfig(node *capacity.NumaNode, cpus *client.CpusConfig, memory *client.MemoryConfig) {
+	affinity := make([]client.CpuAffinity, 0, cpus.BootVcpus)
+	for vcpu := range cpus.BootVcpus {
+		affinity = append(affinity, client.CpuAffinity{Vcpu: vcpu, HostCpus: slices.Clone(node.CPUs)})
+	}
+	cpus.Affinity = &affinity
+
+	memory.Zones = &[]client.MemoryZoneConfig{{
+		Id:           numaMemoryZoneID,
+		Size:         memory.Size,
+		HostNumaNode: ptr.To(int32(node.ID)),
+		Shared:       memory.Shared,
+	}}
+	// The memory of the vm is made up of its zones only.
+	memory.Size = 0
+}
@@ -7,14 +7,22 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
 )
 
 func NewUnixSocketClient(socketPath string) (*client.ClientWithResponses, error) {
+	return newUnixSocketClient(socketPath, 0)
+}
+
+// newUnixSocketClient returns a client of the api socket, failing connections not accepted within
+// dialTimeout. Zero does not bound the connections.
+func newUnixSocketClient(socketPath string, dialTimeout time.Duration) (*client.ClientWithResponses, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
 	transport := &http.Transport{
 		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			return net.Dial("unix", socketPath)
+			return dialer.DialContext(ctx, "unix", socketPath)
 		},
 	}
 
@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package vmm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/host"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/internal/vmm"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestVmm(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VMM Suite")
+}
+
+// fakeVMM serves a minimal subset of the cloud-hypervisor api on a unix socket.
+type fakeVMM struct {
+	mu    sync.Mutex
+	vm    *client.VmInfo
+	calls []string
+	// bodies holds the body of the last request by operation.
+	bodies map[string][]byte
+	// ignorePowerButton keeps the vm running when its power button is pressed, like a hung guest.
+	ignorePowerButton bool
+
+	srv *http.Server
+}
+
+// Close stops serving, making the fake unresponsive.
+func (f *fakeVMM) Close() {
+	_ = f.srv.Close()
+}
+
+func (f *fakeVMM) VM() *client.VmInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.vm
+}
+
+func (f *fakeVMM) SetVM(vm *client.VmInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.vm = vm
+}
+
+func (f *fakeVMM) SetIgnorePowerButton(ignore bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ignorePowerButton = ignore
+}
+
+// Body returns the body of the last request of the operation.
+func (f *fakeVMM) Body(op string) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bodies[op]
+}
+
+func (f *fakeVMM) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeVMM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	op := filepath.Base(r.URL.Path)
+	f.calls = append(f.calls, op)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.bodies == nil {
+		f.bodies = make(map[string][]byte)
+	}
+	f.bodies[op] = body
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if op == "vmm.ping" {
+		writeJSON(w, client.VmmPingResponse{Version: "fake"})
+		return
+	}
+
+	if op == "vm.create" {
+		var config client.VmConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.vm = &client.VmInfo{Config: config, State: client.Created}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if op == "vm.restore" {
+		if f.vm != nil {
+			http.Error(w, "VM is already created", http.StatusInternalServerError)
+			return
+		}
+		f.vm = &client.VmInfo{State: client.Paused}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if f.vm == nil {
+		http.Error(w, "VM is not created", http.StatusInternalServerError)
+		return
+	}
+
+	switch op {
+	case "vm.info":
+		writeJSON(w, f.vm)
+	case "vm.boot":
+		f.vm.State = client.Running
+		w.WriteHeader(http.StatusNoContent)
+	case "vm.pause":
+		if f.vm.State != client.Running {
+			http.Error(w, "InvalidStateTransition", http.StatusInternalServerError)
+			return
+		}
+		f.vm.State = client.Paused
+		w.WriteHeader(http.StatusNoContent)
+	case "vm.snapshot":
+		if f.vm.State != client.Paused {
+			http.Error(w, "VM is not paused", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "vm.resume":
+		if f.vm.State != client.Paused {
+			http.Error(w, "InvalidStateTransition", http.StatusInternalServerError)
+			return
+		}
+		f.vm.State = client.Running
+		w.WriteHeader(http.StatusNoContent)
+	case "vm.shutdown":
+		f.vm.State = client.Shutdown
+		w.WriteHeader(http.StatusNoContent)
+	case "vm.power-button":
+		if !f.ignorePowerButton {
+			f.vm.State = client.Shutdown
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "vm.delete":
+		f.vm = nil
+		w.WriteHeader(http.StatusNoContent)
+	case "vm.add-disk":
+		var disk client.DiskConfig
+		if err := json.NewDecoder(r.Body).Decode(&disk); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.vm.Config.Disks = ptr.To(append(ptr.Deref(f.vm.Config.Disks, nil), disk))
+		w.WriteHeader(http.StatusNoContent)
+	case "vm.resize":
+		var resize client.VmResize
+		if err := json.NewDecoder(r.Body).Decode(&resize); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if resize.DesiredVcpus != nil {
+			f.vm.Config.Cpus.BootVcpus = *resize.DesiredVcpus
+		}
+		if resize.DesiredRam != nil {
+			f.vm.Config.Memory.HotpluggedSize = ptr.To(*resize.DesiredRam - f.vm.Config.Memory.Size)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// startFakeVMM serves a fake cloud-hypervisor on socketPath until the spec ends.
+func startFakeVMM(socketPath string) *fakeVMM {
+	fake := &fakeVMM{}
+
+	l, err := net.Listen("unix", socketPath)
+	Expect(err).NotTo(HaveOccurred())
+
+	fake.srv = &http.Server{Handler: fake}
+	go func() {
+		_ = fake.srv.Serve(l)
+	}()
+	DeferCleanup(fake.Close)
+
+	return fake
+}
+
+func newManager(socketsDir string) *vmm.Manager {
+	return newManagerWithOptions(vmm.ManagerOptions{CHSocketsPath: socketsDir})
+}
+
+func newManagerWithOptions(opts vmm.ManagerOptions) *vmm.Manager {
+	paths, err := host.PathsAt(GinkgoT().TempDir())
+	Expect(err).NotTo(HaveOccurred())
+
+	if opts.FirmwarePath == "" && opts.KernelPath == "" {
+		opts.FirmwarePath = "/usr/local/bin/hypervisor-fw"
+	}
+	if opts.AvailableMemory == nil {
+		opts.AvailableMemory = func() (int64, error) { return 64 * 1024 * 1024 * 1024, nil }
+	}
+
+	m, err := vmm.NewManager(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)), paths, opts)
+	Expect(err).NotTo(HaveOccurred())
+	return m
+}
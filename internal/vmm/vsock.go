@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package vmm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ironcore-dev/cloud-hypervisor-provider/api"
+	"github.com/ironcore-dev/cloud-hypervisor-provider/cloud-hypervisor/client"
+)
+
+var ErrVsockCIDInUse = errors.New("vsock cid in use")
+
+// VsockTracker tracks the vsock cids of the vms, which have to be unique on the host. The managers of all
+// pools share a tracker. The vms are tracked by their instances, which are unique across the pools.
+type VsockTracker struct {
+	mu   sync.Mutex
+	cids map[string]int64
+}
+
+func NewVsockTracker() *VsockTracker {
+	return &VsockTracker{
+		cids: make(map[string]int64),
+	}
+}
+
+func (t *VsockTracker) reserve(instanceID string, cid int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, reserved := range t.cids {
+		if reserved == cid && id != instanceID {
+			return fmt.Errorf("%w: cid %d is used by the vm on %s", ErrVsockCIDInUse, cid, id)
+		}
+	}
+	t.cids[instanceID] = cid
+	return nil
+}
+
+func (t *VsockTracker) release(instanceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.cids, instanceID)
+}
+
+// track restores the vsock cid of a vm created before the manager started.
+func (t *VsockTracker) track(instanceID string, vm *client.VmInfo) {
+	if vm.Config.Vsock == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cids[instanceID] = vm.Config.Vsock.Cid
+}
+
+// vsockConfig validates the vsock device of the machine. It returns nil if the machine has no vsock device.
+func (m *Manager) vsockConfig(machine *api.Machine) (*client.VsockConfig, error) {
+	vsock := machine.Spec.Vsock
+	if vsock == nil {
+		return nil, nil
+	}
+	if err := api.ValidateVsockSpec(vsock); err != nil {
+		return nil, fmt.Errorf("invalid vsock: %w", err)
+	}
+	return &client.VsockConfig{Cid: vsock.CID, Socket: m.paths.MachineVsockSocket(machine.ID)}, nil
+}
+
+// prepareVsock removes a stale socket of the vsock device and reserves its cid for the vm.
+func (m *Manager) prepareVsock(instanceID string, vsock *client.VsockConfig) error {
+	if vsock == nil {
+		return nil
+	}
+	// cloud-hypervisor creates the socket and fails if it exists.
+	if err := os.Remove(vsock.Socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale vsock socket: %w", err)
+	}
+	return m.vsock.reserve(instanceID, vsock.Cid)
+}